@@ -0,0 +1,115 @@
+// Package config implements ted's settings.
+//
+// Settings are layered: built-in defaults, then the user's configuration
+// file, then a project's .ted.toml, each layer changing only what it sets.
+// [languages.<name>] sections change editor settings for one language, and
+// [snippets.<name>] sections add snippets for it.
+package config
+
+import (
+	"strings"
+)
+
+// Config is the complete set of settings.
+type Config struct {
+	Editor      EditorSettings
+	Search      SearchSettings
+	History     HistorySettings
+	Languages   map[string]LanguageSettings  // Keyed by normalized language name
+	Snippets    map[string]map[string]string // Snippet bodies by prefix, keyed by normalized language name
+	Keybindings []Keybindings                // One entry per file that has any, applied in order
+	Files       []string                     // Files the settings were read from, in order
+}
+
+// EditorSettings control how text is edited and shown.
+type EditorSettings struct {
+	TabSize      int  // Columns per tab stop, 1 to 16
+	UseSpaces    bool // Indent with spaces rather than tabs
+	DetectIndent bool // Follow the indentation a file already uses
+}
+
+// SearchSettings control find and replace.
+type SearchSettings struct {
+	HistorySize int // Patterns and replacements remembered, 0 to 1000
+}
+
+// HistorySettings control undo.
+type HistorySettings struct {
+	UndoLevels int // Edits that can be undone, 1 to 10000
+}
+
+// LanguageSettings override editor settings for one language. Nil fields
+// keep the general setting.
+type LanguageSettings struct {
+	TabSize      *int
+	UseSpaces    *bool
+	DetectIndent *bool
+}
+
+// Keybindings are the [keybindings.<context>] tables of one file: keys
+// mapped to action names, per context.
+type Keybindings struct {
+	File   string
+	Tables map[string]map[string]string
+}
+
+// Default returns the built-in settings.
+func Default() *Config {
+	return &Config{
+		Editor: EditorSettings{
+			TabSize:      4,
+			UseSpaces:    true,
+			DetectIndent: true,
+		},
+		Search: SearchSettings{
+			HistorySize: 20,
+		},
+		History: HistorySettings{
+			UndoLevels: 100,
+		},
+		Languages: make(map[string]LanguageSettings),
+		Snippets: map[string]map[string]string{
+			"go": {
+				"iferr": "if err != nil {\n\treturn ${1:err}\n}",
+			},
+			"python": {
+				"def": "def ${1:name}(${2:args}):\n\t${0:pass}",
+			},
+		},
+	}
+}
+
+// ForLanguage returns the editor settings for a language, such as "Go" or
+// "Plain Text", with its section's overrides applied.
+func (c *Config) ForLanguage(language string) EditorSettings {
+	settings := c.Editor
+	lang := c.Language(language)
+	if lang.TabSize != nil {
+		settings.TabSize = *lang.TabSize
+	}
+	if lang.UseSpaces != nil {
+		settings.UseSpaces = *lang.UseSpaces
+	}
+	if lang.DetectIndent != nil {
+		settings.DetectIndent = *lang.DetectIndent
+	}
+	return settings
+}
+
+// Language returns the overrides a language's section sets, with every
+// field nil if it has none.
+func (c *Config) Language(language string) LanguageSettings {
+	return c.Languages[normalizeLanguage(language)]
+}
+
+// LanguageSnippets returns a language's snippet bodies, keyed by prefix.
+// The map is shared and must not be changed.
+func (c *Config) LanguageSnippets(language string) map[string]string {
+	return c.Snippets[normalizeLanguage(language)]
+}
+
+// normalizeLanguage makes language names match regardless of case and
+// spaces, so [languages.plaintext] applies to "Plain Text".
+func normalizeLanguage(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, " ", ""))
+}
@@ -0,0 +1,60 @@
+package config
+
+import "testing"
+
+func TestDefault(t *testing.T) {
+	cfg := Default()
+	if cfg.Editor.TabSize != 4 || !cfg.Editor.UseSpaces || !cfg.Editor.DetectIndent {
+		t.Errorf("Default().Editor = %+v, want tab size 4 with spaces, detected", cfg.Editor)
+	}
+	if cfg.Search.HistorySize != 20 {
+		t.Errorf("Default().Search.HistorySize = %d, want 20", cfg.Search.HistorySize)
+	}
+	if cfg.History.UndoLevels != 100 {
+		t.Errorf("Default().History.UndoLevels = %d, want 100", cfg.History.UndoLevels)
+	}
+}
+
+func TestConfig_ForLanguage(t *testing.T) {
+	two, no := 2, false
+	cfg := Default()
+	cfg.Languages["go"] = LanguageSettings{UseSpaces: &no}
+	cfg.Languages["plaintext"] = LanguageSettings{TabSize: &two}
+	cfg.Languages["markdown"] = LanguageSettings{DetectIndent: &no}
+
+	tests := []struct {
+		language string
+		want     EditorSettings
+	}{
+		{"Go", EditorSettings{TabSize: 4, UseSpaces: false, DetectIndent: true}},
+		{"Plain Text", EditorSettings{TabSize: 2, UseSpaces: true, DetectIndent: true}},
+		{"Markdown", EditorSettings{TabSize: 4, UseSpaces: true, DetectIndent: false}},
+		{"Python", EditorSettings{TabSize: 4, UseSpaces: true, DetectIndent: true}},
+		{"", EditorSettings{TabSize: 4, UseSpaces: true, DetectIndent: true}},
+	}
+	for _, tt := range tests {
+		if got := cfg.ForLanguage(tt.language); got != tt.want {
+			t.Errorf("ForLanguage(%q) = %+v, want %+v", tt.language, got, tt.want)
+		}
+	}
+}
+
+func TestConfig_LanguageSnippets(t *testing.T) {
+	cfg := Default()
+	tests := []struct {
+		language string
+		prefix   string
+		want     bool
+	}{
+		{"Go", "iferr", true},
+		{"Python", "def", true},
+		{"Go", "def", false},
+		{"Plain Text", "iferr", false},
+	}
+	for _, tt := range tests {
+		_, ok := cfg.LanguageSnippets(tt.language)[tt.prefix]
+		if ok != tt.want {
+			t.Errorf("LanguageSnippets(%q) has %q = %v, want %v", tt.language, tt.prefix, ok, tt.want)
+		}
+	}
+}
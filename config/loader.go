@@ -0,0 +1,358 @@
+// Package config implements loading settings from TOML files.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/AndrewDonelson/ted/core/complete"
+	"github.com/AndrewDonelson/ted/core/snippet"
+	"github.com/BurntSushi/toml"
+)
+
+// ProjectFileName is the name of a project's configuration file.
+const ProjectFileName = ".ted.toml"
+
+// Limits for settings.
+const (
+	minTabSize     = 1
+	maxTabSize     = 16
+	maxHistorySize = 1000
+	minUndoLevels  = 1
+	maxUndoLevels  = 10000
+)
+
+// editorLayer holds the editor settings one file sets.
+type editorLayer struct {
+	TabSize      *int  `toml:"tab_size"`
+	UseSpaces    *bool `toml:"use_spaces"`
+	DetectIndent *bool `toml:"detect_indent"`
+}
+
+// searchLayer holds the search settings one file sets.
+type searchLayer struct {
+	HistorySize *int `toml:"history_size"`
+}
+
+// historyLayer holds the undo settings one file sets.
+type historyLayer struct {
+	UndoLevels *int `toml:"undo_levels"`
+}
+
+// fileLayer is the contents of one configuration file.
+type fileLayer struct {
+	Editor      editorLayer                  `toml:"editor"`
+	Search      searchLayer                  `toml:"search"`
+	History     historyLayer                 `toml:"history"`
+	Languages   map[string]editorLayer       `toml:"languages"`
+	Keybindings map[string]map[string]string `toml:"keybindings"`
+	Snippets    map[string]map[string]string `toml:"snippets"`
+}
+
+// knownKeys lists the settings of each table, for suggesting a fix when a
+// file has a key ted doesn't know.
+var knownKeys = map[string][]string{
+	"editor":    {"tab_size", "use_spaces", "detect_indent"},
+	"search":    {"history_size"},
+	"history":   {"undo_levels"},
+	"languages": {"tab_size", "use_spaces", "detect_indent"},
+}
+
+// UserPath returns the user's configuration file:
+// $XDG_CONFIG_HOME/ted/config.toml, or ~/.config/ted/config.toml when
+// XDG_CONFIG_HOME is unset.
+func UserPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("find config directory: %w", err)
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "ted", "config.toml"), nil
+}
+
+// ProjectPath returns the .ted.toml nearest to dir, looking in dir and
+// then each parent directory. It returns "" if there is none.
+func ProjectPath(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		if exists(path) {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// exists reports whether path is an existing file.
+func exists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// Load returns the default settings changed by each file in turn. Files
+// that don't exist and empty paths are skipped.
+//
+// Problems in a file, such as unknown keys or values out of range, are
+// returned together. The settings are still usable: a bad value leaves the
+// setting as the earlier layers had it.
+func Load(paths ...string) (*Config, error) {
+	cfg := Default()
+	var problems []error
+
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			problems = append(problems, fmt.Errorf("read config: %w", err))
+			continue
+		}
+		if err := cfg.apply(path, data); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	if len(problems) > 0 {
+		return cfg, errors.Join(problems...)
+	}
+	return cfg, nil
+}
+
+// Parse returns the default settings changed by one file's contents. name
+// identifies the contents in errors.
+func Parse(name string, data []byte) (*Config, error) {
+	cfg := Default()
+	return cfg, cfg.apply(name, data)
+}
+
+// apply changes the settings by the contents of the file at path.
+func (c *Config) apply(path string, data []byte) error {
+	var layer fileLayer
+	meta, err := toml.Decode(string(data), &layer)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	c.Files = append(c.Files, path)
+
+	var problems []error
+	unknownTables := make(map[string]bool)
+	for _, key := range meta.Undecoded() {
+		// An unknown table is reported once, not again for each of its keys
+		if _, ok := knownKeys[key[0]]; !ok && len(key) > 1 && unknownTables[key[0]] {
+			continue
+		}
+		unknownTables[key[0]] = true
+		problems = append(problems, unknownKey(key))
+	}
+
+	if v := layer.Editor.TabSize; v != nil {
+		if err := checkRange("editor.tab_size", *v, minTabSize, maxTabSize); err != nil {
+			problems = append(problems, err)
+		} else {
+			c.Editor.TabSize = *v
+		}
+	}
+	if v := layer.Editor.UseSpaces; v != nil {
+		c.Editor.UseSpaces = *v
+	}
+	if v := layer.Editor.DetectIndent; v != nil {
+		c.Editor.DetectIndent = *v
+	}
+	if v := layer.Search.HistorySize; v != nil {
+		if err := checkRange("search.history_size", *v, 0, maxHistorySize); err != nil {
+			problems = append(problems, err)
+		} else {
+			c.Search.HistorySize = *v
+		}
+	}
+	if v := layer.History.UndoLevels; v != nil {
+		if err := checkRange("history.undo_levels", *v, minUndoLevels, maxUndoLevels); err != nil {
+			problems = append(problems, err)
+		} else {
+			c.History.UndoLevels = *v
+		}
+	}
+
+	names := make([]string, 0, len(layer.Languages))
+	for name := range layer.Languages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		lang := layer.Languages[name]
+		settings := c.Languages[normalizeLanguage(name)]
+		if v := lang.TabSize; v != nil {
+			key := fmt.Sprintf("languages.%s.tab_size", name)
+			if err := checkRange(key, *v, minTabSize, maxTabSize); err != nil {
+				problems = append(problems, err)
+			} else {
+				settings.TabSize = v
+			}
+		}
+		if v := lang.UseSpaces; v != nil {
+			settings.UseSpaces = v
+		}
+		if v := lang.DetectIndent; v != nil {
+			settings.DetectIndent = v
+		}
+		c.Languages[normalizeLanguage(name)] = settings
+	}
+
+	problems = append(problems, c.applySnippets(layer.Snippets)...)
+
+	if len(layer.Keybindings) > 0 {
+		c.Keybindings = append(c.Keybindings, Keybindings{File: path, Tables: layer.Keybindings})
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("config %s: %w", path, errors.Join(problems...))
+	}
+	return nil
+}
+
+// applySnippets adds the snippets of one file's [snippets.<language>]
+// tables, replacing any with the same prefix. An empty body removes a
+// snippet. Snippets that don't parse are reported and left out.
+func (c *Config) applySnippets(tables map[string]map[string]string) []error {
+	var problems []error
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		lang := normalizeLanguage(name)
+		snippets := c.Snippets[lang]
+		if snippets == nil {
+			snippets = make(map[string]string)
+		}
+		prefixes := make([]string, 0, len(tables[name]))
+		for prefix := range tables[name] {
+			prefixes = append(prefixes, prefix)
+		}
+		sort.Strings(prefixes)
+		for _, prefix := range prefixes {
+			body := tables[name][prefix]
+			key := fmt.Sprintf("snippets.%s.%s", name, prefix)
+			if !isPrefix(prefix) {
+				problems = append(problems, fmt.Errorf("%s: prefix must be letters, digits and underscores", key))
+				continue
+			}
+			if body == "" {
+				delete(snippets, prefix)
+				continue
+			}
+			if _, err := snippet.Parse(body); err != nil {
+				problems = append(problems, fmt.Errorf("%s: %w", key, err))
+				continue
+			}
+			snippets[prefix] = body
+		}
+		c.Snippets[lang] = snippets
+	}
+	return problems
+}
+
+// isPrefix reports whether s can be typed as a snippet's prefix: a word,
+// as completion and snippet expansion see one.
+func isPrefix(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !complete.IsWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkRange returns an error if the setting key is outside [lo, hi].
+func checkRange(key string, v, lo, hi int) error {
+	if v < lo || v > hi {
+		return fmt.Errorf("%s = %d: must be from %d to %d", key, v, lo, hi)
+	}
+	return nil
+}
+
+// unknownKey describes a key ted doesn't know, naming the closest known
+// setting when there is one.
+func unknownKey(key toml.Key) error {
+	if len(key) == 0 {
+		return errors.New("unknown setting")
+	}
+	table := key[0]
+	known, ok := knownKeys[table]
+	if !ok && len(key) == 1 {
+		for _, t := range []string{"editor", "search", "history"} {
+			if slices.Contains(knownKeys[t], table) {
+				return fmt.Errorf("setting %s belongs in the [%s] table", table, t)
+			}
+		}
+	}
+	if !ok {
+		tables := []string{"editor", "history", "keybindings", "languages", "search", "snippets"}
+		if guess := closest(table, tables); guess != "" {
+			return fmt.Errorf("unknown table [%s] (did you mean [%s]?)", table, guess)
+		}
+		return fmt.Errorf("unknown table [%s] (tables are %s)", table, strings.Join(tables, ", "))
+	}
+
+	name := key[len(key)-1]
+	if guess := closest(name, known); guess != "" {
+		return fmt.Errorf("unknown setting %s (did you mean %s?)", key, guess)
+	}
+	return fmt.Errorf("unknown setting %s (settings are %s)", key, strings.Join(known, ", "))
+}
+
+// closest returns the candidate nearest to name, or "" if none is close
+// enough to be a likely typo.
+func closest(name string, candidates []string) string {
+	best, bestDist := "", 3
+	for _, c := range candidates {
+		if d := distance(strings.ToLower(name), c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// distance returns the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,265 @@
+package config
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFile writes content to name in dir and returns its path.
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_Layers(t *testing.T) {
+	dir := t.TempDir()
+	user := writeFile(t, dir, "config.toml", `
+[editor]
+tab_size = 8
+use_spaces = false
+
+[search]
+history_size = 50
+
+[languages.go]
+tab_size = 4
+
+[keybindings.editor]
+"Ctrl+K Ctrl+C" = "copy"
+`)
+	project := writeFile(t, dir, "project/.ted.toml", `
+[editor]
+tab_size = 2
+
+[history]
+undo_levels = 500
+
+[languages.Python]
+use_spaces = true
+detect_indent = false
+
+[languages.go]
+use_spaces = true
+`)
+
+	cfg, err := Load(user, project, filepath.Join(dir, "missing.toml"), "")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Editor.TabSize != 2 {
+		t.Errorf("Editor.TabSize = %d, want 2 from the project", cfg.Editor.TabSize)
+	}
+	if cfg.Editor.UseSpaces {
+		t.Error("Editor.UseSpaces should be false from the user file")
+	}
+	if cfg.Search.HistorySize != 50 {
+		t.Errorf("Search.HistorySize = %d, want 50", cfg.Search.HistorySize)
+	}
+	if cfg.History.UndoLevels != 500 {
+		t.Errorf("History.UndoLevels = %d, want 500", cfg.History.UndoLevels)
+	}
+
+	// Language sections merge across files too
+	if got, want := cfg.ForLanguage("Go"), (EditorSettings{TabSize: 4, UseSpaces: true, DetectIndent: true}); got != want {
+		t.Errorf("ForLanguage(Go) = %+v, want %+v", got, want)
+	}
+	if got, want := cfg.ForLanguage("Python"), (EditorSettings{TabSize: 2, UseSpaces: true, DetectIndent: false}); got != want {
+		t.Errorf("ForLanguage(Python) = %+v, want %+v", got, want)
+	}
+
+	if len(cfg.Keybindings) != 1 || cfg.Keybindings[0].File != user {
+		t.Fatalf("Keybindings = %+v, want one table from %s", cfg.Keybindings, user)
+	}
+	if got := cfg.Keybindings[0].Tables["editor"]["Ctrl+K Ctrl+C"]; got != "copy" {
+		t.Errorf("keybinding = %q, want %q", got, "copy")
+	}
+	if len(cfg.Files) != 2 {
+		t.Errorf("Files = %v, want the two existing files", cfg.Files)
+	}
+}
+
+func TestLoad_Problems(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.toml", `
+tab_size = 3
+
+[editor]
+tabsize = 2
+tab_size = 40
+use_spaces = false
+
+[search]
+history_size = -1
+
+[history]
+undo_levels = 0
+
+[languages.go]
+tab_size = 0
+
+[edtor]
+tab_size = 2
+`)
+
+	cfg, err := Load(path)
+	if err == nil {
+		t.Fatal("Load() should report the problems")
+	}
+	for _, want := range []string{
+		path,
+		"setting tab_size belongs in the [editor] table",
+		"unknown setting editor.tabsize (did you mean tab_size?)",
+		"editor.tab_size = 40: must be from 1 to 16",
+		"search.history_size = -1",
+		"history.undo_levels = 0",
+		"languages.go.tab_size = 0",
+		"unknown table [edtor] (did you mean [editor]?)",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error should mention %q, got:\n%v", want, err)
+		}
+	}
+	if n := strings.Count(err.Error(), "edtor"); n != 1 {
+		t.Errorf("unknown table reported %d times, want once:\n%v", n, err)
+	}
+
+	// Valid settings still apply; invalid ones keep the defaults
+	want := Default()
+	want.Editor.UseSpaces = false
+	if cfg.Editor != want.Editor || cfg.Search != want.Search || cfg.History != want.History {
+		t.Errorf("Load() = %+v %+v %+v, want %+v %+v %+v", cfg.Editor, cfg.Search, cfg.History, want.Editor, want.Search, want.History)
+	}
+	if got := cfg.ForLanguage("Go"); got != want.Editor {
+		t.Errorf("ForLanguage(Go) = %+v, want %+v", got, want.Editor)
+	}
+}
+
+func TestLoad_Snippets(t *testing.T) {
+	dir := t.TempDir()
+	user := writeFile(t, dir, "config.toml", `
+[snippets.go]
+main = "func main() {\n\t$0\n}"
+fori = "for ${1:i} := 0; $1 < ${2:n}; $1++ {\n\t$0\n}"
+
+[snippets."Plain Text"]
+sig = "-- \n$TM_FILENAME"
+`)
+	project := writeFile(t, dir, "project/.ted.toml", `
+[snippets.go]
+iferr = ""
+main = "func main() {}"
+"bad prefix" = "x"
+broken = "${1:unclosed"
+`)
+
+	cfg, err := Load(user, project)
+	if err == nil {
+		t.Fatal("Load() should report the bad snippets")
+	}
+	for _, want := range []string{
+		`snippets.go.bad prefix: prefix must be`,
+		"snippets.go.broken: parse snippet: missing }",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error should mention %q, got:\n%v", want, err)
+		}
+	}
+
+	// Snippets merge across files; an empty body removes one
+	want := map[string]string{
+		"main": "func main() {}",
+		"fori": "for ${1:i} := 0; $1 < ${2:n}; $1++ {\n\t$0\n}",
+	}
+	if got := cfg.LanguageSnippets("Go"); !maps.Equal(got, want) {
+		t.Errorf("LanguageSnippets(Go) = %q, want %q", got, want)
+	}
+	if got := cfg.LanguageSnippets("Plain Text")["sig"]; got != "-- \n$TM_FILENAME" {
+		t.Errorf("Plain Text snippet sig = %q", got)
+	}
+	if _, ok := cfg.LanguageSnippets("Python")["def"]; !ok {
+		t.Error("default Python snippet should remain")
+	}
+}
+
+func TestLoad_SyntaxError(t *testing.T) {
+	dir := t.TempDir()
+	bad := writeFile(t, dir, "bad.toml", "[editor\ntab_size = 2\n")
+	good := writeFile(t, dir, "good.toml", "[editor]\ntab_size = 2\n")
+
+	cfg, err := Load(bad, good)
+	if err == nil || !strings.Contains(err.Error(), "parse "+bad) {
+		t.Errorf("Load() error = %v, want a parse error naming %s", err, bad)
+	}
+	if cfg.Editor.TabSize != 2 {
+		t.Errorf("Editor.TabSize = %d, want 2 from the file that parsed", cfg.Editor.TabSize)
+	}
+}
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse("example", []byte("[search]\nhistory_size = 0\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if cfg.Search.HistorySize != 0 {
+		t.Errorf("Search.HistorySize = %d, want 0", cfg.Search.HistorySize)
+	}
+
+	if _, err := Parse("example", []byte("[editor]\nuse_spaces = \"yes\"\n")); err == nil {
+		t.Error("Parse() should reject a value of the wrong type")
+	}
+}
+
+func TestUserPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	want := filepath.Join(home, ".config", "ted", "config.toml")
+	if got, err := UserPath(); err != nil || got != want {
+		t.Errorf("UserPath() = %q, %v, want %q", got, err, want)
+	}
+
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	want = filepath.Join(xdg, "ted", "config.toml")
+	if got, _ := UserPath(); got != want {
+		t.Errorf("UserPath() = %q, want %q", got, want)
+	}
+}
+
+func TestProjectPath(t *testing.T) {
+	root := t.TempDir()
+	want := writeFile(t, root, "project/"+ProjectFileName, "")
+	sub := filepath.Join(root, "project", "cmd", "tool")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := ProjectPath(sub); got != want {
+		t.Errorf("ProjectPath(%q) = %q, want %q", sub, got, want)
+	}
+	if got := ProjectPath(root); got == want {
+		t.Errorf("ProjectPath(%q) = %q, should not look in subdirectories", root, got)
+	}
+}
+
+func TestExampleFile(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "ted.example.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse("ted.example.toml", data); err != nil {
+		t.Errorf("ted.example.toml should be valid: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+// Package config implements watching configuration files for changes.
+package config
+
+import (
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// fileStamp identifies a version of a file. The zero stamp means the file
+// doesn't exist.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watcher notices when configuration files are created, changed or
+// removed. It polls, comparing each file's modification time and size with
+// what it saw last. It is safe to use from several goroutines.
+type Watcher struct {
+	mu     sync.Mutex
+	paths  []string
+	stamps []fileStamp
+}
+
+// NewWatcher creates a watcher for paths, which need not exist yet.
+func NewWatcher(paths ...string) *Watcher {
+	w := &Watcher{}
+	w.SetPaths(paths...)
+	return w
+}
+
+// SetPaths changes the files watched. Changes made before the call are not
+// reported.
+func (w *Watcher) SetPaths(paths ...string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.paths = slices.DeleteFunc(slices.Clone(paths), func(p string) bool { return p == "" })
+	w.stamps = make([]fileStamp, len(w.paths))
+	for i, path := range w.paths {
+		w.stamps[i] = stamp(path)
+	}
+}
+
+// Paths returns the files watched.
+func (w *Watcher) Paths() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return slices.Clone(w.paths)
+}
+
+// Changed reports whether any file has changed since the last call, or
+// since the paths were set.
+func (w *Watcher) Changed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	changed := false
+	for i, path := range w.paths {
+		if s := stamp(path); s != w.stamps[i] {
+			w.stamps[i] = s
+			changed = true
+		}
+	}
+	return changed
+}
+
+// stamp returns the current stamp of the file at path.
+func stamp(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
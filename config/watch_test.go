@@ -0,0 +1,54 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcher_Changed(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	w := NewWatcher(path, "")
+
+	if w.Changed() {
+		t.Error("Changed() = true before anything changed")
+	}
+
+	// Creating a watched file is a change
+	writeFile(t, dir, "config.toml", "[editor]\n")
+	if !w.Changed() {
+		t.Error("Changed() = false after the file was created")
+	}
+	if w.Changed() {
+		t.Error("Changed() should report a change once")
+	}
+
+	// So is editing it
+	writeFile(t, dir, "config.toml", "[editor]\ntab_size = 2\n")
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if !w.Changed() {
+		t.Error("Changed() = false after the file was edited")
+	}
+
+	// And removing it
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if !w.Changed() {
+		t.Error("Changed() = false after the file was removed")
+	}
+
+	w.SetPaths(filepath.Join(dir, "other.toml"))
+	if got := w.Paths(); len(got) != 1 {
+		t.Errorf("Paths() = %v, want one path", got)
+	}
+	writeFile(t, dir, "config.toml", "")
+	if w.Changed() {
+		t.Error("Changed() should ignore files no longer watched")
+	}
+}
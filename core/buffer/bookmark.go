@@ -0,0 +1,130 @@
+// Package buffer implements bookmarks.
+package buffer
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MaxNumberedBookmark is the highest number a numbered bookmark can have.
+// Numbered bookmarks are named "1" to "9".
+const MaxNumberedBookmark = 9
+
+// Bookmark marks a position in the text under a name. Its position moves
+// with the text around it as the buffer is edited.
+type Bookmark struct {
+	Name string
+	Pos  Position
+}
+
+// Number returns the number of a numbered bookmark, or 0 for a named one.
+func (bm Bookmark) Number() int {
+	n, err := strconv.Atoi(bm.Name)
+	if err != nil || n < 1 || n > MaxNumberedBookmark || strconv.Itoa(n) != bm.Name {
+		return 0
+	}
+	return n
+}
+
+// SetBookmark sets the bookmark called name to pos, clamped to the text,
+// replacing any bookmark of that name.
+func (b *Buffer) SetBookmark(name string, pos Position) {
+	bm := Bookmark{Name: name, Pos: b.clampPosition(pos)}
+	if i := b.bookmarkIndex(name); i >= 0 {
+		b.bookmarks[i] = bm
+		return
+	}
+	b.bookmarks = append(b.bookmarks, bm)
+}
+
+// RemoveBookmark removes the bookmark called name. It reports whether
+// there was one.
+func (b *Buffer) RemoveBookmark(name string) bool {
+	i := b.bookmarkIndex(name)
+	if i < 0 {
+		return false
+	}
+	b.bookmarks = slices.Delete(b.bookmarks, i, i+1)
+	return true
+}
+
+// Bookmark returns the position of the bookmark called name.
+func (b *Buffer) Bookmark(name string) (Position, bool) {
+	if i := b.bookmarkIndex(name); i >= 0 {
+		return b.bookmarks[i].Pos, true
+	}
+	return Position{}, false
+}
+
+// Bookmarks returns every bookmark in document order, and by name where
+// several share a position.
+func (b *Buffer) Bookmarks() []Bookmark {
+	bookmarks := slices.Clone(b.bookmarks)
+	slices.SortFunc(bookmarks, func(x, y Bookmark) int {
+		if c := ComparePositions(x.Pos, y.Pos); c != 0 {
+			return c
+		}
+		return strings.Compare(x.Name, y.Name)
+	})
+	return bookmarks
+}
+
+// SetBookmarks replaces every bookmark. Positions are clamped to the text
+// and later bookmarks replace earlier ones of the same name.
+func (b *Buffer) SetBookmarks(bookmarks []Bookmark) {
+	b.bookmarks = nil
+	for _, bm := range bookmarks {
+		b.SetBookmark(bm.Name, bm.Pos)
+	}
+}
+
+// bookmarkIndex returns the index of the bookmark called name, or -1.
+func (b *Buffer) bookmarkIndex(name string) int {
+	return slices.IndexFunc(b.bookmarks, func(bm Bookmark) bool { return bm.Name == name })
+}
+
+// clampBookmarks moves bookmarks left outside the text to its nearest
+// position.
+func (b *Buffer) clampBookmarks() {
+	for i := range b.bookmarks {
+		b.bookmarks[i].Pos = b.clampPosition(b.bookmarks[i].Pos)
+	}
+}
+
+// adjustMarks records an edit: it keeps the extra cursors, bookmarks,
+// folds and tracked ranges on the same text, as adjustPosition describes,
+// and counts a new revision.
+func (b *Buffer) adjustMarks(start, oldEnd, newEnd Position, dropped bool) {
+	b.adjustExtraCursors(start, oldEnd, newEnd, dropped)
+	for i := range b.bookmarks {
+		b.bookmarks[i].Pos = b.adjustPosition(b.bookmarks[i].Pos, start, oldEnd, newEnd, dropped)
+	}
+	b.adjustFolds(start, oldEnd, newEnd, dropped)
+	b.adjustTracked(start, oldEnd, newEnd, dropped)
+	b.revision++
+}
+
+// swapMarks records that line and the line below it swapped places,
+// keeping the marks on them on their text, and counts a new revision.
+func (b *Buffer) swapMarks(line int) {
+	b.swapExtraCursors(line)
+	for i := range b.bookmarks {
+		b.bookmarks[i].Pos = swapLines(b.bookmarks[i].Pos, line)
+	}
+	b.swapFolds(line)
+	b.swapTracked(line)
+	b.revision++
+}
+
+// swapLines returns where p is after line and the line below it swapped
+// places.
+func swapLines(p Position, line int) Position {
+	switch p.Line {
+	case line:
+		p.Line++
+	case line + 1:
+		p.Line--
+	}
+	return p
+}
@@ -0,0 +1,185 @@
+package buffer
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestBuffer_SetBookmark(t *testing.T) {
+	buf := NewBuffer()
+	buf.SetLines([]string{"one", "two", "three"})
+
+	buf.SetBookmark("todo", Position{Line: 2, Col: 1})
+	buf.SetBookmark("1", Position{Line: 0, Col: 0})
+	buf.SetBookmark("2", Position{Line: 9, Col: 9}) // Clamped to the end
+	buf.SetBookmark("todo", Position{Line: 1, Col: 0})
+
+	want := []Bookmark{
+		{Name: "1", Pos: Position{Line: 0, Col: 0}},
+		{Name: "todo", Pos: Position{Line: 1, Col: 0}},
+		{Name: "2", Pos: Position{Line: 2, Col: 5}},
+	}
+	if got := buf.Bookmarks(); !slices.Equal(got, want) {
+		t.Errorf("Bookmarks() = %v, want %v", got, want)
+	}
+
+	if !buf.RemoveBookmark("todo") || buf.RemoveBookmark("todo") {
+		t.Error("RemoveBookmark() should remove a bookmark once")
+	}
+	if _, ok := buf.Bookmark("todo"); ok {
+		t.Error("Bookmark() found a removed bookmark")
+	}
+	if pos, ok := buf.Bookmark("2"); !ok || pos != (Position{Line: 2, Col: 5}) {
+		t.Errorf("Bookmark(2) = %v, %v, want %v, true", pos, ok, Position{Line: 2, Col: 5})
+	}
+}
+
+func TestBuffer_BookmarksFollowEdits(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(b *Buffer) error
+		want Position
+	}{
+		{
+			name: "lines inserted above",
+			edit: func(b *Buffer) error { return b.Insert(Position{Line: 0, Col: 3}, "\nnew\nlines") },
+			want: Position{Line: 4, Col: 2},
+		},
+		{
+			name: "line deleted above",
+			edit: func(b *Buffer) error { return b.Delete(Position{Line: 0, Col: 3}, Position{Line: 1, Col: 3}) },
+			want: Position{Line: 1, Col: 2},
+		},
+		{
+			name: "emptied line removed above",
+			edit: func(b *Buffer) error { return b.Delete(Position{Line: 1, Col: 0}, Position{Line: 1, Col: 3}) },
+			want: Position{Line: 1, Col: 2},
+		},
+		{
+			name: "text inserted before on the line",
+			edit: func(b *Buffer) error { return b.Insert(Position{Line: 2, Col: 0}, ">>") },
+			want: Position{Line: 2, Col: 4},
+		},
+		{
+			name: "edit below",
+			edit: func(b *Buffer) error { return b.Insert(Position{Line: 3, Col: 0}, "\n\n") },
+			want: Position{Line: 2, Col: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := NewBuffer()
+			buf.SetLines([]string{"one", "two", "three", "four"})
+			buf.SetBookmark("1", Position{Line: 2, Col: 2})
+
+			if err := tt.edit(buf); err != nil {
+				t.Fatalf("edit error = %v", err)
+			}
+			if got, _ := buf.Bookmark("1"); got != tt.want {
+				t.Errorf("Bookmark(1) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuffer_BookmarksFollowLineOperations(t *testing.T) {
+	tests := []struct {
+		name string
+		line int // Cursor line
+		op   func(b *Buffer) error
+		want []Position // Bookmarks 1 to 4
+	}{
+		{
+			name: "delete first line",
+			op:   func(b *Buffer) error { _, err := b.DeleteLine(); return err },
+			want: []Position{{Line: 0, Col: 0}, {Line: 0, Col: 2}, {Line: 1, Col: 0}, {Line: 2, Col: 3}},
+		},
+		{
+			name: "delete last line",
+			line: 3,
+			op:   func(b *Buffer) error { _, err := b.DeleteLine(); return err },
+			want: []Position{{Line: 0, Col: 1}, {Line: 1, Col: 2}, {Line: 2, Col: 0}, {Line: 2, Col: 5}},
+		},
+		{
+			name: "duplicate line",
+			line: 1,
+			op:   (*Buffer).DuplicateLine,
+			want: []Position{{Line: 0, Col: 1}, {Line: 1, Col: 2}, {Line: 3, Col: 0}, {Line: 4, Col: 3}},
+		},
+		{
+			name: "move line up",
+			line: 2,
+			op:   (*Buffer).MoveLineUp,
+			want: []Position{{Line: 0, Col: 1}, {Line: 2, Col: 2}, {Line: 1, Col: 0}, {Line: 3, Col: 3}},
+		},
+		{
+			name: "move line down",
+			op:   (*Buffer).MoveLineDown,
+			want: []Position{{Line: 1, Col: 1}, {Line: 0, Col: 2}, {Line: 2, Col: 0}, {Line: 3, Col: 3}},
+		},
+		{
+			name: "insert line above",
+			line: 2,
+			op:   (*Buffer).InsertLineAbove,
+			want: []Position{{Line: 0, Col: 1}, {Line: 1, Col: 2}, {Line: 3, Col: 0}, {Line: 4, Col: 3}},
+		},
+		{
+			name: "insert line above the first",
+			op:   (*Buffer).InsertLineAbove,
+			want: []Position{{Line: 1, Col: 1}, {Line: 2, Col: 2}, {Line: 3, Col: 0}, {Line: 4, Col: 3}},
+		},
+		{
+			name: "insert line below",
+			line: 1,
+			op:   (*Buffer).InsertLineBelow,
+			want: []Position{{Line: 0, Col: 1}, {Line: 1, Col: 2}, {Line: 3, Col: 0}, {Line: 4, Col: 3}},
+		},
+		{
+			name: "insert line below the last",
+			line: 3,
+			op:   (*Buffer).InsertLineBelow,
+			want: []Position{{Line: 0, Col: 1}, {Line: 1, Col: 2}, {Line: 2, Col: 0}, {Line: 3, Col: 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := NewBuffer()
+			buf.SetLines([]string{"one", "two", "three", "four"})
+			for i, pos := range []Position{{Line: 0, Col: 1}, {Line: 1, Col: 2}, {Line: 2, Col: 0}, {Line: 3, Col: 3}} {
+				buf.SetBookmark(strconv.Itoa(i+1), pos)
+			}
+			buf.MoveCursor(Position{Line: tt.line})
+
+			if err := tt.op(buf); err != nil {
+				t.Fatalf("line operation error = %v", err)
+			}
+			for i, want := range tt.want {
+				if got, _ := buf.Bookmark(strconv.Itoa(i + 1)); got != want {
+					t.Errorf("Bookmark(%d) = %v, want %v", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBookmark_Number(t *testing.T) {
+	tests := []struct {
+		name string
+		want int
+	}{
+		{"1", 1},
+		{"9", 9},
+		{"0", 0},
+		{"10", 0},
+		{"01", 0},
+		{"todo", 0},
+	}
+	for _, tt := range tests {
+		if got := (Bookmark{Name: tt.name}).Number(); got != tt.want {
+			t.Errorf("Bookmark{Name: %q}.Number() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -21,9 +21,14 @@ type Position struct {
 // It stores text as a slice of lines and provides methods for
 // editing operations. Buffer is not safe for concurrent use.
 type Buffer struct {
-	lines    []string
-	cursor   Position
-	modified bool
+	lines        []string
+	cursor       Position
+	extraCursors []Cursor        // Cursors besides the main one, kept on their text by edits
+	bookmarks    []Bookmark      // Marked positions, also kept on their text by edits
+	folds        []fold          // Folded regions, also kept on their text by edits
+	tracked      []*TrackedRange // Ranges kept on their text for others, such as snippet fields
+	revision     int             // Counts changes to the text
+	modified     bool
 }
 
 // NewBuffer creates a new empty buffer.
@@ -95,6 +100,7 @@ func (b *Buffer) Insert(pos Position, text string) error {
 		}
 	}
 
+	b.adjustMarks(pos, pos, PositionAfter(pos, text), false)
 	b.modified = true
 	return nil
 }
@@ -118,6 +124,7 @@ func (b *Buffer) Delete(start, end Position) error {
 		return nil
 	}
 
+	lineCount := len(b.lines)
 	if start.Line == end.Line {
 		// Single line delete
 		line := b.lines[start.Line]
@@ -202,9 +209,76 @@ func (b *Buffer) Delete(start, end Position) error {
 		b.modified = true
 	}
 
+	// An emptied line may have been removed too
+	dropped := len(b.lines) < lineCount-(end.Line-start.Line)
+	b.adjustMarks(start, end, start, dropped)
 	return nil
 }
 
+// Replace replaces the text between start and end (inclusive start,
+// exclusive end) with text, which may contain newlines.
+// Unlike Delete followed by Insert, Replace never drops lines that become
+// empty, so the result is exactly the surrounding text with the range spliced.
+// The cursor is left at the end of the inserted text, which is returned.
+func (b *Buffer) Replace(start, end Position, text string) (Position, error) {
+	if err := b.validatePosition(start); err != nil {
+		return start, err
+	}
+	if err := b.validatePosition(end); err != nil {
+		return start, err
+	}
+
+	if start.Line > end.Line || (start.Line == end.Line && start.Col > end.Col) {
+		return start, fmt.Errorf("invalid replace range: start position after end position")
+	}
+
+	before := b.lines[start.Line][:start.Col]
+	after := b.lines[end.Line][end.Col:]
+
+	inserted := strings.Split(text, "\n")
+	inserted[0] = before + inserted[0]
+	last := len(inserted) - 1
+	newEnd := Position{Line: start.Line + last, Col: len(inserted[last])}
+	inserted[last] += after
+
+	newLines := make([]string, 0, len(b.lines)-(end.Line-start.Line)+last)
+	newLines = append(newLines, b.lines[:start.Line]...)
+	newLines = append(newLines, inserted...)
+	newLines = append(newLines, b.lines[end.Line+1:]...)
+
+	b.lines = newLines
+	b.cursor = newEnd
+	b.adjustMarks(start, end, newEnd, false)
+	b.modified = true
+	return newEnd, nil
+}
+
+// PositionAfter returns the position just past text when it is inserted at start.
+func PositionAfter(start Position, text string) Position {
+	n := strings.Count(text, "\n")
+	if n == 0 {
+		return Position{Line: start.Line, Col: start.Col + len(text)}
+	}
+	return Position{Line: start.Line + n, Col: len(text) - strings.LastIndex(text, "\n") - 1}
+}
+
+// AdjustForReplace returns where pos ends up after the text between start
+// and oldEnd is replaced by text ending at newEnd. Positions before start
+// are unchanged, positions after oldEnd move with the text that follows,
+// and positions inside the replaced range move to newEnd.
+func AdjustForReplace(pos, start, oldEnd, newEnd Position) Position {
+	if pos.Line < start.Line || (pos.Line == start.Line && pos.Col <= start.Col) {
+		return pos
+	}
+	if pos.Line < oldEnd.Line || (pos.Line == oldEnd.Line && pos.Col < oldEnd.Col) {
+		return newEnd
+	}
+	if pos.Line == oldEnd.Line {
+		return Position{Line: newEnd.Line, Col: newEnd.Col + pos.Col - oldEnd.Col}
+	}
+	return Position{Line: pos.Line + newEnd.Line - oldEnd.Line, Col: pos.Col}
+}
+
 // GetLine returns the text at the specified line number.
 // Returns an error if the line number is invalid.
 func (b *Buffer) GetLine(lineNum int) (string, error) {
@@ -225,7 +299,8 @@ func (b *Buffer) GetCursor() Position {
 }
 
 // MoveCursor moves the cursor to the specified position.
-// The position is validated and adjusted if necessary.
+// The position is validated and adjusted if necessary, and folds hiding
+// it are opened.
 func (b *Buffer) MoveCursor(pos Position) {
 	if pos.Line < 0 {
 		pos.Line = 0
@@ -250,6 +325,13 @@ func (b *Buffer) MoveCursor(pos Position) {
 	}
 
 	b.cursor = pos
+	b.revealCursor()
+}
+
+// Revision returns a number that changes whenever the text does, so that
+// results computed from the text can be kept until it changes.
+func (b *Buffer) Revision() int {
+	return b.revision
 }
 
 // IsModified returns whether the buffer has been modified since the last save.
@@ -263,7 +345,8 @@ func (b *Buffer) MarkSaved() {
 }
 
 // SetLines sets the buffer content from a slice of lines.
-// This is primarily used for loading files.
+// This is primarily used for loading files. Extra cursors, folds and
+// tracked ranges are removed and bookmarks are clamped to the new text.
 func (b *Buffer) SetLines(lines []string) {
 	if len(lines) == 0 {
 		b.lines = []string{""}
@@ -271,6 +354,11 @@ func (b *Buffer) SetLines(lines []string) {
 		b.lines = lines
 	}
 	b.cursor = Position{Line: 0, Col: 0}
+	b.extraCursors = nil
+	b.folds = nil
+	b.tracked = nil
+	b.clampBookmarks()
+	b.revision++
 	b.modified = false
 }
 
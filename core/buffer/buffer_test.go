@@ -428,3 +428,134 @@ func TestBuffer_SetLines(t *testing.T) {
 		t.Errorf("SetLines([]string{}) LineCount = %d, want 1", buf.LineCount())
 	}
 }
+
+func TestBuffer_Replace(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial []string
+		start   Position
+		end     Position
+		text    string
+		want    []string
+		wantEnd Position
+		wantErr bool
+	}{
+		{
+			name:    "replace within line",
+			initial: []string{"hello world"},
+			start:   Position{Line: 0, Col: 6},
+			end:     Position{Line: 0, Col: 11},
+			text:    "there",
+			want:    []string{"hello there"},
+			wantEnd: Position{Line: 0, Col: 11},
+		},
+		{
+			name:    "replace entire line keeps empty line",
+			initial: []string{"one", "two", "three"},
+			start:   Position{Line: 1, Col: 0},
+			end:     Position{Line: 1, Col: 3},
+			text:    "",
+			want:    []string{"one", "", "three"},
+			wantEnd: Position{Line: 1, Col: 0},
+		},
+		{
+			name:    "replace across lines",
+			initial: []string{"}", "", "func main() {"},
+			start:   Position{Line: 0, Col: 0},
+			end:     Position{Line: 2, Col: 4},
+			text:    "} func",
+			want:    []string{"} func main() {"},
+			wantEnd: Position{Line: 0, Col: 6},
+		},
+		{
+			name:    "replace with multiple lines",
+			initial: []string{"a, b"},
+			start:   Position{Line: 0, Col: 1},
+			end:     Position{Line: 0, Col: 3},
+			text:    ",\n\n",
+			want:    []string{"a,", "", "b"},
+			wantEnd: Position{Line: 2, Col: 0},
+		},
+		{
+			name:    "invalid range",
+			initial: []string{"hello"},
+			start:   Position{Line: 0, Col: 3},
+			end:     Position{Line: 0, Col: 1},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := NewBuffer()
+			buf.SetLines(tt.initial)
+
+			gotEnd, err := buf.Replace(tt.start, tt.end, tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Replace() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got := buf.GetAllLines(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Replace() got = %q, want %q", got, tt.want)
+			}
+			if gotEnd != tt.wantEnd {
+				t.Errorf("Replace() end = %v, want %v", gotEnd, tt.wantEnd)
+			}
+			if buf.GetCursor() != tt.wantEnd {
+				t.Errorf("Replace() cursor = %v, want %v", buf.GetCursor(), tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestPositionAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		start Position
+		text  string
+		want  Position
+	}{
+		{name: "empty", start: Position{Line: 2, Col: 3}, text: "", want: Position{Line: 2, Col: 3}},
+		{name: "single line", start: Position{Line: 2, Col: 3}, text: "abc", want: Position{Line: 2, Col: 6}},
+		{name: "multi line", start: Position{Line: 2, Col: 3}, text: "ab\ncd\nxyz", want: Position{Line: 4, Col: 3}},
+		{name: "trailing newline", start: Position{Line: 0, Col: 5}, text: "ab\n", want: Position{Line: 1, Col: 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PositionAfter(tt.start, tt.text); got != tt.want {
+				t.Errorf("PositionAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdjustForReplace(t *testing.T) {
+	// "hello world" -> replace "hello" (0:0-0:5) with "hi\nthere" (ends at 1:5)
+	start := Position{Line: 0, Col: 0}
+	oldEnd := Position{Line: 0, Col: 5}
+	newEnd := Position{Line: 1, Col: 5}
+
+	tests := []struct {
+		name string
+		pos  Position
+		want Position
+	}{
+		{name: "at start", pos: Position{Line: 0, Col: 0}, want: Position{Line: 0, Col: 0}},
+		{name: "inside", pos: Position{Line: 0, Col: 3}, want: Position{Line: 1, Col: 5}},
+		{name: "at old end", pos: Position{Line: 0, Col: 5}, want: Position{Line: 1, Col: 5}},
+		{name: "after on same line", pos: Position{Line: 0, Col: 8}, want: Position{Line: 1, Col: 8}},
+		{name: "later line", pos: Position{Line: 3, Col: 2}, want: Position{Line: 4, Col: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AdjustForReplace(tt.pos, start, oldEnd, newEnd); got != tt.want {
+				t.Errorf("AdjustForReplace(%v) = %v, want %v", tt.pos, got, tt.want)
+			}
+		})
+	}
+}
@@ -10,8 +10,8 @@ func (b *Buffer) MoveCursorLeft() {
 		// Move left within the same line
 		pos.Col--
 	} else if pos.Line > 0 {
-		// Move to end of previous line
-		pos.Line--
+		// Move to end of previous line in view
+		pos.Line = b.lineAbove(pos.Line)
 		pos.Col = len(b.lines[pos.Line])
 	}
 
@@ -27,22 +27,22 @@ func (b *Buffer) MoveCursorRight() {
 	if pos.Col < currentLineLen {
 		// Move right within the same line
 		pos.Col++
-	} else if pos.Line < len(b.lines)-1 {
-		// Move to start of next line
-		pos.Line++
+	} else if below := b.lineBelow(pos.Line); below != pos.Line {
+		// Move to start of next line in view
+		pos.Line = below
 		pos.Col = 0
 	}
 
 	b.MoveCursor(pos)
 }
 
-// MoveCursorUp moves the cursor one line up.
+// MoveCursorUp moves the cursor one line up, over folded lines.
 // The column position is preserved if possible, otherwise adjusted.
 func (b *Buffer) MoveCursorUp() {
 	pos := b.cursor
 
 	if pos.Line > 0 {
-		pos.Line--
+		pos.Line = b.lineAbove(pos.Line)
 		// Preserve column position if possible
 		maxCol := len(b.lines[pos.Line])
 		if pos.Col > maxCol {
@@ -53,13 +53,13 @@ func (b *Buffer) MoveCursorUp() {
 	b.MoveCursor(pos)
 }
 
-// MoveCursorDown moves the cursor one line down.
+// MoveCursorDown moves the cursor one line down, over folded lines.
 // The column position is preserved if possible, otherwise adjusted.
 func (b *Buffer) MoveCursorDown() {
 	pos := b.cursor
 
 	if pos.Line < len(b.lines)-1 {
-		pos.Line++
+		pos.Line = b.lineBelow(pos.Line)
 		// Preserve column position if possible
 		maxCol := len(b.lines[pos.Line])
 		if pos.Col > maxCol {
@@ -0,0 +1,154 @@
+// Package buffer implements code folding.
+package buffer
+
+import (
+	"slices"
+)
+
+// FoldRange is a region of text that can be folded: its first line stays
+// in view as a header and the lines after it, up to End, are hidden.
+type FoldRange struct {
+	Start int // Header line
+	End   int // Last hidden line
+}
+
+// fold is a folded region. It is kept as the ends of its header line and
+// of its last hidden line, so that edits keep it on its text.
+type fold struct {
+	header Position
+	last   Position
+}
+
+// foldRange returns the lines of a fold.
+func (f fold) foldRange() FoldRange {
+	return FoldRange{Start: f.header.Line, End: f.last.Line}
+}
+
+// Fold hides the lines of r after its header, replacing any fold with the
+// same header. Ranges with no lines to hide are ignored. A cursor that was
+// hidden moves to the header.
+func (b *Buffer) Fold(r FoldRange) {
+	r.End = min(r.End, len(b.lines)-1)
+	if r.Start < 0 || r.End <= r.Start {
+		return
+	}
+	b.Unfold(r.Start)
+	b.folds = append(b.folds, fold{
+		header: Position{Line: r.Start, Col: len(b.lines[r.Start])},
+		last:   Position{Line: r.End, Col: len(b.lines[r.End])},
+	})
+
+	if outer, ok := b.outerFold(b.cursor.Line); ok {
+		b.cursor = b.clampPosition(Position{Line: outer.Start, Col: b.cursor.Col})
+	}
+}
+
+// Unfold shows the lines folded under line. It reports whether line was
+// the header of a fold.
+func (b *Buffer) Unfold(line int) bool {
+	n := len(b.folds)
+	b.folds = slices.DeleteFunc(b.folds, func(f fold) bool { return f.header.Line == line })
+	return len(b.folds) < n
+}
+
+// UnfoldAll shows every folded line.
+func (b *Buffer) UnfoldAll() {
+	b.folds = nil
+}
+
+// Folds returns the folded regions, nested ones included, in order of
+// their headers.
+func (b *Buffer) Folds() []FoldRange {
+	folds := make([]FoldRange, len(b.folds))
+	for i, f := range b.folds {
+		folds[i] = f.foldRange()
+	}
+	slices.SortFunc(folds, func(x, y FoldRange) int { return x.Start - y.Start })
+	return folds
+}
+
+// IsFolded reports whether line is the header of a fold.
+func (b *Buffer) IsFolded(line int) bool {
+	return slices.ContainsFunc(b.folds, func(f fold) bool { return f.header.Line == line })
+}
+
+// IsHidden reports whether line is hidden in a fold.
+func (b *Buffer) IsHidden(line int) bool {
+	_, ok := b.outerFold(line)
+	return ok
+}
+
+// outerFold returns the outermost fold hiding line, whose header is in
+// view.
+func (b *Buffer) outerFold(line int) (FoldRange, bool) {
+	var outer FoldRange
+	found := false
+	for _, f := range b.folds {
+		r := f.foldRange()
+		if r.Start < line && line <= r.End && (!found || r.Start < outer.Start) {
+			outer, found = r, true
+		}
+	}
+	return outer, found
+}
+
+// lineAbove returns the nearest line above line that is in view, or line
+// if there is none.
+func (b *Buffer) lineAbove(line int) int {
+	if line <= 0 {
+		return line
+	}
+	if outer, ok := b.outerFold(line - 1); ok {
+		return outer.Start
+	}
+	return line - 1
+}
+
+// lineBelow returns the nearest line below line that is in view, or line
+// if there is none.
+func (b *Buffer) lineBelow(line int) int {
+	below := line + 1
+	if outer, ok := b.outerFold(below); ok {
+		below = outer.End + 1
+	}
+	if below >= len(b.lines) {
+		return line
+	}
+	return below
+}
+
+// adjustFolds keeps folds on the same text after an edit, as
+// adjustPosition describes. Folds whose header line was removed, or that
+// no longer hide any line, are dropped, as are any hiding the cursor.
+func (b *Buffer) adjustFolds(start, oldEnd, newEnd Position, dropped bool) {
+	if len(b.folds) == 0 {
+		return
+	}
+	b.folds = slices.DeleteFunc(b.folds, func(f fold) bool {
+		return ComparePositions(f.header, start) > 0 && ComparePositions(f.header, oldEnd) < 0
+	})
+	for i := range b.folds {
+		b.folds[i].header = b.adjustPosition(b.folds[i].header, start, oldEnd, newEnd, dropped)
+		b.folds[i].last = b.adjustPosition(b.folds[i].last, start, oldEnd, newEnd, dropped)
+	}
+	b.folds = slices.DeleteFunc(b.folds, func(f fold) bool { return f.last.Line <= f.header.Line })
+	b.revealCursor()
+}
+
+// swapFolds drops the folds with an end on line or the line below it when
+// the two swap places, as they no longer fold the same text, and any
+// hiding the cursor.
+func (b *Buffer) swapFolds(line int) {
+	b.folds = slices.DeleteFunc(b.folds, func(f fold) bool {
+		return f.header.Line == line || f.header.Line == line+1 || f.last.Line == line || f.last.Line == line+1
+	})
+	b.revealCursor()
+}
+
+// revealCursor opens the folds hiding the cursor.
+func (b *Buffer) revealCursor() {
+	line := b.cursor.Line
+	b.folds = slices.DeleteFunc(b.folds, func(f fold) bool {
+		return f.header.Line < line && line <= f.last.Line
+	})
+}
@@ -0,0 +1,190 @@
+package buffer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBuffer_Fold(t *testing.T) {
+	buf := NewBuffer()
+	buf.SetLines([]string{"a {", "  b", "  c {", "    d", "  }", "}", "e"})
+	buf.MoveCursor(Position{Line: 3, Col: 2})
+
+	buf.Fold(FoldRange{Start: 2, End: 3})
+	buf.Fold(FoldRange{Start: 0, End: 4})
+	buf.Fold(FoldRange{Start: 5, End: 5}) // Nothing to hide
+
+	want := []FoldRange{{Start: 0, End: 4}, {Start: 2, End: 3}}
+	if got := buf.Folds(); !slices.Equal(got, want) {
+		t.Errorf("Folds() = %v, want %v", got, want)
+	}
+	if got, want := buf.GetCursor(), (Position{Line: 0, Col: 2}); got != want {
+		t.Errorf("cursor = %v, want %v on the header of the fold hiding it", got, want)
+	}
+	for line, want := range []bool{false, true, true, true, true, false, false} {
+		if got := buf.IsHidden(line); got != want {
+			t.Errorf("IsHidden(%d) = %v, want %v", line, got, want)
+		}
+	}
+
+	// Unfolding the outer fold keeps the inner one
+	if !buf.Unfold(0) || buf.Unfold(0) {
+		t.Error("Unfold(0) should unfold once")
+	}
+	if !buf.IsFolded(2) || !buf.IsHidden(3) || buf.IsHidden(2) {
+		t.Error("the inner fold should still be folded")
+	}
+
+	// Moving the cursor into a fold opens it
+	buf.MoveCursor(Position{Line: 3})
+	if len(buf.Folds()) != 0 {
+		t.Errorf("Folds() = %v after moving into the fold, want none", buf.Folds())
+	}
+}
+
+func TestBuffer_CursorSkipsFolds(t *testing.T) {
+	buf := NewBuffer()
+	buf.SetLines([]string{"head {", "  one", "  two", "}", "tail"})
+	buf.Fold(FoldRange{Start: 0, End: 2})
+
+	tests := []struct {
+		name string
+		from Position
+		move func()
+		want Position
+	}{
+		{"down", Position{Line: 0, Col: 1}, buf.MoveCursorDown, Position{Line: 3, Col: 1}},
+		{"up", Position{Line: 3, Col: 1}, buf.MoveCursorUp, Position{Line: 0, Col: 1}},
+		{"right at line end", Position{Line: 0, Col: 6}, buf.MoveCursorRight, Position{Line: 3, Col: 0}},
+		{"left at line start", Position{Line: 3, Col: 0}, buf.MoveCursorLeft, Position{Line: 0, Col: 6}},
+		{"word right at line end", Position{Line: 0, Col: 6}, buf.MoveCursorWordRight, Position{Line: 3, Col: 0}},
+		{"word left at line start", Position{Line: 3, Col: 0}, buf.MoveCursorWordLeft, Position{Line: 0, Col: 6}},
+		{"page down", Position{Line: 0, Col: 0}, func() { buf.MoveCursorPageDown(2) }, Position{Line: 4, Col: 0}},
+		{"page up", Position{Line: 4, Col: 0}, func() { buf.MoveCursorPageUp(2) }, Position{Line: 0, Col: 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.MoveCursor(tt.from)
+			tt.move()
+			if got := buf.GetCursor(); got != tt.want {
+				t.Errorf("cursor = %v, want %v", got, tt.want)
+			}
+			if !buf.IsFolded(0) {
+				t.Error("moving over the fold opened it")
+			}
+		})
+	}
+}
+
+func TestBuffer_FoldsFollowEdits(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(b *Buffer) error
+		want []FoldRange
+	}{
+		{
+			name: "lines inserted above",
+			edit: func(b *Buffer) error { return b.Insert(Position{Line: 0, Col: 0}, "x\ny\n") },
+			want: []FoldRange{{Start: 3, End: 5}},
+		},
+		{
+			name: "line deleted above",
+			edit: func(b *Buffer) error { return b.Delete(Position{Line: 0, Col: 0}, Position{Line: 1, Col: 0}) },
+			want: []FoldRange{{Start: 0, End: 2}},
+		},
+		{
+			name: "header line deleted",
+			edit: func(b *Buffer) error { return b.Delete(Position{Line: 1, Col: 0}, Position{Line: 2, Col: 0}) },
+			want: []FoldRange{},
+		},
+		{
+			name: "header edited",
+			edit: func(b *Buffer) error { return b.Insert(Position{Line: 1, Col: 0}, "// ") },
+			want: []FoldRange{{Start: 1, End: 3}},
+		},
+		{
+			name: "lines replaced below",
+			edit: func(b *Buffer) error {
+				_, err := b.Replace(Position{Line: 4, Col: 0}, Position{Line: 5, Col: 0}, "")
+				return err
+			},
+			want: []FoldRange{{Start: 1, End: 3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := NewBuffer()
+			buf.SetLines([]string{"top", "head {", "  a", "  b", "}", "end"})
+			buf.MoveCursor(Position{Line: 5})
+			buf.Fold(FoldRange{Start: 1, End: 3})
+			revision := buf.Revision()
+
+			if err := tt.edit(buf); err != nil {
+				t.Fatalf("edit error = %v", err)
+			}
+			if got := buf.Folds(); !slices.Equal(got, tt.want) {
+				t.Errorf("Folds() = %v, want %v", got, tt.want)
+			}
+			if buf.Revision() == revision {
+				t.Error("Revision() did not change after an edit")
+			}
+		})
+	}
+}
+
+func TestBuffer_FoldsFollowLineOperations(t *testing.T) {
+	deleteLine := func(b *Buffer) error { _, err := b.DeleteLine(); return err }
+	tests := []struct {
+		name string
+		line int // Cursor line
+		ops  []func(b *Buffer) error
+		want []FoldRange
+	}{
+		{"line deleted above", 0, []func(*Buffer) error{deleteLine}, []FoldRange{{Start: 0, End: 2}}},
+		{"header line deleted", 0, []func(*Buffer) error{deleteLine, deleteLine}, []FoldRange{}},
+		{"last line deleted", 5, []func(*Buffer) error{deleteLine}, []FoldRange{{Start: 1, End: 3}}},
+		{"line duplicated above", 0, []func(*Buffer) error{(*Buffer).DuplicateLine}, []FoldRange{{Start: 2, End: 4}}},
+		{"line inserted above the header", 1, []func(*Buffer) error{(*Buffer).InsertLineAbove}, []FoldRange{{Start: 2, End: 4}}},
+		{"line inserted below", 4, []func(*Buffer) error{(*Buffer).InsertLineBelow}, []FoldRange{{Start: 1, End: 3}}},
+		{"line inserted into the fold", 1, []func(*Buffer) error{(*Buffer).InsertLineBelow}, []FoldRange{}},
+		{"lines swapped below", 5, []func(*Buffer) error{(*Buffer).MoveLineUp}, []FoldRange{{Start: 1, End: 3}}},
+		{"header moved up", 1, []func(*Buffer) error{(*Buffer).MoveLineUp}, []FoldRange{}},
+		{"line moved down over the header", 0, []func(*Buffer) error{(*Buffer).MoveLineDown}, []FoldRange{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := NewBuffer()
+			buf.SetLines([]string{"top", "head {", "  a", "  b", "}", "end"})
+			buf.MoveCursor(Position{Line: tt.line})
+			buf.Fold(FoldRange{Start: 1, End: 3})
+
+			for _, op := range tt.ops {
+				revision := buf.Revision()
+				if err := op(buf); err != nil {
+					t.Fatalf("line operation error = %v", err)
+				}
+				if buf.Revision() == revision {
+					t.Error("Revision() did not change after a line operation")
+				}
+			}
+			if got := buf.Folds(); !slices.Equal(got, tt.want) {
+				t.Errorf("Folds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuffer_FoldOpensAtCursor(t *testing.T) {
+	buf := NewBuffer()
+	buf.SetLines([]string{"head {", "  a", "}"})
+	buf.Fold(FoldRange{Start: 0, End: 1})
+
+	// A new line typed after the header lands in the fold, which opens
+	buf.MoveCursor(Position{Line: 0, Col: 6})
+	if err := buf.Insert(buf.GetCursor(), "\n"); err != nil {
+		t.Fatalf("Insert() error = %v", err)
+	}
+	if got := buf.Folds(); len(got) != 0 {
+		t.Errorf("Folds() = %v, want none once the cursor is inside", got)
+	}
+}
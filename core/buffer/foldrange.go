@@ -0,0 +1,160 @@
+// Package buffer implements finding the regions of text that can be folded.
+package buffer
+
+import (
+	"slices"
+)
+
+// FoldProvider finds the regions of a text that can be folded. Blocks can
+// be found from indentation or brackets, as IndentFolds and BracketFolds
+// do, or from what a syntax highlighter knows of the text.
+type FoldProvider interface {
+	// FoldRanges returns the foldable regions of lines, as
+	// SortFoldRanges orders them.
+	FoldRanges(lines []string) []FoldRange
+}
+
+// IndentFolds finds foldable regions from indentation: a line folds the
+// lines after it that are indented further. Blank lines inside a block are
+// folded with it; those after its last line are not.
+type IndentFolds struct {
+	TabSize int // Columns between tab stops; 4 if not set
+}
+
+// FoldRanges returns the blocks of lines indented under another line.
+func (f IndentFolds) FoldRanges(lines []string) []FoldRange {
+	tabSize := f.TabSize
+	if tabSize <= 0 {
+		tabSize = 4
+	}
+
+	type open struct{ line, indent int }
+	var stack []open
+	var ranges []FoldRange
+	lastText := -1 // Last line that is not blank
+	closeTo := func(indent int) {
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if lastText > top.line {
+				ranges = append(ranges, FoldRange{Start: top.line, End: lastText})
+			}
+		}
+	}
+
+	for i, line := range lines {
+		indent, blank := indentWidth(line, tabSize)
+		if blank {
+			continue
+		}
+		closeTo(indent)
+		stack = append(stack, open{line: i, indent: indent})
+		lastText = i
+	}
+	closeTo(0)
+	return SortFoldRanges(ranges)
+}
+
+// indentWidth returns the columns a line is indented by, with tabs
+// reaching the next tab stop, and whether the line is blank.
+func indentWidth(line string, tabSize int) (int, bool) {
+	width := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case ' ':
+			width++
+		case '\t':
+			width += tabSize - width%tabSize
+		default:
+			return width, false
+		}
+	}
+	return width, true
+}
+
+// BracketFolds finds foldable regions from brackets: (), [] and {} pairs
+// that open and close on different lines fold the lines between them,
+// leaving the closing bracket in view. Brackets in strings and comments,
+// written as in C, Go or JavaScript, are skipped.
+type BracketFolds struct{}
+
+// FoldRanges returns the lines between brackets that span lines.
+func (BracketFolds) FoldRanges(lines []string) []FoldRange {
+	type open struct {
+		line  int
+		close byte
+	}
+	var stack []open
+	var ranges []FoldRange
+	var quote byte        // Quote of the string being read, or 0
+	blockComment := false // Inside /* */
+
+	for i, line := range lines {
+		for j := 0; j < len(line); j++ {
+			c := line[j]
+			switch {
+			case blockComment:
+				if c == '*' && j+1 < len(line) && line[j+1] == '/' {
+					blockComment = false
+					j++
+				}
+			case quote != 0:
+				if c == '\\' && quote != '`' {
+					j++ // Skip the escaped character
+				} else if c == quote {
+					quote = 0
+				}
+			case c == '/' && j+1 < len(line) && line[j+1] == '/':
+				j = len(line) // Rest of the line is a comment
+			case c == '/' && j+1 < len(line) && line[j+1] == '*':
+				blockComment = true
+				j++
+			case c == '"' || c == '\'' || c == '`':
+				quote = c
+			case c == '(' || c == '[' || c == '{':
+				stack = append(stack, open{line: i, close: closingBracket(c)})
+			case c == ')' || c == ']' || c == '}':
+				// Drop brackets left open inside this pair
+				k := len(stack) - 1
+				for k >= 0 && stack[k].close != c {
+					k--
+				}
+				if k < 0 {
+					continue // Unmatched
+				}
+				if start := stack[k].line; i-1 > start {
+					ranges = append(ranges, FoldRange{Start: start, End: i - 1})
+				}
+				stack = stack[:k]
+			}
+		}
+		if quote != '`' {
+			quote = 0 // Only raw strings span lines
+		}
+	}
+	return SortFoldRanges(ranges)
+}
+
+// closingBracket returns the bracket that closes c.
+func closingBracket(c byte) byte {
+	switch c {
+	case '(':
+		return ')'
+	case '[':
+		return ']'
+	}
+	return '}'
+}
+
+// SortFoldRanges orders fold ranges by their header lines, keeping only
+// the largest of those sharing a header, so that each header folds one
+// region. It sorts ranges in place and returns them.
+func SortFoldRanges(ranges []FoldRange) []FoldRange {
+	slices.SortFunc(ranges, func(x, y FoldRange) int {
+		if x.Start != y.Start {
+			return x.Start - y.Start
+		}
+		return y.End - x.End
+	})
+	return slices.CompactFunc(ranges, func(x, y FoldRange) bool { return x.Start == y.Start })
+}
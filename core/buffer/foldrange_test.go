@@ -0,0 +1,84 @@
+package buffer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIndentFolds(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []FoldRange
+	}{
+		{
+			name:  "yaml",
+			lines: []string{"a:", "  b: 1", "  c:", "    - d", "", "e: 2"},
+			want:  []FoldRange{{Start: 0, End: 3}, {Start: 2, End: 3}},
+		},
+		{
+			name:  "blank lines inside a block",
+			lines: []string{"def f():", "    x = 1", "", "    return x", "", ""},
+			want:  []FoldRange{{Start: 0, End: 3}},
+		},
+		{
+			name:  "tabs",
+			lines: []string{"func f() {", "\tif x {", "\t\ty()", "\t}", "}"},
+			want:  []FoldRange{{Start: 0, End: 3}, {Start: 1, End: 2}},
+		},
+		{
+			name:  "flat",
+			lines: []string{"a", "b", "", "c"},
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := IndentFolds{TabSize: 4}.FoldRanges(tt.lines)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FoldRanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBracketFolds(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []FoldRange
+	}{
+		{
+			name:  "nested blocks",
+			lines: []string{"func f() {", "\tif x {", "\t\ty()", "\t}", "}"},
+			want:  []FoldRange{{Start: 0, End: 3}, {Start: 1, End: 2}},
+		},
+		{
+			name:  "grouped declaration",
+			lines: []string{"import (", `	"fmt"`, `	"os"`, ")"},
+			want:  []FoldRange{{Start: 0, End: 2}},
+		},
+		{
+			name:  "pair closing on the next line",
+			lines: []string{"x := []int{", "}"},
+			want:  nil,
+		},
+		{
+			name:  "brackets in strings and comments",
+			lines: []string{"s := \"{\" // {", "/* {", "} */ f('{')", "t := `", "{", "`"},
+			want:  nil,
+		},
+		{
+			name:  "widest region of a header",
+			lines: []string{"call(func() {", "\tx()", "})"},
+			want:  []FoldRange{{Start: 0, End: 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (BracketFolds{}).FoldRanges(tt.lines); !slices.Equal(got, tt.want) {
+				t.Errorf("FoldRanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Package buffer implements indentation detection.
+package buffer
+
+// maxDetectLines limits how many lines DetectIndentation reads, so large
+// files open quickly.
+const maxDetectLines = 10000
+
+// maxIndentSize is the largest indentation step counted as one level.
+const maxIndentSize = 8
+
+// Indentation is how lines are indented: with tabs, or with Size spaces
+// per level.
+type Indentation struct {
+	UseSpaces bool
+	Size      int // Spaces per level; 0 for tabs
+}
+
+// DetectIndentation guesses how the buffer's lines are indented. See
+// DetectIndentation.
+func (b *Buffer) DetectIndentation() (Indentation, bool) {
+	return DetectIndentation(b.lines)
+}
+
+// DetectIndentation guesses how lines are indented. Tabs win if more lines
+// start with a tab than with spaces. For spaces, the size is the increase
+// in leading spaces seen most often between a line and the non-blank line
+// before it; ties go to the smaller size. Single spaces, as in the " *"
+// of block comments, don't count. It reports false if the lines give no
+// clear answer, such as when none are indented.
+func DetectIndentation(lines []string) (Indentation, bool) {
+	var tabLines, spaceLines int
+	var deltas [maxIndentSize + 1]int // How often each increase was seen
+	prev := 0                         // Leading spaces of the last non-blank line
+
+	for _, line := range lines[:min(len(lines), maxDetectLines)] {
+		spaces := 0
+		for spaces < len(line) && line[spaces] == ' ' {
+			spaces++
+		}
+		switch {
+		case spaces == len(line):
+			continue // Blank lines say nothing
+		case spaces == 0 && line[0] == '\t':
+			tabLines++
+		case spaces >= 2:
+			spaceLines++
+			if delta := spaces - prev; delta >= 2 && delta <= maxIndentSize {
+				deltas[delta]++
+			}
+		}
+		prev = spaces
+	}
+
+	switch {
+	case tabLines > spaceLines:
+		return Indentation{UseSpaces: false}, true
+	case spaceLines > tabLines:
+		best := 0
+		for size := 2; size <= maxIndentSize; size++ {
+			if deltas[size] > deltas[best] {
+				best = size
+			}
+		}
+		if best > 0 {
+			return Indentation{UseSpaces: true, Size: best}, true
+		}
+	}
+	return Indentation{}, false
+}
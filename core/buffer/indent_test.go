@@ -0,0 +1,95 @@
+package buffer
+
+import (
+	"testing"
+)
+
+func TestDetectIndentation(t *testing.T) {
+	tests := []struct {
+		name   string
+		lines  []string
+		want   Indentation
+		wantOK bool
+	}{
+		{
+			name:   "tabs",
+			lines:  []string{"func main() {", "\tif x {", "\t\ty()", "\t}", "}"},
+			want:   Indentation{UseSpaces: false},
+			wantOK: true,
+		},
+		{
+			name:   "two spaces",
+			lines:  []string{"a:", "  b:", "    c: 1", "    d: 2", "  e: 3"},
+			want:   Indentation{UseSpaces: true, Size: 2},
+			wantOK: true,
+		},
+		{
+			name:   "four spaces with blank lines",
+			lines:  []string{"def f():", "    if x:", "", "        return 1", "    return 2"},
+			want:   Indentation{UseSpaces: true, Size: 4},
+			wantOK: true,
+		},
+		{
+			name: "most common increase wins",
+			lines: []string{
+				"class A:", "    def f(self):", "        pass",
+				"    x = call(a,", "             b)", // Alignment, seen once
+				"    def g(self):", "        pass",
+			},
+			want:   Indentation{UseSpaces: true, Size: 4},
+			wantOK: true,
+		},
+		{
+			name:   "tie goes to smaller size",
+			lines:  []string{"a", "  b", "c", "    d"},
+			want:   Indentation{UseSpaces: true, Size: 2},
+			wantOK: true,
+		},
+		{
+			name:   "block comment stars ignored",
+			lines:  []string{"/*", " * text", " */", "func f() {", "\treturn", "}"},
+			want:   Indentation{UseSpaces: false},
+			wantOK: true,
+		},
+		{
+			name:   "tabs with space alignment",
+			lines:  []string{"var (", "\ta   = 1", "\tbcd = 2", ")", "\tx", "  y"},
+			want:   Indentation{UseSpaces: false},
+			wantOK: true,
+		},
+		{
+			name:   "no indentation",
+			lines:  []string{"one", "two", "", "three"},
+			wantOK: false,
+		},
+		{
+			name:   "as many tab lines as space lines",
+			lines:  []string{"a", "\tb", "c", "  d"},
+			wantOK: false,
+		},
+		{
+			name:   "empty",
+			lines:  []string{""},
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := DetectIndentation(tt.lines)
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Errorf("DetectIndentation() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestBuffer_DetectIndentation(t *testing.T) {
+	buf := NewBuffer()
+	buf.SetLines([]string{"if x:", "   y", "   z"})
+
+	got, ok := buf.DetectIndentation()
+	if want := (Indentation{UseSpaces: true, Size: 3}); !ok || got != want {
+		t.Errorf("DetectIndentation() = %+v, %v, want %+v, true", got, ok, want)
+	}
+}
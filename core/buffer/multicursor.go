@@ -0,0 +1,113 @@
+// Package buffer implements multiple cursors.
+package buffer
+
+// Cursor is a cursor besides the main one: where it is, and where its
+// selection starts. Anchor equals Pos when nothing is selected.
+type Cursor struct {
+	Pos    Position
+	Anchor Position
+}
+
+// HasSelection reports whether the cursor has text selected.
+func (c Cursor) HasSelection() bool {
+	return c.Pos != c.Anchor
+}
+
+// Range returns the cursor's selection, start before end.
+func (c Cursor) Range() (start, end Position) {
+	if ComparePositions(c.Anchor, c.Pos) <= 0 {
+		return c.Anchor, c.Pos
+	}
+	return c.Pos, c.Anchor
+}
+
+// ComparePositions returns -1 if a is before b, 1 if it is after and 0 if
+// they are the same.
+func ComparePositions(a, b Position) int {
+	switch {
+	case a.Line < b.Line || (a.Line == b.Line && a.Col < b.Col):
+		return -1
+	case a == b:
+		return 0
+	}
+	return 1
+}
+
+// AddCursor adds a cursor besides the main one. Its positions are clamped
+// to the text. It reports false, adding nothing, if a cursor is already
+// at c.Pos.
+func (b *Buffer) AddCursor(c Cursor) bool {
+	c = Cursor{Pos: b.clampPosition(c.Pos), Anchor: b.clampPosition(c.Anchor)}
+	if c.Pos == b.cursor {
+		return false
+	}
+	for _, other := range b.extraCursors {
+		if other.Pos == c.Pos {
+			return false
+		}
+	}
+	b.extraCursors = append(b.extraCursors, c)
+	return true
+}
+
+// ExtraCursors returns the cursors besides the main one.
+func (b *Buffer) ExtraCursors() []Cursor {
+	return append([]Cursor(nil), b.extraCursors...)
+}
+
+// SetExtraCursors replaces the cursors besides the main one, keeping
+// their order. Their positions are clamped to the text.
+func (b *Buffer) SetExtraCursors(cursors []Cursor) {
+	b.extraCursors = b.extraCursors[:0]
+	for _, c := range cursors {
+		b.extraCursors = append(b.extraCursors, Cursor{Pos: b.clampPosition(c.Pos), Anchor: b.clampPosition(c.Anchor)})
+	}
+}
+
+// ClearExtraCursors removes every cursor but the main one.
+func (b *Buffer) ClearExtraCursors() {
+	b.extraCursors = nil
+}
+
+// HasExtraCursors reports whether there is more than one cursor.
+func (b *Buffer) HasExtraCursors() bool {
+	return len(b.extraCursors) > 0
+}
+
+// adjustExtraCursors keeps the extra cursors on the same text after an
+// edit, as adjustPosition describes.
+func (b *Buffer) adjustExtraCursors(start, oldEnd, newEnd Position, dropped bool) {
+	for i, c := range b.extraCursors {
+		b.extraCursors[i] = Cursor{
+			Pos:    b.adjustPosition(c.Pos, start, oldEnd, newEnd, dropped),
+			Anchor: b.adjustPosition(c.Anchor, start, oldEnd, newEnd, dropped),
+		}
+	}
+}
+
+// swapExtraCursors moves the extra cursors on line and the line below it
+// with their text when the two swap places.
+func (b *Buffer) swapExtraCursors(line int) {
+	for i, c := range b.extraCursors {
+		b.extraCursors[i] = Cursor{Pos: swapLines(c.Pos, line), Anchor: swapLines(c.Anchor, line)}
+	}
+}
+
+// adjustPosition returns where p is after the text between start and
+// oldEnd was replaced by text ending at newEnd. dropped reports that the
+// line at start was removed as well, as Delete does with a line it
+// empties.
+func (b *Buffer) adjustPosition(p, start, oldEnd, newEnd Position, dropped bool) Position {
+	p = AdjustForReplace(p, start, oldEnd, newEnd)
+	if dropped && p.Line > start.Line {
+		p.Line--
+	}
+	return b.clampPosition(p)
+}
+
+// clampPosition returns the position in the text nearest to pos.
+func (b *Buffer) clampPosition(pos Position) Position {
+	pos.Line = min(max(pos.Line, 0), len(b.lines)-1)
+	pos.Col = min(max(pos.Col, 0), len(b.lines[pos.Line]))
+	return pos
+}
@@ -0,0 +1,208 @@
+package buffer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBuffer_AddCursor(t *testing.T) {
+	buf := NewBuffer()
+	buf.SetLines([]string{"one", "two"})
+
+	tests := []struct {
+		name string
+		pos  Position
+		want bool
+	}{
+		{"new position", Position{Line: 1, Col: 1}, true},
+		{"same position again", Position{Line: 1, Col: 1}, false},
+		{"main cursor", Position{Line: 0, Col: 0}, false},
+		{"clamped to the text", Position{Line: 5, Col: 9}, true},
+	}
+	for _, tt := range tests {
+		if got := buf.AddCursor(Cursor{Pos: tt.pos, Anchor: tt.pos}); got != tt.want {
+			t.Errorf("%s: AddCursor(%v) = %v, want %v", tt.name, tt.pos, got, tt.want)
+		}
+	}
+
+	want := []Cursor{
+		{Pos: Position{Line: 1, Col: 1}, Anchor: Position{Line: 1, Col: 1}},
+		{Pos: Position{Line: 1, Col: 3}, Anchor: Position{Line: 1, Col: 3}},
+	}
+	if got := buf.ExtraCursors(); !slices.Equal(got, want) {
+		t.Errorf("ExtraCursors() = %v, want %v", got, want)
+	}
+
+	buf.SetLines([]string{"new"})
+	if buf.HasExtraCursors() {
+		t.Error("SetLines() should remove the extra cursors")
+	}
+}
+
+func TestBuffer_ExtraCursorsFollowEdits(t *testing.T) {
+	at := func(line, col int) Cursor {
+		return Cursor{Pos: Position{Line: line, Col: col}, Anchor: Position{Line: line, Col: col}}
+	}
+
+	tests := []struct {
+		name  string
+		edit  func(b *Buffer) error
+		lines []string
+		want  []Cursor
+	}{
+		{
+			name: "insert before on the same line",
+			edit: func(b *Buffer) error {
+				return b.Insert(Position{Line: 0, Col: 0}, "xx")
+			},
+			lines: []string{"xxabc", "def", "ghi"},
+			want:  []Cursor{at(0, 4), at(1, 1), {Pos: Position{Line: 2, Col: 3}, Anchor: Position{Line: 2, Col: 0}}},
+		},
+		{
+			name: "insert lines",
+			edit: func(b *Buffer) error {
+				return b.Insert(Position{Line: 0, Col: 1}, "\n\n")
+			},
+			lines: []string{"a", "", "bc", "def", "ghi"},
+			want:  []Cursor{at(2, 1), at(3, 1), {Pos: Position{Line: 4, Col: 3}, Anchor: Position{Line: 4, Col: 0}}},
+		},
+		{
+			name: "delete across a cursor",
+			edit: func(b *Buffer) error {
+				return b.Delete(Position{Line: 0, Col: 1}, Position{Line: 1, Col: 2})
+			},
+			lines: []string{"af", "ghi"},
+			want:  []Cursor{at(0, 1), at(0, 1), {Pos: Position{Line: 1, Col: 3}, Anchor: Position{Line: 1, Col: 0}}},
+		},
+		{
+			name: "delete that removes a line",
+			edit: func(b *Buffer) error {
+				return b.Delete(Position{Line: 1, Col: 0}, Position{Line: 1, Col: 3})
+			},
+			lines: []string{"abc", "ghi"},
+			want:  []Cursor{at(0, 2), at(1, 0), {Pos: Position{Line: 1, Col: 3}, Anchor: Position{Line: 1, Col: 0}}},
+		},
+		{
+			name: "replace a selection",
+			edit: func(b *Buffer) error {
+				_, err := b.Replace(Position{Line: 2, Col: 0}, Position{Line: 2, Col: 3}, "z")
+				return err
+			},
+			lines: []string{"abc", "def", "z"},
+			want:  []Cursor{at(0, 2), at(1, 1), {Pos: Position{Line: 2, Col: 1}, Anchor: Position{Line: 2, Col: 0}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := NewBuffer()
+			buf.SetLines([]string{"abc", "def", "ghi"})
+			buf.SetExtraCursors([]Cursor{at(0, 2), at(1, 1), {Pos: Position{Line: 2, Col: 3}, Anchor: Position{Line: 2, Col: 0}}})
+
+			if err := tt.edit(buf); err != nil {
+				t.Fatalf("edit error = %v", err)
+			}
+			if got := buf.GetAllLines(); !slices.Equal(got, tt.lines) {
+				t.Errorf("lines = %q, want %q", got, tt.lines)
+			}
+			if got := buf.ExtraCursors(); !slices.Equal(got, tt.want) {
+				t.Errorf("ExtraCursors() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuffer_ExtraCursorsFollowLineOperations(t *testing.T) {
+	at := func(line, col int) Cursor {
+		return Cursor{Pos: Position{Line: line, Col: col}, Anchor: Position{Line: line, Col: col}}
+	}
+	selection := func(line int) Cursor {
+		return Cursor{Pos: Position{Line: line, Col: 3}, Anchor: Position{Line: line, Col: 0}}
+	}
+
+	tests := []struct {
+		name  string
+		line  int // Main cursor line
+		op    func(b *Buffer) error
+		lines []string
+		want  []Cursor
+	}{
+		{
+			name:  "delete first line",
+			op:    func(b *Buffer) error { _, err := b.DeleteLine(); return err },
+			lines: []string{"def", "ghi"},
+			want:  []Cursor{at(0, 0), at(0, 1), selection(1)},
+		},
+		{
+			name:  "delete last line",
+			line:  2,
+			op:    func(b *Buffer) error { _, err := b.DeleteLine(); return err },
+			lines: []string{"abc", "def"},
+			want:  []Cursor{at(0, 2), at(1, 1), at(1, 3)},
+		},
+		{
+			name:  "duplicate line",
+			op:    (*Buffer).DuplicateLine,
+			lines: []string{"abc", "abc", "def", "ghi"},
+			want:  []Cursor{at(0, 2), at(2, 1), selection(3)},
+		},
+		{
+			name:  "move line up",
+			line:  1,
+			op:    (*Buffer).MoveLineUp,
+			lines: []string{"def", "abc", "ghi"},
+			want:  []Cursor{at(1, 2), at(0, 1), selection(2)},
+		},
+		{
+			name:  "move line down",
+			line:  1,
+			op:    (*Buffer).MoveLineDown,
+			lines: []string{"abc", "ghi", "def"},
+			want:  []Cursor{at(0, 2), at(2, 1), selection(1)},
+		},
+		{
+			name:  "insert line above",
+			line:  1,
+			op:    (*Buffer).InsertLineAbove,
+			lines: []string{"abc", "", "def", "ghi"},
+			want:  []Cursor{at(0, 2), at(2, 1), selection(3)},
+		},
+		{
+			name:  "insert line below",
+			line:  2,
+			op:    (*Buffer).InsertLineBelow,
+			lines: []string{"abc", "def", "ghi", ""},
+			want:  []Cursor{at(0, 2), at(1, 1), selection(2)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := NewBuffer()
+			buf.SetLines([]string{"abc", "def", "ghi"})
+			buf.MoveCursor(Position{Line: tt.line})
+			buf.SetExtraCursors([]Cursor{at(0, 2), at(1, 1), selection(2)})
+
+			if err := tt.op(buf); err != nil {
+				t.Fatalf("line operation error = %v", err)
+			}
+			if got := buf.GetAllLines(); !slices.Equal(got, tt.lines) {
+				t.Errorf("lines = %q, want %q", got, tt.lines)
+			}
+			if got := buf.ExtraCursors(); !slices.Equal(got, tt.want) {
+				t.Errorf("ExtraCursors() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCursor_Range(t *testing.T) {
+	c := Cursor{Pos: Position{Line: 0, Col: 1}, Anchor: Position{Line: 2, Col: 0}}
+	start, end := c.Range()
+	if start != c.Pos || end != c.Anchor {
+		t.Errorf("Range() = %v, %v, want %v, %v", start, end, c.Pos, c.Anchor)
+	}
+	if !c.HasSelection() {
+		t.Error("HasSelection() = false, want true")
+	}
+}
@@ -21,6 +21,17 @@ func (b *Buffer) DeleteLine() (string, error) {
 
 	deletedLine := b.lines[lineNum]
 
+	// The text removed, for keeping marks on theirs: the line and the line
+	// break after it, or before it for the last line
+	start := Position{Line: lineNum}
+	oldEnd := Position{Line: lineNum + 1}
+	if lineNum == len(b.lines)-1 {
+		oldEnd = Position{Line: lineNum, Col: len(deletedLine)}
+		if lineNum > 0 {
+			start = Position{Line: lineNum - 1, Col: len(b.lines[lineNum-1])}
+		}
+	}
+
 	// Remove the line
 	newLines := make([]string, 0, len(b.lines)-1)
 	newLines = append(newLines, b.lines[:lineNum]...)
@@ -46,6 +57,7 @@ func (b *Buffer) DeleteLine() (string, error) {
 		b.cursor.Col = 0
 	}
 
+	b.adjustMarks(start, oldEnd, start, false)
 	b.modified = true
 	return deletedLine, nil
 }
@@ -81,6 +93,8 @@ func (b *Buffer) DuplicateLine() error {
 		b.cursor.Col = len(line)
 	}
 
+	end := Position{Line: lineNum, Col: len(line)}
+	b.adjustMarks(end, end, Position{Line: lineNum + 1, Col: len(line)}, false)
 	b.modified = true
 	return nil
 }
@@ -104,6 +118,7 @@ func (b *Buffer) MoveLineUp() error {
 	// Move cursor up with the line
 	b.cursor.Line = lineNum - 1
 
+	b.swapMarks(lineNum - 1)
 	b.modified = true
 	return nil
 }
@@ -127,6 +142,7 @@ func (b *Buffer) MoveLineDown() error {
 	// Move cursor down with the line
 	b.cursor.Line = lineNum + 1
 
+	b.swapMarks(lineNum)
 	b.modified = true
 	return nil
 }
@@ -148,6 +164,13 @@ func (b *Buffer) InsertLineAbove() error {
 	b.cursor.Line = lineNum
 	b.cursor.Col = 0
 
+	// The line break goes at the end of the line above, so marks at the
+	// start of the current line stay on it; only the first line has none
+	at := Position{}
+	if lineNum > 0 {
+		at = Position{Line: lineNum - 1, Col: len(b.lines[lineNum-1])}
+	}
+	b.adjustMarks(at, at, Position{Line: at.Line + 1}, false)
 	b.modified = true
 	return nil
 }
@@ -171,6 +194,8 @@ func (b *Buffer) InsertLineBelow() error {
 	b.cursor.Line = lineNum + 1
 	b.cursor.Col = 0
 
+	end := Position{Line: lineNum, Col: len(b.lines[lineNum])}
+	b.adjustMarks(end, end, b.cursor, false)
 	b.modified = true
 	return nil
 }
@@ -186,10 +211,10 @@ func (b *Buffer) MoveCursorWordLeft() {
 	pos := b.cursor
 	line := b.lines[pos.Line]
 
-	// If at the start of a line, move to end of previous line
+	// If at the start of a line, move to end of previous line in view
 	if pos.Col == 0 {
 		if pos.Line > 0 {
-			pos.Line--
+			pos.Line = b.lineAbove(pos.Line)
 			pos.Col = len(b.lines[pos.Line])
 			b.MoveCursor(pos)
 		}
@@ -224,10 +249,10 @@ func (b *Buffer) MoveCursorWordRight() {
 	pos := b.cursor
 	line := b.lines[pos.Line]
 
-	// If at the end of a line, move to start of next line
+	// If at the end of a line, move to start of next line in view
 	if pos.Col >= len(line) {
-		if pos.Line < len(b.lines)-1 {
-			pos.Line++
+		if below := b.lineBelow(pos.Line); below != pos.Line {
+			pos.Line = below
 			pos.Col = 0
 			b.MoveCursor(pos)
 		}
@@ -252,7 +277,8 @@ func (b *Buffer) MoveCursorWordRight() {
 	b.MoveCursor(pos)
 }
 
-// MoveCursorPageUp moves the cursor up by the specified number of lines.
+// MoveCursorPageUp moves the cursor up by the specified number of lines,
+// not counting folded lines.
 // Typically used with viewport height to scroll by page.
 func (b *Buffer) MoveCursorPageUp(pageSize int) {
 	if pageSize <= 0 {
@@ -260,10 +286,8 @@ func (b *Buffer) MoveCursorPageUp(pageSize int) {
 	}
 
 	pos := b.cursor
-	pos.Line -= pageSize
-
-	if pos.Line < 0 {
-		pos.Line = 0
+	for range pageSize {
+		pos.Line = b.lineAbove(pos.Line)
 	}
 
 	// Adjust column to fit new line
@@ -277,7 +301,8 @@ func (b *Buffer) MoveCursorPageUp(pageSize int) {
 	b.MoveCursor(pos)
 }
 
-// MoveCursorPageDown moves the cursor down by the specified number of lines.
+// MoveCursorPageDown moves the cursor down by the specified number of
+// lines, not counting folded lines.
 // Typically used with viewport height to scroll by page.
 func (b *Buffer) MoveCursorPageDown(pageSize int) {
 	if pageSize <= 0 {
@@ -285,10 +310,8 @@ func (b *Buffer) MoveCursorPageDown(pageSize int) {
 	}
 
 	pos := b.cursor
-	pos.Line += pageSize
-
-	if pos.Line >= len(b.lines) {
-		pos.Line = len(b.lines) - 1
+	for range pageSize {
+		pos.Line = b.lineBelow(pos.Line)
 	}
 
 	// Adjust column to fit new line
@@ -321,3 +344,40 @@ func (b *Buffer) GetCurrentLineIndentation() string {
 
 	return indent.String()
 }
+
+// WordAt returns the range of the word containing pos, end exclusive.
+// A word is a run of word characters; a run of spaces or of punctuation
+// counts as one word too, so double-clicking anywhere selects something.
+// At the end of a line the word before pos is used.
+func (b *Buffer) WordAt(pos Position) (start, end Position) {
+	if pos.Line < 0 || pos.Line >= len(b.lines) {
+		return pos, pos
+	}
+	line := b.lines[pos.Line]
+	if line == "" {
+		return Position{Line: pos.Line}, Position{Line: pos.Line}
+	}
+
+	col := min(max(pos.Col, 0), len(line)-1)
+	class := charClass(line[col])
+	first, last := col, col+1
+	for first > 0 && charClass(line[first-1]) == class {
+		first--
+	}
+	for last < len(line) && charClass(line[last]) == class {
+		last++
+	}
+	return Position{Line: pos.Line, Col: first}, Position{Line: pos.Line, Col: last}
+}
+
+// charClass groups bytes for WordAt: word characters, blanks and
+// everything else.
+func charClass(r byte) int {
+	switch {
+	case isWordChar(r):
+		return 0
+	case r == ' ' || r == '\t':
+		return 1
+	}
+	return 2
+}
@@ -594,6 +594,36 @@ func TestGetCurrentLineIndentation(t *testing.T) {
 	}
 }
 
+func TestWordAt(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		col       int
+		wantStart int
+		wantEnd   int
+	}{
+		{name: "middle of word", content: "hello world", col: 2, wantStart: 0, wantEnd: 5},
+		{name: "start of word", content: "hello world", col: 6, wantStart: 6, wantEnd: 11},
+		{name: "end of line", content: "hello world", col: 11, wantStart: 6, wantEnd: 11},
+		{name: "underscore and digits", content: "x := my_var2;", col: 7, wantStart: 5, wantEnd: 12},
+		{name: "spaces", content: "a   b", col: 2, wantStart: 1, wantEnd: 4},
+		{name: "punctuation", content: "f(a), b", col: 4, wantStart: 3, wantEnd: 5},
+		{name: "empty line", content: "", col: 0, wantStart: 0, wantEnd: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBuffer()
+			b.SetLines([]string{tt.content})
+
+			start, end := b.WordAt(Position{Line: 0, Col: tt.col})
+			if start.Col != tt.wantStart || end.Col != tt.wantEnd {
+				t.Errorf("WordAt() = %d-%d, want %d-%d", start.Col, end.Col, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
 // Helper function
 func slicesEqual(a, b []string) bool {
 	if len(a) != len(b) {
@@ -0,0 +1,65 @@
+// Package buffer implements ranges of text that follow edits.
+package buffer
+
+import (
+	"slices"
+)
+
+// TrackedRange is a range of text, from Start up to End, that the buffer
+// keeps on the same text as it is edited, as it does bookmarks. Text
+// inserted at an edge of the range is left out of it, unless Grow is set:
+// then it joins the range, so typing at either end of a field lengthens
+// it.
+type TrackedRange struct {
+	Start Position
+	End   Position
+	Grow  bool
+}
+
+// Track starts keeping a range on its text and returns it. The range's
+// ends are updated in place until Untrack is called or the buffer's lines
+// are replaced.
+func (b *Buffer) Track(start, end Position) *TrackedRange {
+	r := &TrackedRange{Start: b.clampPosition(start), End: b.clampPosition(end)}
+	b.tracked = append(b.tracked, r)
+	return r
+}
+
+// Untrack stops keeping ranges on their text.
+func (b *Buffer) Untrack(ranges ...*TrackedRange) {
+	b.tracked = slices.DeleteFunc(b.tracked, func(r *TrackedRange) bool {
+		return slices.Contains(ranges, r)
+	})
+}
+
+// adjustTracked keeps tracked ranges on the same text after an edit, as
+// adjustPosition describes, except that text inserted at an edge of a
+// range that grows is taken into it and left out of one that doesn't.
+func (b *Buffer) adjustTracked(start, oldEnd, newEnd Position, dropped bool) {
+	inserted := start == oldEnd
+	edge := func(p Position, moves bool) Position {
+		if inserted && moves && p == start {
+			return b.clampPosition(newEnd)
+		}
+		return b.adjustPosition(p, start, oldEnd, newEnd, dropped)
+	}
+	for _, r := range b.tracked {
+		r.Start = edge(r.Start, !r.Grow)
+		r.End = edge(r.End, r.Grow)
+		if ComparePositions(r.End, r.Start) < 0 {
+			r.End = r.Start
+		}
+	}
+}
+
+// swapTracked moves the tracked ranges on line and the line below it with
+// their text when the two swap places. A range spanning both lines can end
+// up reversed; it is then left empty.
+func (b *Buffer) swapTracked(line int) {
+	for _, r := range b.tracked {
+		r.Start, r.End = swapLines(r.Start, line), swapLines(r.End, line)
+		if ComparePositions(r.End, r.Start) < 0 {
+			r.End = r.Start
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package buffer
+
+import (
+	"testing"
+)
+
+func TestBuffer_Track(t *testing.T) {
+	pos := func(col int) Position { return Position{Col: col} }
+	tests := []struct {
+		name      string
+		grow      bool
+		start     int // Columns of the range in "abcdef"
+		end       int
+		edit      func(b *Buffer)
+		wantStart int
+		wantEnd   int
+	}{
+		{"insert at end, growing", true, 1, 3, func(b *Buffer) { b.Insert(pos(3), "xy") }, 1, 5},
+		{"insert at start, growing", true, 1, 3, func(b *Buffer) { b.Insert(pos(1), "xy") }, 1, 5},
+		{"insert at end", false, 1, 3, func(b *Buffer) { b.Insert(pos(3), "xy") }, 1, 3},
+		{"insert at start", false, 1, 3, func(b *Buffer) { b.Insert(pos(1), "xy") }, 3, 5},
+		{"insert into empty, growing", true, 2, 2, func(b *Buffer) { b.Insert(pos(2), "xy") }, 2, 4},
+		{"insert at empty", false, 2, 2, func(b *Buffer) { b.Insert(pos(2), "xy") }, 4, 4},
+		{"insert before", false, 2, 4, func(b *Buffer) { b.Insert(pos(0), "x") }, 3, 5},
+		{"delete inside", true, 1, 5, func(b *Buffer) { b.Delete(pos(2), pos(4)) }, 1, 3},
+		{"delete over start", true, 2, 5, func(b *Buffer) { b.Delete(pos(1), pos(3)) }, 1, 3},
+		{"replace whole range", true, 1, 3, func(b *Buffer) { b.Replace(pos(1), pos(3), "wxyz") }, 1, 5},
+		{"replace adjacent", false, 1, 3, func(b *Buffer) { b.Replace(pos(3), pos(4), "wxyz") }, 1, 3},
+		{"line break before", false, 2, 4, func(b *Buffer) { b.Insert(pos(1), "\n") }, 1, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := NewBuffer()
+			buf.SetLines([]string{"abcdef"})
+			r := buf.Track(pos(tt.start), pos(tt.end))
+			r.Grow = tt.grow
+			tt.edit(buf)
+			if r.Start.Col != tt.wantStart || r.End.Col != tt.wantEnd {
+				t.Errorf("range = %v-%v, want columns %d-%d", r.Start, r.End, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+
+	// Untracked ranges stay put
+	buf := NewBuffer()
+	buf.SetLines([]string{"abcdef"})
+	r := buf.Track(pos(1), pos(2))
+	buf.Untrack(r)
+	buf.Insert(pos(0), "x")
+	if r.Start != pos(1) || r.End != pos(2) {
+		t.Errorf("untracked range moved to %v-%v", r.Start, r.End)
+	}
+}
+
+func TestBuffer_TrackFollowsLineOperations(t *testing.T) {
+	deleteLine := func(b *Buffer) error { _, err := b.DeleteLine(); return err }
+	tests := []struct {
+		name       string
+		line       int // Cursor line
+		op         func(b *Buffer) error
+		start, end Position
+	}{
+		{"line deleted above", 0, deleteLine, Position{Line: 0, Col: 1}, Position{Line: 0, Col: 3}},
+		{"its line deleted", 1, deleteLine, Position{Line: 1}, Position{Line: 1}},
+		{"line duplicated", 1, (*Buffer).DuplicateLine, Position{Line: 1, Col: 1}, Position{Line: 1, Col: 3}},
+		{"line moved up", 1, (*Buffer).MoveLineUp, Position{Line: 0, Col: 1}, Position{Line: 0, Col: 3}},
+		{"line above moved down", 0, (*Buffer).MoveLineDown, Position{Line: 0, Col: 1}, Position{Line: 0, Col: 3}},
+		{"line inserted above", 1, (*Buffer).InsertLineAbove, Position{Line: 2, Col: 1}, Position{Line: 2, Col: 3}},
+		{"line inserted below", 1, (*Buffer).InsertLineBelow, Position{Line: 1, Col: 1}, Position{Line: 1, Col: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := NewBuffer()
+			buf.SetLines([]string{"one", "two", "three"})
+			buf.MoveCursor(Position{Line: tt.line})
+			r := buf.Track(Position{Line: 1, Col: 1}, Position{Line: 1, Col: 3})
+			if err := tt.op(buf); err != nil {
+				t.Fatalf("line operation error = %v", err)
+			}
+			if r.Start != tt.start || r.End != tt.end {
+				t.Errorf("range = %v-%v, want %v-%v", r.Start, r.End, tt.start, tt.end)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Package complete implements completion of the word being typed.
+//
+// Completions come from providers, each a source of candidates such as the
+// words already in the open buffers. The editor asks every provider and
+// offers what they return, first provider first.
+package complete
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Item is a completion offered for the word being typed.
+type Item struct {
+	Text   string // Word that replaces the prefix
+	Detail string // Short note shown beside it, such as where it came from
+}
+
+// Request describes the word being completed.
+type Request struct {
+	Lines  []string   // Lines of the buffer being edited
+	Line   int        // Line of the cursor
+	Col    int        // Byte column of the cursor
+	Prefix string     // Word characters just before the cursor
+	Others [][]string // Lines of the other open buffers
+}
+
+// Provider is a source of completions.
+type Provider interface {
+	// Complete returns the completions for req, best first. Each must
+	// start with req.Prefix and be longer than it.
+	Complete(req Request) []Item
+}
+
+// Complete asks each provider in turn and returns up to limit
+// completions, dropping any a provider before offered.
+func Complete(providers []Provider, req Request, limit int) []Item {
+	var items []Item
+	seen := make(map[string]bool)
+	for _, p := range providers {
+		for _, item := range p.Complete(req) {
+			if len(items) == limit {
+				return items
+			}
+			if seen[item.Text] {
+				continue
+			}
+			seen[item.Text] = true
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Prefix returns the word characters just before byte column col of line.
+func Prefix(line string, col int) string {
+	col = min(max(col, 0), len(line))
+	start := col
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(line[:start])
+		if !IsWordRune(r) {
+			break
+		}
+		start -= size
+	}
+	return line[start:col]
+}
+
+// IsWordRune reports whether r can be part of a word: a letter, a digit or
+// an underscore.
+func IsWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
@@ -0,0 +1,57 @@
+package complete
+
+import (
+	"slices"
+	"testing"
+)
+
+// fixedProvider offers the same completions for every request.
+type fixedProvider []Item
+
+func (p fixedProvider) Complete(Request) []Item {
+	return p
+}
+
+func TestComplete(t *testing.T) {
+	providers := []Provider{
+		fixedProvider{{Text: "alpha"}, {Text: "beta"}},
+		fixedProvider{{Text: "beta", Detail: "again"}, {Text: "gamma"}},
+	}
+	tests := []struct {
+		name  string
+		limit int
+		want  []Item
+	}{
+		{"all", 10, []Item{{Text: "alpha"}, {Text: "beta"}, {Text: "gamma"}}},
+		{"limited", 2, []Item{{Text: "alpha"}, {Text: "beta"}}},
+		{"none", 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Complete(providers, Request{}, tt.limit); !slices.Equal(got, tt.want) {
+				t.Errorf("Complete() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	tests := []struct {
+		line string
+		col  int
+		want string
+	}{
+		{"foo.barBaz", 10, "barBaz"},
+		{"foo.barBaz", 7, "bar"},
+		{"foo.", 4, ""},
+		{"x := été", 10, "été"},
+		{"snake_case", 10, "snake_case"},
+		{"abc", 99, "abc"},
+		{"", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := Prefix(tt.line, tt.col); got != tt.want {
+			t.Errorf("Prefix(%q, %d) = %q, want %q", tt.line, tt.col, got, tt.want)
+		}
+	}
+}
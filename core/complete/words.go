@@ -0,0 +1,132 @@
+// Package complete implements completion from the words in open buffers.
+package complete
+
+import (
+	"cmp"
+	"math"
+	"math/bits"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// wordScanLines is how many lines above and below the cursor Words reads,
+// so completing in a very large buffer stays fast.
+const wordScanLines = 5000
+
+// Words completes from the words already typed: those in the buffer being
+// edited, nearest the cursor first, then those in other open buffers.
+// Among words about as near, those used more often come first. A prefix
+// in lower case matches words in any case.
+type Words struct{}
+
+// wordStats is what Words knows of one candidate.
+type wordStats struct {
+	word     string
+	distance int // Lines from the cursor to the nearest use, or MaxInt if only in other buffers
+	count    int // Uses in every buffer
+}
+
+// Complete returns the words that start with req.Prefix.
+func (Words) Complete(req Request) []Item {
+	if req.Prefix == "" {
+		return nil
+	}
+	match := func(word string) bool { return strings.HasPrefix(word, req.Prefix) }
+	if !strings.ContainsFunc(req.Prefix, unicode.IsUpper) {
+		match = func(word string) bool { return strings.HasPrefix(strings.ToLower(word), req.Prefix) }
+	}
+
+	stats := make(map[string]*wordStats)
+	add := func(word string, distance int) {
+		if len(word) <= len(req.Prefix) || !match(word) {
+			return
+		}
+		s := stats[word]
+		if s == nil {
+			s = &wordStats{word: word, distance: distance}
+			stats[word] = s
+		}
+		s.distance = min(s.distance, distance)
+		s.count++
+	}
+
+	first := max(req.Line-wordScanLines, 0)
+	last := min(req.Line+wordScanLines, len(req.Lines)-1)
+	for i := first; i <= last; i++ {
+		skip := -1 // Start of the word being typed, which is not offered
+		if i == req.Line {
+			skip = req.Col - len(req.Prefix)
+		}
+		eachWord(req.Lines[i], func(word string, col int) {
+			if col != skip {
+				add(word, abs(i-req.Line))
+			}
+		})
+	}
+	for _, lines := range req.Others {
+		for _, line := range lines {
+			eachWord(line, func(word string, _ int) { add(word, math.MaxInt) })
+		}
+	}
+
+	ranked := make([]*wordStats, 0, len(stats))
+	for _, s := range stats {
+		ranked = append(ranked, s)
+	}
+	slices.SortFunc(ranked, func(x, y *wordStats) int {
+		return cmp.Or(
+			cmp.Compare(proximity(x.distance), proximity(y.distance)),
+			cmp.Compare(y.count, x.count),
+			strings.Compare(x.word, y.word),
+		)
+	})
+
+	items := make([]Item, len(ranked))
+	for i, s := range ranked {
+		items[i] = Item{Text: s.word}
+		if s.distance == math.MaxInt {
+			items[i].Detail = "open file"
+		}
+	}
+	return items
+}
+
+// proximity groups distances into bands that double in size, so that
+// words a few lines apart rank by how often they are used.
+func proximity(distance int) int {
+	return bits.Len(uint(distance))
+}
+
+// eachWord calls f with each word in line and the byte column it starts
+// at. Runs of word characters starting with a digit are numbers, not
+// words.
+func eachWord(line string, f func(word string, col int)) {
+	start := -1
+	for i := 0; i <= len(line); {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		if i < len(line) && IsWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			i += size
+			continue
+		}
+		if start >= 0 {
+			if first, _ := utf8.DecodeRuneInString(line[start:]); !unicode.IsDigit(first) {
+				f(line[start:i], start)
+			}
+			start = -1
+		}
+		i += max(size, 1)
+	}
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
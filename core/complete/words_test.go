@@ -0,0 +1,84 @@
+package complete
+
+import (
+	"slices"
+	"testing"
+)
+
+// texts returns the text of each item.
+func texts(items []Item) []string {
+	var words []string
+	for _, item := range items {
+		words = append(words, item.Text)
+	}
+	return words
+}
+
+func TestWords(t *testing.T) {
+	lines := []string{
+		"counter := count(items)", // 0
+		"countAll(items)",         // 1
+		"countAll(items)",         // 2
+		"",                        // 3
+		"co",                      // 4: the word being typed
+		"",                        // 5
+		"cost 42co 3count",        // 6: numbers are not words
+	}
+	tests := []struct {
+		name   string
+		req    Request
+		want   []string
+		detail map[string]string
+	}{
+		{
+			name: "nearest first, then most used",
+			req:  Request{Lines: lines, Line: 4, Col: 2, Prefix: "co"},
+			want: []string{"countAll", "cost", "count", "counter"},
+		},
+		{
+			name: "lower case matches any case",
+			req:  Request{Lines: []string{"Count count", "cou"}, Line: 1, Col: 3, Prefix: "cou"},
+			want: []string{"Count", "count"},
+		},
+		{
+			name: "upper case matches exactly",
+			req:  Request{Lines: []string{"Count count", "Cou"}, Line: 1, Col: 3, Prefix: "Cou"},
+			want: []string{"Count"},
+		},
+		{
+			name: "other buffers after this one",
+			req: Request{
+				Lines:  []string{"cobra", "co"},
+				Line:   1,
+				Col:    2,
+				Prefix: "co",
+				Others: [][]string{{"coral cobra"}},
+			},
+			want:   []string{"cobra", "coral"},
+			detail: map[string]string{"coral": "open file"},
+		},
+		{
+			name: "the word at the cursor is not offered",
+			req:  Request{Lines: []string{"cobalt"}, Line: 0, Col: 2, Prefix: "co"},
+			want: nil,
+		},
+		{
+			name: "no prefix",
+			req:  Request{Lines: lines, Line: 3},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := Words{}.Complete(tt.req)
+			if got := texts(items); !slices.Equal(got, tt.want) {
+				t.Errorf("Complete() = %q, want %q", got, tt.want)
+			}
+			for _, item := range items {
+				if item.Detail != tt.detail[item.Text] {
+					t.Errorf("detail of %q = %q, want %q", item.Text, item.Detail, tt.detail[item.Text])
+				}
+			}
+		})
+	}
+}
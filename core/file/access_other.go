@@ -0,0 +1,12 @@
+//go:build !unix
+
+package file
+
+import "io/fs"
+
+// canWrite reports whether the current user may write to path. Without an
+// access check it goes by the permission bits in info, which on Windows
+// reflect the read-only attribute.
+func canWrite(path string, info fs.FileInfo) bool {
+	return info.Mode().Perm()&0200 != 0
+}
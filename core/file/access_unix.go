@@ -0,0 +1,16 @@
+//go:build unix
+
+package file
+
+import (
+	"io/fs"
+
+	"golang.org/x/sys/unix"
+)
+
+// canWrite reports whether the current user may write to path, asking the
+// system rather than going by the permission bits in info, so ACLs,
+// read-only mounts and root are accounted for.
+func canWrite(path string, info fs.FileInfo) bool {
+	return unix.Access(path, unix.W_OK) == nil
+}
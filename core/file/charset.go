@@ -0,0 +1,116 @@
+// Package file implements character encodings for reading and saving.
+package file
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// Charset is a character encoding a file is read and saved in, named as in
+// EditorConfig. The empty Charset is UTF-8 kept byte for byte.
+type Charset string
+
+const (
+	// CharsetUTF8 is UTF-8 without a byte order mark.
+	CharsetUTF8 Charset = "utf-8"
+	// CharsetUTF8BOM is UTF-8 starting with a byte order mark.
+	CharsetUTF8BOM Charset = "utf-8-bom"
+	// CharsetLatin1 is ISO 8859-1, one byte per character.
+	CharsetLatin1 Charset = "latin1"
+	// CharsetUTF16BE is big-endian UTF-16 with a byte order mark.
+	CharsetUTF16BE Charset = "utf-16be"
+	// CharsetUTF16LE is little-endian UTF-16 with a byte order mark.
+	CharsetUTF16LE Charset = "utf-16le"
+)
+
+// utf8BOM is the UTF-8 encoding of the byte order mark.
+const utf8BOM = "\ufeff"
+
+// ParseCharset returns the charset named name, ignoring case.
+func ParseCharset(name string) (Charset, bool) {
+	switch cs := Charset(strings.ToLower(name)); cs {
+	case CharsetUTF8, CharsetUTF8BOM, CharsetLatin1, CharsetUTF16BE, CharsetUTF16LE:
+		return cs, true
+	}
+	return "", false
+}
+
+// String returns the name shown for the charset in the info bar.
+func (cs Charset) String() string {
+	switch cs {
+	case CharsetUTF8BOM:
+		return "UTF-8 BOM"
+	case CharsetLatin1:
+		return "Latin-1"
+	case CharsetUTF16BE:
+		return "UTF-16 BE"
+	case CharsetUTF16LE:
+		return "UTF-16 LE"
+	}
+	return "UTF-8"
+}
+
+// DecodeText returns the text of data encoded in cs. A byte order mark at
+// the start is removed for every charset but the empty one.
+func DecodeText(data []byte, cs Charset) (string, error) {
+	switch cs {
+	case CharsetUTF8, CharsetUTF8BOM:
+		return strings.TrimPrefix(string(data), utf8BOM), nil
+
+	case CharsetLatin1:
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes), nil
+
+	case CharsetUTF16BE, CharsetUTF16LE:
+		if len(data)%2 != 0 {
+			return "", fmt.Errorf("decode %s: odd number of bytes", cs)
+		}
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			hi, lo := data[2*i], data[2*i+1]
+			if cs == CharsetUTF16LE {
+				hi, lo = lo, hi
+			}
+			units[i] = uint16(hi)<<8 | uint16(lo)
+		}
+		text := string(utf16.Decode(units))
+		return strings.TrimPrefix(text, utf8BOM), nil
+	}
+	return string(data), nil
+}
+
+// EncodeText returns text encoded in cs. It fails if text has characters
+// cs cannot represent.
+func EncodeText(text string, cs Charset) ([]byte, error) {
+	switch cs {
+	case CharsetUTF8BOM:
+		return []byte(utf8BOM + strings.TrimPrefix(text, utf8BOM)), nil
+
+	case CharsetLatin1:
+		data := make([]byte, 0, len(text))
+		for _, r := range text {
+			if r > 0xFF {
+				return nil, fmt.Errorf("encode latin1: %q cannot be saved as latin1", r)
+			}
+			data = append(data, byte(r))
+		}
+		return data, nil
+
+	case CharsetUTF16BE, CharsetUTF16LE:
+		units := utf16.Encode([]rune(utf8BOM + strings.TrimPrefix(text, utf8BOM)))
+		data := make([]byte, 2*len(units))
+		for i, u := range units {
+			hi, lo := byte(u>>8), byte(u)
+			if cs == CharsetUTF16LE {
+				hi, lo = lo, hi
+			}
+			data[2*i], data[2*i+1] = hi, lo
+		}
+		return data, nil
+	}
+	return []byte(text), nil
+}
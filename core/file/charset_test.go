@@ -0,0 +1,87 @@
+package file
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseCharset(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   Charset
+		wantOK bool
+	}{
+		{"utf-8", CharsetUTF8, true},
+		{"UTF-8-BOM", CharsetUTF8BOM, true},
+		{"latin1", CharsetLatin1, true},
+		{"utf-16be", CharsetUTF16BE, true},
+		{"utf-16le", CharsetUTF16LE, true},
+		{"ascii", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseCharset(tt.name)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseCharset(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestEncodeText(t *testing.T) {
+	tests := []struct {
+		name    string
+		charset Charset
+		text    string
+		want    []byte
+	}{
+		{"unchanged", "", "héllo", []byte("héllo")},
+		{"utf-8", CharsetUTF8, "héllo", []byte("héllo")},
+		{"utf-8 bom", CharsetUTF8BOM, "hi", []byte("\xef\xbb\xbfhi")},
+		{"latin1", CharsetLatin1, "café", []byte("caf\xe9")},
+		{"utf-16be", CharsetUTF16BE, "hi", []byte{0xfe, 0xff, 0, 'h', 0, 'i'}},
+		{"utf-16le", CharsetUTF16LE, "hi", []byte{0xff, 0xfe, 'h', 0, 'i', 0}},
+		{"utf-16le surrogate pair", CharsetUTF16LE, "😀", []byte{0xff, 0xfe, 0x3d, 0xd8, 0x00, 0xde}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeText(tt.text, tt.charset)
+			if err != nil {
+				t.Fatalf("EncodeText() error = %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("EncodeText() = %x, want %x", got, tt.want)
+			}
+
+			// Decoding gives the text back
+			text, err := DecodeText(got, tt.charset)
+			if err != nil {
+				t.Fatalf("DecodeText() error = %v", err)
+			}
+			if text != tt.text {
+				t.Errorf("DecodeText() = %q, want %q", text, tt.text)
+			}
+		})
+	}
+}
+
+func TestEncodeText_Latin1Unrepresentable(t *testing.T) {
+	if _, err := EncodeText("price: €5", CharsetLatin1); err == nil {
+		t.Error("EncodeText() with € as latin1 should return error")
+	}
+}
+
+func TestDecodeText_Errors(t *testing.T) {
+	if _, err := DecodeText([]byte{0xff, 0xfe, 'h'}, CharsetUTF16LE); err == nil {
+		t.Error("DecodeText() with odd-length UTF-16 should return error")
+	}
+}
+
+func TestDecodeText_UTF16WithoutBOM(t *testing.T) {
+	got, err := DecodeText([]byte{0, 'o', 0, 'k'}, CharsetUTF16BE)
+	if err != nil {
+		t.Fatalf("DecodeText() error = %v", err)
+	}
+	if got != "ok" {
+		t.Errorf("DecodeText() = %q, want %q", got, "ok")
+	}
+}
@@ -0,0 +1,402 @@
+// Package file implements reading .editorconfig files.
+package file
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// EditorConfigFileName is the name of EditorConfig files.
+const EditorConfigFileName = ".editorconfig"
+
+// EditorConfig holds the EditorConfig (https://editorconfig.org)
+// properties that apply to one file. Zero values mean a property is not set.
+type EditorConfig struct {
+	IndentStyle            string     // "tab" or "space"
+	IndentSize             int        // Columns per indentation level
+	TabWidth               int        // Columns per tab stop
+	EndOfLine              LineEnding // Line ending to save with
+	Charset                Charset    // Encoding to read and save with
+	TrimTrailingWhitespace *bool      // Remove whitespace at line ends when saving
+	InsertFinalNewline     *bool      // End the file with a newline, or without one
+	MaxLineLength          int        // Preferred maximum line length
+	Files                  []string   // .editorconfig files read, nearest first
+}
+
+// IsEmpty reports whether no property is set.
+func (ec *EditorConfig) IsEmpty() bool {
+	return ec.IndentStyle == "" && ec.IndentSize == 0 && ec.TabWidth == 0 &&
+		ec.EndOfLine == "" && ec.Charset == "" && ec.TrimTrailingWhitespace == nil &&
+		ec.InsertFinalNewline == nil && ec.MaxLineLength == 0
+}
+
+// editorConfigSection is a [glob] section of an .editorconfig file.
+type editorConfigSection struct {
+	glob       string
+	properties [][2]string // Name and value, in file order
+}
+
+// editorConfigFile is a parsed .editorconfig file.
+type editorConfigFile struct {
+	dir      string
+	root     bool
+	sections []editorConfigSection
+}
+
+// FindEditorConfig returns the EditorConfig properties for the file at
+// path, which need not exist. It reads the .editorconfig files in the
+// file's directory and each parent up to one marked root = true; nearer
+// files take precedence, as do later sections within a file.
+func FindEditorConfig(path string) (*EditorConfig, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("resolve path %q: %w", path, err)
+	}
+
+	var files []*editorConfigFile
+	for dir := filepath.Dir(abs); ; {
+		f, err := readEditorConfig(filepath.Join(dir, EditorConfigFileName))
+		if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			files = append(files, f)
+			if f.root {
+				break
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	// Apply from the farthest file to the nearest
+	properties := make(map[string]string)
+	ec := &EditorConfig{}
+	for i := len(files) - 1; i >= 0; i-- {
+		f := files[i]
+		for _, section := range f.sections {
+			if !matchEditorConfigGlob(f.dir, section.glob, abs) {
+				continue
+			}
+			for _, p := range section.properties {
+				properties[p[0]] = p[1]
+			}
+		}
+	}
+	for i := range files {
+		ec.Files = append(ec.Files, filepath.Join(files[i].dir, EditorConfigFileName))
+	}
+
+	ec.resolve(properties)
+	return ec, nil
+}
+
+// readEditorConfig parses the .editorconfig file at path, returning nil if
+// there is none. Lines that are not comments, sections or properties are
+// ignored, as the specification asks.
+func readEditorConfig(path string) (*editorConfigFile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return parseEditorConfig(filepath.Dir(path), string(data)), nil
+}
+
+// parseEditorConfig parses the contents of an .editorconfig file in dir.
+func parseEditorConfig(dir, content string) *editorConfigFile {
+	f := &editorConfigFile{dir: dir}
+	var section *editorConfigSection
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			f.sections = append(f.sections, editorConfigSection{glob: line[1 : len(line)-1]})
+			section = &f.sections[len(f.sections)-1]
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		if section == nil {
+			// Only root is allowed before the first section
+			if name == "root" {
+				f.root = strings.EqualFold(value, "true")
+			}
+			continue
+		}
+		section.properties = append(section.properties, [2]string{name, value})
+	}
+	return f
+}
+
+// resolve sets the properties from their raw values. Invalid values and
+// "unset" leave a property unset.
+func (ec *EditorConfig) resolve(properties map[string]string) {
+	get := func(name string) string {
+		v := strings.ToLower(properties[name])
+		if v == "unset" {
+			return ""
+		}
+		return v
+	}
+	number := func(name string) int {
+		n, err := strconv.Atoi(get(name))
+		if err != nil || n < 1 {
+			return 0
+		}
+		return n
+	}
+	boolean := func(name string) *bool {
+		switch get(name) {
+		case "true":
+			v := true
+			return &v
+		case "false":
+			v := false
+			return &v
+		}
+		return nil
+	}
+
+	switch style := get("indent_style"); style {
+	case "tab", "space":
+		ec.IndentStyle = style
+	}
+
+	ec.TabWidth = number("tab_width")
+	indentSize := get("indent_size")
+	if indentSize == "" && ec.IndentStyle == "tab" {
+		indentSize = "tab"
+	}
+	if indentSize == "tab" {
+		ec.IndentSize = ec.TabWidth
+	} else {
+		ec.IndentSize = number("indent_size")
+		if ec.TabWidth == 0 {
+			ec.TabWidth = ec.IndentSize
+		}
+	}
+
+	switch get("end_of_line") {
+	case "lf":
+		ec.EndOfLine = LineEndingLF
+	case "crlf":
+		ec.EndOfLine = LineEndingCRLF
+	case "cr":
+		ec.EndOfLine = LineEndingCR
+	}
+
+	if cs, ok := ParseCharset(get("charset")); ok {
+		ec.Charset = cs
+	}
+
+	ec.TrimTrailingWhitespace = boolean("trim_trailing_whitespace")
+	ec.InsertFinalNewline = boolean("insert_final_newline")
+	ec.MaxLineLength = number("max_line_length")
+}
+
+// matchEditorConfigGlob reports whether the section glob of an
+// .editorconfig file in dir matches the file at path. Globs without a
+// slash match files of that name in any directory below dir.
+func matchEditorConfigGlob(dir, glob, path string) bool {
+	dir = filepath.ToSlash(dir)
+	path = filepath.ToSlash(path)
+
+	prefix := regexp.QuoteMeta(strings.TrimSuffix(dir, "/")) + "/"
+	if strings.Contains(glob, "/") {
+		glob = strings.TrimPrefix(glob, "/")
+	} else {
+		prefix += "(?:.*/)?"
+	}
+
+	var ranges [][2]int
+	pattern, err := regexp.Compile("^" + prefix + globToRegexp(glob, &ranges) + "$")
+	if err != nil {
+		return false
+	}
+
+	m := pattern.FindStringSubmatch(path)
+	if m == nil {
+		return false
+	}
+	// Numeric ranges are captured; check each number is in its range. A
+	// range in an alternative that did not match captures nothing.
+	for i, r := range ranges {
+		if m[i+1] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+1])
+		if err != nil || n < r[0] || n > r[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// globToRegexp converts an EditorConfig glob to a regular expression.
+// Numeric ranges such as {1..3} become capturing groups, with their bounds
+// appended to ranges; nothing else captures.
+//
+//	?          one character except /
+//	*          any characters except /
+//	**         any characters
+//	[abc]      one of the characters (with [!abc] for none of them)
+//	{a,b,c}    any of the comma-separated globs
+//	{n1..n2}   a whole number from n1 to n2
+func globToRegexp(glob string, ranges *[][2]int) string {
+	var re strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				re.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+			} else {
+				re.WriteString(`\\`)
+			}
+
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				re.WriteString(".*")
+				i++
+			} else {
+				re.WriteString("[^/]*")
+			}
+
+		case '?':
+			re.WriteString("[^/]")
+
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				re.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			re.WriteByte('[')
+			if strings.HasPrefix(class, "!") {
+				re.WriteByte('^')
+				class = class[1:]
+			}
+			for _, ch := range class {
+				if ch == '-' {
+					re.WriteByte('-')
+				} else {
+					re.WriteString(regexp.QuoteMeta(string(ch)))
+				}
+			}
+			re.WriteByte(']')
+			i += end + 1
+
+		case '{':
+			end := matchingBrace(glob, i)
+			if end < 0 {
+				re.WriteString(`\{`)
+				continue
+			}
+			inner := glob[i+1 : end]
+			i = end
+			if lo, hi, ok := numericRange(inner); ok {
+				*ranges = append(*ranges, [2]int{lo, hi})
+				re.WriteString(`([+-]?\d+)`)
+				continue
+			}
+			alternatives := splitAlternatives(inner)
+			if len(alternatives) < 2 {
+				// A single choice is taken literally
+				re.WriteString(regexp.QuoteMeta("{") + globToRegexp(inner, ranges) + regexp.QuoteMeta("}"))
+				continue
+			}
+			re.WriteString("(?:")
+			for j, alt := range alternatives {
+				if j > 0 {
+					re.WriteByte('|')
+				}
+				re.WriteString(globToRegexp(alt, ranges))
+			}
+			re.WriteByte(')')
+
+		default:
+			re.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	return re.String()
+}
+
+// matchingBrace returns the index of the } closing the { at start, or -1.
+func matchingBrace(glob string, start int) int {
+	depth := 0
+	for i := start; i < len(glob); i++ {
+		switch glob[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitAlternatives splits the inside of braces at commas not nested in
+// further braces.
+func splitAlternatives(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// numericRange parses "n1..n2".
+func numericRange(s string) (lo, hi int, ok bool) {
+	a, b, found := strings.Cut(s, "..")
+	if !found {
+		return 0, 0, false
+	}
+	lo, errA := strconv.Atoi(a)
+	hi, errB := strconv.Atoi(b)
+	if errA != nil || errB != nil {
+		return 0, 0, false
+	}
+	return min(lo, hi), max(lo, hi), true
+}
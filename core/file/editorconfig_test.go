@@ -0,0 +1,168 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchEditorConfigGlob(t *testing.T) {
+	tests := []struct {
+		glob string
+		path string
+		want bool
+	}{
+		{"*", "/p/a.go", true},
+		{"*", "/p/sub/a.go", true},
+		{"*.go", "/p/sub/a.go", true},
+		{"*.go", "/p/a.txt", false},
+		{"*.{js,ts}", "/p/web/app.ts", true},
+		{"*.{js,ts}", "/p/web/app.tsx", false},
+		{"{Makefile,*.mk}", "/p/build/rules.mk", true},
+		{"{single}", "/p/{single}", true},
+		{"lib/*.js", "/p/lib/a.js", true},
+		{"lib/*.js", "/p/lib/sub/a.js", false},
+		{"lib/*.js", "/p/src/lib/a.js", false},
+		{"/lib/**.js", "/p/lib/sub/a.js", true},
+		{"src/**/test_*.py", "/p/src/a/b/test_x.py", true},
+		{"?.c", "/p/a.c", true},
+		{"?.c", "/p/ab.c", false},
+		{"[abc].txt", "/p/b.txt", true},
+		{"[!abc].txt", "/p/b.txt", false},
+		{"[!abc].txt", "/p/d.txt", true},
+		{"file{1..3}.txt", "/p/file2.txt", true},
+		{"file{1..3}.txt", "/p/file4.txt", false},
+		{"{*.md,file{1..3}.txt}", "/p/README.md", true},
+		{"a\\*b", "/p/a*b", true},
+		{"a\\*b", "/p/axb", false},
+		{"*.GO", "/p/a.go", false},
+	}
+	for _, tt := range tests {
+		if got := matchEditorConfigGlob("/p", tt.glob, tt.path); got != tt.want {
+			t.Errorf("matchEditorConfigGlob(%q, %q) = %v, want %v", tt.glob, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFindEditorConfig(t *testing.T) {
+	root := t.TempDir()
+	project := filepath.Join(root, "project")
+	sub := filepath.Join(project, "web")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(dir, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, EditorConfigFileName), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Above the root file; must not be read
+	write(root, "[*]\nindent_style = tab\ncharset = latin1\n")
+	write(project, `root = true
+
+; Defaults for everything
+[*]
+indent_style = space
+indent_size = 4
+end_of_line = lf
+charset = utf-8
+trim_trailing_whitespace = true
+insert_final_newline = true
+
+[*.md]
+trim_trailing_whitespace = false
+max_line_length = 80
+
+[Makefile]
+indent_style = tab
+`)
+	write(sub, `# Nearer files win
+[*.js]
+indent_size = 2
+end_of_line = CRLF
+insert_final_newline = unset
+max_line_length = off
+`)
+
+	yes, no := true, false
+	tests := []struct {
+		path string
+		want EditorConfig
+	}{
+		{
+			path: filepath.Join(project, "main.go"),
+			want: EditorConfig{IndentStyle: "space", IndentSize: 4, TabWidth: 4, EndOfLine: LineEndingLF, Charset: CharsetUTF8, TrimTrailingWhitespace: &yes, InsertFinalNewline: &yes},
+		},
+		{
+			path: filepath.Join(project, "README.md"),
+			want: EditorConfig{IndentStyle: "space", IndentSize: 4, TabWidth: 4, EndOfLine: LineEndingLF, Charset: CharsetUTF8, TrimTrailingWhitespace: &no, InsertFinalNewline: &yes, MaxLineLength: 80},
+		},
+		{
+			// indent_size = 4 still applies; tab_width follows it
+			path: filepath.Join(project, "Makefile"),
+			want: EditorConfig{IndentStyle: "tab", IndentSize: 4, TabWidth: 4, EndOfLine: LineEndingLF, Charset: CharsetUTF8, TrimTrailingWhitespace: &yes, InsertFinalNewline: &yes},
+		},
+		{
+			path: filepath.Join(sub, "app.js"),
+			want: EditorConfig{IndentStyle: "space", IndentSize: 2, TabWidth: 2, EndOfLine: LineEndingCRLF, Charset: CharsetUTF8, TrimTrailingWhitespace: &yes},
+		},
+	}
+	for _, tt := range tests {
+		got, err := FindEditorConfig(tt.path)
+		if err != nil {
+			t.Fatalf("FindEditorConfig(%q) error = %v", tt.path, err)
+		}
+		if !sameEditorConfig(got, &tt.want) {
+			t.Errorf("FindEditorConfig(%q) = %s, want %s", filepath.Base(tt.path), describeEditorConfig(got), describeEditorConfig(&tt.want))
+		}
+	}
+
+	ec, _ := FindEditorConfig(filepath.Join(sub, "app.js"))
+	if len(ec.Files) != 2 || ec.Files[0] != filepath.Join(sub, EditorConfigFileName) {
+		t.Errorf("Files = %v, want the web and project files, nearest first", ec.Files)
+	}
+
+	// No .editorconfig at all
+	ec, err := FindEditorConfig(filepath.Join(t.TempDir(), "x.go"))
+	if err != nil || !ec.IsEmpty() {
+		t.Errorf("FindEditorConfig() without files = %s, %v, want no properties", describeEditorConfig(ec), err)
+	}
+}
+
+func TestEditorConfig_IndentSizeTab(t *testing.T) {
+	ec := &EditorConfig{}
+	ec.resolve(map[string]string{"indent_style": "tab", "tab_width": "8"})
+	if ec.IndentSize != 8 || ec.TabWidth != 8 {
+		t.Errorf("indent_style = tab: IndentSize %d, TabWidth %d, want 8, 8", ec.IndentSize, ec.TabWidth)
+	}
+
+	ec = &EditorConfig{}
+	ec.resolve(map[string]string{"indent_size": "tab", "tab_width": "3", "charset": "UTF-16LE"})
+	if ec.IndentSize != 3 || ec.Charset != CharsetUTF16LE {
+		t.Errorf("indent_size = tab: IndentSize %d, Charset %q, want 3, %q", ec.IndentSize, ec.Charset, CharsetUTF16LE)
+	}
+}
+
+// sameEditorConfig compares the properties of a and b, ignoring Files.
+func sameEditorConfig(a, b *EditorConfig) bool {
+	sameBool := func(x, y *bool) bool { return (x == nil) == (y == nil) && (x == nil || *x == *y) }
+	return a.IndentStyle == b.IndentStyle && a.IndentSize == b.IndentSize && a.TabWidth == b.TabWidth &&
+		a.EndOfLine == b.EndOfLine && a.Charset == b.Charset && a.MaxLineLength == b.MaxLineLength &&
+		sameBool(a.TrimTrailingWhitespace, b.TrimTrailingWhitespace) && sameBool(a.InsertFinalNewline, b.InsertFinalNewline)
+}
+
+// describeEditorConfig formats the properties of ec for test failures.
+func describeEditorConfig(ec *EditorConfig) string {
+	describeBool := func(v *bool) string {
+		if v == nil {
+			return "unset"
+		}
+		return fmt.Sprint(*v)
+	}
+	return fmt.Sprintf("{style %q size %d tab %d eol %q charset %q trim %s final %s max %d}",
+		ec.IndentStyle, ec.IndentSize, ec.TabWidth, ec.EndOfLine, ec.Charset,
+		describeBool(ec.TrimTrailingWhitespace), describeBool(ec.InsertFinalNewline), ec.MaxLineLength)
+}
@@ -0,0 +1,206 @@
+// Package file implements a rollback journal for multi-file edits.
+package file
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// journalManifest is the name of the file listing a journal's entries.
+const journalManifest = "journal.json"
+
+// JournalEntry records one file changed through a Journal.
+type JournalEntry struct {
+	Path    string      `json:"path"`    // File that was changed
+	Backup  string      `json:"backup"`  // Copy of the original content, inside the journal directory
+	Mode    os.FileMode `json:"mode"`    // Original permissions
+	Written string      `json:"written"` // SHA-256 of the content written, to detect later changes
+}
+
+// Journal keeps backups of files changed by a multi-file edit so the edit
+// can be rolled back in one step.
+//
+// Each file is backed up, and listed in a manifest next to the backups,
+// before it is overwritten.
+type Journal struct {
+	dir     string
+	entries []JournalEntry
+}
+
+// NewJournal creates an empty journal in a new temporary directory.
+func NewJournal() (*Journal, error) {
+	dir, err := os.MkdirTemp("", "ted-journal-*")
+	if err != nil {
+		return nil, fmt.Errorf("create journal directory: %w", err)
+	}
+	return &Journal{dir: dir}, nil
+}
+
+// Entries returns the files changed so far, in the order they were written.
+func (j *Journal) Entries() []JournalEntry {
+	return j.entries
+}
+
+// WriteFile backs up path and then atomically replaces its content with
+// lines, keeping its permissions. A file may be written more than once;
+// only the first backup is kept.
+func (j *Journal) WriteFile(path string, lines []string, lineEnding LineEnding) error {
+	cleanPath, err := validatePath(path)
+	if err != nil {
+		return err
+	}
+
+	index := j.find(cleanPath)
+	if index < 0 {
+		entry, err := j.backup(cleanPath)
+		if err != nil {
+			return err
+		}
+		j.entries = append(j.entries, entry)
+		index = len(j.entries) - 1
+	}
+
+	data := buildContent(lines, lineEnding)
+	j.entries[index].Written = contentHash(data)
+	if err := j.save(); err != nil {
+		return err
+	}
+
+	if err := atomicWrite(cleanPath, data); err != nil {
+		return err
+	}
+	if err := os.Chmod(cleanPath, j.entries[index].Mode); err != nil {
+		return fmt.Errorf("restore permissions of %q: %w", cleanPath, err)
+	}
+	return nil
+}
+
+// Restore puts back the original content of one file and removes it from
+// the journal. It fails without changing the file if the file was modified
+// since the journal wrote it.
+func (j *Journal) Restore(path string) error {
+	cleanPath, err := validatePath(path)
+	if err != nil {
+		return err
+	}
+
+	index := j.find(cleanPath)
+	if index < 0 {
+		return fmt.Errorf("file %q is not in the journal", cleanPath)
+	}
+
+	if err := j.restore(j.entries[index]); err != nil {
+		return err
+	}
+
+	j.entries = append(j.entries[:index], j.entries[index+1:]...)
+	return j.save()
+}
+
+// Rollback restores every file in the journal, newest first, and then
+// discards the journal. Files that cannot be restored are reported in the
+// returned error and stay in the journal so the rollback can be retried.
+func (j *Journal) Rollback() error {
+	var errs []error
+	var kept []JournalEntry
+
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		if err := j.restore(j.entries[i]); err != nil {
+			errs = append(errs, err)
+			kept = append([]JournalEntry{j.entries[i]}, kept...)
+		}
+	}
+
+	j.entries = kept
+	if len(errs) > 0 {
+		if err := j.save(); err != nil {
+			errs = append(errs, err)
+		}
+		return errors.Join(errs...)
+	}
+	return j.Discard()
+}
+
+// Discard deletes the journal and its backups, keeping the changes.
+func (j *Journal) Discard() error {
+	j.entries = nil
+	if err := os.RemoveAll(j.dir); err != nil {
+		return fmt.Errorf("remove journal: %w", err)
+	}
+	return nil
+}
+
+// find returns the index of the entry for path, or -1.
+func (j *Journal) find(path string) int {
+	for i, entry := range j.entries {
+		if entry.Path == path {
+			return i
+		}
+	}
+	return -1
+}
+
+// backup copies path into the journal directory.
+func (j *Journal) backup(path string) (JournalEntry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return JournalEntry{}, fmt.Errorf("stat file %q: %w", path, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return JournalEntry{}, fmt.Errorf("read file %q: %w", path, err)
+	}
+
+	backup := filepath.Join(j.dir, fmt.Sprintf("%04d-%s", len(j.entries), filepath.Base(path)))
+	if err := atomicWrite(backup, data); err != nil {
+		return JournalEntry{}, fmt.Errorf("back up %q: %w", path, err)
+	}
+
+	return JournalEntry{Path: path, Backup: backup, Mode: info.Mode().Perm()}, nil
+}
+
+// restore writes an entry's backup over its file.
+func (j *Journal) restore(entry JournalEntry) error {
+	current, err := os.ReadFile(entry.Path)
+	if err != nil {
+		return fmt.Errorf("read file %q: %w", entry.Path, err)
+	}
+	if entry.Written != "" && contentHash(current) != entry.Written {
+		return fmt.Errorf("file %q changed since it was replaced", entry.Path)
+	}
+
+	data, err := os.ReadFile(entry.Backup)
+	if err != nil {
+		return fmt.Errorf("read backup of %q: %w", entry.Path, err)
+	}
+	if err := atomicWrite(entry.Path, data); err != nil {
+		return err
+	}
+	if err := os.Chmod(entry.Path, entry.Mode); err != nil {
+		return fmt.Errorf("restore permissions of %q: %w", entry.Path, err)
+	}
+	return nil
+}
+
+// save writes the manifest.
+func (j *Journal) save() error {
+	data, err := json.MarshalIndent(j.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode journal: %w", err)
+	}
+	if err := atomicWrite(filepath.Join(j.dir, journalManifest), data); err != nil {
+		return fmt.Errorf("write journal: %w", err)
+	}
+	return nil
+}
+
+// contentHash returns the hex SHA-256 of data.
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,127 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readString returns the content of path, failing the test on error.
+func readString(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	return string(data)
+}
+
+func TestJournal_WriteAndRollback(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	b := filepath.Join(dir, "b.txt")
+	os.WriteFile(a, []byte("alpha\n"), 0640)
+	os.WriteFile(b, []byte("beta\r\n"), 0644)
+
+	j, err := NewJournal()
+	if err != nil {
+		t.Fatalf("NewJournal() error = %v", err)
+	}
+
+	if err := j.WriteFile(a, []string{"ALPHA", ""}, LineEndingLF); err != nil {
+		t.Fatalf("WriteFile(a) error = %v", err)
+	}
+	if err := j.WriteFile(b, []string{"BETA", ""}, LineEndingCRLF); err != nil {
+		t.Fatalf("WriteFile(b) error = %v", err)
+	}
+	// A second write keeps the original backup
+	if err := j.WriteFile(a, []string{"ALPHA 2", ""}, LineEndingLF); err != nil {
+		t.Fatalf("WriteFile(a) again error = %v", err)
+	}
+
+	if got := readString(t, b); got != "BETA\r\n" {
+		t.Errorf("b after write = %q, want %q", got, "BETA\r\n")
+	}
+	if info, _ := os.Stat(a); info.Mode().Perm() != 0640 {
+		t.Errorf("a mode = %v, want 0640", info.Mode().Perm())
+	}
+	if len(j.Entries()) != 2 {
+		t.Errorf("Entries() = %d, want 2", len(j.Entries()))
+	}
+
+	if err := j.Rollback(); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if got := readString(t, a); got != "alpha\n" {
+		t.Errorf("a after rollback = %q, want %q", got, "alpha\n")
+	}
+	if got := readString(t, b); got != "beta\r\n" {
+		t.Errorf("b after rollback = %q, want %q", got, "beta\r\n")
+	}
+	if _, err := os.Stat(j.dir); !os.IsNotExist(err) {
+		t.Error("journal directory should be removed after rollback")
+	}
+}
+
+func TestJournal_Restore(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	b := filepath.Join(dir, "b.txt")
+	os.WriteFile(a, []byte("a"), 0644)
+	os.WriteFile(b, []byte("b"), 0644)
+
+	j, err := NewJournal()
+	if err != nil {
+		t.Fatalf("NewJournal() error = %v", err)
+	}
+	defer j.Discard()
+
+	j.WriteFile(a, []string{"A"}, LineEndingLF)
+	j.WriteFile(b, []string{"B"}, LineEndingLF)
+
+	if err := j.Restore(a); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if got := readString(t, a); got != "a" {
+		t.Errorf("a after restore = %q, want %q", got, "a")
+	}
+	if got := readString(t, b); got != "B" {
+		t.Errorf("b after restoring a = %q, want %q", got, "B")
+	}
+	if err := j.Restore(a); err == nil {
+		t.Error("Restore() of a file no longer in the journal should fail")
+	}
+}
+
+func TestJournal_RollbackSkipsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	b := filepath.Join(dir, "b.txt")
+	os.WriteFile(a, []byte("a"), 0644)
+	os.WriteFile(b, []byte("b"), 0644)
+
+	j, err := NewJournal()
+	if err != nil {
+		t.Fatalf("NewJournal() error = %v", err)
+	}
+	defer j.Discard()
+
+	j.WriteFile(a, []string{"A"}, LineEndingLF)
+	j.WriteFile(b, []string{"B"}, LineEndingLF)
+	os.WriteFile(a, []byte("edited later"), 0644)
+
+	err = j.Rollback()
+	if err == nil || !strings.Contains(err.Error(), "changed since") {
+		t.Fatalf("Rollback() error = %v, want changed file error", err)
+	}
+	if got := readString(t, a); got != "edited later" {
+		t.Errorf("changed file was overwritten: %q", got)
+	}
+	if got := readString(t, b); got != "b" {
+		t.Errorf("b after rollback = %q, want %q", got, "b")
+	}
+	if len(j.Entries()) != 1 {
+		t.Errorf("Entries() after partial rollback = %d, want 1", len(j.Entries()))
+	}
+}
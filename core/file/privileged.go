@@ -0,0 +1,141 @@
+// Package file implements permission checks and privilege-escalated saving.
+package file
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultSaveHelper is the command used to save files the user cannot write.
+// The {src} placeholder is replaced with the temp file holding the new content
+// and {dst} with the destination path.
+var DefaultSaveHelper = []string{"sudo", "cp", "{src}", "{dst}"}
+
+// IsWritable reports whether the current user can save to path.
+// Saving writes a temp file next to the target and renames it into place,
+// so the parent directory must be writable as well as the file itself.
+// For a path that does not exist yet, only the directory is checked.
+func IsWritable(path string) bool {
+	cleanPath, err := validatePath(path)
+	if err != nil {
+		return false
+	}
+
+	info, err := os.Stat(cleanPath)
+	if err == nil {
+		if info.IsDir() || !canWrite(cleanPath, info) {
+			return false
+		}
+	} else if !os.IsNotExist(err) {
+		return false
+	}
+
+	return isDirWritable(filepath.Dir(cleanPath))
+}
+
+// isDirWritable reports whether files can be created in dir.
+// A missing directory counts as writable if its nearest existing ancestor is,
+// since WriteFile creates missing directories.
+func isDirWritable(dir string) bool {
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			return info.IsDir() && canWrite(dir, info)
+		}
+		if !os.IsNotExist(err) {
+			return false
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
+// ParseSaveHelper splits a helper command line into its arguments.
+// An empty command yields DefaultSaveHelper.
+//
+// Example:
+//
+//	helper := ParseSaveHelper("doas cp {src} {dst}")
+func ParseSaveHelper(command string) []string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return append([]string(nil), DefaultSaveHelper...)
+	}
+	return fields
+}
+
+// WriteFileWithHelper writes lines to a private temp file and runs helper to
+// copy it over path. It is used when the user cannot write path directly,
+// with a helper such as "sudo cp {src} {dst}".
+//
+// The helper inherits the process's stdin, stdout and stderr so it can prompt
+// for a password. Callers running a full-screen UI must suspend it first.
+func WriteFileWithHelper(path string, lines []string, opts SaveOptions, helper []string) error {
+	if path == "" {
+		return fmt.Errorf("path cannot be empty")
+	}
+	if len(helper) == 0 {
+		helper = DefaultSaveHelper
+	}
+
+	cleanPath, err := validatePath(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := opts.Encode(lines)
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp("", "ted-save-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+
+	args := expandHelperArgs(helper, tmpPath, cleanPath)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run save helper %q: %w", args[0], err)
+	}
+
+	return nil
+}
+
+// expandHelperArgs substitutes the {src} and {dst} placeholders in helper.
+// If helper has no placeholders, src and dst are appended as the last two arguments.
+func expandHelperArgs(helper []string, src, dst string) []string {
+	args := make([]string, 0, len(helper)+2)
+	substituted := false
+	for _, arg := range helper {
+		if strings.Contains(arg, "{src}") || strings.Contains(arg, "{dst}") {
+			substituted = true
+		}
+		arg = strings.ReplaceAll(arg, "{src}", src)
+		arg = strings.ReplaceAll(arg, "{dst}", dst)
+		args = append(args, arg)
+	}
+	if !substituted {
+		args = append(args, src, dst)
+	}
+	return args
+}
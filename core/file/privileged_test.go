@@ -0,0 +1,180 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIsWritable(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	existing := filepath.Join(tmpDir, "existing.txt")
+	if err := os.WriteFile(existing, []byte("content"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if !IsWritable(existing) {
+		t.Error("IsWritable() = false for writable file, want true")
+	}
+
+	if !IsWritable(filepath.Join(tmpDir, "new.txt")) {
+		t.Error("IsWritable() = false for new file in writable dir, want true")
+	}
+
+	if !IsWritable(filepath.Join(tmpDir, "sub", "dir", "new.txt")) {
+		t.Error("IsWritable() = false for new file in missing subdirectory, want true")
+	}
+
+	if IsWritable(tmpDir) {
+		t.Error("IsWritable() = true for directory, want false")
+	}
+
+	if IsWritable("") {
+		t.Error("IsWritable() = true for empty path, want false")
+	}
+}
+
+func TestIsWritable_ReadOnlyFile(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("Skipping test - root can write read-only files")
+	}
+
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "readonly.txt")
+	if err := os.WriteFile(path, []byte("content"), 0444); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if IsWritable(path) {
+		t.Error("IsWritable() = true for read-only file, want false")
+	}
+}
+
+func TestIsWritable_ReadOnlyDirectory(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("Skipping test - root can write read-only directories")
+	}
+
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "file.txt")
+	if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.Chmod(tmpDir, 0555); err != nil {
+		t.Fatalf("Chmod() error = %v", err)
+	}
+	defer os.Chmod(tmpDir, 0755)
+
+	if IsWritable(path) {
+		t.Error("IsWritable() = true for file in read-only directory, want false")
+	}
+}
+
+func TestParseSaveHelper(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+	}{
+		{
+			name:    "empty uses default",
+			command: "",
+			want:    DefaultSaveHelper,
+		},
+		{
+			name:    "custom command",
+			command: "doas cp {src} {dst}",
+			want:    []string{"doas", "cp", "{src}", "{dst}"},
+		},
+		{
+			name:    "extra whitespace",
+			command: "  pkexec   cp {src} {dst} ",
+			want:    []string{"pkexec", "cp", "{src}", "{dst}"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseSaveHelper(tt.command)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSaveHelper(%q) = %v, want %v", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandHelperArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		helper []string
+		want   []string
+	}{
+		{
+			name:   "placeholders",
+			helper: []string{"sudo", "cp", "{src}", "{dst}"},
+			want:   []string{"sudo", "cp", "/tmp/a", "/etc/b"},
+		},
+		{
+			name:   "no placeholders appends paths",
+			helper: []string{"sudo", "cp"},
+			want:   []string{"sudo", "cp", "/tmp/a", "/etc/b"},
+		},
+		{
+			name:   "placeholder inside argument",
+			helper: []string{"sh", "-c", "cat {src} > {dst}"},
+			want:   []string{"sh", "-c", "cat /tmp/a > /etc/b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := expandHelperArgs(tt.helper, "/tmp/a", "/etc/b")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expandHelperArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteFileWithHelper(t *testing.T) {
+	if _, err := os.Stat("/bin/cp"); err != nil {
+		t.Skip("Skipping test - cp not available")
+	}
+
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "target.txt")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	lines := []string{"line1", "line2", ""}
+	if err := WriteFileWithHelper(path, lines, SaveOptions{LineEnding: LineEndingCRLF}, []string{"cp", "{src}", "{dst}"}); err != nil {
+		t.Fatalf("WriteFileWithHelper() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "line1\r\nline2\r\n" {
+		t.Errorf("WriteFileWithHelper() content = %q, want %q", string(data), "line1\r\nline2\r\n")
+	}
+}
+
+func TestWriteFileWithHelper_HelperFails(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "target.txt")
+
+	err := WriteFileWithHelper(path, []string{"x"}, SaveOptions{LineEnding: LineEndingLF}, []string{"ted-no-such-helper-command"})
+	if err == nil {
+		t.Error("WriteFileWithHelper() with missing helper should return error")
+	}
+}
+
+func TestWriteFileWithHelper_EmptyPath(t *testing.T) {
+	if err := WriteFileWithHelper("", []string{"x"}, SaveOptions{LineEnding: LineEndingLF}, nil); err == nil {
+		t.Error("WriteFileWithHelper() with empty path should return error")
+	}
+}
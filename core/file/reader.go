@@ -31,7 +31,7 @@ type FileInfo struct {
 	Path       string
 	Size       int64
 	LineEnding LineEnding
-	Encoding   string // Always "UTF-8" for now
+	Encoding   string // Name of the charset the file was read with
 }
 
 // ReadFile reads a file and returns its contents as a slice of lines.
@@ -68,33 +68,49 @@ func ReadFile(path string) ([]string, error) {
 	}
 
 	// Split into lines (handle different line endings)
-	lines := splitLines(data)
+	lines := SplitLines(data)
 	return lines, nil
 }
 
 // ReadFileWithInfo reads a file and returns both the contents and file metadata.
 func ReadFileWithInfo(path string) ([]string, *FileInfo, error) {
-	lines, err := ReadFile(path)
+	return ReadFileWithCharset(path, "")
+}
+
+// ReadFileWithCharset reads a file encoded in cs and returns its contents
+// and metadata. The empty charset reads the file as UTF-8, byte for byte.
+func ReadFileWithCharset(path string, cs Charset) ([]string, *FileInfo, error) {
+	cleanPath, err := validatePath(path)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	info, err := os.Stat(path)
+	info, err := os.Stat(cleanPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("stat file %q: %w", cleanPath, err)
+	}
+	if info.IsDir() {
+		return nil, nil, fmt.Errorf("path %q is a directory", cleanPath)
+	}
+
+	data, err := os.ReadFile(cleanPath)
 	if err != nil {
-		return nil, nil, fmt.Errorf("stat file %q: %w", path, err)
+		return nil, nil, fmt.Errorf("read file %q: %w", cleanPath, err)
 	}
 
-	// Detect line ending
-	lineEnding := detectLineEnding(path)
+	text, err := DecodeText(data, cs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read file %q: %w", cleanPath, err)
+	}
 
 	fileInfo := &FileInfo{
 		Path:       path,
 		Size:       info.Size(),
-		LineEnding: lineEnding,
-		Encoding:   "UTF-8",
+		LineEnding: DetectLineEnding([]byte(text)),
+		Encoding:   cs.String(),
 	}
 
-	return lines, fileInfo, nil
+	return SplitLines([]byte(text)), fileInfo, nil
 }
 
 // validatePath validates and cleans a file path.
@@ -116,9 +132,9 @@ func validatePath(path string) (string, error) {
 	return cleanPath, nil
 }
 
-// splitLines splits file data into lines, handling different line endings.
+// SplitLines splits file data into lines, handling different line endings.
 // It preserves the original line ending style by detecting it from the data.
-func splitLines(data []byte) []string {
+func SplitLines(data []byte) []string {
 	if len(data) == 0 {
 		return []string{""}
 	}
@@ -153,6 +169,12 @@ func detectLineEnding(path string) LineEnding {
 		return LineEndingUnknown
 	}
 
+	return DetectLineEnding(data)
+}
+
+// DetectLineEnding detects the line ending style of file data.
+// Data without line endings is treated as LF.
+func DetectLineEnding(data []byte) LineEnding {
 	content := string(data)
 	if strings.Contains(content, "\r\n") {
 		return LineEndingCRLF
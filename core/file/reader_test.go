@@ -336,9 +336,68 @@ func TestSplitLines(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := splitLines(tt.data)
+			got := SplitLines(tt.data)
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("splitLines() = %v, want %v", got, tt.want)
+				t.Errorf("SplitLines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadFileWithCharset(t *testing.T) {
+	tests := []struct {
+		name         string
+		charset      Charset
+		data         []byte
+		wantLines    []string
+		wantEncoding string
+		wantEnding   LineEnding
+	}{
+		{
+			name:         "latin1",
+			charset:      CharsetLatin1,
+			data:         []byte("caf\xe9\r\nna\xefve\r\n"),
+			wantLines:    []string{"café", "naïve", ""},
+			wantEncoding: "Latin-1",
+			wantEnding:   LineEndingCRLF,
+		},
+		{
+			name:         "utf-16le",
+			charset:      CharsetUTF16LE,
+			data:         []byte{0xff, 0xfe, 'a', 0, '\n', 0, 'b', 0},
+			wantLines:    []string{"a", "b"},
+			wantEncoding: "UTF-16 LE",
+			wantEnding:   LineEndingLF,
+		},
+		{
+			name:         "utf-8 bom",
+			charset:      CharsetUTF8BOM,
+			data:         []byte("\xef\xbb\xbfx\ny"),
+			wantLines:    []string{"x", "y"},
+			wantEncoding: "UTF-8 BOM",
+			wantEnding:   LineEndingLF,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := t.TempDir() + "/file.txt"
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+
+			lines, info, err := ReadFileWithCharset(path, tt.charset)
+			if err != nil {
+				t.Fatalf("ReadFileWithCharset() error = %v", err)
+			}
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("ReadFileWithCharset() lines = %q, want %q", lines, tt.wantLines)
+			}
+			if info.Encoding != tt.wantEncoding {
+				t.Errorf("ReadFileWithCharset() info.Encoding = %q, want %q", info.Encoding, tt.wantEncoding)
+			}
+			if info.LineEnding != tt.wantEnding {
+				t.Errorf("ReadFileWithCharset() info.LineEnding = %q, want %q", info.LineEnding, tt.wantEnding)
 			}
 		})
 	}
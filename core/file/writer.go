@@ -8,6 +8,61 @@ import (
 	"strings"
 )
 
+// FinalNewline says what saving does with the newline at the end of a file.
+type FinalNewline int
+
+const (
+	// FinalNewlineKeep saves the file as edited.
+	FinalNewlineKeep FinalNewline = iota
+	// FinalNewlineInsert ends a non-empty file with a newline.
+	FinalNewlineInsert
+	// FinalNewlineRemove removes the newline ending the file, if any.
+	FinalNewlineRemove
+)
+
+// SaveOptions control how lines are written to a file.
+type SaveOptions struct {
+	LineEnding             LineEnding
+	Charset                Charset // The empty charset writes text unchanged
+	TrimTrailingWhitespace bool    // Remove spaces and tabs at the end of lines
+	FinalNewline           FinalNewline
+}
+
+// PrepareLines returns lines as they are saved with these options: with
+// trailing whitespace trimmed and the final newline added or removed. A
+// trailing newline is an empty last line. lines is not modified.
+func (o SaveOptions) PrepareLines(lines []string) []string {
+	prepared := make([]string, len(lines))
+	copy(prepared, lines)
+
+	if o.TrimTrailingWhitespace {
+		for i, line := range prepared {
+			prepared[i] = strings.TrimRight(line, " \t")
+		}
+	}
+
+	switch n := len(prepared); o.FinalNewline {
+	case FinalNewlineInsert:
+		if n > 0 && prepared[n-1] != "" {
+			prepared = append(prepared, "")
+		}
+	case FinalNewlineRemove:
+		if n > 1 && prepared[n-1] == "" {
+			prepared = prepared[:n-1]
+		}
+	}
+	return prepared
+}
+
+// Encode returns the file content for lines saved with these options.
+func (o SaveOptions) Encode(lines []string) ([]byte, error) {
+	content := buildContent(o.PrepareLines(lines), o.LineEnding)
+	if o.Charset == "" {
+		return content, nil
+	}
+	return EncodeText(string(content), o.Charset)
+}
+
 // WriteFile writes lines to a file atomically (using temp file + rename).
 // It preserves the specified line ending style.
 // Returns an error if the file cannot be written.
@@ -17,6 +72,11 @@ import (
 //	lines := []string{"line1", "line2", "line3"}
 //	err := WriteFile("example.txt", lines, LineEndingLF)
 func WriteFile(path string, lines []string, lineEnding LineEnding) error {
+	return WriteFileWithOptions(path, lines, SaveOptions{LineEnding: lineEnding})
+}
+
+// WriteFileWithOptions writes lines to a file atomically, as opts say.
+func WriteFileWithOptions(path string, lines []string, opts SaveOptions) error {
 	if path == "" {
 		return fmt.Errorf("path cannot be empty")
 	}
@@ -27,16 +87,42 @@ func WriteFile(path string, lines []string, lineEnding LineEnding) error {
 		return err
 	}
 
+	data, err := opts.Encode(lines)
+	if err != nil {
+		return err
+	}
+
 	// Ensure directory exists
 	dir := filepath.Dir(cleanPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("create directory %q: %w", dir, err)
 	}
 
+	// Atomic write: write to temp file, then rename
+	return atomicWrite(cleanPath, data)
+}
+
+// WriteData writes data to a file atomically, creating its directory if
+// needed. It is used for files the editor manages itself, such as state.
+func WriteData(path string, data []byte) error {
+	cleanPath, err := validatePath(path)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(cleanPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create directory %q: %w", dir, err)
+	}
+
+	return atomicWrite(cleanPath, data)
+}
+
+// buildContent joins lines with the given line ending into file content.
+func buildContent(lines []string, lineEnding LineEnding) []byte {
 	// Convert line ending to string
 	ending := lineEndingToString(lineEnding)
 
-	// Build file content
 	var content strings.Builder
 	for i, line := range lines {
 		content.WriteString(line)
@@ -48,8 +134,7 @@ func WriteFile(path string, lines []string, lineEnding LineEnding) error {
 		// added after the previous line, so we don't add another one
 	}
 
-	// Atomic write: write to temp file, then rename
-	return atomicWrite(cleanPath, []byte(content.String()))
+	return []byte(content.String())
 }
 
 // WriteFilePreserveEnding writes lines to a file, preserving the original line ending.
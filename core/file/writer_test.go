@@ -359,3 +359,103 @@ func TestLineEndingToString(t *testing.T) {
 		})
 	}
 }
+
+func TestSaveOptions_PrepareLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  SaveOptions
+		lines []string
+		want  []string
+	}{
+		{
+			name:  "keep",
+			opts:  SaveOptions{},
+			lines: []string{"a  ", "b\t"},
+			want:  []string{"a  ", "b\t"},
+		},
+		{
+			name:  "trim trailing whitespace",
+			opts:  SaveOptions{TrimTrailingWhitespace: true},
+			lines: []string{"a  ", "  b\t ", "   ", ""},
+			want:  []string{"a", "  b", "", ""},
+		},
+		{
+			name:  "insert final newline",
+			opts:  SaveOptions{FinalNewline: FinalNewlineInsert},
+			lines: []string{"a", "b"},
+			want:  []string{"a", "b", ""},
+		},
+		{
+			name:  "insert final newline already present",
+			opts:  SaveOptions{FinalNewline: FinalNewlineInsert},
+			lines: []string{"a", ""},
+			want:  []string{"a", ""},
+		},
+		{
+			name:  "insert final newline empty file",
+			opts:  SaveOptions{FinalNewline: FinalNewlineInsert},
+			lines: []string{""},
+			want:  []string{""},
+		},
+		{
+			name:  "remove final newline",
+			opts:  SaveOptions{FinalNewline: FinalNewlineRemove},
+			lines: []string{"a", ""},
+			want:  []string{"a"},
+		},
+		{
+			name:  "remove final newline empty file",
+			opts:  SaveOptions{FinalNewline: FinalNewlineRemove},
+			lines: []string{""},
+			want:  []string{""},
+		},
+		{
+			name:  "trim then insert",
+			opts:  SaveOptions{TrimTrailingWhitespace: true, FinalNewline: FinalNewlineInsert},
+			lines: []string{"a", "b "},
+			want:  []string{"a", "b", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := append([]string(nil), tt.lines...)
+			got := tt.opts.PrepareLines(tt.lines)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PrepareLines() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.lines, original) {
+				t.Errorf("PrepareLines() modified its input: %q, want %q", tt.lines, original)
+			}
+		})
+	}
+}
+
+func TestWriteFileWithOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.txt")
+	opts := SaveOptions{
+		LineEnding:             LineEndingCRLF,
+		Charset:                CharsetLatin1,
+		TrimTrailingWhitespace: true,
+		FinalNewline:           FinalNewlineInsert,
+	}
+	if err := WriteFileWithOptions(path, []string{"café ", "fin"}, opts); err != nil {
+		t.Fatalf("WriteFileWithOptions() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if want := "caf\xe9\r\nfin\r\n"; string(data) != want {
+		t.Errorf("WriteFileWithOptions() content = %q, want %q", data, want)
+	}
+
+	// A file that cannot be encoded is left alone
+	if err := WriteFileWithOptions(path, []string{"€"}, opts); err == nil {
+		t.Error("WriteFileWithOptions() with unencodable text should return error")
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(data) {
+		t.Errorf("failed WriteFileWithOptions() changed the file to %q", after)
+	}
+}
@@ -23,7 +23,9 @@ type Operation interface {
 type History struct {
 	undoStack []Operation
 	redoStack []Operation
-	maxDepth  int // Maximum number of operations to keep
+	maxDepth  int                 // Maximum number of operations to keep
+	group     *CompositeOperation // Collects operations between BeginGroup and EndGroup
+	depth     int                 // Groups begun and not yet ended
 }
 
 // NewHistory creates a new history manager with the specified maximum depth.
@@ -42,6 +44,12 @@ func NewHistory(maxDepth int) *History {
 // Push adds a new operation to the undo stack.
 // This clears the redo stack (new operation invalidates redo history).
 func (h *History) Push(op Operation) {
+	if h.group != nil {
+		h.redoStack = h.redoStack[:0]
+		h.group.Operations = append(h.group.Operations, op)
+		return
+	}
+
 	// Clear redo stack when new operation is pushed
 	h.redoStack = h.redoStack[:0]
 
@@ -58,7 +66,7 @@ func (h *History) Push(op Operation) {
 
 // CanUndo returns whether there are operations that can be undone.
 func (h *History) CanUndo() bool {
-	return len(h.undoStack) > 0
+	return len(h.undoStack) > 0 || (h.group != nil && len(h.group.Operations) > 0)
 }
 
 // CanRedo returns whether there are operations that can be redone.
@@ -67,8 +75,17 @@ func (h *History) CanRedo() bool {
 }
 
 // Undo undoes the last operation and moves it to the redo stack.
-// Returns an error if there are no operations to undo.
+// Returns an error if there are no operations to undo. In a group, an
+// operation pushed to the group is undone and dropped instead.
 func (h *History) Undo(buf *buffer.Buffer) error {
+	if h.group != nil && len(h.group.Operations) > 0 {
+		ops := h.group.Operations
+		if err := ops[len(ops)-1].Undo(buf); err != nil {
+			return err
+		}
+		h.group.Operations = ops[:len(ops)-1]
+		return nil
+	}
 	if !h.CanUndo() {
 		return ErrNoUndo
 	}
@@ -114,7 +131,11 @@ func (h *History) Redo(buf *buffer.Buffer) error {
 		return err
 	}
 
-	// Move back to undo stack
+	// Move back to undo stack, or into the open group
+	if h.group != nil {
+		h.group.Operations = append(h.group.Operations, op)
+		return nil
+	}
 	h.undoStack = append(h.undoStack, op)
 
 	// Limit undo stack size
@@ -126,8 +147,42 @@ func (h *History) Redo(buf *buffer.Buffer) error {
 	return nil
 }
 
+// BeginGroup starts collecting the operations pushed from now on, so that
+// EndGroup records them as one. Groups nest: a group begun inside another
+// is part of it, and keeps the outer group's description.
+func (h *History) BeginGroup(description string) {
+	h.depth++
+	if h.group != nil {
+		return
+	}
+	h.group = &CompositeOperation{}
+	h.group.SetDescription(description)
+}
+
+// EndGroup ends the group last begun. Ending the outermost group pushes
+// its operations as one if there are any. It reports whether it pushed
+// anything.
+func (h *History) EndGroup() bool {
+	if h.depth > 0 {
+		h.depth--
+	}
+	if h.depth > 0 {
+		return false
+	}
+	group := h.group
+	h.group = nil
+	if group == nil || len(group.Operations) == 0 {
+		return false
+	}
+	h.Push(group)
+	return true
+}
+
 // Clear clears all history.
 func (h *History) Clear() {
+	if h.group != nil {
+		h.group.Operations = nil
+	}
 	h.undoStack = h.undoStack[:0]
 	h.redoStack = h.redoStack[:0]
 }
@@ -137,6 +192,23 @@ func (h *History) ClearRedo() {
 	h.redoStack = h.redoStack[:0]
 }
 
+// SetMaxDepth changes the maximum number of operations kept, dropping the
+// oldest ones if there are more. Values below 1 are treated as 1.
+func (h *History) SetMaxDepth(maxDepth int) {
+	h.maxDepth = max(maxDepth, 1)
+	if excess := len(h.undoStack) - h.maxDepth; excess > 0 {
+		h.undoStack = append(h.undoStack[:0], h.undoStack[excess:]...)
+	}
+	if excess := len(h.redoStack) - h.maxDepth; excess > 0 {
+		h.redoStack = append(h.redoStack[:0], h.redoStack[excess:]...)
+	}
+}
+
+// MaxDepth returns the maximum number of operations kept.
+func (h *History) MaxDepth() int {
+	return h.maxDepth
+}
+
 // Depth returns the current depth of the undo stack.
 func (h *History) Depth() int {
 	return len(h.undoStack)
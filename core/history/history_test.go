@@ -153,6 +153,35 @@ func TestHistory_MaxDepth(t *testing.T) {
 	}
 }
 
+func TestHistory_SetMaxDepth(t *testing.T) {
+	h := NewHistory(10)
+	buf := buffer.NewBuffer()
+	for i := 0; i < 6; i++ {
+		op := &InsertOperation{Pos: buffer.Position{Line: 0, Col: i}, Text: "x"}
+		buf.Insert(op.Pos, op.Text)
+		h.Push(op)
+	}
+
+	// Shrinking drops the oldest operations
+	h.SetMaxDepth(4)
+	if h.Depth() != 4 || h.MaxDepth() != 4 {
+		t.Errorf("after SetMaxDepth(4): Depth() = %d, MaxDepth() = %d, want 4, 4", h.Depth(), h.MaxDepth())
+	}
+	for h.CanUndo() {
+		if err := h.Undo(buf); err != nil {
+			t.Fatalf("Undo() error = %v", err)
+		}
+	}
+	if got, _ := buf.GetLine(0); got != "xx" {
+		t.Errorf("after undoing everything line = %q, want %q", got, "xx")
+	}
+
+	h.SetMaxDepth(0)
+	if h.MaxDepth() != 1 {
+		t.Errorf("SetMaxDepth(0): MaxDepth() = %d, want 1", h.MaxDepth())
+	}
+}
+
 func TestHistory_ClearRedoOnPush(t *testing.T) {
 	h := NewHistory(10)
 	buf := buffer.NewBuffer()
@@ -177,6 +206,71 @@ func TestHistory_ClearRedoOnPush(t *testing.T) {
 	}
 }
 
+func TestHistory_Group(t *testing.T) {
+	h := NewHistory(10)
+	buf := buffer.NewBuffer()
+	insert := func(text string) {
+		op := &InsertOperation{Pos: buffer.Position{Line: 0, Col: 0}, Text: text}
+		buf.Insert(op.Pos, op.Text)
+		h.Push(op)
+	}
+
+	insert("a")
+	h.BeginGroup("macro")
+	insert("b")
+	insert("c")
+	insert("d")
+	if err := h.Undo(buf); err != nil { // Undoes "d" within the group
+		t.Fatalf("Undo() in group error = %v", err)
+	}
+	if !h.EndGroup() {
+		t.Fatal("EndGroup() = false, want true")
+	}
+	if h.Depth() != 2 {
+		t.Fatalf("Depth() = %d, want 2", h.Depth())
+	}
+	if got := h.undoStack[1].Description(); got != "macro" {
+		t.Errorf("group Description() = %q, want %q", got, "macro")
+	}
+
+	if err := h.Undo(buf); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if line, _ := buf.GetLine(0); line != "a" {
+		t.Errorf("after Undo(), line = %q, want %q", line, "a")
+	}
+
+	h.BeginGroup("empty")
+	if h.EndGroup() {
+		t.Error("EndGroup() of an empty group = true, want false")
+	}
+	if !h.CanRedo() {
+		t.Error("an empty group should keep the redo stack")
+	}
+
+	// A group inside another is part of it
+	h.BeginGroup("outer")
+	insert("e")
+	h.BeginGroup("inner")
+	insert("f")
+	if h.EndGroup() {
+		t.Error("EndGroup() of an inner group = true, want false")
+	}
+	insert("g")
+	if !h.EndGroup() {
+		t.Fatal("EndGroup() of the outer group = false, want true")
+	}
+	if got := h.undoStack[len(h.undoStack)-1].Description(); got != "outer" {
+		t.Errorf("nested group Description() = %q, want %q", got, "outer")
+	}
+	if err := h.Undo(buf); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if line, _ := buf.GetLine(0); line != "a" {
+		t.Errorf("after undoing the nested groups, line = %q, want %q", line, "a")
+	}
+}
+
 func TestDeleteOperation_UndoRedo(t *testing.T) {
 	h := NewHistory(10)
 	buf := buffer.NewBuffer()
@@ -214,3 +308,47 @@ func TestDeleteOperation_UndoRedo(t *testing.T) {
 		t.Errorf("After Redo(), line = %q, want %q", line, "heo")
 	}
 }
+
+func TestReplaceOperation_UndoRedo(t *testing.T) {
+	h := NewHistory(10)
+	buf := buffer.NewBuffer()
+	buf.SetLines([]string{"func a() {", "}", "", "func b() {"})
+
+	start := buffer.Position{Line: 1, Col: 0}
+	end := buffer.Position{Line: 3, Col: 4}
+	old, _ := buf.GetText(start, end)
+	if _, err := buf.Replace(start, end, "} // a\nfunc"); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+
+	h.Push(&ReplaceOperation{StartPos: start, EndPos: end, Old: old, New: "} // a\nfunc"})
+
+	after := []string{"func a() {", "} // a", "func b() {"}
+	original := []string{"func a() {", "}", "", "func b() {"}
+
+	if err := h.Undo(buf); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if got := buf.GetAllLines(); !equalLines(got, original) {
+		t.Errorf("After Undo(), lines = %q, want %q", got, original)
+	}
+
+	if err := h.Redo(buf); err != nil {
+		t.Fatalf("Redo() error = %v", err)
+	}
+	if got := buf.GetAllLines(); !equalLines(got, after) {
+		t.Errorf("After Redo(), lines = %q, want %q", got, after)
+	}
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
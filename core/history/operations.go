@@ -67,6 +67,31 @@ func (op *DeleteOperation) Description() string {
 	return "delete text"
 }
 
+// ReplaceOperation represents replacing a range of text with new text.
+type ReplaceOperation struct {
+	StartPos buffer.Position
+	EndPos   buffer.Position // End of the replaced (old) text
+	Old      string          // The text that was replaced
+	New      string          // The replacement text
+}
+
+// Undo restores the replaced text.
+func (op *ReplaceOperation) Undo(buf *buffer.Buffer) error {
+	_, err := buf.Replace(op.StartPos, buffer.PositionAfter(op.StartPos, op.New), op.Old)
+	return err
+}
+
+// Redo replaces the text again.
+func (op *ReplaceOperation) Redo(buf *buffer.Buffer) error {
+	_, err := buf.Replace(op.StartPos, op.EndPos, op.New)
+	return err
+}
+
+// Description returns a description of the operation.
+func (op *ReplaceOperation) Description() string {
+	return "replace text"
+}
+
 // SetLinesOperation represents a SetLines operation (used for bulk changes).
 type SetLinesOperation struct {
 	OldLines []string
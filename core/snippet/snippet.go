@@ -0,0 +1,395 @@
+// Package snippet implements snippets: templates of text with tab stops,
+// placeholders, choices and variables, written as in TextMate and VS Code.
+//
+//	$1, ${1}             tab stop 1; $0 is where the cursor ends up
+//	${1:text}            tab stop with a placeholder, which may hold others
+//	${1|one,two,three|}  tab stop offering a choice of text
+//	$NAME, ${NAME:text}  variable, with text to use if it is empty or unknown
+//
+// A tab stop used more than once is mirrored: each copy shows the text of
+// the first one that has a placeholder. \$, \} and \\ stand for the
+// character itself, as do \, and \| in a choice.
+package snippet
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Snippet is a parsed snippet body.
+type Snippet struct {
+	nodes []node
+}
+
+// node is a part of a snippet: text, tabStop or variable.
+type node interface{}
+
+// text is literal text.
+type text string
+
+// tabStop is a tab stop, with its placeholder or choices if it has them.
+type tabStop struct {
+	number      int
+	placeholder []node
+	choices     []string
+}
+
+// variable is a variable, with the text used when it has no value.
+type variable struct {
+	name string
+	def  []node
+}
+
+// Options control how a snippet is expanded.
+type Options struct {
+	Indent   string                           // Inserted after each line break, to line up with the first line
+	Tab      string                           // Replaces each tab in the snippet's own text; empty keeps tabs
+	Variable func(name string) (string, bool) // Value of a variable; nil knows none
+}
+
+// Expansion is the text of an expanded snippet and where its tab stops are
+// in it.
+type Expansion struct {
+	Text  string
+	Stops []Stop // In the order Tab visits them: by number, with 0 last
+}
+
+// Stop is a tab stop of an expansion.
+type Stop struct {
+	Number  int
+	Fields  []Range  // The tab stop's text, first where it is edited and then its mirrors
+	Choices []string // Text offered for the tab stop, if it has a choice
+}
+
+// Range is a range of bytes of an expansion's text, from Start up to End.
+type Range struct {
+	Start int
+	End   int
+}
+
+// Parse parses a snippet body.
+func Parse(body string) (*Snippet, error) {
+	p := &parser{src: body}
+	nodes, err := p.parse(false)
+	if err != nil {
+		return nil, err
+	}
+	return &Snippet{nodes: nodes}, nil
+}
+
+// Expand returns the snippet's text with its variables filled in, and
+// where its tab stops are. A snippet without $0 ends at its end.
+func (s *Snippet) Expand(opts Options) Expansion {
+	e := &expander{
+		opts:    opts,
+		defs:    make(map[int]*tabStop),
+		fields:  make(map[int][]Range),
+		primary: make(map[int]bool),
+	}
+	e.define(s.nodes)
+	e.emit(s.nodes)
+
+	numbers := make([]int, 0, len(e.fields))
+	for n := range e.fields {
+		numbers = append(numbers, n)
+	}
+	slices.SortFunc(numbers, func(x, y int) int {
+		// 0 sorts last
+		if x == 0 || y == 0 {
+			return y - x
+		}
+		return x - y
+	})
+
+	exp := Expansion{Text: e.out.String()}
+	for _, n := range numbers {
+		exp.Stops = append(exp.Stops, Stop{Number: n, Fields: e.fields[n], Choices: e.defs[n].choices})
+	}
+	if len(numbers) == 0 || numbers[len(numbers)-1] != 0 {
+		end := len(exp.Text)
+		exp.Stops = append(exp.Stops, Stop{Number: 0, Fields: []Range{{Start: end, End: end}}})
+	}
+	return exp
+}
+
+// expander builds an expansion.
+type expander struct {
+	opts    Options
+	out     strings.Builder
+	defs    map[int]*tabStop // The occurrence of each tab stop whose text the others mirror
+	fields  map[int][]Range
+	primary map[int]bool // Whether the defining occurrence has been written
+	writing []int        // Tab stops whose text is being written, innermost last
+}
+
+// define finds the occurrence of each tab stop that gives its text: the
+// first with a placeholder or choices, or else the first.
+func (e *expander) define(nodes []node) {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *tabStop:
+			def, ok := e.defs[n.number]
+			if !ok || (len(def.placeholder) == 0 && len(def.choices) == 0) {
+				e.defs[n.number] = n
+			}
+			e.define(n.placeholder)
+		case *variable:
+			e.define(n.def)
+		}
+	}
+}
+
+// emit writes nodes to the expansion.
+func (e *expander) emit(nodes []node) {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case text:
+			s := string(n)
+			if e.opts.Tab != "" {
+				s = strings.ReplaceAll(s, "\t", e.opts.Tab)
+			}
+			e.write(s)
+		case *variable:
+			value, ok := "", false
+			if e.opts.Variable != nil {
+				value, ok = e.opts.Variable(n.name)
+			}
+			switch {
+			case value != "":
+				e.write(value)
+			case len(n.def) > 0:
+				e.emit(n.def)
+			case !ok:
+				e.write(n.name) // Unknown, so show what was meant
+			}
+		case *tabStop:
+			e.emitTabStop(n)
+		}
+	}
+}
+
+// emitTabStop writes a tab stop's text and records where it is. The
+// defining occurrence writes its placeholder, with any tab stops in it;
+// mirrors copy its text.
+func (e *expander) emitTabStop(n *tabStop) {
+	if slices.Contains(e.writing, n.number) {
+		return // A tab stop inside itself has no text of its own
+	}
+	def := e.defs[n.number]
+	start := e.out.Len()
+	e.writing = append(e.writing, n.number)
+	if n == def && !e.primary[n.number] {
+		e.primary[n.number] = true
+		e.writeTabStopText(def)
+		e.fields[n.number] = slices.Insert(e.fields[n.number], 0, Range{Start: start, End: e.out.Len()})
+	} else {
+		// Mirror: write the defining text without recording the tab stops in it
+		saved := e.fields
+		e.fields = make(map[int][]Range)
+		primary := e.primary
+		e.primary = make(map[int]bool)
+		e.writeTabStopText(def)
+		e.fields, e.primary = saved, primary
+		e.fields[n.number] = append(e.fields[n.number], Range{Start: start, End: e.out.Len()})
+	}
+	e.writing = e.writing[:len(e.writing)-1]
+}
+
+// writeTabStopText writes the text a tab stop starts with: its first
+// choice or its placeholder.
+func (e *expander) writeTabStopText(n *tabStop) {
+	if len(n.choices) > 0 {
+		e.write(n.choices[0])
+		return
+	}
+	e.emit(n.placeholder)
+}
+
+// write writes s, indenting each line after the first.
+func (e *expander) write(s string) {
+	if e.opts.Indent != "" {
+		s = strings.ReplaceAll(s, "\n", "\n"+e.opts.Indent)
+	}
+	e.out.WriteString(s)
+}
+
+// parser reads a snippet body.
+type parser struct {
+	src string
+	pos int
+}
+
+// parse reads nodes up to the end of the body, or up to the "}" closing a
+// placeholder if inner is set.
+func (p *parser) parse(inner bool) ([]node, error) {
+	var nodes []node
+	var lit strings.Builder
+	flush := func() {
+		if lit.Len() > 0 {
+			nodes = append(nodes, text(lit.String()))
+			lit.Reset()
+		}
+	}
+
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.src) && strings.IndexByte(`$}\`, p.src[p.pos+1]) >= 0:
+			lit.WriteByte(p.src[p.pos+1])
+			p.pos += 2
+		case c == '}' && inner:
+			flush()
+			return nodes, nil
+		case c == '$':
+			n, err := p.parseDollar()
+			if err != nil {
+				return nil, err
+			}
+			if n == nil {
+				lit.WriteByte('$')
+				continue
+			}
+			flush()
+			nodes = append(nodes, n)
+		default:
+			lit.WriteByte(c)
+			p.pos++
+		}
+	}
+	if inner {
+		return nil, fmt.Errorf("parse snippet: missing } to close ${ at %d", p.pos)
+	}
+	flush()
+	return nodes, nil
+}
+
+// parseDollar reads a tab stop or variable at a "$". It returns nil, and
+// reads only the "$", if none starts there.
+func (p *parser) parseDollar() (node, error) {
+	start := p.pos
+	p.pos++ // $
+	if p.pos < len(p.src) && p.src[p.pos] != '{' {
+		if number, ok := p.number(); ok {
+			return &tabStop{number: number}, nil
+		}
+		if name := p.name(); name != "" {
+			return &variable{name: name}, nil
+		}
+		return nil, nil
+	}
+	if p.pos >= len(p.src) {
+		return nil, nil
+	}
+
+	p.pos++ // {
+	if number, ok := p.number(); ok {
+		stop := &tabStop{number: number}
+		switch {
+		case p.consume('}'):
+			return stop, nil
+		case p.consume(':'):
+			placeholder, err := p.parse(true)
+			if err != nil {
+				return nil, err
+			}
+			p.pos++ // }
+			stop.placeholder = placeholder
+			return stop, nil
+		case p.consume('|'):
+			choices, err := p.choices()
+			if err != nil {
+				return nil, err
+			}
+			stop.choices = choices
+			return stop, nil
+		}
+		return nil, p.badDollar(start)
+	}
+	if name := p.name(); name != "" {
+		v := &variable{name: name}
+		switch {
+		case p.consume('}'):
+			return v, nil
+		case p.consume(':'):
+			def, err := p.parse(true)
+			if err != nil {
+				return nil, err
+			}
+			p.pos++ // }
+			v.def = def
+			return v, nil
+		}
+	}
+	return nil, p.badDollar(start)
+}
+
+// badDollar describes a "${" at start that is not followed by a tab stop
+// or variable ted understands.
+func (p *parser) badDollar(start int) error {
+	if p.pos < len(p.src) && p.src[p.pos] == '/' {
+		return fmt.Errorf("parse snippet: transformations are not supported, at %d", start)
+	}
+	return fmt.Errorf("parse snippet: ${ at %d is not a tab stop or variable", start)
+}
+
+// choices reads the choices of a tab stop after its "|", up to and
+// including the closing "|}".
+func (p *parser) choices() ([]string, error) {
+	var choices []string
+	var choice strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.src) && strings.IndexByte(`,|\$}`, p.src[p.pos+1]) >= 0:
+			choice.WriteByte(p.src[p.pos+1])
+			p.pos += 2
+		case c == ',':
+			choices = append(choices, choice.String())
+			choice.Reset()
+			p.pos++
+		case c == '|' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '}':
+			p.pos += 2
+			return append(choices, choice.String()), nil
+		default:
+			choice.WriteByte(c)
+			p.pos++
+		}
+	}
+	return nil, fmt.Errorf("parse snippet: missing |} to close a choice at %d", p.pos)
+}
+
+// number reads a tab stop number.
+func (p *parser) number() (int, bool) {
+	start := p.pos
+	n := 0
+	for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+		n = n*10 + int(p.src[p.pos]-'0')
+		p.pos++
+	}
+	return n, p.pos > start
+}
+
+// name reads a variable name: a letter or underscore, then letters,
+// digits and underscores.
+func (p *parser) name() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '_' || (c|0x20 >= 'a' && c|0x20 <= 'z') || (p.pos > start && c >= '0' && c <= '9') {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+// consume reads c if it is next.
+func (p *parser) consume(c byte) bool {
+	if p.pos < len(p.src) && p.src[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package snippet
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"unclosed placeholder", "if ${1:cond {", "missing }"},
+		{"unclosed choice", "${1|a,b}", "missing |}"},
+		{"transformation", "${1/a/b/}", "transformations"},
+		{"not a tab stop", "${-}", "not a tab stop"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.body)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse(%q) error = %v, want one mentioning %q", tt.body, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnippet_Expand(t *testing.T) {
+	variables := func(name string) (string, bool) {
+		switch name {
+		case "TM_FILENAME":
+			return "main.go", true
+		case "CLIPBOARD":
+			return "", true
+		}
+		return "", false
+	}
+
+	tests := []struct {
+		name  string
+		body  string
+		opts  Options
+		want  string
+		stops []Stop
+	}{
+		{
+			name: "plain text ends at its end",
+			body: "hello",
+			want: "hello",
+			stops: []Stop{
+				{Number: 0, Fields: []Range{{5, 5}}},
+			},
+		},
+		{
+			name: "tab stops in number order with 0 last",
+			body: "$2 $0 ${1}",
+			want: "  ",
+			stops: []Stop{
+				{Number: 1, Fields: []Range{{2, 2}}},
+				{Number: 2, Fields: []Range{{0, 0}}},
+				{Number: 0, Fields: []Range{{1, 1}}},
+			},
+		},
+		{
+			name: "placeholders",
+			body: "return ${1:err}$0",
+			want: "return err",
+			stops: []Stop{
+				{Number: 1, Fields: []Range{{7, 10}}},
+				{Number: 0, Fields: []Range{{10, 10}}},
+			},
+		},
+		{
+			name: "nested placeholders",
+			body: "${1:a ${2:b}}",
+			want: "a b",
+			stops: []Stop{
+				{Number: 1, Fields: []Range{{0, 3}}},
+				{Number: 2, Fields: []Range{{2, 3}}},
+				{Number: 0, Fields: []Range{{3, 3}}},
+			},
+		},
+		{
+			name: "mirrors copy the placeholder",
+			body: "$1 := ${1:x}; $1",
+			want: "x := x; x",
+			stops: []Stop{
+				{Number: 1, Fields: []Range{{5, 6}, {0, 1}, {8, 9}}},
+				{Number: 0, Fields: []Range{{9, 9}}},
+			},
+		},
+		{
+			name: "choices start with the first",
+			body: "${1|int,string|} ${1}",
+			want: "int int",
+			stops: []Stop{
+				{Number: 1, Fields: []Range{{0, 3}, {4, 7}}, Choices: []string{"int", "string"}},
+				{Number: 0, Fields: []Range{{7, 7}}},
+			},
+		},
+		{
+			name: "escapes",
+			body: `\$1 \} \\ ${1|a\,b,c\|d|} $ x`,
+			want: `$1 } \ a,b $ x`,
+			stops: []Stop{
+				{Number: 1, Fields: []Range{{7, 10}}, Choices: []string{"a,b", "c|d"}},
+				{Number: 0, Fields: []Range{{14, 14}}},
+			},
+		},
+		{
+			name: "variables",
+			body: "$TM_FILENAME ${CLIPBOARD:none} ${UNKNOWN} ${EMPTY:${1:x}}",
+			opts: Options{Variable: variables},
+			want: "main.go none UNKNOWN x",
+			stops: []Stop{
+				{Number: 1, Fields: []Range{{21, 22}}},
+				{Number: 0, Fields: []Range{{22, 22}}},
+			},
+		},
+		{
+			name: "indent and tabs",
+			body: "if x {\n\t$0\n}",
+			opts: Options{Indent: "  ", Tab: "    "},
+			want: "if x {\n      \n  }",
+			stops: []Stop{
+				{Number: 0, Fields: []Range{{13, 13}}},
+			},
+		},
+		{
+			name: "a tab stop inside itself is empty",
+			body: "${1:a$1b}",
+			want: "ab",
+			stops: []Stop{
+				{Number: 1, Fields: []Range{{0, 2}}},
+				{Number: 0, Fields: []Range{{2, 2}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.body)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.body, err)
+			}
+			got := s.Expand(tt.opts)
+			if got.Text != tt.want {
+				t.Errorf("text = %q, want %q", got.Text, tt.want)
+			}
+			if !reflect.DeepEqual(got.Stops, tt.stops) {
+				t.Errorf("stops = %v, want %v", got.Stops, tt.stops)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// Package state persists editor state between sessions, such as search
+// history. State is kept in a single JSON file, separate from configuration.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/AndrewDonelson/ted/core/file"
+)
+
+// State is everything the editor remembers between sessions.
+type State struct {
+	Search         Search                `json:"search"`
+	RecentCommands []string              `json:"recentCommands,omitempty"` // Command palette, most recent first
+	Macros         map[string][]MacroKey `json:"macros,omitempty"`         // Keyboard macros saved by name
+	Bookmarks      map[string][]Bookmark `json:"bookmarks,omitempty"`      // Bookmarks of each file, by absolute path
+}
+
+// Bookmark is a bookmark in a file: its name, "1" to "9" for numbered
+// ones, and where it is.
+type Bookmark struct {
+	Name string `json:"name"`
+	Line int    `json:"line"`
+	Col  int    `json:"col,omitempty"`
+}
+
+// MacroKey is one key of a keyboard macro, as the editor or an open dialog
+// received it.
+type MacroKey struct {
+	Action    string `json:"action"`           // Key action name, such as "save" or "character"
+	Character string `json:"char,omitempty"`   // Character typed, if any
+	Key       int    `json:"key,omitempty"`    // Terminal key code
+	Modifiers int    `json:"mod,omitempty"`    // Modifier keys held
+	Dialog    bool   `json:"dialog,omitempty"` // The key went to a dialog
+}
+
+// Search is the find and replace history and the last option flags.
+// Histories are ordered oldest first.
+type Search struct {
+	Patterns      []string `json:"patterns,omitempty"`
+	Replacements  []string `json:"replacements,omitempty"`
+	CaseSensitive bool     `json:"caseSensitive,omitempty"`
+	WholeWord     bool     `json:"wholeWord,omitempty"`
+	UseRegex      bool     `json:"useRegex,omitempty"`
+	PreserveCase  bool     `json:"preserveCase,omitempty"`
+	Fuzzy         bool     `json:"fuzzy,omitempty"`
+}
+
+// DefaultPath returns the state file location: ted/state.json under
+// $XDG_STATE_HOME, or ~/.local/state when it is not set.
+func DefaultPath() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "ted", "state.json"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("find home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "state", "ted", "state.json"), nil
+}
+
+// Load reads the state file at path. A missing file yields empty state.
+func Load(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read state: %w", err)
+	}
+
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parse state: %w", err)
+	}
+	return &s, nil
+}
+
+// Save writes the state to path atomically.
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode state: %w", err)
+	}
+	if err := file.WriteData(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("write state: %w", err)
+	}
+	return nil
+}
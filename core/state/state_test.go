@@ -0,0 +1,75 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoad_Missing(t *testing.T) {
+	s, err := Load(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(s.Search.Patterns) != 0 {
+		t.Errorf("Load() of missing file = %+v, want empty state", s)
+	}
+}
+
+func TestSaveLoad_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ted", "state.json")
+
+	want := &State{Search: Search{
+		Patterns:     []string{"foo", "ba[rz]"},
+		Replacements: []string{"qux"},
+		UseRegex:     true,
+		PreserveCase: true,
+	}, Macros: map[string][]MacroKey{
+		"wrap": {{Action: "home"}, {Action: "character", Character: "(", Key: 256}},
+	}, Bookmarks: map[string][]Bookmark{
+		"/src/main.go": {{Name: "1", Line: 12}, {Name: "todo", Line: 40, Col: 3}},
+	}}
+	if err := want.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !slices.Equal(got.Search.Patterns, want.Search.Patterns) ||
+		!slices.Equal(got.Search.Replacements, want.Search.Replacements) ||
+		got.Search.UseRegex != want.Search.UseRegex ||
+		got.Search.PreserveCase != want.Search.PreserveCase ||
+		got.Search.CaseSensitive || got.Search.WholeWord {
+		t.Errorf("Load() = %+v, want %+v", got.Search, want.Search)
+	}
+	if !slices.Equal(got.Macros["wrap"], want.Macros["wrap"]) {
+		t.Errorf("Load() macros = %+v, want %+v", got.Macros, want.Macros)
+	}
+	if !slices.Equal(got.Bookmarks["/src/main.go"], want.Bookmarks["/src/main.go"]) {
+		t.Errorf("Load() bookmarks = %+v, want %+v", got.Bookmarks, want.Bookmarks)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	os.WriteFile(path, []byte("{not json"), 0644)
+
+	if _, err := Load(path); err == nil {
+		t.Error("Load() of invalid file should fail")
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/xdg/state")
+
+	path, err := DefaultPath()
+	if err != nil {
+		t.Fatalf("DefaultPath() error = %v", err)
+	}
+	if want := filepath.Join("/xdg/state", "ted", "state.json"); path != want {
+		t.Errorf("DefaultPath() = %q, want %q", path, want)
+	}
+}
@@ -0,0 +1,206 @@
+package editor
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/core/history"
+	"github.com/AndrewDonelson/ted/ui/layout"
+)
+
+// blockSelection is a rectangular selection: the lines from one corner to
+// the other, between the corners' screen columns. Columns may lie past the
+// end of a line.
+type blockSelection struct {
+	anchorLine, anchorCol int // Corner where the selection started
+	line, col             int // Corner at the main cursor
+}
+
+// cursors returns a cursor for each line of the block, selecting the text
+// between its columns, in document order, and the index of the main one.
+// A character partly inside the block, such as a tab, is selected.
+func (b blockSelection) cursors(buf *buffer.Buffer, tabSize int) ([]buffer.Cursor, int) {
+	top, bottom := min(b.anchorLine, b.line), max(b.anchorLine, b.line)
+	left, right := min(b.anchorCol, b.col), max(b.anchorCol, b.col)
+	cursors := make([]buffer.Cursor, 0, bottom-top+1)
+	for line := top; line <= bottom; line++ {
+		text, _ := buf.GetLine(line)
+		start := buffer.Position{Line: line, Col: blockStart(text, left, tabSize)}
+		end := buffer.Position{Line: line, Col: blockEnd(text, right, tabSize)}
+		if left == right {
+			start = end
+		}
+		if b.col < b.anchorCol {
+			start, end = end, start
+		}
+		cursors = append(cursors, buffer.Cursor{Pos: end, Anchor: start})
+	}
+	return cursors, b.line - top
+}
+
+// blockStart returns the byte offset in text of the character covering
+// screen column col, or the end of the text if col lies past it.
+func blockStart(text string, col, tabSize int) int {
+	x := 0
+	for i := 0; i < len(text); {
+		width, size := charWidth(text[i:], x, tabSize)
+		if col < x+width {
+			return i
+		}
+		x += width
+		i += size
+	}
+	return len(text)
+}
+
+// blockEnd returns the byte offset in text of the first character drawn
+// at or after screen column col, or the end of the text.
+func blockEnd(text string, col, tabSize int) int {
+	x := 0
+	for i := 0; i < len(text); {
+		if x >= col {
+			return i
+		}
+		width, size := charWidth(text[i:], x, tabSize)
+		x += width
+		i += size
+	}
+	return len(text)
+}
+
+// charWidth returns the screen columns and bytes of the character at the
+// start of text, drawn at screen column x: one column per byte, and tabs
+// up to the next tab stop.
+func charWidth(text string, x, tabSize int) (width, size int) {
+	if text[0] == '\t' {
+		return tabSize - x%tabSize, 1
+	}
+	_, size = utf8.DecodeRuneInString(text)
+	return size, size
+}
+
+// activeBlock returns the block selection, or nil once the cursors are no
+// longer the ones it made.
+func (e *Editor) activeBlock() *blockSelection {
+	if e.block == nil {
+		return nil
+	}
+	cursors, main := e.allCursors()
+	want, wantMain := e.block.cursors(e.buffer, e.renderer.TabSize())
+	if main != wantMain || !slices.Equal(cursors, want) {
+		e.block = nil
+	}
+	return e.block
+}
+
+// selectBlock makes b the selection, with a cursor on each of its lines.
+func (e *Editor) selectBlock(b blockSelection) {
+	cursors, main := b.cursors(e.buffer, e.renderer.TabSize())
+	e.setAllCursors(cursors, main)
+	e.block = &b
+}
+
+// handleBlockSelect moves the block selection's corner at the cursor by
+// one line or screen column, starting a block at the cursor if there is
+// none.
+func (e *Editor) handleBlockSelect(dLine, dCol int) {
+	b := e.activeBlock()
+	if b == nil {
+		pos := e.buffer.GetCursor()
+		text, _ := e.buffer.GetLine(pos.Line)
+		col := layout.DisplayColumn(text, pos.Col, e.renderer.TabSize())
+		b = &blockSelection{anchorLine: pos.Line, anchorCol: col, line: pos.Line, col: col}
+	}
+	b.line = min(max(b.line+dLine, 0), e.buffer.LineCount()-1)
+	b.col = max(b.col+dCol, 0)
+	e.selectBlock(*b)
+}
+
+// blockText returns the text of the block selection, a line for each of
+// its lines.
+func (e *Editor) blockText() (string, error) {
+	cursors, _ := e.allCursors()
+	texts := make([]string, len(cursors))
+	for i, c := range cursors {
+		text, err := e.buffer.GetText(c.Range())
+		if err != nil {
+			return "", err
+		}
+		texts[i] = text
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// deleteBlock deletes the text of the block selection as one undoable
+// edit.
+func (e *Editor) deleteBlock() {
+	e.replaceAtEachCursor("delete block", func(_ int, c buffer.Cursor) (buffer.Position, buffer.Position, string) {
+		start, end := c.Range()
+		return start, end, ""
+	})
+}
+
+// pasteColumn inserts the lines of text as a column, one below the other
+// starting at the cursor's screen column, in place of the block selection
+// if there is one. Short lines are padded with spaces to reach the column,
+// and lines are added at the end of the text as needed.
+func (e *Editor) pasteColumn(text string) {
+	tabSize := e.renderer.TabSize()
+	cursor := e.buffer.GetCursor()
+	cursorLine, _ := e.buffer.GetLine(cursor.Line)
+	top, col := cursor.Line, layout.DisplayColumn(cursorLine, cursor.Col, tabSize)
+
+	op := &history.CompositeOperation{}
+	op.SetDescription("paste column")
+	replace := func(start, end buffer.Position, text string) buffer.Position {
+		if start == end && text == "" {
+			return start
+		}
+		old, err := e.buffer.GetText(start, end)
+		if err != nil {
+			return start
+		}
+		newEnd, err := e.buffer.Replace(start, end, text)
+		if err != nil {
+			return start
+		}
+		op.Operations = append(op.Operations, &history.ReplaceOperation{StartPos: start, EndPos: end, Old: old, New: text})
+		return newEnd
+	}
+
+	if b := e.activeBlock(); b != nil {
+		top, col = min(b.anchorLine, b.line), min(b.anchorCol, b.col)
+		cursors, _ := e.allCursors()
+		for _, c := range cursors {
+			start, end := c.Range()
+			replace(start, end, "") // Each is on its own line, so the others stay put
+		}
+	}
+	e.block = nil
+	e.buffer.ClearExtraCursors()
+	e.clearSelection()
+
+	end := cursor
+	for i, piece := range strings.Split(text, "\n") {
+		line := top + i
+		if line == e.buffer.LineCount() {
+			last, _ := e.buffer.GetLine(line - 1)
+			at := buffer.Position{Line: line - 1, Col: len(last)}
+			replace(at, at, "\n")
+		}
+		lineText, _ := e.buffer.GetLine(line)
+		at := buffer.Position{Line: line, Col: blockStart(lineText, col, tabSize)}
+		if at.Col == len(lineText) && piece != "" {
+			piece = strings.Repeat(" ", max(col-layout.DisplayColumn(lineText, len(lineText), tabSize), 0)) + piece
+		}
+		end = replace(at, at, piece)
+	}
+	e.buffer.MoveCursor(end)
+
+	if len(op.Operations) > 0 {
+		e.isDirty = true
+		e.history.Push(op)
+	}
+}
@@ -0,0 +1,190 @@
+package editor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/core/state"
+	"github.com/AndrewDonelson/ted/ui/dialog"
+)
+
+// namedBookmarkMark marks a named bookmark in the gutter; numbered ones
+// show their number.
+const namedBookmarkMark = '•'
+
+// handleToggleBookmark removes the bookmarks on the cursor's line, or sets
+// the lowest numbered bookmark not in use there.
+func (e *Editor) handleToggleBookmark() {
+	cursor := e.buffer.GetCursor()
+	var removed []string
+	for _, bm := range e.buffer.Bookmarks() {
+		if bm.Pos.Line == cursor.Line {
+			e.buffer.RemoveBookmark(bm.Name)
+			removed = append(removed, bm.Name)
+		}
+	}
+	if len(removed) > 0 {
+		e.setStatus(fmt.Sprintf("Removed bookmark %s", strings.Join(removed, ", ")))
+		return
+	}
+
+	for n := 1; n <= buffer.MaxNumberedBookmark; n++ {
+		name := strconv.Itoa(n)
+		if _, ok := e.buffer.Bookmark(name); !ok {
+			e.buffer.SetBookmark(name, cursor)
+			e.setStatus(fmt.Sprintf("Set bookmark %s", name))
+			return
+		}
+	}
+	e.setStatus(fmt.Sprintf("All %d numbered bookmarks are in use", buffer.MaxNumberedBookmark))
+}
+
+// handleSetBookmark asks for a name and sets a bookmark of that name at
+// the cursor, moving it if it is already set. A number from 1 to 9 sets
+// that numbered bookmark.
+func (e *Editor) handleSetBookmark() error {
+	pos := e.buffer.GetCursor()
+	dlg := dialog.NewInputDialog("Set Bookmark", "Name:", "", func(input string) {
+		name := strings.TrimSpace(input)
+		if name == "" {
+			return
+		}
+		e.buffer.SetBookmark(name, pos)
+		e.setStatus(fmt.Sprintf("Set bookmark %s", name))
+	}, nil)
+
+	width, height := e.screen.GetSize()
+	e.dialogManager.Push(dlg, width, height)
+	return nil
+}
+
+// goToBookmark moves the cursor to the bookmark called name.
+func (e *Editor) goToBookmark(name string) {
+	pos, ok := e.buffer.Bookmark(name)
+	if !ok {
+		e.setStatus(fmt.Sprintf("Bookmark %s is not set", name))
+		return
+	}
+	e.jumpTo(pos)
+}
+
+// handleNextBookmark moves the cursor to the next bookmark after it, when
+// delta is 1, or before it, when it is -1, wrapping around at the ends of
+// the file.
+func (e *Editor) handleNextBookmark(delta int) {
+	bookmarks := e.buffer.Bookmarks()
+	if len(bookmarks) == 0 {
+		e.setStatus("No bookmarks")
+		return
+	}
+
+	cursor := e.buffer.GetCursor()
+	if delta < 0 {
+		for i := len(bookmarks) - 1; i >= 0; i-- {
+			if buffer.ComparePositions(bookmarks[i].Pos, cursor) < 0 {
+				e.jumpTo(bookmarks[i].Pos)
+				return
+			}
+		}
+		e.jumpTo(bookmarks[len(bookmarks)-1].Pos)
+		return
+	}
+	for _, bm := range bookmarks {
+		if buffer.ComparePositions(bm.Pos, cursor) > 0 {
+			e.jumpTo(bm.Pos)
+			return
+		}
+	}
+	e.jumpTo(bookmarks[0].Pos)
+}
+
+// handleGoToBookmark lists the bookmarks with their lines and moves the
+// cursor to the one chosen.
+func (e *Editor) handleGoToBookmark() error {
+	bookmarks := e.buffer.Bookmarks()
+	if len(bookmarks) == 0 {
+		e.setStatus("No bookmarks")
+		return nil
+	}
+
+	choices := make([]string, len(bookmarks))
+	for i, bm := range bookmarks {
+		line, _ := e.buffer.GetLine(bm.Pos.Line)
+		choices[i] = fmt.Sprintf("%-6s %4d: %s", bm.Name, bm.Pos.Line+1, strings.TrimSpace(line))
+	}
+
+	dlg := dialog.NewChoiceDialog("Go to Bookmark", choices, 0, func(i int) {
+		e.jumpTo(bookmarks[i].Pos)
+	}, nil)
+
+	width, height := e.screen.GetSize()
+	e.dialogManager.Push(dlg, width, height)
+	return nil
+}
+
+// bookmarkMarks returns the gutter marker of each line with a bookmark:
+// the number of a numbered bookmark, or a dot for a named one.
+func (e *Editor) bookmarkMarks() map[int]rune {
+	bookmarks := e.buffer.Bookmarks()
+	if len(bookmarks) == 0 {
+		return nil
+	}
+	marks := make(map[int]rune, len(bookmarks))
+	for _, bm := range bookmarks {
+		if _, ok := marks[bm.Pos.Line]; ok {
+			continue // Numbered bookmarks sort first
+		}
+		mark := rune(namedBookmarkMark)
+		if n := bm.Number(); n > 0 {
+			mark = rune('0' + n)
+		}
+		marks[bm.Pos.Line] = mark
+	}
+	return marks
+}
+
+// bookmarkKey returns the key the bookmarks of the file at path are saved
+// under: its absolute path.
+func bookmarkKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// stashBookmarks keeps the bookmarks of the open file, if it has a path,
+// to be saved in the state file and restored when it is opened again.
+func (e *Editor) stashBookmarks() {
+	if e.filePath == "" {
+		return
+	}
+	key := bookmarkKey(e.filePath)
+	bookmarks := e.buffer.Bookmarks()
+	if len(bookmarks) == 0 {
+		delete(e.savedBookmarks, key)
+		return
+	}
+
+	saved := make([]state.Bookmark, len(bookmarks))
+	for i, bm := range bookmarks {
+		saved[i] = state.Bookmark{Name: bm.Name, Line: bm.Pos.Line, Col: bm.Pos.Col}
+	}
+	if e.savedBookmarks == nil {
+		e.savedBookmarks = make(map[string][]state.Bookmark)
+	}
+	e.savedBookmarks[key] = saved
+}
+
+// restoreBookmarks replaces the buffer's bookmarks with those kept for the
+// file at path.
+func (e *Editor) restoreBookmarks(path string) {
+	saved := e.savedBookmarks[bookmarkKey(path)]
+	bookmarks := make([]buffer.Bookmark, len(saved))
+	for i, bm := range saved {
+		bookmarks[i] = buffer.Bookmark{Name: bm.Name, Pos: buffer.Position{Line: bm.Line, Col: bm.Col}}
+	}
+	e.buffer.SetBookmarks(bookmarks)
+}
@@ -0,0 +1,366 @@
+package editor
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/AndrewDonelson/ted/ui/dialog"
+	"github.com/AndrewDonelson/ted/ui/menu"
+	"github.com/AndrewDonelson/ted/ui/terminal"
+)
+
+// maxRecentCommands is how many commands the palette remembers.
+const maxRecentCommands = 10
+
+// command is something the user can run from a key binding, a menu item
+// or the command palette. Its key action names it in keybindings and
+// gives its description.
+type command struct {
+	action terminal.KeyAction // Key binding and name
+	menu   menu.MenuAction    // Menu item that runs it, if any
+	edits  bool               // Changes the buffer, so refused when read-only
+	multi  bool               // Works with several cursors; other commands keep only the main one
+	run    func(e *Editor) error
+}
+
+// commandRegistry holds every command. Key bindings, menus and the command
+// palette all run commands through it.
+type commandRegistry struct {
+	commands []*command // In command palette order
+	byAction map[terminal.KeyAction]*command
+	byMenu   map[menu.MenuAction]*command
+}
+
+// newCommandRegistry creates a registry with the built-in commands.
+func newCommandRegistry() *commandRegistry {
+	r := &commandRegistry{
+		byAction: make(map[terminal.KeyAction]*command),
+		byMenu:   make(map[menu.MenuAction]*command),
+	}
+	for _, cmd := range builtinCommands() {
+		r.register(cmd)
+	}
+	return r
+}
+
+// register adds a command, replacing any with the same key action.
+func (r *commandRegistry) register(cmd *command) {
+	if old, ok := r.byAction[cmd.action]; ok {
+		r.commands = slices.DeleteFunc(r.commands, func(c *command) bool { return c == old })
+		delete(r.byMenu, old.menu)
+	}
+	r.commands = append(r.commands, cmd)
+	r.byAction[cmd.action] = cmd
+	if cmd.menu != menu.ActionNone {
+		r.byMenu[cmd.menu] = cmd
+	}
+}
+
+// lookup returns the command for a key action.
+func (r *commandRegistry) lookup(action terminal.KeyAction) (*command, bool) {
+	cmd, ok := r.byAction[action]
+	return cmd, ok
+}
+
+// lookupMenu returns the command for a menu item.
+func (r *commandRegistry) lookupMenu(action menu.MenuAction) (*command, bool) {
+	cmd, ok := r.byMenu[action]
+	return cmd, ok
+}
+
+// edits reports whether a key action modifies the buffer. Typing does.
+func (r *commandRegistry) edits(action terminal.KeyAction) bool {
+	if action == terminal.KeyActionCharacter {
+		return true
+	}
+	cmd, ok := r.byAction[action]
+	return ok && cmd.edits
+}
+
+// builtinCommands returns ted's commands in command palette order.
+func builtinCommands() []*command {
+	// do adapts a handler that cannot fail
+	do := func(f func(e *Editor)) func(e *Editor) error {
+		return func(e *Editor) error {
+			f(e)
+			return nil
+		}
+	}
+
+	return []*command{
+		// File
+		{action: terminal.KeyActionNew, menu: menu.ActionFileNew, run: (*Editor).handleNew},
+		{action: terminal.KeyActionOpen, menu: menu.ActionFileOpen, run: (*Editor).handleOpen},
+		{action: terminal.KeyActionSave, menu: menu.ActionFileSave, multi: true, run: (*Editor).handleSave},
+		{action: terminal.KeyActionSaveAs, menu: menu.ActionFileSaveAs, multi: true, run: (*Editor).handleSaveAs},
+		{action: terminal.KeyActionToggleReadOnly, menu: menu.ActionFileToggleReadOnly, multi: true, run: do((*Editor).handleToggleReadOnly)},
+		{action: terminal.KeyActionClose, menu: menu.ActionFileClose, run: (*Editor).handleClose},
+		{action: terminal.KeyActionQuit, menu: menu.ActionFileQuit, multi: true, run: func(e *Editor) error { return ErrQuit }},
+
+		// Edit
+		{action: terminal.KeyActionUndo, menu: menu.ActionEditUndo, edits: true, run: func(e *Editor) error {
+			e.Undo() // Nothing to undo is not an error
+			return nil
+		}},
+		{action: terminal.KeyActionRedo, menu: menu.ActionEditRedo, edits: true, run: func(e *Editor) error {
+			e.Redo() // Nothing to redo is not an error
+			return nil
+		}},
+		{action: terminal.KeyActionCut, menu: menu.ActionEditCut, edits: true, run: func(e *Editor) error {
+			if err := e.Cut(); err != nil {
+				return fmt.Errorf("cut: %w", err)
+			}
+			return nil
+		}},
+		{action: terminal.KeyActionCopy, menu: menu.ActionEditCopy, multi: true, run: func(e *Editor) error {
+			if err := e.Copy(); err != nil {
+				return fmt.Errorf("copy: %w", err)
+			}
+			return nil
+		}},
+		{action: terminal.KeyActionPaste, menu: menu.ActionEditPaste, edits: true, multi: true, run: func(e *Editor) error {
+			if err := e.Paste(); err != nil {
+				return fmt.Errorf("paste: %w", err)
+			}
+			return nil
+		}},
+		{action: terminal.KeyActionSelectAll, menu: menu.ActionEditSelectAll, run: do((*Editor).handleSelectAll)},
+		{action: terminal.KeyActionDeleteLine, menu: menu.ActionEditDeleteLine, edits: true, run: do((*Editor).handleDeleteLine)},
+		{action: terminal.KeyActionDuplicateLine, menu: menu.ActionEditDuplicateLine, edits: true, run: do((*Editor).handleDuplicateLine)},
+		{action: terminal.KeyActionMoveLineUp, menu: menu.ActionEditMoveLineUp, edits: true, run: do((*Editor).handleMoveLineUp)},
+		{action: terminal.KeyActionMoveLineDown, menu: menu.ActionEditMoveLineDown, edits: true, run: do((*Editor).handleMoveLineDown)},
+		{action: terminal.KeyActionInsertLineAbove, edits: true, run: do((*Editor).handleInsertLineAbove)},
+		{action: terminal.KeyActionInsertLineBelow, edits: true, run: do((*Editor).handleInsertLineBelow)},
+		{action: terminal.KeyActionIndent, edits: true, run: (*Editor).handleIndent},
+		{action: terminal.KeyActionOutdent, edits: true, run: do((*Editor).handleOutdent)},
+		{action: terminal.KeyActionComplete, edits: true, run: do((*Editor).handleComplete)},
+		{action: terminal.KeyActionAddNextOccurrence, multi: true, run: do((*Editor).handleAddNextOccurrence)},
+		{action: terminal.KeyActionAddCursorAbove, multi: true, run: func(e *Editor) error {
+			e.handleAddCursor(-1)
+			return nil
+		}},
+		{action: terminal.KeyActionAddCursorBelow, multi: true, run: func(e *Editor) error {
+			e.handleAddCursor(1)
+			return nil
+		}},
+		{action: terminal.KeyActionBackspace, edits: true, multi: true, run: do((*Editor).handleBackspace)},
+		{action: terminal.KeyActionDelete, edits: true, multi: true, run: do((*Editor).handleDelete)},
+		{action: terminal.KeyActionEnter, edits: true, multi: true, run: func(e *Editor) error {
+			if e.buffer.HasExtraCursors() {
+				e.insertAtEachCursor("\n")
+				return nil
+			}
+			e.insertCharacter('\n')
+			return nil
+		}},
+
+		// Search
+		{action: terminal.KeyActionFind, menu: menu.ActionSearchFind, run: (*Editor).handleFind},
+		{action: terminal.KeyActionReplace, menu: menu.ActionSearchReplace, edits: true, run: (*Editor).handleReplace},
+		{action: terminal.KeyActionFindInFiles, menu: menu.ActionSearchFindInFiles, run: (*Editor).handleFindInFiles},
+		{action: terminal.KeyActionReplaceInFiles, menu: menu.ActionSearchReplaceInFiles, run: (*Editor).handleReplaceInFiles},
+		{action: terminal.KeyActionGoToLine, menu: menu.ActionSearchGoToLine, run: (*Editor).handleGoToLine},
+		{action: terminal.KeyActionGoToSymbol, menu: menu.ActionSearchGoToSymbol, run: (*Editor).handleGoToSymbol},
+
+		// Navigation
+		{action: terminal.KeyActionMoveLeft, multi: true, run: moveCursor((*Editor).moveLeft)},
+		{action: terminal.KeyActionMoveRight, multi: true, run: moveCursor((*Editor).moveRight)},
+		{action: terminal.KeyActionMoveUp, multi: true, run: moveCursor((*Editor).moveUp)},
+		{action: terminal.KeyActionMoveDown, multi: true, run: moveCursor((*Editor).moveDown)},
+		{action: terminal.KeyActionWordLeft, multi: true, run: moveCursor((*Editor).moveWordLeft)},
+		{action: terminal.KeyActionWordRight, multi: true, run: moveCursor((*Editor).moveWordRight)},
+		{action: terminal.KeyActionHome, multi: true, run: do(eachCursor((*Editor).moveLineStart))},
+		{action: terminal.KeyActionEnd, multi: true, run: do(eachCursor((*Editor).moveLineEnd))},
+		{action: terminal.KeyActionPageUp, multi: true, run: moveCursor((*Editor).movePageUp)},
+		{action: terminal.KeyActionPageDown, multi: true, run: moveCursor((*Editor).movePageDown)},
+		{action: terminal.KeyActionDocumentStart, run: do((*Editor).handleDocumentStart)},
+		{action: terminal.KeyActionDocumentEnd, run: do((*Editor).handleDocumentEnd)},
+		{action: terminal.KeyActionJumpBack, run: do((*Editor).handleJumpBack)},
+		{action: terminal.KeyActionJumpForward, run: do((*Editor).handleJumpForward)},
+		{action: terminal.KeyActionSelectLeft, multi: true, run: extendSelection((*Editor).moveLeft)},
+		{action: terminal.KeyActionSelectRight, multi: true, run: extendSelection((*Editor).moveRight)},
+		{action: terminal.KeyActionSelectUp, multi: true, run: extendSelection((*Editor).moveUp)},
+		{action: terminal.KeyActionSelectDown, multi: true, run: extendSelection((*Editor).moveDown)},
+		{action: terminal.KeyActionBlockSelectLeft, multi: true, run: selectBlock(0, -1)},
+		{action: terminal.KeyActionBlockSelectRight, multi: true, run: selectBlock(0, 1)},
+		{action: terminal.KeyActionBlockSelectUp, multi: true, run: selectBlock(-1, 0)},
+		{action: terminal.KeyActionBlockSelectDown, multi: true, run: selectBlock(1, 0)},
+		{action: terminal.KeyActionEscape, run: func(e *Editor) error {
+			e.clearSelection()
+			e.menuBar.CloseMenu()
+			return nil
+		}},
+
+		// View
+		{action: terminal.KeyActionToggleLineNumbers, menu: menu.ActionViewLineNumbers, multi: true, run: (*Editor).handleToggleLineNumbers},
+		{action: terminal.KeyActionToggleWordWrap, menu: menu.ActionViewWordWrap, multi: true, run: (*Editor).handleToggleWordWrap},
+		{action: terminal.KeyActionIndentation, menu: menu.ActionViewIndentation, multi: true, run: (*Editor).handleIndentation},
+		{action: terminal.KeyActionCommandPalette, menu: menu.ActionViewCommands, multi: true, run: (*Editor).handleCommandPalette},
+		{action: terminal.KeyActionMenuToggle, multi: true, run: func(e *Editor) error {
+			e.menuBar.Toggle()
+			return nil
+		}},
+
+		// Bookmarks
+		{action: terminal.KeyActionToggleBookmark, multi: true, run: do((*Editor).handleToggleBookmark)},
+		{action: terminal.KeyActionSetBookmark, multi: true, run: (*Editor).handleSetBookmark},
+		{action: terminal.KeyActionNextBookmark, run: func(e *Editor) error {
+			e.handleNextBookmark(1)
+			return nil
+		}},
+		{action: terminal.KeyActionPreviousBookmark, run: func(e *Editor) error {
+			e.handleNextBookmark(-1)
+			return nil
+		}},
+		{action: terminal.KeyActionGoToBookmark, run: (*Editor).handleGoToBookmark},
+		{action: terminal.KeyActionGoToBookmark1, run: goToBookmark(1)},
+		{action: terminal.KeyActionGoToBookmark2, run: goToBookmark(2)},
+		{action: terminal.KeyActionGoToBookmark3, run: goToBookmark(3)},
+		{action: terminal.KeyActionGoToBookmark4, run: goToBookmark(4)},
+		{action: terminal.KeyActionGoToBookmark5, run: goToBookmark(5)},
+		{action: terminal.KeyActionGoToBookmark6, run: goToBookmark(6)},
+		{action: terminal.KeyActionGoToBookmark7, run: goToBookmark(7)},
+		{action: terminal.KeyActionGoToBookmark8, run: goToBookmark(8)},
+		{action: terminal.KeyActionGoToBookmark9, run: goToBookmark(9)},
+
+		// Folding
+		{action: terminal.KeyActionToggleFold, run: do((*Editor).handleToggleFold)},
+		{action: terminal.KeyActionFold, run: do((*Editor).handleFold)},
+		{action: terminal.KeyActionUnfold, run: do((*Editor).handleUnfold)},
+		{action: terminal.KeyActionFoldAll, run: do((*Editor).handleFoldAll)},
+		{action: terminal.KeyActionUnfoldAll, multi: true, run: do((*Editor).handleUnfoldAll)},
+
+		// Macros
+		{action: terminal.KeyActionRecordMacro, multi: true, run: do((*Editor).handleRecordMacro)},
+		{action: terminal.KeyActionPlayMacro, multi: true, run: func(e *Editor) error { return e.playMacro(e.lastMacro, 1, nil) }},
+		{action: terminal.KeyActionPlayMacroTimes, multi: true, run: (*Editor).handlePlayMacroTimes},
+		{action: terminal.KeyActionPlayMacroOnLines, run: (*Editor).handlePlayMacroOnLines},
+		{action: terminal.KeyActionSaveMacro, multi: true, run: (*Editor).handleSaveMacro},
+		{action: terminal.KeyActionPlayNamedMacro, multi: true, run: (*Editor).handlePlayNamedMacro},
+
+		// Help
+		{action: terminal.KeyActionHelp, menu: menu.ActionHelpShortcuts, multi: true, run: (*Editor).handleHelp},
+		{action: terminal.KeyActionAbout, menu: menu.ActionHelpAbout, multi: true, run: (*Editor).handleAbout},
+	}
+}
+
+// moveCursor makes a command that clears the selection and moves the
+// cursor, every cursor if there are several.
+func moveCursor(move func(e *Editor)) func(e *Editor) error {
+	return func(e *Editor) error {
+		e.atEachCursor(func() {
+			e.clearSelection()
+			move(e)
+		})
+		return nil
+	}
+}
+
+// extendSelection makes a command that moves the cursor and extends the
+// selection to it, at every cursor if there are several.
+func extendSelection(move func(e *Editor)) func(e *Editor) error {
+	return func(e *Editor) error {
+		e.atEachCursor(func() {
+			e.startSelectionIfNeeded()
+			move(e)
+			e.updateSelectionEnd()
+		})
+		return nil
+	}
+}
+
+// goToBookmark makes a command that moves the cursor to numbered bookmark
+// n.
+func goToBookmark(n int) func(e *Editor) error {
+	return func(e *Editor) error {
+		e.goToBookmark(strconv.Itoa(n))
+		return nil
+	}
+}
+
+// selectBlock makes a command that extends the block selection by dLine
+// lines and dCol screen columns.
+func selectBlock(dLine, dCol int) func(e *Editor) error {
+	return func(e *Editor) error {
+		e.handleBlockSelect(dLine, dCol)
+		return nil
+	}
+}
+
+// eachCursor adapts f to run at every cursor.
+func eachCursor(f func(e *Editor)) func(e *Editor) {
+	return func(e *Editor) {
+		e.atEachCursor(func() { f(e) })
+	}
+}
+
+func (e *Editor) moveLeft()      { e.buffer.MoveCursorLeft() }
+func (e *Editor) moveRight()     { e.buffer.MoveCursorRight() }
+func (e *Editor) moveUp()        { e.buffer.MoveCursorUp() }
+func (e *Editor) moveDown()      { e.buffer.MoveCursorDown() }
+func (e *Editor) moveWordLeft()  { e.buffer.MoveCursorWordLeft() }
+func (e *Editor) moveWordRight() { e.buffer.MoveCursorWordRight() }
+func (e *Editor) moveLineStart() { e.buffer.MoveCursorToLineStart() }
+func (e *Editor) moveLineEnd()   { e.buffer.MoveCursorToLineEnd() }
+func (e *Editor) movePageUp()    { e.buffer.MoveCursorPageUp(e.layout.GetEditAreaRegion().Height) }
+func (e *Editor) movePageDown()  { e.buffer.MoveCursorPageDown(e.layout.GetEditAreaRegion().Height) }
+
+// runCommand runs a command, unless it would edit a read-only buffer.
+func (e *Editor) runCommand(cmd *command) error {
+	if e.readOnly && cmd.edits {
+		e.showReadOnlyStatus()
+		return nil
+	}
+	if !cmd.multi {
+		e.buffer.ClearExtraCursors()
+	}
+	return cmd.run(e)
+}
+
+// handleCommandPalette lists every command with its key binding. The
+// chosen command runs once the palette has closed.
+func (e *Editor) handleCommandPalette() error {
+	commands := make([]dialog.PaletteCommand, 0, len(e.commands.commands))
+	for _, cmd := range e.commands.commands {
+		commands = append(commands, dialog.PaletteCommand{
+			Name:     cmd.action.String(),
+			Title:    cmd.action.Description(),
+			Shortcut: e.keymap.Shortcut(terminal.ContextEditor, cmd.action),
+		})
+	}
+
+	palette := dialog.NewCommandPalette(commands, e.recentCommands,
+		func(chosen dialog.PaletteCommand) {
+			action, _ := terminal.ParseKeyAction(chosen.Name)
+			e.queuedCommand, _ = e.commands.lookup(action)
+			e.rememberCommand(chosen.Name)
+		},
+		nil,
+	)
+
+	width, height := e.screen.GetSize()
+	e.dialogManager.Push(palette, width, height)
+	return nil
+}
+
+// rememberCommand moves a command to the front of the recently used list.
+func (e *Editor) rememberCommand(name string) {
+	recent := slices.DeleteFunc(e.recentCommands, func(n string) bool { return n == name })
+	e.recentCommands = append([]string{name}, recent...)
+	if len(e.recentCommands) > maxRecentCommands {
+		e.recentCommands = e.recentCommands[:maxRecentCommands]
+	}
+}
+
+// runQueuedCommand runs the command chosen in the palette, if any. It
+// waits until the palette has closed so the command can open dialogs of
+// its own or quit.
+func (e *Editor) runQueuedCommand() error {
+	cmd := e.queuedCommand
+	if cmd == nil {
+		return nil
+	}
+	e.queuedCommand = nil
+	return e.runCommand(cmd)
+}
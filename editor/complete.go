@@ -0,0 +1,113 @@
+package editor
+
+import (
+	"unicode/utf8"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/core/complete"
+	"github.com/AndrewDonelson/ted/core/history"
+	"github.com/AndrewDonelson/ted/ui/popup"
+	"github.com/AndrewDonelson/ted/ui/terminal"
+)
+
+const (
+	// completionMinPrefix is how many characters of a word must be typed
+	// before completions are offered unasked.
+	completionMinPrefix = 2
+
+	// completionLimit is the most completions offered at once.
+	completionLimit = 50
+)
+
+// showCompletions offers completions for the word before the cursor once
+// it is at least minPrefix characters long, and closes the popup if there
+// are none.
+func (e *Editor) showCompletions(minPrefix int) {
+	cursor := e.buffer.GetCursor()
+	line, _ := e.buffer.GetLine(cursor.Line)
+	prefix := complete.Prefix(line, cursor.Col)
+	if e.snippet != nil {
+		e.snippet.choosing = false // Words replace the tab stop's choices
+	}
+	if e.readOnly || e.hasSelection || e.buffer.HasExtraCursors() || utf8.RuneCountInString(prefix) < max(minPrefix, 1) {
+		e.completion.Hide()
+		return
+	}
+
+	// Ted edits one file at a time, so there are no other buffers to offer
+	// words from yet
+	req := complete.Request{
+		Lines:  e.buffer.GetAllLines(),
+		Line:   cursor.Line,
+		Col:    cursor.Col,
+		Prefix: prefix,
+	}
+	items := complete.Complete(e.completers, req, completionLimit)
+	e.completion.Show(items, buffer.Position{Line: cursor.Line, Col: cursor.Col - len(prefix)})
+}
+
+// handleComplete shows the completions for the word at the cursor, however
+// short it is.
+func (e *Editor) handleComplete() {
+	e.showCompletions(1)
+	if !e.completion.IsOpen() {
+		e.setStatus("No completions")
+	}
+}
+
+// handleCompletionKey handles a key while the completion popup is open.
+// Tab or Enter accepts the selected completion, the arrows and page keys
+// choose one and Esc closes the popup. Typing and Backspace go to the
+// editor, which updates the popup; any other key closes it. It reports
+// whether the popup used the key.
+func (e *Editor) handleCompletionKey(ke *terminal.KeyEvent) bool {
+	switch ke.Action {
+	case terminal.KeyActionMoveUp:
+		e.completion.Move(-1)
+	case terminal.KeyActionMoveDown:
+		e.completion.Move(1)
+	case terminal.KeyActionPageUp:
+		e.completion.Move(-popup.MaxRows)
+	case terminal.KeyActionPageDown:
+		e.completion.Move(popup.MaxRows)
+	case terminal.KeyActionIndent, terminal.KeyActionEnter:
+		e.acceptCompletion()
+	case terminal.KeyActionEscape:
+		e.completion.Hide()
+	case terminal.KeyActionCharacter, terminal.KeyActionBackspace:
+		return false
+	default:
+		e.completion.Hide()
+		return false
+	}
+	return true
+}
+
+// acceptCompletion replaces the word before the cursor with the selected
+// completion, or a snippet's tab stop with the selected choice, and closes
+// the popup.
+func (e *Editor) acceptCompletion() {
+	item, ok := e.completion.Selected()
+	e.completion.Hide()
+	if !ok {
+		return
+	}
+	if e.snippet != nil && e.snippet.choosing {
+		e.snippet.choosing = false
+		e.chooseSnippetChoice(item.Text)
+		return
+	}
+
+	cursor := e.buffer.GetCursor()
+	line, _ := e.buffer.GetLine(cursor.Line)
+	start := buffer.Position{Line: cursor.Line, Col: cursor.Col - len(complete.Prefix(line, cursor.Col))}
+	old, err := e.buffer.GetText(start, cursor)
+	if err != nil {
+		return
+	}
+	if _, err := e.buffer.Replace(start, cursor, item.Text); err != nil {
+		return
+	}
+	e.isDirty = true
+	e.history.Push(&history.ReplaceOperation{StartPos: start, EndPos: cursor, Old: old, New: item.Text})
+}
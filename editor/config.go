@@ -0,0 +1,212 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/AndrewDonelson/ted/config"
+	"github.com/AndrewDonelson/ted/ui/terminal"
+	"github.com/gdamore/tcell/v2"
+)
+
+// configPollInterval is how often the configuration files are checked for
+// changes while the editor runs.
+const configPollInterval = time.Second
+
+// configChangedEvent wakes the event loop to reload the configuration.
+type configChangedEvent struct {
+	tcell.EventTime
+}
+
+// NewKeymap returns the default key bindings changed by the keybindings
+// tables of cfg, applied in the order their files were loaded. Invalid
+// entries are skipped and reported together; the keymap is always usable.
+func NewKeymap(cfg *config.Config) (*terminal.Keymap, error) {
+	km := terminal.DefaultKeymap()
+	var problems []error
+	for _, kb := range cfg.Keybindings {
+		if err := km.Apply(kb.Tables); err != nil {
+			problems = append(problems, fmt.Errorf("keybindings in %s: %w", kb.File, err))
+		}
+	}
+	return km, errors.Join(problems...)
+}
+
+// SetConfigPath sets the user's configuration file and loads the settings
+// from it and from the .ted.toml of the project being edited. Problems are
+// returned, and shown in the info bar, after the valid settings have been
+// applied. While the editor runs, the settings are reloaded whenever either
+// file changes.
+func (e *Editor) SetConfigPath(path string) error {
+	e.configPath = path
+	e.configWatcher = config.NewWatcher()
+	err := e.reloadConfig()
+	if err != nil {
+		e.statusMessage = configStatus(err)
+	}
+	return err
+}
+
+// reloadConfig reads the configuration files again and applies them, and
+// watches the files it read, or would have read had they existed.
+func (e *Editor) reloadConfig() error {
+	dir := e.projectDir()
+	e.projectConfigPath = config.ProjectPath(dir)
+
+	cfg, err := config.Load(e.configPath, e.projectConfigPath)
+	km, keyErr := NewKeymap(cfg)
+	e.applyConfig(cfg, km)
+
+	// Watch for a project file being created when there is none yet
+	project := e.projectConfigPath
+	if project == "" {
+		project = filepath.Join(dir, config.ProjectFileName)
+	}
+	e.configWatcher.SetPaths(e.configPath, project)
+
+	return errors.Join(err, keyErr)
+}
+
+// applyConfig makes cfg and km the editor's settings.
+func (e *Editor) applyConfig(cfg *config.Config, km *terminal.Keymap) {
+	e.config = cfg
+	e.history.SetMaxDepth(cfg.History.UndoLevels)
+	e.searchManager.GetFinder().SetMaxHistory(cfg.Search.HistorySize)
+	e.searchManager.GetReplacer().SetMaxHistory(cfg.Search.HistorySize)
+	e.SetKeymap(km)
+}
+
+// fileSettings are the settings for the file being edited.
+type fileSettings struct {
+	config.EditorSettings
+	IndentSize    int // Columns per indentation level
+	MaxLineLength int // Column a guide is drawn at, or 0 for none
+}
+
+// indentWidth returns the size of an indentation level as the info bar
+// shows it: the indent size with spaces, the tab size with tabs.
+func (s fileSettings) indentWidth() int {
+	if s.UseSpaces {
+		return s.IndentSize
+	}
+	return s.TabSize
+}
+
+// editorSettings returns the settings for the current file: the
+// configuration for its language, then the indentation the file already
+// uses where the configuration doesn't set it, then the file's
+// .editorconfig, and last any indentation chosen in the info bar.
+func (e *Editor) editorSettings() fileSettings {
+	language := e.detectFileType()
+	settings := fileSettings{EditorSettings: e.config.ForLanguage(language)}
+	settings.IndentSize = settings.TabSize
+
+	if d := e.detectedIndent; d != nil && settings.DetectIndent {
+		lang := e.config.Language(language)
+		if lang.UseSpaces == nil {
+			settings.UseSpaces = d.UseSpaces
+		}
+		if settings.UseSpaces && d.UseSpaces && lang.TabSize == nil {
+			settings.IndentSize = d.Size
+		}
+	}
+
+	if ec := e.editorConfig; ec != nil {
+		switch ec.IndentStyle {
+		case "tab":
+			settings.UseSpaces = false
+		case "space":
+			settings.UseSpaces = true
+		}
+		if ec.TabWidth > 0 {
+			settings.TabSize = ec.TabWidth
+			settings.IndentSize = ec.TabWidth
+		}
+		if ec.IndentSize > 0 {
+			settings.IndentSize = ec.IndentSize
+		}
+		settings.MaxLineLength = ec.MaxLineLength
+	}
+
+	if o := e.indentOverride; o != nil {
+		settings.setIndentation(*o)
+	}
+	return settings
+}
+
+// projectDir returns the directory a project configuration is looked for
+// from: the current file's directory, or the working directory.
+func (e *Editor) projectDir() string {
+	dir := "."
+	if e.filePath != "" {
+		dir = filepath.Dir(e.filePath)
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return dir
+}
+
+// reloadProjectConfig reloads the settings if the current file belongs to
+// a different project than the one they were loaded for.
+func (e *Editor) reloadProjectConfig() {
+	if e.configWatcher == nil || config.ProjectPath(e.projectDir()) == e.projectConfigPath {
+		return
+	}
+	if err := e.reloadConfig(); err != nil {
+		e.statusMessage = configStatus(err)
+	}
+}
+
+// handleConfigChanged reloads the settings after a configuration file
+// changed and reports the result.
+func (e *Editor) handleConfigChanged() {
+	if err := e.reloadConfig(); err != nil {
+		e.statusMessage = configStatus(err)
+		return
+	}
+	e.statusMessage = "Configuration reloaded"
+}
+
+// watchConfig checks the configuration files for changes until the
+// returned function is called, posting a configChangedEvent for each.
+func (e *Editor) watchConfig() (stop func()) {
+	if e.configWatcher == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	watcher := e.configWatcher
+	screen := e.screen
+	go func() {
+		ticker := time.NewTicker(configPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if watcher.Changed() {
+					ev := &configChangedEvent{}
+					ev.SetEventNow()
+					screen.PostEvent(ev)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// configStatus summarizes configuration problems for the info bar: the
+// first problem and how many more there are.
+func configStatus(err error) string {
+	lines := strings.Split(err.Error(), "\n")
+	status := lines[0]
+	if more := len(lines) - 1; more > 0 {
+		status += fmt.Sprintf(" (and %d more)", more)
+	}
+	return status
+}
@@ -7,14 +7,19 @@ package editor
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 
+	"github.com/AndrewDonelson/ted/config"
 	"github.com/AndrewDonelson/ted/core/buffer"
 	"github.com/AndrewDonelson/ted/core/clipboard"
+	"github.com/AndrewDonelson/ted/core/complete"
 	"github.com/AndrewDonelson/ted/core/file"
 	"github.com/AndrewDonelson/ted/core/history"
+	"github.com/AndrewDonelson/ted/core/state"
 	"github.com/AndrewDonelson/ted/ui/dialog"
 	"github.com/AndrewDonelson/ted/ui/layout"
 	"github.com/AndrewDonelson/ted/ui/menu"
+	"github.com/AndrewDonelson/ted/ui/popup"
 	"github.com/AndrewDonelson/ted/ui/renderer"
 	"github.com/AndrewDonelson/ted/ui/terminal"
 	"github.com/gdamore/tcell/v2"
@@ -44,21 +49,85 @@ type Editor struct {
 	menuBar       *menu.MenuBar
 	screen        terminal.Screen
 	dialogManager *dialog.DialogManager
+	keymap        *terminal.Keymap
+	commands      *commandRegistry
 
 	// State
-	mode       EditorMode
-	isDirty    bool
-	filePath   string
-	fileInfo   *file.FileInfo
-	lineEnding file.LineEnding
+	mode          EditorMode
+	isDirty       bool
+	readOnly      bool     // Editing is disabled (forced or file not writable)
+	forceReadOnly bool     // Read-only requested on the command line; cannot be unlocked
+	saveHelper    []string // Command used to save files the user cannot write
+	filePath      string
+	fileInfo      *file.FileInfo
+	lineEnding    file.LineEnding
+	editorConfig  *file.EditorConfig // .editorconfig properties of the file, if any
+
+	// Indentation guessed from the file's contents, and chosen in the info
+	// bar; nil if there is none
+	detectedIndent *buffer.Indentation
+	indentOverride *buffer.Indentation
 
 	// Selection state
 	selectionStart buffer.Position // Start of selection (anchor point)
 	selectionEnd   buffer.Position // End of selection (cursor position)
 	hasSelection   bool            // Whether there is an active selection
+	block          *blockSelection // Rectangular selection made of a cursor per line, if any
+	blockClipboard string          // Text last copied from a block, pasted as a column
+
+	// Mouse state, for drags and double clicks
+	mouse mouseState
+
+	// Text of a bracketed paste in progress, if any
+	paste *strings.Builder
 
 	// Search state
-	searchStatus string // Status message for search (e.g., "Match 3 of 12")
+	searchStatus string             // Status message for search (e.g., "Match 3 of 12")
+	incSearch    *incrementalSearch // Active search-as-you-type session, if any
+	fileSearch   *fileSearch        // Running find in files search, if any
+
+	// Journal of the last replace in files, kept so it can be undone
+	replaceJournal *file.Journal
+
+	// File that search history is saved to between sessions, if any
+	statePath string
+
+	// Settings, and the files they were loaded from
+	config            *config.Config
+	configPath        string          // User's configuration file
+	projectConfigPath string          // Project's .ted.toml, if any
+	configWatcher     *config.Watcher // Nil unless settings come from files
+
+	// Keyboard macros
+	recordingMacro bool                  // Keys are being recorded
+	recordedKeys   []macroKey            // Keys recorded so far
+	lastMacro      []macroKey            // Last macro recorded, played by play-macro
+	namedMacros    map[string][]macroKey // Macros saved by name, kept in the state file
+	playingMacro   bool                  // A macro is playing, so its keys are not recorded
+
+	// Bookmarks of the files opened, by absolute path, kept in the state file;
+	// the open file's are only stored here when it is closed or state is saved
+	savedBookmarks map[string][]state.Bookmark
+
+	// Where the cursor was before large jumps
+	jumps jumpList
+
+	// Regions of the buffer that can be folded, found again after edits
+	folds foldCache
+
+	// Word completion, offered in a popup while typing
+	completion popup.Popup
+	completers []complete.Provider // Sources of completions, asked in order
+
+	// Snippet being filled in, if any
+	snippet *snippetSession
+
+	// Command palette state
+	recentCommands []string // Names of commands run from the palette, most recent first
+	queuedCommand  *command // Chosen in the palette, run once it closes
+
+	// Status state
+	statusMessage string // Transient message shown in the info bar until the next key press
 }
 
 // FileState tracks file-related state.
@@ -92,7 +161,8 @@ func NewEditor() (*Editor, error) {
 	buf := buffer.NewBuffer()
 
 	// Initialize history (undo/redo)
-	hist := history.NewHistory(100) // 100 operations deep
+	cfg := config.Default()
+	hist := history.NewHistory(cfg.History.UndoLevels)
 
 	// Initialize dialog manager
 	dialogManager := dialog.NewDialogManager()
@@ -110,9 +180,14 @@ func NewEditor() (*Editor, error) {
 		menuBar:        menuBar,
 		screen:         screen,
 		dialogManager:  dialogManager,
+		keymap:         terminal.DefaultKeymap(),
+		config:         cfg,
+		commands:       newCommandRegistry(),
+		completers:     []complete.Provider{complete.Words{}},
 		mode:           ModeInsert,
 		isDirty:        false,
 		lineEnding:     file.LineEndingLF,
+		saveHelper:     file.DefaultSaveHelper,
 		hasSelection:   false,
 		selectionStart: buffer.Position{Line: 0, Col: 0},
 		selectionEnd:   buffer.Position{Line: 0, Col: 0},
@@ -121,21 +196,31 @@ func NewEditor() (*Editor, error) {
 
 // OpenFile opens a file and loads it into the buffer.
 func (e *Editor) OpenFile(path string) error {
-	lines, fileInfo, err := file.ReadFileWithInfo(path)
+	ec := e.findEditorConfig(path)
+	lines, fileInfo, err := file.ReadFileWithCharset(path, ec.Charset)
 	if err != nil {
 		return fmt.Errorf("read file: %w", err)
 	}
 
+	e.endSnippet()
+	e.stashBookmarks()
 	e.buffer.SetLines(lines)
 	e.buffer.MarkSaved() // File is loaded, not modified
+	e.restoreBookmarks(path)
+	e.jumps = jumpList{}
+	e.detectIndentation()
 	e.filePath = path
 	e.fileInfo = fileInfo
 	e.lineEnding = fileInfo.LineEnding
+	e.file.Encoding = fileInfo.Encoding
+	e.applyEditorConfig(ec)
 	e.isDirty = false
+	e.readOnly = e.forceReadOnly || !file.IsWritable(path)
 
 	// Clear history when opening a new file
 	e.history.Clear()
 
+	e.reloadProjectConfig()
 	return nil
 }
 
@@ -144,8 +229,12 @@ func (e *Editor) SetFilePath(path string) {
 	e.filePath = path
 	e.fileInfo = nil                 // No file info for new files
 	e.lineEnding = file.LineEndingLF // Default to LF for new files
-	e.buffer.MarkSaved()             // New file starts as "saved" (empty)
+	e.file.Encoding = "UTF-8"
+	e.applyEditorConfig(e.findEditorConfig(path))
+	e.buffer.MarkSaved() // New file starts as "saved" (empty)
 	e.isDirty = false
+	e.readOnly = e.forceReadOnly || !file.IsWritable(path)
+	e.reloadProjectConfig()
 }
 
 // SaveFile saves the current buffer to the file.
@@ -154,11 +243,19 @@ func (e *Editor) SaveFile() error {
 		return fmt.Errorf("no file path set")
 	}
 
-	lines := e.buffer.GetAllLines()
-	if err := file.WriteFile(e.filePath, lines, e.lineEnding); err != nil {
+	opts := e.saveOptions()
+	lines := opts.PrepareLines(e.buffer.GetAllLines())
+	if err := file.WriteFileWithOptions(e.filePath, lines, opts); err != nil {
 		return fmt.Errorf("write file: %w", err)
 	}
 
+	e.applySavedLines(lines)
+	e.markSaved(lines)
+	return nil
+}
+
+// markSaved updates editor state after lines were written to e.filePath.
+func (e *Editor) markSaved(lines []string) {
 	// Mark buffer as saved
 	e.buffer.MarkSaved()
 	e.isDirty = false
@@ -195,13 +292,17 @@ func (e *Editor) SaveFile() error {
 			LineEnding: e.lineEnding,
 		}
 	}
-
-	return nil
 }
 
 // Run starts the main event loop.
 func (e *Editor) Run() error {
 	defer e.screen.Fini()
+	defer e.discardReplaceJournal()
+	defer e.saveState() // Best effort: losing search history is not worth an error on exit
+
+	// Reload the settings when their files change
+	stopWatching := e.watchConfig()
+	defer stopWatching()
 
 	// Initial render
 	if err := e.render(); err != nil {
@@ -227,10 +328,82 @@ func (e *Editor) Run() error {
 			continue
 		}
 
+		// Apply results from a background incremental search
+		if resultEv, ok := ev.(*searchResultEvent); ok {
+			e.handleSearchResult(resultEv)
+			if err := e.render(); err != nil {
+				return fmt.Errorf("render after search: %w", err)
+			}
+			continue
+		}
+
+		// Collect results from a background find in files search
+		if fileEv, ok := ev.(*fileSearchEvent); ok {
+			e.handleFileSearchEvent(fileEv)
+			if err := e.render(); err != nil {
+				return fmt.Errorf("render after file search: %w", err)
+			}
+			continue
+		}
+
+		// Reload the settings after a configuration file changed
+		if _, ok := ev.(*configChangedEvent); ok {
+			e.handleConfigChanged()
+			if err := e.render(); err != nil {
+				return fmt.Errorf("render after config change: %w", err)
+			}
+			continue
+		}
+
+		// Collect a bracketed paste and insert it in one go
+		if consumed, err := e.handlePasteEvent(ev); consumed {
+			if err != nil {
+				return fmt.Errorf("paste: %w", err)
+			}
+			if e.paste == nil {
+				if err := e.render(); err != nil {
+					return fmt.Errorf("render after paste: %w", err)
+				}
+			}
+			continue
+		}
+
+		// Handle clicks, drags and the mouse wheel
+		if mouseEv, ok := ev.(*tcell.EventMouse); ok {
+			redraw, err := e.handleMouseEvent(mouseEv)
+			if err == ErrQuit {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("handle mouse event: %w", err)
+			}
+			if redraw {
+				if err := e.render(); err != nil {
+					return fmt.Errorf("render after mouse event: %w", err)
+				}
+			}
+			continue
+		}
+
 		// Check if dialog is open - handle dialog input first
 		if e.dialogManager.HasOpenDialog() {
 			if keyEv, ok := ev.(*tcell.EventKey); ok {
-				if handled := e.dialogManager.HandleInput(keyEv.Key(), keyEv.Modifiers(), keyEv.Rune()); handled {
+				keyEvent := e.keymap.Process(terminal.ContextDialog, keyEv)
+				if e.showChordStatus(keyEvent) {
+					if err := e.render(); err != nil {
+						return fmt.Errorf("render after dialog: %w", err)
+					}
+					continue
+				}
+				keyEvent.Character = keyEv.Rune() // Dialogs take the key's own character
+				handled, err := e.handleDialogKey(keyEvent)
+				if err == ErrQuit {
+					break
+				}
+				if err != nil {
+					return fmt.Errorf("run command: %w", err)
+				}
+				if handled {
 					if err := e.render(); err != nil {
 						return fmt.Errorf("render after dialog: %w", err)
 					}
@@ -240,7 +413,8 @@ func (e *Editor) Run() error {
 		}
 
 		// Process keyboard events
-		keyEvent := terminal.ProcessEvent(ev)
+		chording := e.keymap.Pending() != ""
+		keyEvent := e.keymap.Process(e.keyContext(), ev)
 		if keyEvent == nil {
 			continue
 		}
@@ -254,7 +428,7 @@ func (e *Editor) Run() error {
 		}
 
 		// Render after handling event (unless it was a no-op)
-		if keyEvent.Action != terminal.KeyActionNone {
+		if keyEvent.Action != terminal.KeyActionNone || keyEvent.Pending || chording {
 			if err := e.render(); err != nil {
 				return fmt.Errorf("render: %w", err)
 			}
@@ -266,136 +440,56 @@ func (e *Editor) Run() error {
 
 // handleKeyEvent processes a key event and updates the editor state.
 func (e *Editor) handleKeyEvent(ke *terminal.KeyEvent) error {
+	// A new key press replaces the previous status message
+	e.statusMessage = ""
+	if e.showChordStatus(ke) {
+		return nil
+	}
+	e.recordKey(ke, false)
+
 	// If menu is open, handle menu navigation first
 	if e.menuBar.IsOpen() {
 		return e.handleMenuKeyEvent(ke)
 	}
 
+	if e.snippet != nil {
+		e.updateSnippet() // The mouse may have moved the cursor out of it
+		defer e.updateSnippet()
+	}
+	if e.handleSnippetKey(ke) {
+		return nil
+	}
+
+	completing := e.completion.IsOpen()
+	if completing && e.handleCompletionKey(ke) {
+		return nil
+	}
+
 	switch ke.Action {
-	case terminal.KeyActionQuit:
-		return ErrQuit
-	case terminal.KeyActionSave:
-		return e.handleSave()
-	case terminal.KeyActionNew:
-		return e.handleNew()
-	case terminal.KeyActionOpen:
-		return e.handleOpen()
-	case terminal.KeyActionFind:
-		return e.handleFind()
-	case terminal.KeyActionReplace:
-		return e.handleReplace()
-	case terminal.KeyActionGoToLine:
-		return e.handleGoToLine()
-	case terminal.KeyActionToggleLineNumbers:
-		return e.handleToggleLineNumbers()
-	case terminal.KeyActionHelp:
-		return e.handleHelp()
-	case terminal.KeyActionMenuToggle:
-		e.menuBar.Toggle()
-	case terminal.KeyActionMenuAlt:
-		// Alt+key for menu activation
-		if e.menuBar.FindMenuByKey(ke.Character) {
-			// Menu opened, will be handled in next iteration
-		}
-	case terminal.KeyActionEscape:
-		e.clearSelection()
-		e.menuBar.CloseMenu()
 	case terminal.KeyActionCharacter:
-		if ke.IsPrintable() {
+		if e.readOnly {
+			e.showReadOnlyStatus()
+		} else if ke.IsPrintable() && e.buffer.HasExtraCursors() {
+			e.insertAtEachCursor(string(ke.Character))
+		} else if ke.IsPrintable() {
 			e.clearSelection() // Clear selection when typing
 			e.insertCharacter(ke.Character)
+			e.showCompletions(completionMinPrefix)
 		}
-	case terminal.KeyActionMoveLeft:
-		e.clearSelection()
-		e.buffer.MoveCursorLeft()
-	case terminal.KeyActionMoveRight:
-		e.clearSelection()
-		e.buffer.MoveCursorRight()
-	case terminal.KeyActionMoveUp:
-		e.clearSelection()
-		e.buffer.MoveCursorUp()
-	case terminal.KeyActionMoveDown:
-		e.clearSelection()
-		e.buffer.MoveCursorDown()
-	case terminal.KeyActionSelectLeft:
-		e.startSelectionIfNeeded()
-		e.buffer.MoveCursorLeft()
-		e.updateSelectionEnd()
-	case terminal.KeyActionSelectRight:
-		e.startSelectionIfNeeded()
-		e.buffer.MoveCursorRight()
-		e.updateSelectionEnd()
-	case terminal.KeyActionSelectUp:
-		e.startSelectionIfNeeded()
-		e.buffer.MoveCursorUp()
-		e.updateSelectionEnd()
-	case terminal.KeyActionSelectDown:
-		e.startSelectionIfNeeded()
-		e.buffer.MoveCursorDown()
-		e.updateSelectionEnd()
-	case terminal.KeyActionSelectAll:
-		e.handleSelectAll()
-	case terminal.KeyActionBackspace:
-		e.handleBackspace()
-	case terminal.KeyActionDelete:
-		e.handleDelete()
-	case terminal.KeyActionEnter:
-		e.insertCharacter('\n')
-	case terminal.KeyActionHome:
-		e.buffer.MoveCursorToLineStart()
-	case terminal.KeyActionEnd:
-		e.buffer.MoveCursorToLineEnd()
-	case terminal.KeyActionUndo:
-		if err := e.Undo(); err != nil {
-			// Silently ignore if no undo available
-			return nil
-		}
-	case terminal.KeyActionRedo:
-		if err := e.Redo(); err != nil {
-			// Silently ignore if no redo available
-			return nil
-		}
-	case terminal.KeyActionCut:
-		if err := e.Cut(); err != nil {
-			return fmt.Errorf("cut: %w", err)
-		}
-	case terminal.KeyActionCopy:
-		if err := e.Copy(); err != nil {
-			return fmt.Errorf("copy: %w", err)
-		}
-	case terminal.KeyActionPaste:
-		if err := e.Paste(); err != nil {
-			return fmt.Errorf("paste: %w", err)
-		}
-	// Line operations
-	case terminal.KeyActionDeleteLine:
-		e.handleDeleteLine()
-	case terminal.KeyActionDuplicateLine:
-		e.handleDuplicateLine()
-	case terminal.KeyActionMoveLineUp:
-		e.handleMoveLineUp()
-	case terminal.KeyActionMoveLineDown:
-		e.handleMoveLineDown()
-	case terminal.KeyActionInsertLineAbove:
-		e.handleInsertLineAbove()
-	case terminal.KeyActionInsertLineBelow:
-		e.handleInsertLineBelow()
-	// Word navigation
-	case terminal.KeyActionWordLeft:
-		e.clearSelection()
-		e.buffer.MoveCursorWordLeft()
-	case terminal.KeyActionWordRight:
-		e.clearSelection()
-		e.buffer.MoveCursorWordRight()
-	// Page navigation
-	case terminal.KeyActionPageUp:
-		e.clearSelection()
-		e.buffer.MoveCursorPageUp(e.layout.GetEditAreaRegion().Height)
-	case terminal.KeyActionPageDown:
-		e.clearSelection()
-		e.buffer.MoveCursorPageDown(e.layout.GetEditAreaRegion().Height)
+		return nil
+	case terminal.KeyActionMenuAlt:
+		// Alt+key for menu activation
+		e.menuBar.FindMenuByKey(ke.Character)
+		return nil
 	}
 
+	if cmd, ok := e.commands.lookup(ke.Action); ok {
+		err := e.runCommand(cmd)
+		if completing && ke.Action == terminal.KeyActionBackspace {
+			e.showCompletions(1) // Follow the shorter word
+		}
+		return err
+	}
 	return nil
 }
 
@@ -422,55 +516,10 @@ func (e *Editor) handleMenuKeyEvent(ke *terminal.KeyEvent) error {
 	return nil
 }
 
-// executeMenuAction executes the action associated with a menu item.
+// executeMenuAction executes the command associated with a menu item.
 func (e *Editor) executeMenuAction(action menu.MenuAction) error {
-	switch action {
-	case menu.ActionFileNew:
-		return e.handleNew()
-	case menu.ActionFileOpen:
-		return e.handleOpen()
-	case menu.ActionFileSave:
-		return e.handleSave()
-	case menu.ActionFileSaveAs:
-		return e.handleSaveAs()
-	case menu.ActionFileClose:
-		return e.handleClose()
-	case menu.ActionFileQuit:
-		return ErrQuit
-	case menu.ActionEditUndo:
-		return e.Undo()
-	case menu.ActionEditRedo:
-		return e.Redo()
-	case menu.ActionEditCut:
-		return e.Cut()
-	case menu.ActionEditCopy:
-		return e.Copy()
-	case menu.ActionEditPaste:
-		return e.Paste()
-	case menu.ActionEditSelectAll:
-		e.handleSelectAll()
-	case menu.ActionEditDeleteLine:
-		e.handleDeleteLine()
-	case menu.ActionEditDuplicateLine:
-		e.handleDuplicateLine()
-	case menu.ActionEditMoveLineUp:
-		e.handleMoveLineUp()
-	case menu.ActionEditMoveLineDown:
-		e.handleMoveLineDown()
-	case menu.ActionSearchFind:
-		return e.handleFind()
-	case menu.ActionSearchReplace:
-		return e.handleReplace()
-	case menu.ActionSearchGoToLine:
-		return e.handleGoToLine()
-	case menu.ActionViewLineNumbers:
-		return e.handleToggleLineNumbers()
-	case menu.ActionViewWordWrap:
-		return e.handleToggleWordWrap()
-	case menu.ActionHelpShortcuts:
-		return e.handleHelp()
-	case menu.ActionHelpAbout:
-		return e.handleAbout()
+	if cmd, ok := e.commands.lookupMenu(action); ok {
+		return e.runCommand(cmd)
 	}
 	return nil
 }
@@ -479,13 +528,20 @@ func (e *Editor) executeMenuAction(action menu.MenuAction) error {
 
 // handleNew creates a new empty buffer.
 func (e *Editor) handleNew() error {
+	e.endSnippet()
+	e.stashBookmarks()
 	e.buffer = buffer.NewBuffer()
 	e.filePath = ""
 	e.fileInfo = nil
 	e.isDirty = false
 	e.lineEnding = file.LineEndingLF
+	e.file.Encoding = "UTF-8"
+	e.editorConfig = nil
+	e.detectedIndent, e.indentOverride = nil, nil
+	e.readOnly = e.forceReadOnly
 	e.history.Clear()
 	e.clearSelection()
+	e.jumps = jumpList{}
 	return nil
 }
 
@@ -519,17 +575,6 @@ func (e *Editor) handleOpen() error {
 	return nil
 }
 
-// handleSave saves the current file.
-func (e *Editor) handleSave() error {
-	if e.filePath != "" {
-		if err := e.SaveFile(); err != nil {
-			return fmt.Errorf("save file: %w", err)
-		}
-	}
-	// If no file path, would need Save As dialog
-	return nil
-}
-
 // handleSaveAs shows a save as prompt.
 func (e *Editor) handleSaveAs() error {
 	// Use current file path as default, or empty
@@ -544,9 +589,10 @@ func (e *Editor) handleSaveAs() error {
 		func(path string) {
 			if path != "" {
 				e.filePath = path
-				if err := e.SaveFile(); err != nil {
-					// Silently handle error for now
-					_ = err
+				e.applyEditorConfig(e.findEditorConfig(path))
+				e.readOnly = e.forceReadOnly || !file.IsWritable(path)
+				if err := e.handleSave(); err != nil {
+					e.setStatus(fmt.Sprintf("Save failed: %v", err))
 				}
 			}
 		},
@@ -567,7 +613,10 @@ func (e *Editor) handleClose() error {
 }
 
 // handleFind shows the find dialog.
+// The dialog searches as you type: Enter accepts the current match and
+// Esc returns the cursor to where it was.
 func (e *Editor) handleFind() error {
+	e.scopeSearchToSelection()
 	finder := e.searchManager.GetFinder()
 
 	// Create and show find dialog
@@ -582,56 +631,87 @@ func (e *Editor) handleFind() error {
 			} else {
 				e.searchStatus = "No matches found"
 			}
+			e.updateSearchHighlights()
 		},
 		func() {
-			// Cancelled - do nothing
+			// Cancelled - go back to where the search started
+			e.endIncrementalSearch(true)
 		},
 	)
+	findDlg.SetOnAccept(func() {
+		e.searchStatus = e.searchManager.BuildStatusMessage()
+		e.endIncrementalSearch(false)
+	})
 
 	width, height := e.screen.GetSize()
 	e.dialogManager.Push(findDlg, width, height)
+	e.startIncrementalSearch(findDlg.SearchDialog)
 	return nil
 }
 
 // handleReplace shows the replace dialog.
 func (e *Editor) handleReplace() error {
+	e.scopeSearchToSelection()
 	finder := e.searchManager.GetFinder()
 	replacer := e.searchManager.GetReplacer()
 
 	// Create and show replace dialog
-	replaceDlg := dialog.NewReplaceDialog(
+	var replaceDlg *dialog.ReplaceDialog
+	replaceDlg = dialog.NewReplaceDialog(
 		finder,
 		replacer,
 		func() {
 			// Replace callback - replace current match
+			if err := replacer.ValidateReplacement(); err != nil {
+				replaceDlg.SetMessage(fmt.Sprintf("Invalid replacement: %v", err))
+				return
+			}
+			if _, ok := finder.GetCurrentMatch(); !ok {
+				// Entering the dialog values cleared the matches; replace
+				// the match at or after the cursor
+				finder.FindAll(e.buffer)
+				finder.FindFrom(e.buffer.GetCursor())
+			}
 			_, err := replacer.ReplaceCurrent(e.buffer, e.history)
 			if err != nil {
 				e.searchStatus = "Replace failed"
 			} else {
 				e.isDirty = true
 				e.searchStatus = "Replaced"
-				// Move to next match
-				cursorPos := e.buffer.GetCursor()
-				e.searchManager.FindNext(e.buffer, cursorPos)
+				e.syncSelectionToScope()
+				e.refreshIncrementalSearch()
 			}
 		},
 		func() {
 			// Replace All callback
+			if err := replacer.ValidateReplacement(); err != nil {
+				replaceDlg.SetMessage(fmt.Sprintf("Invalid replacement: %v", err))
+				return
+			}
 			count, err := replacer.ReplaceAll(e.buffer, e.history)
 			if err != nil {
 				e.searchStatus = fmt.Sprintf("Replace all failed: %v", err)
 			} else {
 				e.isDirty = true
 				e.searchStatus = fmt.Sprintf("Replaced %d occurrences", count)
+				if finder.IsScoped() {
+					e.searchStatus += " in selection"
+				}
+				e.syncSelectionToScope()
+				e.refreshIncrementalSearch()
 			}
 		},
 		func() {
-			// Cancelled - do nothing
+			// Cancelled - go back to where the search started
+			e.endIncrementalSearch(true)
 		},
 	)
 
+	replaceDlg.SetOnReplaceEach(e.startQueryReplace)
+
 	width, height := e.screen.GetSize()
 	e.dialogManager.Push(replaceDlg, width, height)
+	e.startIncrementalSearch(replaceDlg.SearchDialog)
 	return nil
 }
 
@@ -654,7 +734,7 @@ func (e *Editor) handleGoToLine() error {
 			if targetLine >= e.buffer.LineCount() {
 				targetLine = e.buffer.LineCount() - 1
 			}
-			e.buffer.MoveCursor(buffer.Position{Line: targetLine, Col: 0})
+			e.jumpTo(buffer.Position{Line: targetLine, Col: 0})
 		},
 		func() {
 			// Cancelled - do nothing
@@ -678,12 +758,6 @@ func (e *Editor) handleToggleWordWrap() error {
 	return nil
 }
 
-// handleHelp shows the help/keyboard shortcuts (placeholder for now).
-func (e *Editor) handleHelp() error {
-	// TODO: Implement help dialog
-	return nil
-}
-
 // handleAbout shows the about dialog (placeholder for now).
 func (e *Editor) handleAbout() error {
 	// TODO: Implement about dialog
@@ -738,6 +812,15 @@ func (e *Editor) insertCharacter(r rune) {
 
 // handleBackspace handles the backspace key.
 func (e *Editor) handleBackspace() {
+	if e.activeBlock() != nil {
+		e.deleteBlock()
+		return
+	}
+	if e.buffer.HasExtraCursors() {
+		e.backspaceAtEachCursor()
+		return
+	}
+
 	pos := e.buffer.GetCursor()
 	var start, end buffer.Position
 	var deletedText string
@@ -794,6 +877,15 @@ func (e *Editor) handleBackspace() {
 
 // handleDelete handles the delete key.
 func (e *Editor) handleDelete() {
+	if e.activeBlock() != nil {
+		e.deleteBlock()
+		return
+	}
+	if e.buffer.HasExtraCursors() {
+		e.deleteAtEachCursor()
+		return
+	}
+
 	pos := e.buffer.GetCursor()
 	line, err := e.buffer.GetLine(pos.Line)
 	if err != nil {
@@ -843,20 +935,37 @@ func (e *Editor) handleDelete() {
 
 // Undo undoes the last operation.
 func (e *Editor) Undo() error {
+	e.endSnippet() // Undo the snippet as a whole
 	return e.history.Undo(e.buffer)
 }
 
 // Redo redoes the last undone operation.
 func (e *Editor) Redo() error {
+	e.endSnippet()
 	return e.history.Redo(e.buffer)
 }
 
 // Copy copies the selected text (or current line if no selection) to clipboard.
+// With several cursors the text at each is copied, one per line, and a
+// block selection is copied as a rectangle.
 func (e *Editor) Copy() error {
 	var text string
 	var err error
 
-	if e.hasSelection {
+	if e.activeBlock() != nil {
+		// Copy the rectangle, to be pasted as a column
+		text, err = e.blockText()
+		if err != nil {
+			return fmt.Errorf("get block text: %w", err)
+		}
+		e.blockClipboard = text
+	} else if e.buffer.HasExtraCursors() {
+		// Copy the text at every cursor, one per line
+		text, err = e.textAtEachCursor()
+		if err != nil {
+			return fmt.Errorf("get text: %w", err)
+		}
+	} else if e.hasSelection {
 		// Copy selected text
 		start, end := e.getSelectionRange()
 		text, err = e.buffer.GetText(start, end)
@@ -881,7 +990,16 @@ func (e *Editor) Copy() error {
 }
 
 // Cut cuts the selected text (or current line if no selection) to clipboard.
+// A block selection is cut as a rectangle.
 func (e *Editor) Cut() error {
+	if e.activeBlock() != nil {
+		if err := e.Copy(); err != nil {
+			return err
+		}
+		e.deleteBlock()
+		return nil
+	}
+
 	var start, end buffer.Position
 	var deletedText string
 	var err error
@@ -943,7 +1061,8 @@ func (e *Editor) Cut() error {
 	return nil
 }
 
-// Paste pastes text from clipboard at the current cursor position.
+// Paste pastes text from clipboard at the current cursor position, at
+// every cursor if there are several, or as a column into a block selection.
 func (e *Editor) Paste() error {
 	// Read from clipboard
 	text, err := clipboard.Read()
@@ -951,8 +1070,30 @@ func (e *Editor) Paste() error {
 		return fmt.Errorf("read clipboard: %w", err)
 	}
 
+	return e.pasteText(text)
+}
+
+// pasteText inserts pasted text: as a column into a block selection, or
+// when it was copied from one, at every cursor if there are several, or
+// else at the cursor.
+func (e *Editor) pasteText(text string) error {
+	switch {
+	case e.activeBlock() != nil:
+		e.pasteColumn(text)
+	case e.buffer.HasExtraCursors():
+		e.pasteAtEachCursor(text)
+	case text != "" && text == e.blockClipboard:
+		e.pasteColumn(text)
+	default:
+		return e.insertText(text)
+	}
+	return nil
+}
+
+// insertText inserts text at the cursor as one undoable operation.
+func (e *Editor) insertText(text string) error {
 	if text == "" {
-		return nil // Nothing to paste
+		return nil // Nothing to insert
 	}
 
 	// Record operation for undo
@@ -967,6 +1108,9 @@ func (e *Editor) Paste() error {
 		return fmt.Errorf("insert: %w", err)
 	}
 
+	// Mark as modified
+	e.isDirty = true
+
 	// Push to history
 	e.history.Push(op)
 
@@ -1015,7 +1159,15 @@ func (e *Editor) render() error {
 	cursorPos := e.buffer.GetCursor()
 
 	// Build file info for info bar
-	fileInfo := e.buildFileInfo()
+	settings := e.editorSettings()
+	fileInfo := e.buildFileInfo(settings)
+	e.renderer.SetTabSize(settings.TabSize)
+	e.renderer.SetGuide(settings.MaxLineLength)
+	e.renderer.SetExtraCursors(e.extraCursorPositions())
+	e.renderer.SetPopup(&e.completion)
+
+	e.setGutter()
+	e.layout.SetHiddenLines(e.hiddenLines())
 
 	// Render everything with interactive menu bar
 	if err := e.renderer.RenderAllWithMenu(e.buffer, cursorPos, fileInfo, e.menuBar); err != nil {
@@ -1027,24 +1179,32 @@ func (e *Editor) render() error {
 		// Get the active style from renderer
 		style := tcell.StyleDefault
 		e.dialogManager.Render(e.screen, style)
+
+		// The renderer already refreshed; show the dialogs too
+		return e.screen.Refresh()
 	}
 
 	return nil
 }
 
-// buildFileInfo builds the file info for the info bar.
-func (e *Editor) buildFileInfo() *renderer.FileInfo {
+// buildFileInfo builds the file info for the info bar from the settings
+// the frame is rendered with.
+func (e *Editor) buildFileInfo(settings fileSettings) *renderer.FileInfo {
 	// Use buffer's modified flag as source of truth
 	isModified := e.buffer.IsModified()
 
 	info := &renderer.FileInfo{
-		Name:       e.getFileName(),
-		Path:       e.filePath,
-		Encoding:   e.file.Encoding,
-		LineEnding: string(e.lineEnding),
-		TabSize:    4, // Default for Phase 0
-		TotalLines: e.buffer.LineCount(),
-		IsModified: isModified,
+		Name:        e.getFileName(),
+		Path:        e.filePath,
+		Encoding:    e.file.Encoding,
+		LineEnding:  string(e.lineEnding),
+		TabSize:     settings.indentWidth(),
+		UseSpaces:   settings.UseSpaces,
+		TotalLines:  e.buffer.LineCount(),
+		IsModified:  isModified,
+		IsReadOnly:  e.readOnly,
+		IsRecording: e.recordingMacro,
+		Message:     e.statusMessage,
 	}
 
 	if e.fileInfo != nil {
@@ -1106,6 +1266,8 @@ func (e *Editor) detectFileType() string {
 		return "Python"
 	case ".md":
 		return "Markdown"
+	case ".yaml", ".yml":
+		return "YAML"
 	case ".txt":
 		return "Plain Text"
 	default:
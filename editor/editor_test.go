@@ -1,12 +1,22 @@
 package editor
 
 import (
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/AndrewDonelson/ted/config"
 	"github.com/AndrewDonelson/ted/core/buffer"
 	"github.com/AndrewDonelson/ted/core/file"
+	"github.com/AndrewDonelson/ted/search"
+	"github.com/AndrewDonelson/ted/ui/dialog"
+	"github.com/AndrewDonelson/ted/ui/layout"
+	"github.com/AndrewDonelson/ted/ui/menu"
+	"github.com/AndrewDonelson/ted/ui/terminal"
+	"github.com/gdamore/tcell/v2"
 )
 
 func TestNewEditor(t *testing.T) {
@@ -171,6 +181,39 @@ func TestEditor_SaveFile_NoPath(t *testing.T) {
 	}
 }
 
+func TestEditor_SaveAs(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	// A file that could not be written is saved somewhere it can be
+	ed.buffer.SetLines([]string{"hello"})
+	ed.readOnly = true
+	t.Chdir(t.TempDir())
+	path := "copy.txt"
+
+	if err := ed.handleSaveAs(); err != nil {
+		t.Fatalf("handleSaveAs() error = %v", err)
+	}
+	for _, ch := range path {
+		ed.dialogManager.HandleInput(tcell.KeyRune, tcell.ModNone, ch)
+	}
+	ed.dialogManager.HandleInput(tcell.KeyEnter, tcell.ModNone, 0)
+
+	if ed.filePath != path || ed.statusMessage != "Saved" {
+		t.Errorf("filePath = %q, status = %q after Save As", ed.filePath, ed.statusMessage)
+	}
+	if ed.readOnly {
+		t.Error("readOnly = true after saving to a writable path")
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "hello" {
+		t.Errorf("saved file = %q, %v", data, err)
+	}
+}
+
 func TestEditor_InsertCharacter(t *testing.T) {
 	ed, err := NewEditor()
 	if err != nil {
@@ -394,6 +437,11 @@ func TestEditor_DetectFileType(t *testing.T) {
 			filePath: "test.md",
 			want:     "Markdown",
 		},
+		{
+			name:     "YAML file",
+			filePath: "test.yml",
+			want:     "YAML",
+		},
 		{
 			name:     "text file",
 			filePath: "test.txt",
@@ -440,7 +488,7 @@ func TestEditor_BuildFileInfo(t *testing.T) {
 	// Modify the buffer to set the modified flag
 	ed.buffer.Insert(buffer.Position{Line: 0, Col: 0}, "x")
 
-	fileInfo := ed.buildFileInfo()
+	fileInfo := ed.buildFileInfo(ed.editorSettings())
 
 	if fileInfo == nil {
 		t.Fatal("buildFileInfo() returned nil")
@@ -468,7 +516,7 @@ func TestEditor_BuildFileInfo_NoFile(t *testing.T) {
 	defer ed.screen.Fini()
 
 	ed.filePath = ""
-	fileInfo := ed.buildFileInfo()
+	fileInfo := ed.buildFileInfo(ed.editorSettings())
 
 	if fileInfo == nil {
 		t.Fatal("buildFileInfo() returned nil")
@@ -478,3 +526,2317 @@ func TestEditor_BuildFileInfo_NoFile(t *testing.T) {
 		t.Errorf("FileInfo.Name = %q, want empty", fileInfo.Name)
 	}
 }
+
+func TestEditor_ReadOnly_BlocksEditing(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	ed.buffer.SetLines([]string{"test"})
+	ed.SetReadOnly(true)
+
+	ed.handleKeyEvent(&terminal.KeyEvent{Action: terminal.KeyActionCharacter, Character: 'x'})
+
+	line, _ := ed.buffer.GetLine(0)
+	if line != "test" {
+		t.Errorf("Line = %q after typing in read-only mode, want %q", line, "test")
+	}
+
+	if ed.statusMessage == "" {
+		t.Error("read-only edit should set a status message")
+	}
+
+	if !ed.buildFileInfo(ed.editorSettings()).IsReadOnly {
+		t.Error("FileInfo.IsReadOnly = false, want true")
+	}
+}
+
+func TestEditor_ToggleReadOnly(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	ed.readOnly = true
+	ed.handleToggleReadOnly()
+	if ed.readOnly {
+		t.Error("handleToggleReadOnly() should unlock a non-forced read-only file")
+	}
+
+	ed.SetReadOnly(true)
+	ed.handleToggleReadOnly()
+	if !ed.readOnly {
+		t.Error("handleToggleReadOnly() should not unlock --readonly mode")
+	}
+}
+
+func TestCommandRegistry_Edits(t *testing.T) {
+	commands := newCommandRegistry()
+	if !commands.edits(terminal.KeyActionCharacter) {
+		t.Error("edits(Character) = false, want true")
+	}
+	if !commands.edits(terminal.KeyActionPaste) {
+		t.Error("edits(Paste) = false, want true")
+	}
+	if commands.edits(terminal.KeyActionCopy) {
+		t.Error("edits(Copy) = true, want false")
+	}
+	if commands.edits(terminal.KeyActionMoveDown) {
+		t.Error("edits(MoveDown) = true, want false")
+	}
+}
+
+func TestEditor_IncrementalSearch(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	ed.buffer.SetLines([]string{"alpha", "beta", "alphabet"})
+	ed.buffer.MoveCursor(buffer.Position{Line: 1, Col: 0})
+
+	if err := ed.handleFind(); err != nil {
+		t.Fatalf("handleFind() error = %v", err)
+	}
+
+	for _, ch := range "alp" {
+		ed.dialogManager.HandleInput(tcell.KeyRune, tcell.ModNone, ch)
+	}
+
+	// Nearest match after the cursor is on the last line
+	if got := ed.buffer.GetCursor(); got != (buffer.Position{Line: 2, Col: 0}) {
+		t.Errorf("cursor after typing = %v, want {2 0}", got)
+	}
+	if _, total := ed.searchManager.GetCurrentMatch(); total != 2 {
+		t.Errorf("match count = %d, want 2", total)
+	}
+
+	// Esc restores the cursor
+	ed.dialogManager.HandleInput(tcell.KeyEscape, tcell.ModNone, 0)
+	if got := ed.buffer.GetCursor(); got != (buffer.Position{Line: 1, Col: 0}) {
+		t.Errorf("cursor after Esc = %v, want {1 0}", got)
+	}
+	if ed.incSearch != nil {
+		t.Error("incremental search should end when the dialog is cancelled")
+	}
+
+	// Enter accepts the match
+	if err := ed.handleFind(); err != nil {
+		t.Fatalf("handleFind() error = %v", err)
+	}
+	ed.dialogManager.HandleInput(tcell.KeyEnter, tcell.ModNone, 0)
+	if got := ed.buffer.GetCursor(); got != (buffer.Position{Line: 2, Col: 0}) {
+		t.Errorf("cursor after Enter = %v, want {2 0}", got)
+	}
+	if ed.dialogManager.HasOpenDialog() {
+		t.Error("Enter should close the find dialog")
+	}
+}
+
+func TestEditor_IncrementalSearch_LargeBuffer(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	lines := make([]string, 0, 40000)
+	for i := 0; i < 40000; i++ {
+		lines = append(lines, "filler text for a large buffer")
+	}
+	lines = append(lines, "needle")
+	ed.buffer.SetLines(lines)
+
+	if err := ed.handleFind(); err != nil {
+		t.Fatalf("handleFind() error = %v", err)
+	}
+	for _, ch := range "needle" {
+		ed.dialogManager.HandleInput(tcell.KeyRune, tcell.ModNone, ch)
+	}
+
+	// Large buffers are searched in the background; only the last
+	// keystroke's search delivers a result
+	for {
+		ev := ed.screen.PollEvent()
+		if resultEv, ok := ev.(*searchResultEvent); ok {
+			if resultEv.pattern != "needle" {
+				t.Errorf("result for stale pattern %q delivered", resultEv.pattern)
+			}
+			ed.handleSearchResult(resultEv)
+			break
+		}
+	}
+
+	if got := ed.buffer.GetCursor(); got != (buffer.Position{Line: 40000, Col: 0}) {
+		t.Errorf("cursor = %v, want {40000 0}", got)
+	}
+}
+
+func TestEditor_ReplaceInSelection(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	ed.buffer.SetLines([]string{"a := 1", "a++", "a--", "print(a)"})
+	ed.hasSelection = true
+	ed.selectionStart = buffer.Position{Line: 1, Col: 0}
+	ed.selectionEnd = buffer.Position{Line: 2, Col: 3}
+
+	if err := ed.handleReplace(); err != nil {
+		t.Fatalf("handleReplace() error = %v", err)
+	}
+	if !ed.searchManager.GetOptions().InSelection {
+		t.Error("multi-line selection should enable In selection")
+	}
+
+	ed.searchManager.SetPattern("a")
+	ed.searchManager.SetReplacement("count")
+	if _, err := ed.searchManager.GetReplacer().ReplaceAll(ed.buffer, ed.history); err != nil {
+		t.Fatalf("ReplaceAll() error = %v", err)
+	}
+	ed.syncSelectionToScope()
+
+	want := []string{"a := 1", "count++", "count--", "print(a)"}
+	for i, line := range want {
+		if got, _ := ed.buffer.GetLine(i); got != line {
+			t.Errorf("line %d = %q, want %q", i, got, line)
+		}
+	}
+	if ed.selectionEnd != (buffer.Position{Line: 2, Col: 7}) {
+		t.Errorf("selection end = %v, want {2 7}", ed.selectionEnd)
+	}
+}
+
+func TestEditor_QueryReplace(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	original := []string{"foo foo", "foo"}
+	ed.buffer.SetLines(original)
+
+	if err := ed.handleReplace(); err != nil {
+		t.Fatalf("handleReplace() error = %v", err)
+	}
+	ed.searchManager.SetPattern("foo")
+	ed.searchManager.SetReplacement("bar")
+	ed.dialogManager.Pop()
+	ed.startQueryReplace()
+
+	if !ed.dialogManager.HasOpenDialog() {
+		t.Fatal("query replace prompt should be open")
+	}
+
+	// Yes, No, Yes
+	for _, ch := range "yny" {
+		ed.dialogManager.HandleInput(tcell.KeyRune, 0, ch)
+	}
+
+	if ed.dialogManager.HasOpenDialog() {
+		t.Error("prompt should close when no matches are left")
+	}
+	want := []string{"bar foo", "bar"}
+	for i, line := range want {
+		if got, _ := ed.buffer.GetLine(i); got != line {
+			t.Errorf("line %d = %q, want %q", i, got, line)
+		}
+	}
+	if ed.searchStatus != "Replaced 2 of 3 matches" {
+		t.Errorf("searchStatus = %q, want %q", ed.searchStatus, "Replaced 2 of 3 matches")
+	}
+
+	// The whole session is one undo step
+	if err := ed.history.Undo(ed.buffer); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	for i, line := range original {
+		if got, _ := ed.buffer.GetLine(i); got != line {
+			t.Errorf("after undo line %d = %q, want %q", i, got, line)
+		}
+	}
+}
+
+func TestEditor_FindInFiles(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	dir := t.TempDir()
+	current := filepath.Join(dir, "main.go")
+	other := filepath.Join(dir, "lib", "util.go")
+	os.MkdirAll(filepath.Dir(other), 0755)
+	os.WriteFile(current, []byte("package main\n"), 0644)
+	os.WriteFile(other, []byte("package lib\n\nfunc needle() {}\n"), 0644)
+
+	if err := ed.OpenFile(current); err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	if err := ed.handleFindInFiles(); err != nil {
+		t.Fatalf("handleFindInFiles() error = %v", err)
+	}
+	for _, ch := range "needle" {
+		ed.dialogManager.HandleInput(tcell.KeyRune, tcell.ModNone, ch)
+	}
+	ed.dialogManager.HandleInput(tcell.KeyEnter, tcell.ModNone, 0)
+
+	// Results arrive through the event loop
+	for ed.fileSearch != nil {
+		if ev, ok := ed.screen.PollEvent().(*fileSearchEvent); ok {
+			ed.handleFileSearchEvent(ev)
+		}
+	}
+
+	// Down moves to the results, then to the first match
+	ed.dialogManager.HandleInput(tcell.KeyDown, tcell.ModNone, 0)
+	ed.dialogManager.HandleInput(tcell.KeyDown, tcell.ModNone, 0)
+	ed.dialogManager.HandleInput(tcell.KeyEnter, tcell.ModNone, 0)
+
+	if ed.dialogManager.HasOpenDialog() {
+		t.Error("panel should close after opening a result")
+	}
+	if ed.filePath != other {
+		t.Errorf("filePath = %q, want %q", ed.filePath, other)
+	}
+	start, end := ed.getSelectionRange()
+	if start != (buffer.Position{Line: 2, Col: 5}) || end != (buffer.Position{Line: 2, Col: 11}) {
+		t.Errorf("selection = %v-%v, want {2 5}-{2 11}", start, end)
+	}
+
+	// Results in other files don't replace unsaved changes, however they
+	// were made
+	ed.handleDeleteLine()
+	ed.openFileResult(search.FileResult{Path: current, RelPath: "main.go"}, search.Match{})
+	if ed.filePath != other || !strings.Contains(ed.statusMessage, "Save changes") {
+		t.Errorf("after a line was deleted: filePath = %q, status = %q, want the unsaved file kept", ed.filePath, ed.statusMessage)
+	}
+}
+
+func TestEditor_ReplaceInFiles(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	dir := t.TempDir()
+	current := filepath.Join(dir, "main.go")
+	other := filepath.Join(dir, "lib", "util.go")
+	os.MkdirAll(filepath.Dir(other), 0755)
+	os.WriteFile(current, []byte("package main\n\nvar old = 1\n"), 0644)
+	os.WriteFile(other, []byte("package lib\n\nfunc old() {}\n"), 0644)
+
+	if err := ed.OpenFile(current); err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	if err := ed.handleReplaceInFiles(); err != nil {
+		t.Fatalf("handleReplaceInFiles() error = %v", err)
+	}
+	for _, ch := range "old" {
+		ed.dialogManager.HandleInput(tcell.KeyRune, tcell.ModNone, ch)
+	}
+	ed.dialogManager.HandleInput(tcell.KeyTab, tcell.ModNone, 0)
+	for _, ch := range "fresh" {
+		ed.dialogManager.HandleInput(tcell.KeyRune, tcell.ModNone, ch)
+	}
+	ed.dialogManager.HandleInput(tcell.KeyEnter, tcell.ModNone, 0)
+
+	for ed.fileSearch != nil {
+		if ev, ok := ed.screen.PollEvent().(*fileSearchEvent); ok {
+			ed.handleFileSearchEvent(ev)
+		}
+	}
+
+	ed.dialogManager.HandleInput(tcell.KeyRune, tcell.ModAlt, 'a')
+
+	data, _ := os.ReadFile(other)
+	if string(data) != "package lib\n\nfunc fresh() {}\n" {
+		t.Errorf("other file = %q after replace", data)
+	}
+	if line, _ := ed.buffer.GetLine(2); line != "var fresh = 1" {
+		t.Errorf("open file line 2 = %q, want reloaded replacement", line)
+	}
+
+	// Alt+U rolls back every file in one step
+	ed.dialogManager.HandleInput(tcell.KeyRune, tcell.ModAlt, 'u')
+
+	data, _ = os.ReadFile(other)
+	if string(data) != "package lib\n\nfunc old() {}\n" {
+		t.Errorf("other file = %q after undo", data)
+	}
+	if line, _ := ed.buffer.GetLine(2); line != "var old = 1" {
+		t.Errorf("open file line 2 = %q after undo", line)
+	}
+	if ed.replaceJournal != nil {
+		t.Error("journal should be discarded after undo")
+	}
+}
+
+func TestEditor_ReplaceInFilesKeepsUnsavedChanges(t *testing.T) {
+	tests := []struct {
+		name  string
+		edit  func(ed *Editor) error
+		lines []string // The open file's lines after the edit
+	}{
+		{
+			name:  "line operation",
+			edit:  func(ed *Editor) error { ed.handleInsertLineAbove(); return nil },
+			lines: []string{"", "var old = 1", ""},
+		},
+		{
+			name: "undo after saving",
+			edit: func(ed *Editor) error {
+				ed.insertCharacter('x')
+				if err := ed.SaveFile(); err != nil {
+					return err
+				}
+				return ed.Undo()
+			},
+			lines: []string{"var old = 1", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ed, err := NewEditor()
+			if err != nil {
+				t.Skipf("Skipping test - terminal not available: %v", err)
+				return
+			}
+			defer ed.screen.Fini()
+
+			dir := t.TempDir()
+			current := filepath.Join(dir, "main.go")
+			other := filepath.Join(dir, "util.go")
+			os.WriteFile(current, []byte("var old = 1\n"), 0644)
+			os.WriteFile(other, []byte("func old() {}\n"), 0644)
+			if err := ed.OpenFile(current); err != nil {
+				t.Fatalf("OpenFile() error = %v", err)
+			}
+			if err := tt.edit(ed); err != nil {
+				t.Fatalf("edit error = %v", err)
+			}
+			saved, _ := os.ReadFile(current)
+
+			if err := ed.handleReplaceInFiles(); err != nil {
+				t.Fatalf("handleReplaceInFiles() error = %v", err)
+			}
+			for _, ch := range "old" {
+				ed.dialogManager.HandleInput(tcell.KeyRune, tcell.ModNone, ch)
+			}
+			ed.dialogManager.HandleInput(tcell.KeyTab, tcell.ModNone, 0)
+			for _, ch := range "fresh" {
+				ed.dialogManager.HandleInput(tcell.KeyRune, tcell.ModNone, ch)
+			}
+			ed.dialogManager.HandleInput(tcell.KeyEnter, tcell.ModNone, 0)
+			for ed.fileSearch != nil {
+				if ev, ok := ed.screen.PollEvent().(*fileSearchEvent); ok {
+					ed.handleFileSearchEvent(ev)
+				}
+			}
+			ed.dialogManager.HandleInput(tcell.KeyRune, tcell.ModAlt, 'a')
+
+			// The other file is replaced; the open one is left on disk and
+			// in the editor as it was
+			if data, _ := os.ReadFile(other); string(data) != "func fresh() {}\n" {
+				t.Errorf("other file = %q after replace", data)
+			}
+			if data, _ := os.ReadFile(current); string(data) != string(saved) {
+				t.Errorf("open file = %q on disk after replace, want %q", data, saved)
+			}
+			if got := ed.buffer.GetAllLines(); !slices.Equal(got, tt.lines) {
+				t.Errorf("open file lines = %q, want the unsaved %q", got, tt.lines)
+			}
+		})
+	}
+}
+
+func TestEditor_SearchStatePersists(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := ed.SetStatePath(path); err != nil {
+		t.Fatalf("SetStatePath() error = %v", err)
+	}
+
+	ed.searchManager.SetPattern("needle")
+	ed.searchManager.SetReplacement("thread")
+	options := ed.searchManager.GetOptions()
+	options.WholeWord = true
+	ed.searchManager.SetOptions(options)
+	if err := ed.saveState(); err != nil {
+		t.Fatalf("saveState() error = %v", err)
+	}
+	ed.screen.Fini()
+
+	// A later session starts with the same history and options
+	next, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer next.screen.Fini()
+	if err := next.SetStatePath(path); err != nil {
+		t.Fatalf("SetStatePath() error = %v", err)
+	}
+
+	if got := next.searchManager.GetFinder().GetHistory(); !slices.Equal(got, []string{"needle"}) {
+		t.Errorf("pattern history = %v, want [needle]", got)
+	}
+	if got := next.searchManager.GetReplacer().GetHistory(); !slices.Equal(got, []string{"thread"}) {
+		t.Errorf("replacement history = %v, want [thread]", got)
+	}
+	if !next.searchManager.GetOptions().WholeWord {
+		t.Error("WholeWord option was not restored")
+	}
+}
+
+func TestEditor_SetStatePath_Unreadable(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	os.WriteFile(path, []byte("{not json"), 0644)
+	if err := ed.SetStatePath(path); err == nil {
+		t.Fatal("SetStatePath() error = nil for a corrupt state file")
+	}
+	if !strings.Contains(ed.statusMessage, "parse state") {
+		t.Errorf("status = %q, want the state error", ed.statusMessage)
+	}
+}
+
+func TestEditor_GoToSymbol(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	path := filepath.Join(t.TempDir(), "main.go")
+	os.WriteFile(path, []byte("package main\n\nfunc main() {}\n\nfunc (e *Editor) handleSave() error {\n"), 0644)
+	if err := ed.OpenFile(path); err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+
+	if err := ed.handleGoToSymbol(); err != nil {
+		t.Fatalf("handleGoToSymbol() error = %v", err)
+	}
+	for _, ch := range "hsave" {
+		ed.dialogManager.HandleInput(tcell.KeyRune, tcell.ModNone, ch)
+	}
+	ed.dialogManager.HandleInput(tcell.KeyEnter, tcell.ModNone, 0)
+
+	if got, want := ed.buffer.GetCursor(), (buffer.Position{Line: 4, Col: 17}); got != want {
+		t.Errorf("cursor = %v, want %v", got, want)
+	}
+
+	// A buffer without symbols reports it instead of opening the picker
+	ed.buffer.SetLines([]string{"plain"})
+	ed.handleGoToSymbol()
+	if ed.dialogManager.HasOpenDialog() || ed.statusMessage != "No symbols found" {
+		t.Errorf("no symbols: dialog open = %v, status = %q", ed.dialogManager.HasOpenDialog(), ed.statusMessage)
+	}
+}
+
+func TestEditor_FuzzyIncrementalSearchGoesToBest(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	ed.buffer.SetLines([]string{"gasthelm", "x", "goToLine()"})
+	options := ed.searchManager.GetOptions()
+	options.Fuzzy = true
+	ed.searchManager.SetOptions(options)
+
+	if err := ed.handleFind(); err != nil {
+		t.Fatalf("handleFind() error = %v", err)
+	}
+	// Small buffers are searched as each key is typed
+	for _, ch := range "gtl" {
+		ed.dialogManager.HandleInput(tcell.KeyRune, tcell.ModNone, ch)
+	}
+
+	if got := ed.buffer.GetCursor(); got.Line != 2 {
+		t.Errorf("cursor on line %d, want the best match on line 2", got.Line)
+	}
+}
+
+func TestEditor_MouseSelection(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	ed.layout.AdjustForResize(80, 24) // Text area on rows 1-22
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = "item alpha_beta end"
+	}
+	ed.buffer.SetLines(lines)
+
+	mouse := func(x, y int, buttons tcell.ButtonMask, mod tcell.ModMask) {
+		t.Helper()
+		if _, err := ed.handleMouseEvent(tcell.NewEventMouse(x, y, buttons, mod)); err != nil {
+			t.Fatalf("handleMouseEvent() error = %v", err)
+		}
+	}
+	click := func(x, y int) {
+		mouse(x, y, tcell.Button1, tcell.ModNone)
+		mouse(x, y, tcell.ButtonNone, tcell.ModNone)
+	}
+
+	// A click places the cursor, clamped to the line
+	click(3, 3)
+	if got, want := ed.buffer.GetCursor(), (buffer.Position{Line: 2, Col: 3}); got != want || ed.hasSelection {
+		t.Errorf("click: cursor = %v, selection %v; want %v, none", got, ed.hasSelection, want)
+	}
+	mouse(70, 5, tcell.Button1, tcell.ModNone)
+	mouse(70, 5, tcell.ButtonNone, tcell.ModNone)
+	if got, want := ed.buffer.GetCursor(), (buffer.Position{Line: 4, Col: 19}); got != want {
+		t.Errorf("click past the end: cursor = %v, want %v", got, want)
+	}
+
+	// Dragging selects from the press to the pointer
+	mouse(2, 1, tcell.Button1, tcell.ModNone)
+	mouse(6, 2, tcell.Button1, tcell.ModNone)
+	mouse(6, 2, tcell.ButtonNone, tcell.ModNone)
+	start, end := ed.getSelectionRange()
+	if !ed.hasSelection || start != (buffer.Position{Line: 0, Col: 2}) || end != (buffer.Position{Line: 1, Col: 6}) {
+		t.Errorf("drag: selection = %v-%v, want {0 2}-{1 6}", start, end)
+	}
+
+	// Double click selects a word, triple click the line
+	click(8, 4)
+	click(8, 4)
+	start, end = ed.getSelectionRange()
+	if start != (buffer.Position{Line: 3, Col: 5}) || end != (buffer.Position{Line: 3, Col: 15}) {
+		t.Errorf("double click: selection = %v-%v, want {3 5}-{3 15}", start, end)
+	}
+	click(8, 4)
+	start, end = ed.getSelectionRange()
+	if start != (buffer.Position{Line: 3, Col: 0}) || end != (buffer.Position{Line: 3, Col: 19}) {
+		t.Errorf("triple click: selection = %v-%v, want {3 0}-{3 19}", start, end)
+	}
+
+	// Shift+click extends the selection from its anchor
+	ed.clearSelection()
+	ed.buffer.MoveCursor(buffer.Position{Line: 0, Col: 0})
+	mouse(4, 2, tcell.Button1, tcell.ModShift)
+	start, end = ed.getSelectionRange()
+	if start != (buffer.Position{Line: 0, Col: 0}) || end != (buffer.Position{Line: 1, Col: 4}) {
+		t.Errorf("shift+click: selection = %v-%v, want {0 0}-{1 4}", start, end)
+	}
+}
+
+func TestEditor_MouseWheelAndMenu(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	ed.layout.AdjustForResize(80, 24)
+	ed.buffer.SetLines(make([]string, 100))
+
+	// The wheel scrolls the view and keeps the cursor on screen
+	for range 4 {
+		ed.handleMouseEvent(tcell.NewEventMouse(10, 10, tcell.WheelDown, tcell.ModNone))
+	}
+	if vp := ed.currentViewport(); vp.StartLine != 12 {
+		t.Errorf("after scrolling down: first line = %d, want 12", vp.StartLine)
+	}
+	if got := ed.buffer.GetCursor().Line; got != 12 {
+		t.Errorf("after scrolling down: cursor on line %d, want 12", got)
+	}
+	ed.handleMouseEvent(tcell.NewEventMouse(10, 10, tcell.WheelUp, tcell.ModNone))
+	if vp := ed.currentViewport(); vp.StartLine != 9 || ed.buffer.GetCursor().Line != 12 {
+		t.Errorf("after scrolling up: first line = %d, cursor line %d; want 9, 12", vp.StartLine, ed.buffer.GetCursor().Line)
+	}
+
+	// Clicking a menu title opens it, clicking it again closes it
+	x := ed.menuBar.GetMenuPosition(0)
+	ed.handleMouseEvent(tcell.NewEventMouse(x, 0, tcell.Button1, tcell.ModNone))
+	ed.handleMouseEvent(tcell.NewEventMouse(x, 0, tcell.ButtonNone, tcell.ModNone))
+	if !ed.menuBar.IsOpen() {
+		t.Fatal("menu should open when its title is clicked")
+	}
+	ed.handleMouseEvent(tcell.NewEventMouse(x, 0, tcell.Button1, tcell.ModNone))
+	if ed.menuBar.IsOpen() {
+		t.Error("menu should close when its title is clicked again")
+	}
+}
+
+func TestEditor_BracketedPaste(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	ed.buffer.SetLines([]string{"    if ok {"})
+	ed.buffer.MoveCursor(buffer.Position{Line: 0, Col: 11})
+	depth := ed.history.Depth()
+
+	events := []tcell.Event{tcell.NewEventPaste(true)}
+	for _, r := range "a" {
+		events = append(events, tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone))
+	}
+	events = append(events,
+		tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyTab, 0, tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyRune, 'b', tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyCtrlQ, 0, tcell.ModCtrl), // Must not quit
+		tcell.NewEventKey(tcell.KeyRune, 'c', tcell.ModNone),
+		tcell.NewEventPaste(false),
+	)
+	for _, ev := range events {
+		consumed, err := ed.handlePasteEvent(ev)
+		if err != nil {
+			t.Fatalf("handlePasteEvent() error = %v", err)
+		}
+		if !consumed {
+			t.Fatalf("handlePasteEvent(%T) not consumed during a paste", ev)
+		}
+	}
+
+	// Pasted newlines are not auto-indented
+	want := []string{"    if ok {a", "\tb", "c"}
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, want) {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+	if got := ed.history.Depth() - depth; got != 1 {
+		t.Errorf("paste added %d undo entries, want 1", got)
+	}
+	ed.history.Undo(ed.buffer)
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, []string{"    if ok {"}) {
+		t.Errorf("after undo lines = %q", got)
+	}
+
+	// Outside a paste, keys are left to the normal handling
+	if consumed, _ := ed.handlePasteEvent(tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone)); consumed {
+		t.Error("key outside a paste should not be consumed")
+	}
+}
+
+func TestPastedLineBreaks(t *testing.T) {
+	// Enter keys are collected as CRs: the terminal sends CR and LF alike
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"no line breaks", "abc", "abc"},
+		{"LF line endings", "a\rb\rc", "a\nb\nc"},
+		{"LF with a blank line", "a\rb\r\rc", "a\nb\n\nc"},
+		{"CRLF line endings", "a\r\rb\r\rc", "a\nb\nc"},
+		{"CRLF with a blank line", "a\r\rb\r\r\r\rc", "a\nb\n\nc"},
+		{"CRLF at the end", "a\r\r", "a\n"},
+		// Indistinguishable from CRLF, so the blank line is lost
+		{"LF with only doubled breaks", "a\r\rb", "a\nb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pastedLineBreaks(tt.text); got != tt.want {
+				t.Errorf("pastedLineBreaks(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditor_BracketedPasteIntoDialog(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	ed.buffer.SetLines([]string{"foo bar"})
+	if err := ed.handleFind(); err != nil {
+		t.Fatalf("handleFind() error = %v", err)
+	}
+
+	ed.handlePasteEvent(tcell.NewEventPaste(true))
+	for _, r := range "bar\nbaz" {
+		key := tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone)
+		if r == '\n' {
+			key = tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone)
+		}
+		ed.handlePasteEvent(key)
+	}
+	ed.handlePasteEvent(tcell.NewEventPaste(false))
+
+	// Only the first line goes into the single-line field
+	if got := ed.incSearch.dlg.GetSearchInput(); got != "bar" {
+		t.Errorf("search input = %q, want %q", got, "bar")
+	}
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, []string{"foo bar"}) {
+		t.Errorf("buffer changed to %q", got)
+	}
+}
+
+func TestEditor_KeymapChord(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	km := terminal.DefaultKeymap()
+	if err := km.Bind(terminal.ContextEditor, "Ctrl+K Ctrl+D", terminal.KeyActionDuplicateLine); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	ed.SetKeymap(km)
+	ed.buffer.SetLines([]string{"one"})
+
+	press := func(key tcell.Key) {
+		t.Helper()
+		ke := ed.keymap.Process(ed.keyContext(), tcell.NewEventKey(key, 0, tcell.ModCtrl))
+		if err := ed.handleKeyEvent(ke); err != nil {
+			t.Fatalf("handleKeyEvent() error = %v", err)
+		}
+	}
+
+	press(tcell.KeyCtrlK)
+	if !strings.Contains(ed.statusMessage, "(Ctrl+K) was pressed") {
+		t.Errorf("statusMessage = %q, want the pending chord", ed.statusMessage)
+	}
+	press(tcell.KeyCtrlD)
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, []string{"one", "one"}) {
+		t.Errorf("lines after Ctrl+K Ctrl+D = %q, want the line duplicated", got)
+	}
+	if ed.statusMessage != "" {
+		t.Errorf("statusMessage = %q, want it cleared", ed.statusMessage)
+	}
+
+	press(tcell.KeyCtrlK)
+	press(tcell.KeyCtrlT)
+	if !strings.Contains(ed.statusMessage, "Ctrl+K Ctrl+T is not bound") {
+		t.Errorf("statusMessage = %q, want the unbound chord", ed.statusMessage)
+	}
+	if got := ed.buffer.LineCount(); got != 2 {
+		t.Errorf("LineCount() = %d, want the unbound chord to do nothing", got)
+	}
+}
+
+func TestEditor_KeymapSyncsMenuAndHelp(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	km := terminal.DefaultKeymap()
+	km.Unbind(terminal.ContextEditor, "Ctrl+S")
+	if err := km.Bind(terminal.ContextEditor, "Ctrl+K Ctrl+S", terminal.KeyActionSave); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	km.Unbind(terminal.ContextEditor, "Ctrl+W")
+	ed.SetKeymap(km)
+
+	shortcuts := make(map[menu.MenuAction]string)
+	for _, m := range ed.menuBar.GetMenus() {
+		for _, item := range m.Items {
+			shortcuts[item.Action] = item.Shortcut
+		}
+	}
+	tests := []struct {
+		action menu.MenuAction
+		want   string
+	}{
+		{menu.ActionFileSave, "Ctrl+K Ctrl+S"},
+		{menu.ActionFileClose, ""},
+		{menu.ActionFileSaveAs, "Ctrl+Shift+S"},
+		{menu.ActionHelpShortcuts, "F1"},
+	}
+	for _, tt := range tests {
+		if got := shortcuts[tt.action]; got != tt.want {
+			t.Errorf("menu shortcut for %s = %q, want %q", tt.action, got, tt.want)
+		}
+	}
+
+	if err := ed.executeMenuAction(menu.ActionHelpShortcuts); err != nil {
+		t.Fatalf("executeMenuAction(help) error = %v", err)
+	}
+	if _, ok := ed.dialogManager.Peek().(*dialog.TextDialog); !ok {
+		t.Fatalf("help should open the cheat sheet, got %T", ed.dialogManager.Peek())
+	}
+}
+
+func TestEditor_CommandPalette(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	if err := ed.SetStatePath(statePath); err != nil {
+		t.Fatalf("SetStatePath() error = %v", err)
+	}
+	ed.buffer.SetLines([]string{"one"})
+
+	ke := ed.keymap.Process(ed.keyContext(), tcell.NewEventKey(tcell.KeyCtrlP, 0, tcell.ModCtrl|tcell.ModShift))
+	if err := ed.handleKeyEvent(ke); err != nil {
+		t.Fatalf("handleKeyEvent(Ctrl+Shift+P) error = %v", err)
+	}
+	palette, ok := ed.dialogManager.Peek().(*dialog.CommandPalette)
+	if !ok {
+		t.Fatalf("Ctrl+Shift+P should open the command palette, got %T", ed.dialogManager.Peek())
+	}
+	if cmd, _ := palette.GetResult().(dialog.PaletteCommand); cmd.Name != "new" || cmd.Shortcut != "Ctrl+N" {
+		t.Errorf("first command = %+v, want new with Ctrl+N", cmd)
+	}
+
+	for _, r := range "duplicate" {
+		ed.dialogManager.HandleInput(tcell.KeyRune, tcell.ModNone, r)
+	}
+	ed.dialogManager.HandleInput(tcell.KeyEnter, tcell.ModNone, 0)
+	if err := ed.runQueuedCommand(); err != nil {
+		t.Fatalf("runQueuedCommand() error = %v", err)
+	}
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, []string{"one", "one"}) {
+		t.Errorf("lines = %q, want the line duplicated", got)
+	}
+	if ed.dialogManager.HasOpenDialog() {
+		t.Error("palette should close after running a command")
+	}
+
+	// The command is remembered, also across sessions
+	if err := ed.saveState(); err != nil {
+		t.Fatalf("saveState() error = %v", err)
+	}
+	ed.recentCommands = nil
+	if err := ed.SetStatePath(statePath); err != nil {
+		t.Fatalf("SetStatePath() error = %v", err)
+	}
+	if !slices.Equal(ed.recentCommands, []string{"duplicate-line"}) {
+		t.Errorf("recentCommands = %q, want [duplicate-line]", ed.recentCommands)
+	}
+
+	// Quitting from the palette reaches the event loop
+	ed.handleCommandPalette()
+	for _, r := range "quit" {
+		ed.dialogManager.HandleInput(tcell.KeyRune, tcell.ModNone, r)
+	}
+	ed.dialogManager.HandleInput(tcell.KeyEnter, tcell.ModNone, 0)
+	if err := ed.runQueuedCommand(); err != ErrQuit {
+		t.Errorf("runQueuedCommand() error = %v, want ErrQuit", err)
+	}
+}
+
+func TestEditor_MenuRunsCommands(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	// Every menu item runs a registered command
+	for _, m := range ed.menuBar.GetMenus() {
+		for _, item := range m.Items {
+			if item.IsSeparator {
+				continue
+			}
+			if _, ok := ed.commands.lookupMenu(item.Action); !ok {
+				t.Errorf("menu item %q has no command", item.Label)
+			}
+		}
+	}
+
+	ed.buffer.SetLines([]string{"one"})
+	ed.SetReadOnly(true)
+	if err := ed.executeMenuAction(menu.ActionEditDuplicateLine); err != nil {
+		t.Fatalf("executeMenuAction() error = %v", err)
+	}
+	if got := ed.buffer.LineCount(); got != 1 {
+		t.Errorf("LineCount() = %d, want read-only to refuse the edit", got)
+	}
+	if err := ed.executeMenuAction(menu.ActionFileQuit); err != ErrQuit {
+		t.Errorf("executeMenuAction(quit) error = %v, want ErrQuit", err)
+	}
+}
+
+func TestEditor_Config(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	dir := t.TempDir()
+	userPath := filepath.Join(dir, "config.toml")
+	user := `
+[editor]
+tab_size = 8
+
+[search]
+history_size = 3
+
+[history]
+undo_levels = 5
+
+[languages.go]
+tab_size = 2
+use_spaces = false
+
+[keybindings.editor]
+"Ctrl+K Ctrl+S" = "save"
+`
+	if err := os.WriteFile(userPath, []byte(user), 0644); err != nil {
+		t.Fatal(err)
+	}
+	projectDir := filepath.Join(dir, "project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "notes.txt"), []byte("notes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ed.OpenFile(filepath.Join(projectDir, "notes.txt")); err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	if err := ed.SetConfigPath(userPath); err != nil {
+		t.Fatalf("SetConfigPath() error = %v", err)
+	}
+
+	info := ed.buildFileInfo(ed.editorSettings())
+	if info.TabSize != 8 || !info.UseSpaces {
+		t.Errorf("text file: tab size %d, spaces %v, want 8, true", info.TabSize, info.UseSpaces)
+	}
+	if got := ed.history.MaxDepth(); got != 5 {
+		t.Errorf("undo depth = %d, want 5", got)
+	}
+	finder := ed.searchManager.GetFinder()
+	for _, p := range []string{"a", "b", "c", "d"} {
+		finder.SetPattern(p)
+	}
+	if got := finder.GetHistory(); len(got) != 3 {
+		t.Errorf("search history = %v, want 3 entries", got)
+	}
+	if got := ed.keymap.Shortcut(terminal.ContextEditor, terminal.KeyActionSave); got != "Ctrl+S" {
+		t.Errorf("save shortcut = %q, want %q", got, "Ctrl+S")
+	}
+	if ed.keymap.Process(terminal.ContextEditor, tcell.NewEventKey(tcell.KeyCtrlK, 0, tcell.ModCtrl)); ed.keymap.Pending() == "" {
+		t.Error("Ctrl+K should start the configured chord")
+	}
+
+	// Language sections apply to files of that language
+	if err := ed.OpenFile(filepath.Join(projectDir, "main.go")); err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	info = ed.buildFileInfo(ed.editorSettings())
+	if info.TabSize != 2 || info.UseSpaces {
+		t.Errorf("Go file: tab size %d, spaces %v, want 2, false", info.TabSize, info.UseSpaces)
+	}
+
+	// A project file created later is noticed, and overrides the user's
+	if ed.configWatcher.Changed() {
+		t.Error("watcher reported a change before any file changed")
+	}
+	project := "[editor]\ntab_size = 3\n\n[languages.go]\ntab_size = 6\n"
+	if err := os.WriteFile(filepath.Join(projectDir, ".ted.toml"), []byte(project), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !ed.configWatcher.Changed() {
+		t.Fatal("watcher should notice the new project file")
+	}
+	ed.handleConfigChanged()
+	if ed.statusMessage != "Configuration reloaded" {
+		t.Errorf("status = %q, want %q", ed.statusMessage, "Configuration reloaded")
+	}
+	if got := ed.buildFileInfo(ed.editorSettings()).TabSize; got != 6 {
+		t.Errorf("Go file tab size after reload = %d, want 6", got)
+	}
+	if err := ed.render(); err != nil {
+		t.Fatalf("render() error = %v", err)
+	}
+	if got := ed.renderer.TabSize(); got != 6 {
+		t.Errorf("renderer tab size = %d, want 6", got)
+	}
+
+	// Invalid settings are reported and the rest still apply
+	if err := os.WriteFile(filepath.Join(projectDir, ".ted.toml"), []byte("[editor]\ntab_size = 99\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ed.handleConfigChanged()
+	if !strings.Contains(ed.statusMessage, "editor.tab_size = 99") {
+		t.Errorf("status = %q, want the invalid setting named", ed.statusMessage)
+	}
+	if got := ed.buildFileInfo(ed.editorSettings()).TabSize; got != 2 {
+		t.Errorf("Go file tab size = %d, want 2 from the user file", got)
+	}
+}
+
+func TestEditor_MouseClickAfterTab(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	ed.buffer.SetLines([]string{"\tx"})
+	ed.renderer.SetTabSize(4)
+	viewport := ed.layout.CalculateViewport(0, 1)
+	y := ed.layout.GetEditAreaRegion().Y
+
+	tests := []struct {
+		x    int
+		want int
+	}{
+		{0, 0},
+		{3, 1}, // Right half of the tab
+		{4, 1}, // On the x
+		{5, 2},
+	}
+	for _, tt := range tests {
+		if got := ed.bufferPosition(tt.x, y, viewport); got.Col != tt.want {
+			t.Errorf("bufferPosition(%d) column = %d, want %d", tt.x, got.Col, tt.want)
+		}
+	}
+}
+
+func TestEditor_EditorConfig(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	dir := t.TempDir()
+	editorConfig := `root = true
+
+[*]
+indent_style = tab
+tab_width = 8
+end_of_line = crlf
+charset = latin1
+trim_trailing_whitespace = true
+insert_final_newline = true
+max_line_length = 72
+`
+	if err := os.WriteFile(filepath.Join(dir, ".editorconfig"), []byte(editorConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(path, []byte("caf\xe9  \nnext\t\nend"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ed.OpenFile(path); err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, []string{"café  ", "next\t", "end"}) {
+		t.Errorf("lines = %q, want the file decoded as latin1", got)
+	}
+
+	settings := ed.editorSettings()
+	if settings.TabSize != 8 || settings.UseSpaces || settings.MaxLineLength != 72 {
+		t.Errorf("settings = %+v, want tabs of 8 with a guide at 72", settings)
+	}
+	info := ed.buildFileInfo(settings)
+	if info.TabSize != 8 || info.UseSpaces || info.Encoding != "Latin-1" || info.LineEnding != "CRLF" {
+		t.Errorf("info = tab %d, spaces %v, %q, %q, want 8, false, Latin-1, CRLF", info.TabSize, info.UseSpaces, info.Encoding, info.LineEnding)
+	}
+
+	ed.buffer.MoveCursor(buffer.Position{Line: 1, Col: 2})
+	if err := ed.SaveFile(); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "caf\xe9\r\nnext\r\nend\r\n"; string(data) != want {
+		t.Errorf("saved %q, want %q", data, want)
+	}
+
+	// The buffer shows what was saved, and the cursor stays put
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, []string{"café", "next", "end", ""}) {
+		t.Errorf("lines after save = %q, want them trimmed with a final newline", got)
+	}
+	if got := ed.buffer.GetCursor(); got != (buffer.Position{Line: 1, Col: 2}) {
+		t.Errorf("cursor after save = %v, want {1 2}", got)
+	}
+	if ed.buffer.IsModified() {
+		t.Error("buffer modified after save, want saved")
+	}
+
+	// The formatting is one undoable edit
+	if err := ed.Undo(); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, []string{"café  ", "next\t", "end"}) {
+		t.Errorf("lines after undo = %q, want the original lines", got)
+	}
+
+	// Text latin1 cannot hold is refused, leaving the file alone
+	ed.buffer.SetLines([]string{"€"})
+	if err := ed.SaveFile(); err == nil {
+		t.Error("SaveFile() with text latin1 cannot encode should return error")
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(data) {
+		t.Errorf("failed save changed the file to %q", after)
+	}
+}
+
+func TestEditor_DetectIndentation(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	twoSpaces := write("two.py", "def f():\n  if x:\n    return 1\n  return 2\n")
+	tabs := write("tabs.txt", "a\n\tb\n\t\tc\n")
+	plain := write("plain.txt", "no\nindentation\n")
+
+	tests := []struct {
+		name      string
+		path      string
+		setup     func()
+		wantSize  int
+		wantSpace bool
+	}{
+		{"two spaces", twoSpaces, nil, 2, true},
+		{"tabs", tabs, nil, 4, false},
+		{"nothing to detect", plain, nil, 4, true},
+		{"detection off", twoSpaces, func() { ed.config.Editor.DetectIndent = false }, 4, true},
+		{"language sets spaces", tabs, func() {
+			yes := true
+			ed.config.Languages["plaintext"] = config.LanguageSettings{UseSpaces: &yes}
+		}, 4, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ed.config = config.Default()
+			if tt.setup != nil {
+				tt.setup()
+			}
+			if err := ed.OpenFile(tt.path); err != nil {
+				t.Fatalf("OpenFile() error = %v", err)
+			}
+			info := ed.buildFileInfo(ed.editorSettings())
+			if info.TabSize != tt.wantSize || info.UseSpaces != tt.wantSpace {
+				t.Errorf("indentation = %d, spaces %v; want %d, spaces %v", info.TabSize, info.UseSpaces, tt.wantSize, tt.wantSpace)
+			}
+		})
+	}
+}
+
+func TestEditor_IndentOutdent(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	path := filepath.Join(t.TempDir(), "code.py")
+	if err := os.WriteFile(path, []byte("if x:\n  a\n\n  b\nc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ed.OpenFile(path); err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+
+	// Tab without a selection inserts spaces up to the next stop
+	ed.buffer.MoveCursor(buffer.Position{Line: 4, Col: 1})
+	if err := ed.handleIndent(); err != nil {
+		t.Fatalf("handleIndent() error = %v", err)
+	}
+	if line, _ := ed.buffer.GetLine(4); line != "c " {
+		t.Errorf("Tab at column 1 gave %q, want %q", line, "c ")
+	}
+	ed.Undo()
+
+	// Tab with lines selected indents them, leaving blank lines alone
+	ed.hasSelection = true
+	ed.selectionStart = buffer.Position{Line: 1, Col: 0}
+	ed.selectionEnd = buffer.Position{Line: 3, Col: 3}
+	ed.buffer.MoveCursor(ed.selectionEnd)
+	if err := ed.handleIndent(); err != nil {
+		t.Fatalf("handleIndent() error = %v", err)
+	}
+	want := []string{"if x:", "    a", "", "    b", "c"}
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, want) {
+		t.Errorf("after indent = %q, want %q", got, want)
+	}
+	start, end := ed.getSelectionRange()
+	if start != (buffer.Position{Line: 1, Col: 0}) || end != (buffer.Position{Line: 3, Col: 5}) {
+		t.Errorf("selection after indent = %v to %v, want {1 0} to {3 5}", start, end)
+	}
+	if ed.buffer.GetCursor() != end {
+		t.Errorf("cursor after indent = %v, want %v", ed.buffer.GetCursor(), end)
+	}
+
+	// Shift+Tab takes a level off again, twice to reach the margin
+	ed.handleOutdent()
+	ed.handleOutdent()
+	want = []string{"if x:", "a", "", "b", "c"}
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, want) {
+		t.Errorf("after two outdents = %q, want %q", got, want)
+	}
+
+	// Each indent and outdent is one undoable edit
+	ed.Undo()
+	ed.Undo()
+	want = []string{"if x:", "    a", "", "    b", "c"}
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, want) {
+		t.Errorf("after two undos = %q, want %q", got, want)
+	}
+
+	// Outdent without a selection acts on the cursor's line
+	ed.clearSelection()
+	ed.buffer.MoveCursor(buffer.Position{Line: 1, Col: 3})
+	ed.handleOutdent()
+	if line, _ := ed.buffer.GetLine(1); line != "  a" {
+		t.Errorf("outdent of the cursor line gave %q, want %q", line, "  a")
+	}
+	if got := ed.buffer.GetCursor(); got != (buffer.Position{Line: 1, Col: 1}) {
+		t.Errorf("cursor after outdent = %v, want {1 1}", got)
+	}
+}
+
+func TestEditor_IndentationOverride(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	path := filepath.Join(t.TempDir(), "code.py")
+	if err := os.WriteFile(path, []byte("if x:\n  a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ed.OpenFile(path); err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+
+	// Clicking the indentation in the info bar offers the choices
+	if err := ed.render(); err != nil {
+		t.Fatalf("render() error = %v", err)
+	}
+	region := ed.layout.GetInfoBarRegion()
+	clicked := false
+	for x := region.X; x < region.X+region.Width && !clicked; x++ {
+		if ed.renderer.IndentationFieldAt(x, region.Y) {
+			ed.handleMouseEvent(tcell.NewEventMouse(x, region.Y, tcell.Button1, 0))
+			clicked = true
+		}
+	}
+	if !clicked || !ed.dialogManager.HasOpenDialog() {
+		t.Fatal("clicking the indentation should open the choices")
+	}
+
+	// Choices: Automatic, Spaces: 1 to 8, Tab: 1 to 8. Pick Tab: 8.
+	ed.dialogManager.HandleInput(tcell.KeyEnd, 0, 0)
+	ed.dialogManager.HandleInput(tcell.KeyEnter, 0, 0)
+	info := ed.buildFileInfo(ed.editorSettings())
+	if info.TabSize != 8 || info.UseSpaces {
+		t.Errorf("after choosing Tab: 8, indentation = %d, spaces %v", info.TabSize, info.UseSpaces)
+	}
+	if got := ed.editorSettings().TabSize; got != 8 {
+		t.Errorf("tab size = %d, want 8", got)
+	}
+
+	ed.buffer.MoveCursor(buffer.Position{Line: 1, Col: 0})
+	if err := ed.handleIndent(); err != nil {
+		t.Fatalf("handleIndent() error = %v", err)
+	}
+	if line, _ := ed.buffer.GetLine(1); line != "\t  a" {
+		t.Errorf("Tab inserted %q, want a tab", line)
+	}
+
+	// Automatic goes back to what was detected
+	if err := ed.handleIndentation(); err != nil {
+		t.Fatalf("handleIndentation() error = %v", err)
+	}
+	ed.dialogManager.HandleInput(tcell.KeyHome, 0, 0)
+	ed.dialogManager.HandleInput(tcell.KeyEnter, 0, 0)
+	if info := ed.buildFileInfo(ed.editorSettings()); info.TabSize != 2 || !info.UseSpaces {
+		t.Errorf("after choosing Automatic, indentation = %d, spaces %v; want 2, spaces", info.TabSize, info.UseSpaces)
+	}
+
+	// Opening another file forgets the choice
+	ed.indentOverride = &buffer.Indentation{UseSpaces: true, Size: 3}
+	if err := ed.OpenFile(path); err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	if ed.indentOverride != nil {
+		t.Error("OpenFile() kept the indentation chosen for the previous file")
+	}
+}
+
+func TestEditor_MultipleCursors(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	run := func(action terminal.KeyAction) {
+		t.Helper()
+		if err := ed.handleKeyEvent(&terminal.KeyEvent{Action: action}); err != nil {
+			t.Fatalf("%v: error = %v", action, err)
+		}
+	}
+	typeText := func(text string) {
+		t.Helper()
+		for _, r := range text {
+			if err := ed.handleKeyEvent(&terminal.KeyEvent{Action: terminal.KeyActionCharacter, Character: r}); err != nil {
+				t.Fatalf("typing %q: error = %v", r, err)
+			}
+		}
+	}
+	checkLines := func(step string, want ...string) {
+		t.Helper()
+		if got := ed.buffer.GetAllLines(); !slices.Equal(got, want) {
+			t.Errorf("%s: lines = %q, want %q", step, got, want)
+		}
+	}
+
+	// Adding the next occurrence selects the word, then each match in turn
+	ed.buffer.SetLines([]string{"foo bar", "foo baz", "qux foo foo"})
+	ed.buffer.MoveCursor(buffer.Position{Line: 1, Col: 1})
+	run(terminal.KeyActionAddNextOccurrence)
+	if start, end := ed.getSelectionRange(); start != (buffer.Position{Line: 1}) || end != (buffer.Position{Line: 1, Col: 3}) {
+		t.Errorf("first press selects %v-%v, want {1 0}-{1 3}", start, end)
+	}
+	for range 3 {
+		run(terminal.KeyActionAddNextOccurrence)
+	}
+	if got := len(ed.buffer.ExtraCursors()); got != 3 {
+		t.Fatalf("extra cursors = %d, want 3", got)
+	}
+	if got, want := ed.buffer.GetCursor(), (buffer.Position{Line: 0, Col: 3}); got != want {
+		t.Errorf("main cursor wrapped to %v, want %v", got, want)
+	}
+	run(terminal.KeyActionAddNextOccurrence)
+	if ed.statusMessage != "No more occurrences" {
+		t.Errorf("status = %q, want %q", ed.statusMessage, "No more occurrences")
+	}
+
+	// Typing replaces every selection, as one undo step
+	depth := ed.history.Depth()
+	typeText("x")
+	checkLines("typing", "x bar", "x baz", "qux x x")
+	if got := ed.history.Depth() - depth; got != 1 {
+		t.Errorf("typing added %d undo entries, want 1", got)
+	}
+	typeText("y")
+	run(terminal.KeyActionBackspace)
+	run(terminal.KeyActionBackspace)
+	checkLines("backspace", " bar", " baz", "qux  ")
+	for range 4 {
+		ed.Undo()
+	}
+	checkLines("undo", "foo bar", "foo baz", "qux foo foo")
+
+	// Cursors added below keep the column and move together
+	ed.buffer.SetLines([]string{"abc", "abc", "abc", "a"})
+	ed.buffer.MoveCursor(buffer.Position{Line: 0, Col: 1})
+	run(terminal.KeyActionAddCursorBelow)
+	run(terminal.KeyActionAddCursorBelow)
+	run(terminal.KeyActionAddCursorBelow)
+	if got := len(ed.buffer.ExtraCursors()); got != 3 {
+		t.Fatalf("extra cursors = %d, want 3", got)
+	}
+	run(terminal.KeyActionMoveRight)
+	run(terminal.KeyActionDelete)
+	checkLines("move and delete", "ab", "ab", "ab", "a")
+	run(terminal.KeyActionHome)
+	run(terminal.KeyActionEnter)
+	checkLines("enter", "", "ab", "", "ab", "", "ab", "", "a")
+	ed.Undo()
+
+	// Text copied at every cursor is pasted back one line per cursor
+	ed.buffer.SetLines([]string{"a", "b", "c"})
+	ed.buffer.MoveCursor(buffer.Position{Line: 2, Col: 1})
+	run(terminal.KeyActionAddCursorAbove)
+	run(terminal.KeyActionAddCursorAbove)
+	if text, err := ed.textAtEachCursor(); err != nil || text != "a\nb\nc" {
+		t.Errorf("textAtEachCursor() = %q, %v, want %q", text, err, "a\nb\nc")
+	}
+	if err := ed.insertPastedText("1\n2\n3"); err != nil {
+		t.Fatalf("insertPastedText() error = %v", err)
+	}
+	checkLines("paste", "a1", "b2", "c3")
+
+	// Commands that only know one cursor drop the others
+	run(terminal.KeyActionDuplicateLine)
+	if ed.buffer.HasExtraCursors() {
+		t.Error("duplicate line should keep only the main cursor")
+	}
+}
+
+func TestEditor_AltClickAddsCursor(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	ed.layout.AdjustForResize(80, 24) // Text area on rows 1-22
+	ed.buffer.SetLines([]string{"one", "two", "three"})
+	click := func(x, y int, mod tcell.ModMask) {
+		t.Helper()
+		for _, buttons := range []tcell.ButtonMask{tcell.Button1, tcell.ButtonNone} {
+			if _, err := ed.handleMouseEvent(tcell.NewEventMouse(x, y, buttons, mod)); err != nil {
+				t.Fatalf("handleMouseEvent() error = %v", err)
+			}
+		}
+	}
+
+	click(1, 1, tcell.ModNone)
+	click(2, 2, tcell.ModAlt)
+	click(4, 3, tcell.ModAlt)
+	if got, want := ed.buffer.GetCursor(), (buffer.Position{Line: 2, Col: 4}); got != want {
+		t.Errorf("main cursor = %v, want %v", got, want)
+	}
+	want := []buffer.Position{{Line: 0, Col: 1}, {Line: 1, Col: 2}}
+	if got := ed.extraCursorPositions(); !slices.Equal(got, want) {
+		t.Errorf("extra cursors = %v, want %v", got, want)
+	}
+
+	// Alt+click on a cursor removes it; a plain click keeps only one
+	click(2, 2, tcell.ModAlt)
+	if got := len(ed.buffer.ExtraCursors()); got != 1 {
+		t.Errorf("after removing one: extra cursors = %d, want 1", got)
+	}
+	click(0, 1, tcell.ModNone)
+	if ed.buffer.HasExtraCursors() {
+		t.Error("a plain click should remove the extra cursors")
+	}
+}
+
+func TestEditor_BlockSelection(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	ed.buffer.SetLines([]string{"abcdef", "\tgh", "ij", "klmnop"})
+	ed.buffer.MoveCursor(buffer.Position{Line: 0, Col: 2})
+	press := func(action terminal.KeyAction, times int) {
+		t.Helper()
+		for range times {
+			if err := ed.handleKeyEvent(&terminal.KeyEvent{Action: action}); err != nil {
+				t.Fatalf("handleKeyEvent(%v) error = %v", action, err)
+			}
+		}
+	}
+
+	// Columns 2-3 of every line; the tab covers columns 0-3 of line 1
+	press(terminal.KeyActionBlockSelectDown, 3)
+	press(terminal.KeyActionBlockSelectRight, 2)
+	text, err := ed.blockText()
+	if err != nil {
+		t.Fatalf("blockText() error = %v", err)
+	}
+	if want := "cd\n\t\n\nmn"; text != want {
+		t.Errorf("blockText() = %q, want %q", text, want)
+	}
+
+	ed.deleteBlock()
+	want := []string{"abef", "gh", "ij", "klop"}
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, want) {
+		t.Errorf("after delete: lines = %q, want %q", got, want)
+	}
+
+	// Typing goes on every line of the block
+	if err := ed.handleKeyEvent(&terminal.KeyEvent{Action: terminal.KeyActionCharacter, Character: 'x'}); err != nil {
+		t.Fatalf("handleKeyEvent() error = %v", err)
+	}
+	want = []string{"abxef", "xgh", "ijx", "klxop"}
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, want) {
+		t.Errorf("after typing: lines = %q, want %q", got, want)
+	}
+	press(terminal.KeyActionUndo, 1)
+	press(terminal.KeyActionUndo, 1)
+	want = []string{"abcdef", "\tgh", "ij", "klmnop"}
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, want) {
+		t.Errorf("after undo: lines = %q, want %q", got, want)
+	}
+}
+
+func TestEditor_PasteColumn(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	ed.buffer.SetLines([]string{"abcd", "e", "fghi"})
+	ed.buffer.MoveCursor(buffer.Position{Line: 1, Col: 0})
+	ed.handleBlockSelect(0, 3)
+	ed.pasteColumn("1\n2\n3")
+
+	want := []string{"abcd", "1", "2fghi", "3"}
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, want) {
+		t.Fatalf("lines = %q, want %q", got, want)
+	}
+
+	// Into a cursor past the end of short lines, padded with spaces
+	ed.buffer.SetLines([]string{"abcd", "e"})
+	ed.buffer.MoveCursor(buffer.Position{Line: 0, Col: 3})
+	ed.pasteColumn("X\nY")
+	want = []string{"abcXd", "e  Y"}
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, want) {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+	if err := ed.Undo(); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	want = []string{"abcd", "e"}
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, want) {
+		t.Errorf("after undo: lines = %q, want %q", got, want)
+	}
+}
+
+func TestEditor_AltDragSelectsBlock(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	ed.layout.AdjustForResize(80, 24) // Text area on rows 1-22
+	ed.buffer.SetLines([]string{"abcdef", "gh", "ijklmn"})
+	for _, ev := range []*tcell.EventMouse{
+		tcell.NewEventMouse(1, 1, tcell.Button1, tcell.ModAlt),
+		tcell.NewEventMouse(4, 3, tcell.Button1, tcell.ModAlt),
+		tcell.NewEventMouse(4, 3, tcell.ButtonNone, tcell.ModAlt),
+	} {
+		if _, err := ed.handleMouseEvent(ev); err != nil {
+			t.Fatalf("handleMouseEvent() error = %v", err)
+		}
+	}
+
+	text, err := ed.blockText()
+	if err != nil {
+		t.Fatalf("blockText() error = %v", err)
+	}
+	if want := "bcd\nh\njkl"; text != want {
+		t.Errorf("blockText() = %q, want %q", text, want)
+	}
+}
+
+func TestEditor_KeyboardMacro(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	ed.buffer.SetLines([]string{"a", "b", "c", "d"})
+	press := func(ke terminal.KeyEvent) {
+		t.Helper()
+		if err := ed.handleKeyEvent(&ke); err != nil {
+			t.Fatalf("handleKeyEvent(%v) error = %v", ke.Action, err)
+		}
+	}
+	action := func(a terminal.KeyAction) terminal.KeyEvent { return terminal.KeyEvent{Action: a} }
+
+	// Wrap a line in brackets and move down
+	press(action(terminal.KeyActionRecordMacro))
+	press(terminal.KeyEvent{Action: terminal.KeyActionCharacter, Character: '['})
+	press(action(terminal.KeyActionEnd))
+	press(terminal.KeyEvent{Action: terminal.KeyActionCharacter, Character: ']'})
+	press(action(terminal.KeyActionMoveDown))
+	press(action(terminal.KeyActionHome))
+	press(action(terminal.KeyActionRecordMacro))
+	if got := len(ed.lastMacro); got != 5 {
+		t.Fatalf("recorded %d keys, want 5", got)
+	}
+
+	if err := ed.playMacro(ed.lastMacro, 2, nil); err != nil {
+		t.Fatalf("playMacro() error = %v", err)
+	}
+	want := []string{"[a]", "[b]", "[c]", "d"}
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, want) {
+		t.Errorf("after playing twice: lines = %q, want %q", got, want)
+	}
+
+	// Both times are undone together
+	press(action(terminal.KeyActionUndo))
+	want = []string{"[a]", "b", "c", "d"}
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, want) {
+		t.Errorf("after undo: lines = %q, want %q", got, want)
+	}
+}
+
+func TestEditor_KeyboardMacroDialogInput(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	ed.buffer.SetLines([]string{"one", "two", "three"})
+	ed.handleRecordMacro()
+	if err := ed.handleKeyEvent(&terminal.KeyEvent{Action: terminal.KeyActionGoToLine}); err != nil {
+		t.Fatalf("handleKeyEvent() error = %v", err)
+	}
+	for _, ke := range []terminal.KeyEvent{
+		{Action: terminal.KeyActionCharacter, Key: tcell.KeyRune, Character: '3'},
+		{Action: terminal.KeyActionEnter, Key: tcell.KeyEnter},
+	} {
+		if handled, err := ed.handleDialogKey(&ke); !handled || err != nil {
+			t.Fatalf("handleDialogKey() = %v, %v, want true, nil", handled, err)
+		}
+	}
+	if err := ed.handleKeyEvent(&terminal.KeyEvent{Action: terminal.KeyActionCharacter, Character: '>'}); err != nil {
+		t.Fatalf("handleKeyEvent() error = %v", err)
+	}
+	ed.handleRecordMacro()
+
+	ed.buffer.MoveCursor(buffer.Position{Line: 0, Col: 0})
+	if err := ed.playMacro(ed.lastMacro, 1, nil); err != nil {
+		t.Fatalf("playMacro() error = %v", err)
+	}
+	want := []string{"one", "two", ">>three"}
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, want) {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+	if ed.dialogManager.HasOpenDialog() {
+		t.Error("the replayed Enter should close the go to line dialog")
+	}
+}
+
+func TestEditor_KeyboardMacroOnLines(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	ed.buffer.SetLines([]string{"a", "b", "c", "d"})
+	ed.lastMacro = []macroKey{
+		{event: terminal.KeyEvent{Action: terminal.KeyActionCharacter, Character: '-'}},
+		{event: terminal.KeyEvent{Action: terminal.KeyActionEnter}},
+	}
+
+	// Lines 1 and 2: the selection ends at the start of line 3
+	ed.selectRange(buffer.Position{Line: 1, Col: 0}, buffer.Position{Line: 3, Col: 0})
+	if err := ed.handlePlayMacroOnLines(); err != nil {
+		t.Fatalf("handlePlayMacroOnLines() error = %v", err)
+	}
+	want := []string{"a", "-", "b", "-", "c", "d"}
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, want) {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+	if err := ed.Undo(); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	want = []string{"a", "b", "c", "d"}
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, want) {
+		t.Errorf("after undo: lines = %q, want %q", got, want)
+	}
+}
+
+func TestMacrosState_RoundTrip(t *testing.T) {
+	macros := map[string][]macroKey{
+		"menu": {
+			{event: terminal.KeyEvent{Action: terminal.KeyActionMenuAlt, Character: 'F', Key: tcell.KeyRune, Modifiers: tcell.ModAlt}},
+			{event: terminal.KeyEvent{Action: terminal.KeyActionEscape, Key: tcell.KeyEscape}},
+		},
+		"find": {
+			{event: terminal.KeyEvent{Action: terminal.KeyActionFind}},
+			{event: terminal.KeyEvent{Action: terminal.KeyActionCharacter, Character: 'é', Key: tcell.KeyRune}, dialog: true},
+		},
+	}
+
+	got := macrosFromState(macrosToState(macros))
+	if len(got) != len(macros) {
+		t.Fatalf("macros = %v, want %v", got, macros)
+	}
+	for name, keys := range macros {
+		if !slices.Equal(got[name], keys) {
+			t.Errorf("macro %q = %v, want %v", name, got[name], keys)
+		}
+	}
+
+	saved := macrosToState(macros)
+	saved["menu"][0].Action = "no-such-action"
+	if _, ok := macrosFromState(saved)["menu"]; ok {
+		t.Error("a macro with an unknown action should be left out")
+	}
+}
+
+func TestEditor_Bookmarks(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	ed.buffer.SetLines([]string{"a", "b", "c", "d", "e"})
+	press := func(a terminal.KeyAction) {
+		t.Helper()
+		if err := ed.handleKeyEvent(&terminal.KeyEvent{Action: a}); err != nil {
+			t.Fatalf("handleKeyEvent(%v) error = %v", a, err)
+		}
+	}
+	at := func(line int) {
+		ed.buffer.MoveCursor(buffer.Position{Line: line})
+	}
+
+	// Numbered bookmarks take the lowest free number
+	at(1)
+	press(terminal.KeyActionToggleBookmark)
+	at(3)
+	press(terminal.KeyActionToggleBookmark)
+	want := map[int]rune{1: '1', 3: '2'}
+	if got := ed.bookmarkMarks(); !maps.Equal(got, want) {
+		t.Errorf("gutter marks = %q, want %q", got, want)
+	}
+
+	// Bookmarks follow their lines when lines are added above
+	at(0)
+	ed.buffer.Insert(buffer.Position{}, "new\n")
+	press(terminal.KeyActionGoToBookmark2)
+	if got := ed.buffer.GetCursor().Line; got != 4 {
+		t.Errorf("after go-to-bookmark-2: line = %d, want 4", got)
+	}
+
+	// Next and previous wrap around
+	press(terminal.KeyActionNextBookmark)
+	if got := ed.buffer.GetCursor().Line; got != 2 {
+		t.Errorf("after next-bookmark: line = %d, want 2", got)
+	}
+	press(terminal.KeyActionPreviousBookmark)
+	if got := ed.buffer.GetCursor().Line; got != 4 {
+		t.Errorf("after previous-bookmark: line = %d, want 4", got)
+	}
+
+	// Toggling again removes the bookmark, and its number is reused
+	press(terminal.KeyActionToggleBookmark)
+	if _, ok := ed.buffer.Bookmark("2"); ok {
+		t.Error("bookmark 2 was not removed")
+	}
+	at(0)
+	press(terminal.KeyActionToggleBookmark)
+	if pos, ok := ed.buffer.Bookmark("2"); !ok || pos.Line != 0 {
+		t.Errorf("bookmark 2 = %v, %v, want line 0", pos, ok)
+	}
+
+	// Clicks land on the text beside the gutter
+	if err := ed.render(); err != nil {
+		t.Fatalf("render() error = %v", err)
+	}
+	viewport := ed.layout.CalculateViewport(0, ed.buffer.LineCount())
+	y := ed.layout.GetEditAreaRegion().Y
+	_, _, gutter := gutterColumns(ed.bookmarkMarks(), ed.foldRanges())
+	if got := ed.bufferPosition(gutter+1, y, viewport); got.Col != 1 {
+		t.Errorf("click beside the gutter: column = %d, want 1", got.Col)
+	}
+}
+
+func TestEditor_JumpList(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	ed.buffer.SetLines([]string{"one", "two", "three", "four"})
+	press := func(a terminal.KeyAction) {
+		t.Helper()
+		if err := ed.handleKeyEvent(&terminal.KeyEvent{Action: a}); err != nil {
+			t.Fatalf("handleKeyEvent(%v) error = %v", a, err)
+		}
+	}
+	cursor := func() buffer.Position { return ed.buffer.GetCursor() }
+
+	ed.buffer.MoveCursor(buffer.Position{Line: 1, Col: 2})
+	press(terminal.KeyActionDocumentEnd)
+	if got, want := cursor(), (buffer.Position{Line: 3, Col: 4}); got != want {
+		t.Fatalf("after document-end: cursor = %v, want %v", got, want)
+	}
+	press(terminal.KeyActionDocumentStart)
+
+	tests := []struct {
+		action terminal.KeyAction
+		want   buffer.Position
+	}{
+		{terminal.KeyActionJumpBack, buffer.Position{Line: 3, Col: 4}},
+		{terminal.KeyActionJumpBack, buffer.Position{Line: 1, Col: 2}},
+		{terminal.KeyActionJumpBack, buffer.Position{Line: 1, Col: 2}}, // Nothing earlier
+		{terminal.KeyActionJumpForward, buffer.Position{Line: 3, Col: 4}},
+		{terminal.KeyActionJumpForward, buffer.Position{}},
+		{terminal.KeyActionJumpForward, buffer.Position{}}, // Nothing later
+	}
+	for i, tt := range tests {
+		press(tt.action)
+		if got := cursor(); got != tt.want {
+			t.Errorf("step %d (%v): cursor = %v, want %v", i, tt.action, got, tt.want)
+		}
+	}
+
+	// A new jump drops the positions ahead
+	press(terminal.KeyActionJumpBack)
+	press(terminal.KeyActionJumpBack)
+	press(terminal.KeyActionDocumentStart)
+	press(terminal.KeyActionJumpForward)
+	if got := cursor(); got != (buffer.Position{}) {
+		t.Errorf("jump-forward after a new jump: cursor = %v, want start", got)
+	}
+	press(terminal.KeyActionJumpBack)
+	if got, want := cursor(), (buffer.Position{Line: 1, Col: 2}); got != want {
+		t.Errorf("jump-back after a new jump: cursor = %v, want %v", got, want)
+	}
+}
+
+func TestEditor_BookmarksPersist(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "state.json")
+	path := filepath.Join(dir, "notes.txt")
+	os.WriteFile(path, []byte("a\nb\nc\n"), 0644)
+
+	if err := ed.SetStatePath(statePath); err != nil {
+		t.Fatalf("SetStatePath() error = %v", err)
+	}
+	if err := ed.OpenFile(path); err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	ed.buffer.SetBookmark("3", buffer.Position{Line: 2})
+	ed.buffer.SetBookmark("todo", buffer.Position{Line: 1, Col: 1})
+	if err := ed.saveState(); err != nil {
+		t.Fatalf("saveState() error = %v", err)
+	}
+	ed.screen.Fini()
+
+	// A later session restores them when the file is opened
+	next, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer next.screen.Fini()
+	if err := next.SetStatePath(statePath); err != nil {
+		t.Fatalf("SetStatePath() error = %v", err)
+	}
+	if err := next.OpenFile(path); err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+
+	want := []buffer.Bookmark{
+		{Name: "todo", Pos: buffer.Position{Line: 1, Col: 1}},
+		{Name: "3", Pos: buffer.Position{Line: 2}},
+	}
+	if got := next.buffer.Bookmarks(); !slices.Equal(got, want) {
+		t.Errorf("bookmarks = %v, want %v", got, want)
+	}
+
+	// A new file has none, and the file's come back when it is reopened
+	next.handleNew()
+	if got := next.buffer.Bookmarks(); len(got) != 0 {
+		t.Errorf("new file bookmarks = %v, want none", got)
+	}
+	if err := next.OpenFile(path); err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	if got := next.buffer.Bookmarks(); !slices.Equal(got, want) {
+		t.Errorf("reopened bookmarks = %v, want %v", got, want)
+	}
+}
+
+func TestEditor_Folding(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	ed.filePath = "main.go"
+	ed.buffer.SetLines([]string{
+		"func a() {", // 0
+		"\tif x {",   // 1
+		"\t\ty()",    // 2
+		"\t}",        // 3
+		"}",          // 4
+		"func b() {", // 5
+		"\tz()",      // 6
+		"}",          // 7
+	})
+	press := func(a terminal.KeyAction) {
+		t.Helper()
+		if err := ed.handleKeyEvent(&terminal.KeyEvent{Action: a}); err != nil {
+			t.Fatalf("handleKeyEvent(%v) error = %v", a, err)
+		}
+	}
+	at := func(line int) {
+		ed.buffer.MoveCursor(buffer.Position{Line: line})
+	}
+
+	// Folding takes the innermost block, then the one around it
+	at(2)
+	press(terminal.KeyActionFold)
+	if got, want := ed.buffer.Folds(), []buffer.FoldRange{{Start: 1, End: 2}}; !slices.Equal(got, want) {
+		t.Errorf("after fold: folds = %v, want %v", got, want)
+	}
+	if got := ed.buffer.GetCursor().Line; got != 1 {
+		t.Errorf("after fold: cursor line = %d, want 1", got)
+	}
+	press(terminal.KeyActionFold)
+	if !ed.buffer.IsFolded(0) {
+		t.Error("second fold did not fold the enclosing block")
+	}
+	if got := ed.buffer.GetCursor().Line; got != 0 {
+		t.Errorf("after second fold: cursor line = %d, want 0", got)
+	}
+
+	// The cursor steps over folded lines
+	press(terminal.KeyActionMoveDown)
+	if got := ed.buffer.GetCursor().Line; got != 4 {
+		t.Errorf("down over a fold: line = %d, want 4", got)
+	}
+
+	// Unfolding shows the block again
+	at(0)
+	press(terminal.KeyActionUnfold)
+	if ed.buffer.IsFolded(0) || !ed.buffer.IsFolded(1) {
+		t.Errorf("after unfold: folds = %v, want only the inner one", ed.buffer.Folds())
+	}
+	press(terminal.KeyActionUnfold)
+	if got := ed.statusMessage; got != "No fold here" {
+		t.Errorf("unfold with no fold: status = %q", got)
+	}
+
+	press(terminal.KeyActionFoldAll)
+	want := []buffer.FoldRange{{Start: 0, End: 3}, {Start: 1, End: 2}, {Start: 5, End: 6}}
+	if got := ed.buffer.Folds(); !slices.Equal(got, want) {
+		t.Errorf("after fold-all: folds = %v, want %v", got, want)
+	}
+	wantHidden := []layout.LineRange{{First: 1, Last: 3}, {First: 6, Last: 6}}
+	if got := ed.hiddenLines(); !slices.Equal(got, wantHidden) {
+		t.Errorf("hidden lines = %v, want %v", got, wantHidden)
+	}
+	press(terminal.KeyActionUnfoldAll)
+	if got := ed.buffer.Folds(); len(got) != 0 {
+		t.Errorf("after unfold-all: folds = %v, want none", got)
+	}
+
+	// Clicking a fold marker toggles its block
+	if err := ed.render(); err != nil {
+		t.Fatalf("render() error = %v", err)
+	}
+	_, foldCol, _ := gutterColumns(ed.bookmarkMarks(), ed.foldRanges())
+	viewport := ed.currentViewport()
+	x, y := ed.layout.GetGutterRegion().X+foldCol, ed.layout.GetEditAreaRegion().Y+ed.layout.RowOfLine(viewport, 5)
+	click := func() {
+		ed.handleMouseEvent(tcell.NewEventMouse(x, y, tcell.Button1, tcell.ModNone))
+		ed.handleMouseEvent(tcell.NewEventMouse(x, y, tcell.ButtonNone, tcell.ModNone))
+	}
+	click()
+	if !ed.buffer.IsFolded(5) {
+		t.Error("clicking the fold marker did not fold the block")
+	}
+	click()
+	if ed.buffer.IsFolded(5) {
+		t.Error("clicking the fold marker again did not unfold the block")
+	}
+}
+
+func TestHeadingFolds(t *testing.T) {
+	lines := []string{
+		"# Title",   // 0
+		"intro",     // 1
+		"## Part",   // 2
+		"text",      // 3
+		"",          // 4
+		"## Next",   // 5
+		"more",      // 6
+		"",          // 7
+		"# Another", // 8
+	}
+	want := []buffer.FoldRange{{Start: 0, End: 6}, {Start: 2, End: 3}, {Start: 5, End: 6}}
+	if got := (headingFolds{}).FoldRanges(lines); !slices.Equal(got, want) {
+		t.Errorf("FoldRanges() = %v, want %v", got, want)
+	}
+}
+
+func TestEditor_Completion(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	ed.buffer.SetLines([]string{"counter countAll", "countAll", ""})
+	ed.buffer.MoveCursor(buffer.Position{Line: 2})
+	press := func(a terminal.KeyAction) {
+		t.Helper()
+		if err := ed.handleKeyEvent(&terminal.KeyEvent{Action: a}); err != nil {
+			t.Fatalf("handleKeyEvent(%v) error = %v", a, err)
+		}
+	}
+	typeText := func(s string) {
+		t.Helper()
+		for _, r := range s {
+			if err := ed.handleKeyEvent(&terminal.KeyEvent{Action: terminal.KeyActionCharacter, Character: r}); err != nil {
+				t.Fatalf("typing %q: error = %v", r, err)
+			}
+		}
+	}
+	selected := func() string {
+		item, _ := ed.completion.Selected()
+		return item.Text
+	}
+
+	// One character is not enough to offer completions unasked
+	typeText("c")
+	if ed.completion.IsOpen() {
+		t.Error("popup opened after one character")
+	}
+	typeText("o")
+	if !ed.completion.IsOpen() || selected() != "countAll" {
+		t.Fatalf("after typing \"co\": open = %v, selected = %q, want countAll", ed.completion.IsOpen(), selected())
+	}
+
+	// The arrows choose a completion without moving the cursor
+	press(terminal.KeyActionMoveDown)
+	if got := selected(); got != "counter" {
+		t.Errorf("after down: selected = %q, want counter", got)
+	}
+	if got := ed.buffer.GetCursor(); got != (buffer.Position{Line: 2, Col: 2}) {
+		t.Errorf("cursor moved to %v", got)
+	}
+
+	// Tab accepts, and one undo takes the completion back
+	press(terminal.KeyActionIndent)
+	if got, _ := ed.buffer.GetLine(2); got != "counter" || ed.completion.IsOpen() {
+		t.Errorf("after accepting: line = %q, open = %v", got, ed.completion.IsOpen())
+	}
+	press(terminal.KeyActionUndo)
+	if got, _ := ed.buffer.GetLine(2); got != "co" {
+		t.Errorf("after undo: line = %q, want %q", got, "co")
+	}
+
+	// Backspace follows the shorter word; Esc and other keys close the popup
+	ed.buffer.MoveCursor(buffer.Position{Line: 2, Col: 2})
+	typeText("u")
+	press(terminal.KeyActionBackspace)
+	if !ed.completion.IsOpen() || ed.completion.Anchor() != (buffer.Position{Line: 2}) {
+		t.Errorf("after backspace: open = %v, anchor = %v", ed.completion.IsOpen(), ed.completion.Anchor())
+	}
+	press(terminal.KeyActionEscape)
+	if ed.completion.IsOpen() {
+		t.Error("popup still open after Esc")
+	}
+	typeText("u")
+	press(terminal.KeyActionMoveLeft)
+	if ed.completion.IsOpen() {
+		t.Error("popup still open after moving the cursor")
+	}
+	typeText(" ")
+	if ed.completion.IsOpen() {
+		t.Error("popup open after typing a space")
+	}
+
+	// Asking shows completions for a single character, and Enter accepts
+	ed.buffer.SetLines([]string{"countAll", ""})
+	ed.buffer.MoveCursor(buffer.Position{Line: 1})
+	typeText("c")
+	press(terminal.KeyActionComplete)
+	press(terminal.KeyActionEnter)
+	if got, _ := ed.buffer.GetLine(1); got != "countAll" {
+		t.Errorf("after completing a single character: line = %q, want countAll", got)
+	}
+}
+
+func TestEditor_Snippets(t *testing.T) {
+	ed, err := NewEditor()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer ed.screen.Fini()
+
+	noSpaces := false
+	ed.filePath = filepath.Join(t.TempDir(), "main.go")
+	ed.config.Languages["go"] = config.LanguageSettings{UseSpaces: &noSpaces}
+	ed.config.Snippets["go"]["fori"] = "for ${1:i} := 0; $1 < ${2:n}; $1++ {\n\t$0\n}"
+	ed.config.Snippets["go"]["typ"] = "${1|int,string|} $TM_FILENAME_BASE"
+	press := func(a terminal.KeyAction) {
+		t.Helper()
+		if err := ed.handleKeyEvent(&terminal.KeyEvent{Action: a}); err != nil {
+			t.Fatalf("handleKeyEvent(%v) error = %v", a, err)
+		}
+	}
+	typeText := func(s string) {
+		t.Helper()
+		for _, r := range s {
+			if err := ed.handleKeyEvent(&terminal.KeyEvent{Action: terminal.KeyActionCharacter, Character: r}); err != nil {
+				t.Fatalf("typing %q: error = %v", r, err)
+			}
+		}
+	}
+	expand := func(lines []string, cursor buffer.Position) {
+		t.Helper()
+		ed.buffer.SetLines(lines)
+		ed.buffer.MoveCursor(cursor)
+		press(terminal.KeyActionIndent)
+	}
+	selected := func() string {
+		if !ed.hasSelection {
+			return ""
+		}
+		start, end := ed.getSelectionRange()
+		text, _ := ed.buffer.GetText(start, end)
+		return text
+	}
+
+	// Tab expands a prefix, indented like its line, and selects the first
+	// placeholder, which typing replaces
+	expand([]string{"func f() {", "\tiferr", "}"}, buffer.Position{Line: 1, Col: 6})
+	if got, want := ed.buffer.GetAllLines(), []string{"func f() {", "\tif err != nil {", "\t\treturn err", "\t}", "}"}; !slices.Equal(got, want) {
+		t.Fatalf("after expanding iferr: lines = %q, want %q", got, want)
+	}
+	if got := selected(); got != "err" {
+		t.Errorf("selected %q, want the placeholder err", got)
+	}
+	typeText("fmt.Errorf(\"f: %w\", err)")
+	press(terminal.KeyActionIndent)
+	if got, _ := ed.buffer.GetLine(2); got != "\t\treturn fmt.Errorf(\"f: %w\", err)" {
+		t.Errorf("after typing over the placeholder: line = %q", got)
+	}
+	if got := ed.buffer.GetCursor(); got != (buffer.Position{Line: 3, Col: 2}) || ed.snippet != nil {
+		t.Errorf("after the last tab stop: cursor = %v, session = %v, want the end and none", got, ed.snippet)
+	}
+
+	// One undo takes the whole snippet back
+	press(terminal.KeyActionUndo)
+	if got, want := ed.buffer.GetAllLines(), []string{"func f() {", "\tiferr", "}"}; !slices.Equal(got, want) {
+		t.Errorf("after undo: lines = %q, want %q", got, want)
+	}
+
+	// Mirrors follow their tab stop, and Shift+Tab goes back
+	expand([]string{"fori"}, buffer.Position{Col: 4})
+	typeText("j")
+	if got, _ := ed.buffer.GetLine(0); got != "for j := 0; j < n; j++ {" {
+		t.Errorf("after typing in a mirrored tab stop: line = %q", got)
+	}
+	press(terminal.KeyActionIndent)
+	if got := selected(); got != "n" {
+		t.Errorf("second tab stop selected %q, want n", got)
+	}
+	press(terminal.KeyActionBackspace)
+	typeText("10")
+	press(terminal.KeyActionOutdent)
+	typeText("k")
+	if got, _ := ed.buffer.GetLine(0); got != "for k := 0; k < 10; k++ {" {
+		t.Errorf("after going back: line = %q", got)
+	}
+	press(terminal.KeyActionIndent)
+	press(terminal.KeyActionIndent)
+	if got := ed.buffer.GetCursor(); got != (buffer.Position{Line: 1, Col: 1}) || ed.snippet != nil {
+		t.Errorf("at $0: cursor = %v, session = %v", got, ed.snippet)
+	}
+	press(terminal.KeyActionUndo)
+	if got := ed.buffer.GetAllLines(); !slices.Equal(got, []string{"fori"}) {
+		t.Errorf("after undo: lines = %q", got)
+	}
+
+	// Choices are offered in the popup; variables are filled in
+	expand([]string{"typ"}, buffer.Position{Col: 3})
+	if got, _ := ed.buffer.GetLine(0); got != "int main" || !ed.completion.IsOpen() {
+		t.Fatalf("after expanding typ: line = %q, popup open = %v", got, ed.completion.IsOpen())
+	}
+	press(terminal.KeyActionMoveDown)
+	press(terminal.KeyActionIndent)
+	if got, _ := ed.buffer.GetLine(0); got != "string main" {
+		t.Errorf("after choosing: line = %q", got)
+	}
+
+	// Esc or leaving the snippet stops filling it in; without a prefix Tab
+	// indents
+	press(terminal.KeyActionEscape)
+	if ed.snippet != nil {
+		t.Error("snippet still being filled in after Esc")
+	}
+	expand([]string{"fori", "after"}, buffer.Position{Col: 4})
+	press(terminal.KeyActionDocumentEnd)
+	if ed.snippet != nil {
+		t.Error("snippet still being filled in after leaving it")
+	}
+	expand([]string{"x"}, buffer.Position{Col: 1})
+	if got, _ := ed.buffer.GetLine(0); got != "x\t" {
+		t.Errorf("Tab without a prefix: line = %q, want an indent", got)
+	}
+}
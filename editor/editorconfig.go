@@ -0,0 +1,98 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/core/file"
+	"github.com/AndrewDonelson/ted/core/history"
+)
+
+// findEditorConfig returns the .editorconfig properties for the file at
+// path. Problems reading the files are shown in the info bar and give no
+// properties.
+func (e *Editor) findEditorConfig(path string) *file.EditorConfig {
+	if path == "" {
+		return &file.EditorConfig{}
+	}
+	ec, err := file.FindEditorConfig(path)
+	if err != nil {
+		e.setStatus(fmt.Sprintf("EditorConfig: %v", err))
+		return &file.EditorConfig{}
+	}
+	return ec
+}
+
+// applyEditorConfig makes ec the properties of the current file. Its line
+// ending and charset replace those the file was read with.
+func (e *Editor) applyEditorConfig(ec *file.EditorConfig) {
+	e.editorConfig = ec
+	if ec.EndOfLine != "" {
+		e.lineEnding = ec.EndOfLine
+	}
+	if ec.Charset != "" {
+		e.file.Encoding = ec.Charset.String()
+	}
+}
+
+// saveOptions returns how the current file is saved.
+func (e *Editor) saveOptions() file.SaveOptions {
+	opts := file.SaveOptions{LineEnding: e.lineEnding}
+	if ec := e.editorConfig; ec != nil {
+		opts.Charset = ec.Charset
+		opts.TrimTrailingWhitespace = ec.TrimTrailingWhitespace != nil && *ec.TrimTrailingWhitespace
+		if v := ec.InsertFinalNewline; v != nil {
+			opts.FinalNewline = file.FinalNewlineRemove
+			if *v {
+				opts.FinalNewline = file.FinalNewlineInsert
+			}
+		}
+	}
+	return opts
+}
+
+// applySavedLines makes the buffer match saved, its lines as the save
+// options wrote them, so what is shown is what is on disk. The trimmed
+// whitespace and the added or removed final newline are one undoable edit.
+func (e *Editor) applySavedLines(saved []string) {
+	lines := e.buffer.GetAllLines()
+	if len(lines) == 0 || len(saved) == 0 {
+		return
+	}
+	cursor := e.buffer.GetCursor()
+	edit := &history.CompositeOperation{}
+	edit.SetDescription("format on save")
+
+	// Whitespace trimmed from line ends
+	for i := 0; i < min(len(lines), len(saved)); i++ {
+		if lines[i] == saved[i] || !strings.HasPrefix(lines[i], saved[i]) {
+			continue
+		}
+		start := buffer.Position{Line: i, Col: len(saved[i])}
+		end := buffer.Position{Line: i, Col: len(lines[i])}
+		if err := e.buffer.Delete(start, end); err == nil {
+			edit.Operations = append(edit.Operations, &history.DeleteOperation{StartPos: start, EndPos: end, Deleted: lines[i][len(saved[i]):]})
+		}
+	}
+
+	// The final newline is an empty last line
+	switch last := len(lines) - 1; {
+	case len(saved) == len(lines)+1:
+		pos := buffer.Position{Line: last, Col: len(saved[last])}
+		if err := e.buffer.Insert(pos, "\n"); err == nil {
+			edit.Operations = append(edit.Operations, &history.InsertOperation{Pos: pos, Text: "\n"})
+		}
+	case len(saved) == len(lines)-1:
+		start := buffer.Position{Line: last - 1, Col: len(saved[last-1])}
+		end := buffer.Position{Line: last, Col: 0}
+		if err := e.buffer.Delete(start, end); err == nil {
+			edit.Operations = append(edit.Operations, &history.DeleteOperation{StartPos: start, EndPos: end, Deleted: "\n"})
+		}
+	}
+
+	if len(edit.Operations) > 0 {
+		e.history.Push(edit)
+		e.buffer.MoveCursor(cursor)
+	}
+}
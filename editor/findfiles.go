@@ -0,0 +1,252 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/search"
+	"github.com/AndrewDonelson/ted/ui/dialog"
+	"github.com/AndrewDonelson/ted/ui/terminal"
+	"github.com/gdamore/tcell/v2"
+)
+
+// fileSearch is a find in files search running in the background.
+// Results are queued here and the event loop is woken to collect them,
+// so a busy search cannot overflow the event queue.
+type fileSearch struct {
+	dlg    *dialog.FindInFilesDialog
+	cancel context.CancelFunc
+	screen terminal.Screen
+
+	// plan turns each result into a planned replacement in replace mode;
+	// it is nil when only searching
+	plan func(search.FileResult) (*search.FileEdit, error)
+
+	mu       sync.Mutex
+	pending  []search.FileResult
+	edits    []*search.FileEdit
+	done     bool
+	err      error
+	notified bool // A fileSearchEvent is queued and not yet handled
+}
+
+// fileSearchEvent wakes the event loop to collect queued results.
+type fileSearchEvent struct {
+	tcell.EventTime
+	owner *fileSearch
+}
+
+// add queues a result from the search goroutine. In replace mode the
+// replacement is planned here so reading the file stays off the event loop;
+// files that cannot be planned are skipped.
+func (s *fileSearch) add(result search.FileResult) {
+	var edit *search.FileEdit
+	if s.plan != nil {
+		var err error
+		if edit, err = s.plan(result); err != nil || len(edit.Hunks) == 0 {
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if edit != nil {
+		s.edits = append(s.edits, edit)
+	} else {
+		s.pending = append(s.pending, result)
+	}
+	s.notify()
+}
+
+// finish records the search error and wakes the event loop one last time.
+func (s *fileSearch) finish(err error) {
+	s.mu.Lock()
+	s.done = true
+	s.err = err
+	s.notified = false
+	s.mu.Unlock()
+
+	// The final event must arrive, so retry while the queue is full
+	for i := 0; i < 100; i++ {
+		ev := &fileSearchEvent{owner: s}
+		ev.SetEventNow()
+		if s.screen.PostEvent(ev) == nil {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// notify posts a wake-up event unless one is already queued.
+// The caller must hold s.mu.
+func (s *fileSearch) notify() {
+	if s.notified {
+		return
+	}
+	ev := &fileSearchEvent{owner: s}
+	ev.SetEventNow()
+	// If the queue is full, the next result or finish tries again
+	s.notified = s.screen.PostEvent(ev) == nil
+}
+
+// take removes and returns the queued results and edits and the final
+// state.
+func (s *fileSearch) take() ([]search.FileResult, []*search.FileEdit, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results, edits := s.pending, s.edits
+	s.pending, s.edits = nil, nil
+	s.notified = false
+	return results, edits, s.done, s.err
+}
+
+// handleFindInFiles shows the find in files panel.
+// It searches the directory of the open file, or the working directory.
+func (e *Editor) handleFindInFiles() error {
+	root, pattern, options := e.fileSearchDefaults()
+
+	var dlg *dialog.FindInFilesDialog
+	dlg = dialog.NewFindInFilesDialog(root, pattern, options,
+		func(root, pattern string, options search.Options) {
+			e.startFileSearch(dlg, root, pattern, options)
+		},
+		e.stopFileSearch,
+		e.openFileResult,
+		e.stopFileSearch,
+	)
+
+	width, height := e.screen.GetSize()
+	e.dialogManager.Push(dlg, width, height)
+	return nil
+}
+
+// fileSearchDefaults returns the initial root, pattern and options of the
+// find and replace in files panels: the open file's directory (or the
+// working directory), a single-line selection or the last pattern, and the
+// find options that apply to files.
+func (e *Editor) fileSearchDefaults() (string, string, search.Options) {
+	root := "."
+	if e.filePath != "" {
+		root = filepath.Dir(e.filePath)
+	} else if wd, err := os.Getwd(); err == nil {
+		root = wd
+	}
+
+	pattern := e.searchManager.GetPattern()
+	if start, end := e.getSelectionRange(); e.hasSelection && start.Line == end.Line && start != end {
+		if text, err := e.buffer.GetText(start, end); err == nil {
+			pattern = text
+		}
+	}
+
+	options := e.searchManager.GetOptions()
+	options.InSelection = false
+	options.PreserveCase = false
+	options.Fuzzy = false
+	return root, pattern, options
+}
+
+// startFileSearch starts searching root in the background, showing the
+// results in dlg. Any search already running is stopped.
+func (e *Editor) startFileSearch(dlg *dialog.FindInFilesDialog, root, pattern string, options search.Options) {
+	e.stopFileSearch()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &fileSearch{
+		dlg:    dlg,
+		cancel: cancel,
+		screen: e.screen,
+	}
+	// Searches from the panels are remembered like those from Find
+	e.searchManager.GetFinder().SetPattern(pattern)
+	if dlg.IsReplaceMode() {
+		replacement := dlg.GetReplacement()
+		e.searchManager.SetReplacement(replacement)
+		s.plan = func(result search.FileResult) (*search.FileEdit, error) {
+			return search.PlanFileReplace(result, pattern, replacement, options)
+		}
+	}
+	e.fileSearch = s
+
+	go func() {
+		s.finish(search.SearchFiles(ctx, root, pattern, options, s.add))
+	}()
+}
+
+// stopFileSearch cancels the running search, if any. Results found so far
+// stay in the panel.
+func (e *Editor) stopFileSearch() {
+	if e.fileSearch != nil {
+		e.fileSearch.cancel()
+	}
+}
+
+// handleFileSearchEvent moves queued results into the panel and reports
+// the outcome when the search has finished.
+func (e *Editor) handleFileSearchEvent(ev *fileSearchEvent) {
+	s := ev.owner
+	if s != e.fileSearch {
+		return // Superseded by a newer search
+	}
+
+	results, edits, done, err := s.take()
+	for _, result := range results {
+		s.dlg.AddResult(result)
+	}
+	for _, edit := range edits {
+		s.dlg.AddEdit(edit)
+	}
+	if !done {
+		return
+	}
+
+	files, matches := s.dlg.Counts()
+	switch {
+	case errors.Is(err, context.Canceled):
+		s.dlg.FinishSearch(fmt.Sprintf("Stopped: %d matches in %d files", matches, files))
+	case err != nil:
+		s.dlg.FinishSearch(fmt.Sprintf("Search failed: %v", err))
+	default:
+		s.dlg.FinishSearch("")
+	}
+	s.cancel()
+	e.fileSearch = nil
+}
+
+// openFileResult opens the file holding a find in files result and
+// selects the match.
+func (e *Editor) openFileResult(result search.FileResult, match search.Match) {
+	e.stopFileSearch()
+
+	path, err := filepath.Abs(result.Path)
+	if err != nil {
+		path = result.Path
+	}
+	current, _ := filepath.Abs(e.filePath)
+
+	if e.filePath == "" || path != current {
+		if e.buffer.IsModified() {
+			e.statusMessage = fmt.Sprintf("Save changes before opening %s", result.RelPath)
+			return
+		}
+		if err := e.OpenFile(path); err != nil {
+			e.statusMessage = fmt.Sprintf("Open failed: %v", err)
+			return
+		}
+	}
+
+	start := buffer.Position{Line: match.StartLine, Col: match.StartCol}
+	end := buffer.Position{Line: match.EndLine, Col: match.EndCol}
+	e.buffer.MoveCursor(end)
+	e.selectionStart = start
+	e.selectionEnd = end
+	e.hasSelection = start != end
+}
@@ -0,0 +1,238 @@
+package editor
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/search"
+	"github.com/AndrewDonelson/ted/ui/layout"
+)
+
+const (
+	// foldableMark marks a line that can be folded in the gutter.
+	foldableMark = '▾'
+
+	// foldedMark marks a folded line in the gutter.
+	foldedMark = '▸'
+)
+
+// foldCache holds the fold ranges found in a buffer, so they are only
+// found again after an edit.
+type foldCache struct {
+	buffer   *buffer.Buffer
+	revision int
+	fileType string
+	tabSize  int
+	ranges   []buffer.FoldRange
+}
+
+// foldProvider returns how the regions of the file that can be folded are
+// found: brackets for C-like languages, headings for Markdown and
+// indentation for anything else.
+func (e *Editor) foldProvider() buffer.FoldProvider {
+	switch e.detectFileType() {
+	case "Go", "JavaScript", "TypeScript":
+		return buffer.BracketFolds{}
+	case "Markdown":
+		return headingFolds{}
+	}
+	return buffer.IndentFolds{TabSize: e.editorSettings().TabSize}
+}
+
+// foldRanges returns the regions of the buffer that can be folded, in the
+// order SortFoldRanges gives.
+func (e *Editor) foldRanges() []buffer.FoldRange {
+	c := &e.folds
+	fileType, tabSize := e.detectFileType(), e.editorSettings().TabSize
+	if c.buffer != e.buffer || c.revision != e.buffer.Revision() || c.fileType != fileType || c.tabSize != tabSize {
+		*c = foldCache{
+			buffer:   e.buffer,
+			revision: e.buffer.Revision(),
+			fileType: fileType,
+			tabSize:  tabSize,
+			ranges:   e.foldProvider().FoldRanges(e.buffer.GetAllLines()),
+		}
+	}
+	return c.ranges
+}
+
+// headingFolds finds foldable regions of Markdown from its headings: a
+// heading folds its section, up to the next heading of the same or a
+// higher level.
+type headingFolds struct{}
+
+// FoldRanges returns the sections under the headings in lines.
+func (headingFolds) FoldRanges(lines []string) []buffer.FoldRange {
+	var headings []search.Symbol
+	for _, sym := range search.ExtractSymbols(lines, "Markdown") {
+		if sym.Kind == search.SymbolHeading {
+			headings = append(headings, sym)
+		}
+	}
+
+	var ranges []buffer.FoldRange
+	for i, h := range headings {
+		end := len(lines) - 1
+		for _, next := range headings[i+1:] {
+			if next.Level <= h.Level {
+				end = next.Line - 1
+				break
+			}
+		}
+		// Blank lines before the next heading stay in view
+		for end > h.Line && strings.TrimSpace(lines[end]) == "" {
+			end--
+		}
+		if end > h.Line {
+			ranges = append(ranges, buffer.FoldRange{Start: h.Line, End: end})
+		}
+	}
+	return buffer.SortFoldRanges(ranges)
+}
+
+// handleToggleFold unfolds the fold at the cursor's line, or folds the
+// block holding it.
+func (e *Editor) handleToggleFold() {
+	if !e.buffer.Unfold(e.buffer.GetCursor().Line) {
+		e.handleFold()
+	}
+}
+
+// handleFold folds the innermost block holding the cursor's line that is
+// not folded yet.
+func (e *Editor) handleFold() {
+	line := e.buffer.GetCursor().Line
+	ranges := e.foldRanges()
+	// Later ranges are nested in earlier ones
+	for i := len(ranges) - 1; i >= 0; i-- {
+		r := ranges[i]
+		if r.Start <= line && line <= r.End && !e.buffer.IsFolded(r.Start) {
+			e.clearSelection()
+			e.buffer.Fold(r)
+			return
+		}
+	}
+	e.setStatus("No block to fold here")
+}
+
+// handleUnfold unfolds the fold at the cursor's line.
+func (e *Editor) handleUnfold() {
+	if !e.buffer.Unfold(e.buffer.GetCursor().Line) {
+		e.setStatus("No fold here")
+	}
+}
+
+// handleFoldAll folds every block in the file.
+func (e *Editor) handleFoldAll() {
+	ranges := e.foldRanges()
+	if len(ranges) == 0 {
+		e.setStatus("No blocks to fold")
+		return
+	}
+	e.clearSelection()
+	for _, r := range ranges {
+		e.buffer.Fold(r)
+	}
+}
+
+// handleUnfoldAll unfolds every fold in the file.
+func (e *Editor) handleUnfoldAll() {
+	e.buffer.UnfoldAll()
+}
+
+// gutterColumns returns the gutter column of bookmark markers and of fold
+// markers, each -1 if not shown, and the gutter's width. Each column is
+// only shown while it has markers; a space separates them from the text.
+func gutterColumns(bookmarks map[int]rune, ranges []buffer.FoldRange) (bookmarkCol, foldCol, width int) {
+	bookmarkCol, foldCol = -1, -1
+	if len(bookmarks) > 0 {
+		bookmarkCol = width
+		width++
+	}
+	if len(ranges) > 0 {
+		foldCol = width
+		width++
+	}
+	if width > 0 {
+		width++
+	}
+	return bookmarkCol, foldCol, width
+}
+
+// setGutter lays out the gutter and gives the renderer its markers.
+func (e *Editor) setGutter() {
+	bookmarks := e.bookmarkMarks()
+	ranges := e.foldRanges()
+	bookmarkCol, foldCol, width := gutterColumns(bookmarks, ranges)
+	e.layout.SetGutterWidth(width)
+	if width == 0 {
+		e.renderer.SetGutterMarks(nil)
+		return
+	}
+
+	cells := make(map[int][]rune)
+	cell := func(line int) []rune {
+		if cells[line] == nil {
+			cells[line] = slices.Repeat([]rune{' '}, width-1)
+		}
+		return cells[line]
+	}
+	for line, mark := range bookmarks {
+		cell(line)[bookmarkCol] = mark
+	}
+	if foldCol >= 0 {
+		for _, r := range ranges {
+			cell(r.Start)[foldCol] = foldableMark
+		}
+		for _, r := range e.buffer.Folds() {
+			cell(r.Start)[foldCol] = foldedMark
+		}
+	}
+
+	marks := make(map[int]string, len(cells))
+	for line, c := range cells {
+		marks[line] = string(c)
+	}
+	e.renderer.SetGutterMarks(marks)
+}
+
+// hiddenLines returns the runs of lines hidden in folds, for the layout.
+func (e *Editor) hiddenLines() []layout.LineRange {
+	var hidden []layout.LineRange
+	for _, r := range e.buffer.Folds() {
+		if n := len(hidden); n > 0 && r.Start <= hidden[n-1].Last {
+			// Nested in, or hidden by, the last run
+			hidden[n-1].Last = max(hidden[n-1].Last, r.End)
+			continue
+		}
+		hidden = append(hidden, layout.LineRange{First: r.Start + 1, Last: r.End})
+	}
+	return hidden
+}
+
+// foldMarkerAt reports whether screen x, y is on the fold marker column of
+// the gutter.
+func (e *Editor) foldMarkerAt(x, y int) bool {
+	_, foldCol, _ := gutterColumns(e.bookmarkMarks(), e.foldRanges())
+	gutter := e.layout.GetGutterRegion()
+	return foldCol >= 0 && foldCol < gutter.Width && x == gutter.X+foldCol && y >= gutter.Y && y < gutter.Y+gutter.Height
+}
+
+// handleFoldClick folds or unfolds the block whose header is at screen
+// row y, as clicking its gutter marker does.
+func (e *Editor) handleFoldClick(y int) {
+	viewport := e.currentViewport()
+	e.layout.ScrollTo(viewport.StartLine)
+	line, _ := e.screenCell(0, y, viewport)
+	if e.buffer.Unfold(line) {
+		return
+	}
+	ranges := e.foldRanges()
+	i, ok := slices.BinarySearchFunc(ranges, line, func(r buffer.FoldRange, line int) int { return r.Start - line })
+	if !ok {
+		return
+	}
+	e.clearSelection()
+	e.buffer.Fold(ranges[i])
+}
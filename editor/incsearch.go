@@ -0,0 +1,266 @@
+package editor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/search"
+	"github.com/AndrewDonelson/ted/ui/dialog"
+	"github.com/AndrewDonelson/ted/ui/renderer"
+	"github.com/gdamore/tcell/v2"
+)
+
+// incrementalSyncLimit is the buffer size in bytes up to which incremental
+// search runs on every keystroke. Larger buffers are searched in the
+// background once typing pauses, so the editor stays responsive.
+const incrementalSyncLimit = 1 << 20
+
+// incrementalDebounce is how long typing must pause before a background
+// search starts.
+const incrementalDebounce = 150 * time.Millisecond
+
+// incrementalSearch is the state of a search-as-you-type session, which
+// lasts while the find or replace dialog is open.
+type incrementalSearch struct {
+	dlg    *dialog.SearchDialog
+	origin buffer.Position    // Cursor position when the dialog opened
+	gen    int                // Incremented per search; stale results are dropped
+	cancel context.CancelFunc // Cancels the pending background search
+	timer  *time.Timer        // Debounce timer for the pending background search
+}
+
+// stop cancels any pending background search.
+func (s *incrementalSearch) stop() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+}
+
+// searchResultEvent delivers the result of a background search to the
+// event loop, which owns the buffer and the finder.
+type searchResultEvent struct {
+	tcell.EventTime
+	gen     int
+	pattern string
+	options search.Options
+	matches []search.Match
+	err     error
+}
+
+// scopeSearchToSelection limits searches to the current selection when
+// "In selection" is enabled. A selection spanning several lines turns the
+// option on by default; without a selection it is turned off.
+func (e *Editor) scopeSearchToSelection() {
+	finder := e.searchManager.GetFinder()
+	options := finder.GetOptions()
+
+	start, end := e.getSelectionRange()
+	if !e.hasSelection || start == end {
+		finder.ClearScope()
+		options.InSelection = false
+		finder.SetOptions(options)
+		return
+	}
+
+	finder.SetScope(start, end)
+	options.InSelection = start.Line != end.Line
+	finder.SetOptions(options)
+}
+
+// syncSelectionToScope moves the selection to the search scope after
+// replacements shifted it.
+func (e *Editor) syncSelectionToScope() {
+	start, end, ok := e.searchManager.GetFinder().GetScope()
+	if !ok || !e.hasSelection {
+		return
+	}
+	e.selectionStart = start
+	e.selectionEnd = end
+}
+
+// startIncrementalSearch makes dlg search as the user types.
+// If the dialog opens with a pattern, it is searched right away.
+func (e *Editor) startIncrementalSearch(dlg *dialog.SearchDialog) {
+	e.endIncrementalSearch(false)
+
+	e.incSearch = &incrementalSearch{
+		dlg:    dlg,
+		origin: e.buffer.GetCursor(),
+	}
+	dlg.SetOnChange(e.updateIncrementalSearch)
+
+	if pattern := dlg.GetSearchInput(); pattern != "" {
+		e.updateIncrementalSearch(pattern, dlg.GetOptions())
+	}
+}
+
+// endIncrementalSearch stops the session and removes the highlights.
+// If restore is true the cursor returns to where it was when the search began.
+func (e *Editor) endIncrementalSearch(restore bool) {
+	s := e.incSearch
+	if s == nil {
+		return
+	}
+
+	s.stop()
+	if restore {
+		e.buffer.MoveCursor(s.origin)
+	} else if e.buffer.GetCursor().Line != s.origin.Line {
+		e.jumps.push(s.origin) // Going back returns to where the search began
+	}
+	e.renderer.ClearHighlights()
+	e.incSearch = nil
+}
+
+// updateIncrementalSearch searches for pattern after the search field or
+// options change. Small buffers are searched immediately; large ones in the
+// background after a short debounce, cancelling any search still running.
+func (e *Editor) updateIncrementalSearch(pattern string, options search.Options) {
+	s := e.incSearch
+	if s == nil {
+		return
+	}
+
+	s.stop()
+	s.gen++
+
+	if pattern == "" {
+		e.renderer.ClearHighlights()
+		e.buffer.MoveCursor(s.origin)
+		s.dlg.SetMessage("")
+		return
+	}
+
+	// Snapshot the lines so a background search never touches the buffer
+	lines := e.buffer.GetAllLines()
+
+	if textSize(lines) <= incrementalSyncLimit {
+		matches, err := search.FindMatches(context.Background(), lines, pattern, options)
+		e.applySearchResults(pattern, options, matches, err)
+		return
+	}
+
+	s.dlg.SetMessage("Searching...")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	gen := s.gen
+	screen := e.screen
+
+	s.timer = time.AfterFunc(incrementalDebounce, func() {
+		matches, err := search.FindMatches(ctx, lines, pattern, options)
+		if ctx.Err() != nil {
+			return // Superseded by a newer search
+		}
+
+		ev := &searchResultEvent{gen: gen, pattern: pattern, options: options, matches: matches, err: err}
+		ev.SetEventNow()
+		screen.PostEvent(ev)
+	})
+}
+
+// handleSearchResult applies background search results unless they were
+// superseded by a later keystroke.
+func (e *Editor) handleSearchResult(ev *searchResultEvent) {
+	if e.incSearch == nil || ev.gen != e.incSearch.gen {
+		return
+	}
+	e.incSearch.cancel = nil
+	e.incSearch.timer = nil
+	e.applySearchResults(ev.pattern, ev.options, ev.matches, ev.err)
+}
+
+// applySearchResults moves the cursor to the match nearest the search
+// origin, highlights all matches and shows the match count in the dialog.
+func (e *Editor) applySearchResults(pattern string, options search.Options, matches []search.Match, err error) {
+	s := e.incSearch
+	finder := e.searchManager.GetFinder()
+
+	finder.SetOptions(options)
+	finder.SetMatches(pattern, matches)
+
+	if err != nil {
+		e.renderer.ClearHighlights()
+		e.buffer.MoveCursor(s.origin)
+		s.dlg.SetMessage("Invalid pattern")
+		return
+	}
+
+	// Fuzzy matches are ranked, so go to the best one rather than the nearest
+	var match search.Match
+	var found bool
+	if options.Fuzzy {
+		match, found = finder.FindBest()
+	} else {
+		match, found = finder.FindFrom(s.origin)
+	}
+	if !found {
+		e.renderer.ClearHighlights()
+		e.buffer.MoveCursor(s.origin)
+		s.dlg.SetMessage("No matches")
+		return
+	}
+
+	e.buffer.MoveCursor(buffer.Position{Line: match.StartLine, Col: match.StartCol})
+	e.updateSearchHighlights()
+}
+
+// updateSearchHighlights highlights the finder's matches, marks the current
+// one and shows "N of M" in the dialog.
+func (e *Editor) updateSearchHighlights() {
+	if e.incSearch == nil {
+		return
+	}
+
+	finder := e.searchManager.GetFinder()
+	current, total := e.searchManager.GetCurrentMatch()
+	if total == 0 {
+		e.renderer.ClearHighlights()
+		e.incSearch.dlg.SetMessage("No matches")
+		return
+	}
+
+	e.renderer.SetHighlights(matchHighlights(finder, current))
+	e.incSearch.dlg.SetMessage(fmt.Sprintf("%d of %d", current+1, total))
+}
+
+// refreshIncrementalSearch searches again from the cursor after the buffer
+// changed, such as after a replacement.
+func (e *Editor) refreshIncrementalSearch() {
+	s := e.incSearch
+	if s == nil {
+		return
+	}
+	s.origin = e.buffer.GetCursor()
+	e.updateIncrementalSearch(s.dlg.GetSearchInput(), s.dlg.GetOptions())
+}
+
+// matchHighlights converts the finder's matches to renderer highlights.
+func matchHighlights(finder *search.Finder, current int) []renderer.Highlight {
+	matches := finder.GetMatches()
+	highlights := make([]renderer.Highlight, len(matches))
+	for i, m := range matches {
+		highlights[i] = renderer.Highlight{
+			Start:   buffer.Position{Line: m.StartLine, Col: m.StartCol},
+			End:     buffer.Position{Line: m.EndLine, Col: m.EndCol},
+			Current: i == current,
+		}
+	}
+	return highlights
+}
+
+// textSize returns the size in bytes of lines joined by newlines.
+func textSize(lines []string) int {
+	size := 0
+	for _, line := range lines {
+		size += len(line) + 1
+	}
+	return size
+}
@@ -0,0 +1,214 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/core/history"
+	"github.com/AndrewDonelson/ted/ui/dialog"
+	"github.com/AndrewDonelson/ted/ui/layout"
+)
+
+// maxIndentChoice is the largest size offered when choosing the
+// indentation.
+const maxIndentChoice = 8
+
+// detectIndentation guesses the indentation of the file just loaded. The
+// guess is used unless the settings for the file choose one; a choice made
+// in the info bar for the previous file is forgotten.
+func (e *Editor) detectIndentation() {
+	e.indentOverride = nil
+	e.detectedIndent = nil
+	if indent, ok := e.buffer.DetectIndentation(); ok {
+		e.detectedIndent = &indent
+	}
+}
+
+// indentUnit returns the text one level of indentation adds.
+func (s fileSettings) indentUnit() string {
+	if s.UseSpaces {
+		return strings.Repeat(" ", s.IndentSize)
+	}
+	return "\t"
+}
+
+// setIndentation makes indent the indentation used. With tabs, its size
+// is also the tab size.
+func (s *fileSettings) setIndentation(indent buffer.Indentation) {
+	s.UseSpaces = indent.UseSpaces
+	if indent.Size > 0 {
+		s.IndentSize = indent.Size
+		if !indent.UseSpaces {
+			s.TabSize = indent.Size
+		}
+	}
+}
+
+// describeIndentation names an indentation as the info bar shows it.
+func describeIndentation(indent buffer.Indentation) string {
+	if indent.UseSpaces {
+		return fmt.Sprintf("Spaces: %d", indent.Size)
+	}
+	return fmt.Sprintf("Tab: %d", indent.Size)
+}
+
+// indentedLines returns the lines Tab and Shift+Tab act on: those of a
+// selection spanning several lines, or else the cursor's line. A selection
+// ending at the start of a line leaves that line out.
+func (e *Editor) indentedLines() (first, last int, multiline bool) {
+	if e.hasSelection {
+		start, end := e.getSelectionRange()
+		if start.Line != end.Line {
+			last = end.Line
+			if end.Col == 0 {
+				last--
+			}
+			return start.Line, last, true
+		}
+	}
+	line := e.buffer.GetCursor().Line
+	return line, line, false
+}
+
+// handleIndent indents the selected lines by one level. Without a
+// selection spanning lines it inserts indentation at the cursor instead:
+// a tab, or spaces up to the next indentation stop.
+func (e *Editor) handleIndent() error {
+	settings := e.editorSettings()
+	first, last, multiline := e.indentedLines()
+	if multiline {
+		unit := settings.indentUnit()
+		e.shiftLines(first, last, "indent", func(line string) (int, bool) {
+			return len(unit), line != "" // Blank lines stay empty
+		}, unit)
+		return nil
+	}
+
+	e.clearSelection()
+	text := "\t"
+	if settings.UseSpaces {
+		pos := e.buffer.GetCursor()
+		line, _ := e.buffer.GetLine(pos.Line)
+		col := layout.DisplayColumn(line, pos.Col, settings.TabSize)
+		text = strings.Repeat(" ", settings.IndentSize-col%settings.IndentSize)
+	}
+	return e.insertText(text)
+}
+
+// handleOutdent removes one level of indentation from the selected lines,
+// or the cursor's line: a tab, or up to a level's worth of spaces.
+func (e *Editor) handleOutdent() {
+	settings := e.editorSettings()
+	first, last, _ := e.indentedLines()
+	e.shiftLines(first, last, "outdent", func(line string) (int, bool) {
+		if strings.HasPrefix(line, "\t") {
+			return 1, true
+		}
+		n := 0
+		for n < len(line) && n < settings.IndentSize && line[n] == ' ' {
+			n++
+		}
+		return n, n > 0
+	}, "")
+}
+
+// shiftLines indents or outdents lines first to last as one undoable edit.
+// For each line, change returns how many bytes to add or remove at its
+// start and whether to change it at all; unit is the text added, or "" to
+// remove. The cursor and selection keep their place in the text.
+func (e *Editor) shiftLines(first, last int, description string, change func(line string) (int, bool), unit string) {
+	cursor := e.buffer.GetCursor()
+	edit := &history.CompositeOperation{}
+	edit.SetDescription(description)
+	shifts := make([]int, last-first+1)
+
+	for i := first; i <= last; i++ {
+		line, err := e.buffer.GetLine(i)
+		if err != nil {
+			continue
+		}
+		n, ok := change(line)
+		if !ok {
+			continue
+		}
+		start := buffer.Position{Line: i, Col: 0}
+		if unit != "" {
+			if e.buffer.Insert(start, unit) == nil {
+				edit.Operations = append(edit.Operations, &history.InsertOperation{Pos: start, Text: unit})
+				shifts[i-first] = n
+			}
+			continue
+		}
+		end := buffer.Position{Line: i, Col: n}
+		if e.buffer.Delete(start, end) == nil {
+			edit.Operations = append(edit.Operations, &history.DeleteOperation{StartPos: start, EndPos: end, Deleted: line[:n]})
+			shifts[i-first] = -n
+		}
+	}
+	if len(edit.Operations) == 0 {
+		e.buffer.MoveCursor(cursor)
+		return
+	}
+
+	// Text at the start of a line stays there when indented, so a selection
+	// of whole lines still covers them
+	adjust := func(p buffer.Position) buffer.Position {
+		if p.Line < first || p.Line > last {
+			return p
+		}
+		if d := shifts[p.Line-first]; d < 0 || p.Col > 0 {
+			p.Col = max(p.Col+d, 0)
+		}
+		return p
+	}
+	if e.hasSelection {
+		e.selectionStart = adjust(e.selectionStart)
+		e.selectionEnd = adjust(e.selectionEnd)
+	}
+	e.buffer.MoveCursor(adjust(cursor))
+
+	e.isDirty = true
+	e.history.Push(edit)
+}
+
+// handleIndentation lets the user choose how the file is indented,
+// overriding the settings and what was detected, or go back to them.
+func (e *Editor) handleIndentation() error {
+	saved := e.indentOverride
+	e.indentOverride = nil
+	automatic := e.editorSettings()
+	e.indentOverride = saved
+
+	choices := []string{fmt.Sprintf("Automatic (%s)", describeIndentation(buffer.Indentation{
+		UseSpaces: automatic.UseSpaces,
+		Size:      automatic.indentWidth(),
+	}))}
+	indents := []*buffer.Indentation{nil} // Parallel to choices
+	for _, useSpaces := range []bool{true, false} {
+		for size := 1; size <= maxIndentChoice; size++ {
+			indent := &buffer.Indentation{UseSpaces: useSpaces, Size: size}
+			choices = append(choices, describeIndentation(*indent))
+			indents = append(indents, indent)
+		}
+	}
+
+	selected := 0
+	if o := e.indentOverride; o != nil {
+		for i, indent := range indents {
+			if indent != nil && *indent == *o {
+				selected = i
+			}
+		}
+	}
+
+	dlg := dialog.NewChoiceDialog("Indentation", choices, selected,
+		func(i int) {
+			e.indentOverride = indents[i]
+		},
+		nil,
+	)
+	width, height := e.screen.GetSize()
+	e.dialogManager.Push(dlg, width, height)
+	return nil
+}
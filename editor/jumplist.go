@@ -0,0 +1,101 @@
+package editor
+
+import (
+	"github.com/AndrewDonelson/ted/core/buffer"
+)
+
+// maxJumps is how many positions the jump list remembers.
+const maxJumps = 100
+
+// jumpList remembers where the cursor was before large jumps, such as
+// going to a line, a search match or a bookmark. Like a browser's history,
+// going back and forth moves through it and a new jump drops the positions
+// ahead.
+type jumpList struct {
+	positions []buffer.Position
+	index     int // Position being visited; len(positions) when not going back
+}
+
+// push records pos as a place to go back to. A position on the same line
+// as the last one replaces it.
+func (j *jumpList) push(pos buffer.Position) {
+	j.positions = j.positions[:j.index]
+	if n := len(j.positions); n > 0 && j.positions[n-1].Line == pos.Line {
+		j.positions = j.positions[:n-1]
+	}
+	j.positions = append(j.positions, pos)
+	if excess := len(j.positions) - maxJumps; excess > 0 {
+		j.positions = append(j.positions[:0], j.positions[excess:]...)
+	}
+	j.index = len(j.positions)
+}
+
+// back returns the position before the one being visited. Leaving the
+// newest position, current is remembered so forward can return to it.
+func (j *jumpList) back(current buffer.Position) (buffer.Position, bool) {
+	if j.index == 0 {
+		return buffer.Position{}, false
+	}
+	if j.index == len(j.positions) {
+		j.positions = append(j.positions, current)
+	}
+	j.index--
+	return j.positions[j.index], true
+}
+
+// forward returns the position after the one being visited, undoing back.
+func (j *jumpList) forward() (buffer.Position, bool) {
+	if j.index+1 >= len(j.positions) {
+		return buffer.Position{}, false
+	}
+	j.index++
+	return j.positions[j.index], true
+}
+
+// recordJump remembers the cursor position before a large jump.
+func (e *Editor) recordJump() {
+	e.jumps.push(e.buffer.GetCursor())
+}
+
+// jumpTo moves the cursor to pos as a large jump, which can be gone back
+// from.
+func (e *Editor) jumpTo(pos buffer.Position) {
+	e.recordJump()
+	e.clearSelection()
+	e.buffer.MoveCursor(pos)
+}
+
+// handleJumpBack returns the cursor to where it was before the last jump.
+func (e *Editor) handleJumpBack() {
+	pos, ok := e.jumps.back(e.buffer.GetCursor())
+	if !ok {
+		e.setStatus("No earlier position")
+		return
+	}
+	e.clearSelection()
+	e.buffer.MoveCursor(pos)
+}
+
+// handleJumpForward goes forward again to where the cursor was before
+// going back.
+func (e *Editor) handleJumpForward() {
+	pos, ok := e.jumps.forward()
+	if !ok {
+		e.setStatus("No later position")
+		return
+	}
+	e.clearSelection()
+	e.buffer.MoveCursor(pos)
+}
+
+// handleDocumentStart moves the cursor to the start of the document.
+func (e *Editor) handleDocumentStart() {
+	e.jumpTo(buffer.Position{})
+}
+
+// handleDocumentEnd moves the cursor to the end of the document.
+func (e *Editor) handleDocumentEnd() {
+	last := e.buffer.LineCount() - 1
+	line, _ := e.buffer.GetLine(last)
+	e.jumpTo(buffer.Position{Line: last, Col: len(line)})
+}
@@ -0,0 +1,79 @@
+package editor
+
+import (
+	"fmt"
+
+	"github.com/AndrewDonelson/ted/ui/dialog"
+	"github.com/AndrewDonelson/ted/ui/menu"
+	"github.com/AndrewDonelson/ted/ui/terminal"
+	"github.com/gdamore/tcell/v2"
+)
+
+// SetKeymap replaces the key bindings and updates the shortcuts shown in
+// the menus to match.
+func (e *Editor) SetKeymap(km *terminal.Keymap) {
+	e.keymap = km
+	e.syncMenuShortcuts()
+}
+
+// syncMenuShortcuts shows each menu item's current binding beside it.
+func (e *Editor) syncMenuShortcuts() {
+	for _, cmd := range e.commands.commands {
+		if cmd.menu != menu.ActionNone {
+			e.menuBar.SetShortcut(cmd.menu, e.keymap.Shortcut(terminal.ContextEditor, cmd.action))
+		}
+	}
+}
+
+// keyContext returns the keymap context for the next key press.
+func (e *Editor) keyContext() terminal.Context {
+	if e.menuBar.IsOpen() {
+		return terminal.ContextMenu
+	}
+	return terminal.ContextEditor
+}
+
+// showChordStatus tells the user that a chord is waiting for its next key,
+// or that the keys pressed are not bound. It reports whether the key
+// event was part of a chord.
+func (e *Editor) showChordStatus(ke *terminal.KeyEvent) bool {
+	switch {
+	case ke.Pending:
+		e.statusMessage = fmt.Sprintf("(%s) was pressed. Waiting for the next key...", ke.Chord)
+	case ke.Chord != "":
+		e.statusMessage = fmt.Sprintf("%s is not bound to a command", ke.Chord)
+	default:
+		return false
+	}
+	return true
+}
+
+// dialogKey returns the key to give an open dialog for a key event. Keys
+// bound to enter or escape in the dialog context act as Enter or Escape.
+func dialogKey(ke *terminal.KeyEvent) tcell.Key {
+	switch ke.Action {
+	case terminal.KeyActionEnter:
+		return tcell.KeyEnter
+	case terminal.KeyActionEscape:
+		return tcell.KeyEscape
+	}
+	return ke.Key
+}
+
+// handleDialogKey gives a key event to the open dialog and reports whether
+// the dialog took it. A command chosen in the dialog runs once it closes.
+func (e *Editor) handleDialogKey(ke *terminal.KeyEvent) (bool, error) {
+	if !e.dialogManager.HandleInput(dialogKey(ke), ke.Modifiers, ke.Character) {
+		return false, nil
+	}
+	e.recordKey(ke, true)
+	return true, e.runQueuedCommand()
+}
+
+// handleHelp shows the keyboard shortcut cheat sheet for the active
+// keymap.
+func (e *Editor) handleHelp() error {
+	width, height := e.screen.GetSize()
+	e.dialogManager.Push(dialog.NewTextDialog("Keyboard Shortcuts", e.keymap.CheatSheet()), width, height)
+	return nil
+}
@@ -0,0 +1,282 @@
+package editor
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/core/state"
+	"github.com/AndrewDonelson/ted/ui/dialog"
+	"github.com/AndrewDonelson/ted/ui/terminal"
+	"github.com/gdamore/tcell/v2"
+)
+
+// macroKey is one key of a keyboard macro, as the editor or an open dialog
+// received it.
+type macroKey struct {
+	event  terminal.KeyEvent
+	dialog bool // Given to the open dialog rather than the editor
+}
+
+// macroActions are the macro commands themselves, which are never
+// recorded.
+var macroActions = []terminal.KeyAction{
+	terminal.KeyActionRecordMacro,
+	terminal.KeyActionPlayMacro,
+	terminal.KeyActionPlayMacroTimes,
+	terminal.KeyActionPlayMacroOnLines,
+	terminal.KeyActionSaveMacro,
+	terminal.KeyActionPlayNamedMacro,
+}
+
+// recordKey adds a key to the macro being recorded, if any. dialog tells
+// whether an open dialog took the key.
+func (e *Editor) recordKey(ke *terminal.KeyEvent, dialog bool) {
+	if !e.recordingMacro || e.playingMacro || ke.Action == terminal.KeyActionNone || slices.Contains(macroActions, ke.Action) {
+		return
+	}
+	e.recordedKeys = append(e.recordedKeys, macroKey{event: *ke, dialog: dialog})
+}
+
+// handleRecordMacro starts recording keys, or stops and keeps what was
+// recorded as the last macro.
+func (e *Editor) handleRecordMacro() {
+	if !e.recordingMacro {
+		e.recordingMacro = true
+		e.recordedKeys = nil
+		e.statusMessage = "Recording macro"
+		return
+	}
+
+	e.recordingMacro = false
+	if len(e.recordedKeys) == 0 {
+		e.statusMessage = "Nothing recorded"
+		return
+	}
+	e.lastMacro = e.recordedKeys
+	e.recordedKeys = nil
+	e.statusMessage = fmt.Sprintf("Recorded macro of %d keys", len(e.lastMacro))
+}
+
+// playMacro plays keys times times over as one undoable edit. Before each
+// time it calls before, if not nil, with the number of times played so
+// far.
+func (e *Editor) playMacro(keys []macroKey, times int, before func(i int)) error {
+	switch {
+	case e.recordingMacro:
+		e.statusMessage = "Stop recording before playing a macro"
+		return nil
+	case len(keys) == 0:
+		e.statusMessage = "No macro recorded"
+		return nil
+	}
+
+	e.playingMacro = true
+	e.history.BeginGroup("play macro")
+	defer func() {
+		e.history.EndGroup()
+		e.playingMacro = false
+	}()
+
+	for i := range times {
+		if before != nil {
+			before(i)
+		}
+		if err := e.replayKeys(keys); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replayKeys gives each key to the editor or the open dialog, as it was
+// recorded. Keys recorded in a dialog are dropped if no dialog is open.
+func (e *Editor) replayKeys(keys []macroKey) error {
+	for _, key := range keys {
+		ke := key.event
+		if !key.dialog {
+			if err := e.handleKeyEvent(&ke); err != nil {
+				return err
+			}
+			continue
+		}
+		if e.dialogManager.HasOpenDialog() {
+			if _, err := e.handleDialogKey(&ke); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// handlePlayMacroTimes asks how many times to play the last macro, then
+// plays it.
+func (e *Editor) handlePlayMacroTimes() error {
+	if len(e.lastMacro) == 0 {
+		e.statusMessage = "No macro recorded"
+		return nil
+	}
+
+	dlg := dialog.NewInputDialog("Play Macro", "Times:", "", func(input string) {
+		times, err := strconv.Atoi(strings.TrimSpace(input))
+		if err != nil || times < 1 {
+			e.statusMessage = fmt.Sprintf("Not a number of times: %q", input)
+			return
+		}
+		e.queueMacro(e.lastMacro, times, nil)
+	}, nil)
+
+	width, height := e.screen.GetSize()
+	e.dialogManager.Push(dlg, width, height)
+	return nil
+}
+
+// handlePlayMacroOnLines plays the last macro once on each line of the
+// selection, starting each time at the start of the line. Lines are
+// visited from the bottom up, so lines the macro adds or removes do not
+// move the ones still to come.
+func (e *Editor) handlePlayMacroOnLines() error {
+	if !e.hasSelection {
+		e.statusMessage = "Select the lines to play the macro on"
+		return nil
+	}
+
+	start, end := e.getSelectionRange()
+	last := end.Line
+	if end.Col == 0 && end.Line > start.Line {
+		last-- // The selection only reaches the start of its last line
+	}
+	return e.playMacro(e.lastMacro, last-start.Line+1, func(i int) {
+		e.clearSelection()
+		e.buffer.MoveCursor(buffer.Position{Line: last - i})
+	})
+}
+
+// handleSaveMacro asks for a name and saves the last macro under it in the
+// state file, replacing any macro of that name.
+func (e *Editor) handleSaveMacro() error {
+	if len(e.lastMacro) == 0 {
+		e.statusMessage = "No macro recorded"
+		return nil
+	}
+
+	keys := e.lastMacro
+	dlg := dialog.NewInputDialog("Save Macro", "Name:", "", func(input string) {
+		name := strings.TrimSpace(input)
+		if name == "" {
+			return
+		}
+		if e.namedMacros == nil {
+			e.namedMacros = make(map[string][]macroKey)
+		}
+		e.namedMacros[name] = keys
+		if err := e.saveState(); err != nil {
+			e.statusMessage = fmt.Sprintf("Save macro failed: %v", err)
+			return
+		}
+		e.statusMessage = fmt.Sprintf("Saved macro %q", name)
+	}, nil)
+
+	width, height := e.screen.GetSize()
+	e.dialogManager.Push(dlg, width, height)
+	return nil
+}
+
+// handlePlayNamedMacro lists the saved macros and plays the one chosen.
+func (e *Editor) handlePlayNamedMacro() error {
+	if len(e.namedMacros) == 0 {
+		e.statusMessage = "No saved macros"
+		return nil
+	}
+
+	names := make([]string, 0, len(e.namedMacros))
+	for name := range e.namedMacros {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	dlg := dialog.NewChoiceDialog("Play Macro", names, 0, func(i int) {
+		e.queueMacro(e.namedMacros[names[i]], 1, nil)
+	}, nil)
+
+	width, height := e.screen.GetSize()
+	e.dialogManager.Push(dlg, width, height)
+	return nil
+}
+
+// queueMacro plays a macro once the dialog choosing it has closed, so its
+// keys do not go to that dialog.
+func (e *Editor) queueMacro(keys []macroKey, times int, before func(i int)) {
+	e.queuedCommand = &command{multi: true, run: func(e *Editor) error {
+		return e.playMacro(keys, times, before)
+	}}
+}
+
+// macrosToState converts the named macros for the state file.
+func macrosToState(macros map[string][]macroKey) map[string][]state.MacroKey {
+	if len(macros) == 0 {
+		return nil
+	}
+	saved := make(map[string][]state.MacroKey, len(macros))
+	for name, keys := range macros {
+		for _, key := range keys {
+			var char string
+			if key.event.Character != 0 {
+				char = string(key.event.Character)
+			}
+			saved[name] = append(saved[name], state.MacroKey{
+				Action:    key.event.Action.String(),
+				Character: char,
+				Key:       int(key.event.Key),
+				Modifiers: int(key.event.Modifiers),
+				Dialog:    key.dialog,
+			})
+		}
+	}
+	return saved
+}
+
+// macrosFromState converts the macros read from the state file. Macros
+// with actions this version does not know are left out.
+func macrosFromState(saved map[string][]state.MacroKey) map[string][]macroKey {
+	macros := make(map[string][]macroKey, len(saved))
+next:
+	for name, savedKeys := range saved {
+		keys := make([]macroKey, 0, len(savedKeys))
+		for _, key := range savedKeys {
+			action, ok := parseMacroAction(key.Action)
+			if !ok {
+				continue next
+			}
+			var char rune
+			if key.Character != "" {
+				char, _ = utf8.DecodeRuneInString(key.Character)
+			}
+			keys = append(keys, macroKey{
+				event: terminal.KeyEvent{
+					Action:    action,
+					Character: char,
+					Key:       tcell.Key(key.Key),
+					Modifiers: tcell.ModMask(key.Modifiers),
+				},
+				dialog: key.Dialog,
+			})
+		}
+		macros[name] = keys
+	}
+	return macros
+}
+
+// parseMacroAction returns the key action with the given name, including
+// those for typed characters and unbound keys, which cannot be bound.
+func parseMacroAction(name string) (terminal.KeyAction, bool) {
+	for _, action := range []terminal.KeyAction{terminal.KeyActionNone, terminal.KeyActionCharacter, terminal.KeyActionMenuAlt} {
+		if action.String() == name {
+			return action, true
+		}
+	}
+	return terminal.ParseKeyAction(name)
+}
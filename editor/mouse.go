@@ -0,0 +1,231 @@
+package editor
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/ui/layout"
+	"github.com/AndrewDonelson/ted/ui/menu"
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	// multiClickInterval is how soon a press at the same cell must follow
+	// the last one to count as a double or triple click.
+	multiClickInterval = 400 * time.Millisecond
+
+	// wheelScrollLines is how far one wheel step scrolls.
+	wheelScrollLines = 3
+)
+
+// mouseState tracks the mouse between events, for drags and multi-clicks.
+type mouseState struct {
+	buttons   tcell.ButtonMask // Buttons held at the last event
+	dragging  bool             // Primary button went down in the text area
+	anchor    buffer.Position  // Fixed end of the selection while dragging
+	block     bool             // Alt was held at the press, so dragging selects a block
+	blockLine int              // Line where a block drag started
+	blockCol  int              // Screen column where a block drag started
+	lastClick time.Time        // Time of the last press
+	lastX     int              // Cell of the last press
+	lastY     int
+	clicks    int // Presses in a row at the same cell: 1, 2 or 3
+}
+
+// handleMouseEvent handles clicks on dialogs, the menu bar and the text,
+// drags that select text, and the wheel. It reports whether the screen
+// needs redrawing.
+func (e *Editor) handleMouseEvent(ev *tcell.EventMouse) (bool, error) {
+	x, y := ev.Position()
+	buttons := ev.Buttons()
+	prev := e.mouse.buttons
+	e.mouse.buttons = buttons & (tcell.Button1 | tcell.Button2 | tcell.Button3)
+	pressed := buttons&tcell.Button1 != 0 && prev&tcell.Button1 == 0
+
+	if buttons&tcell.Button1 == 0 {
+		e.mouse.dragging = false
+	}
+
+	// Dialogs are modal: clicks elsewhere are ignored
+	if e.dialogManager.HasOpenDialog() {
+		if pressed {
+			e.dialogManager.HandleClick(x, y)
+			return true, e.runQueuedCommand()
+		}
+		return false, nil
+	}
+
+	editRegion := e.layout.GetEditAreaRegion()
+	if pressed && (e.menuBar.IsOpen() || y < editRegion.Y) {
+		handled, action := e.menuBar.HandleClick(x, y)
+		if action != menu.ActionNone {
+			return true, e.executeMenuAction(action)
+		}
+		if handled {
+			return true, nil
+		}
+	}
+	if e.menuBar.IsOpen() {
+		return false, nil
+	}
+
+	switch {
+	case pressed && e.renderer.IndentationFieldAt(x, y):
+		return true, e.handleIndentation()
+	case pressed && e.foldMarkerAt(x, y):
+		e.handleFoldClick(y)
+	case buttons&tcell.WheelUp != 0:
+		e.scrollBy(-wheelScrollLines)
+	case buttons&tcell.WheelDown != 0:
+		e.scrollBy(wheelScrollLines)
+	case pressed && y >= editRegion.Y && y < editRegion.Y+editRegion.Height:
+		e.handleMouseDown(x, y, ev.Modifiers())
+	case buttons&tcell.Button1 != 0 && e.mouse.dragging:
+		e.handleMouseDrag(x, y)
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// handleMouseDown places the cursor at a click. A double click selects
+// the word under the pointer and a triple click the line; Shift+click
+// extends the selection and Alt+click adds or removes a cursor, or starts
+// a block selection if the pointer is dragged.
+func (e *Editor) handleMouseDown(x, y int, mod tcell.ModMask) {
+	e.completion.Hide()
+	viewport := e.currentViewport()
+	e.layout.ScrollTo(viewport.StartLine) // Keep the text still under the pointer
+	pos := e.bufferPosition(x, y, viewport)
+
+	now := time.Now()
+	if x == e.mouse.lastX && y == e.mouse.lastY && now.Sub(e.mouse.lastClick) < multiClickInterval {
+		e.mouse.clicks = e.mouse.clicks%3 + 1
+	} else {
+		e.mouse.clicks = 1
+	}
+	e.mouse.lastClick, e.mouse.lastX, e.mouse.lastY = now, x, y
+	e.mouse.dragging = true
+
+	e.mouse.block = mod&tcell.ModAlt != 0
+	if e.mouse.block {
+		e.block = nil
+		e.toggleCursorAt(pos)
+		e.mouse.anchor = pos
+		e.mouse.blockLine, e.mouse.blockCol = e.screenCell(x, y, viewport)
+		return
+	}
+	e.buffer.ClearExtraCursors()
+
+	switch {
+	case e.mouse.clicks == 2:
+		start, end := e.buffer.WordAt(pos)
+		e.selectRange(start, end)
+	case e.mouse.clicks == 3:
+		line, _ := e.buffer.GetLine(pos.Line)
+		e.selectRange(buffer.Position{Line: pos.Line}, buffer.Position{Line: pos.Line, Col: len(line)})
+	case mod&tcell.ModShift != 0:
+		e.startSelectionIfNeeded()
+		e.buffer.MoveCursor(pos)
+		e.updateSelectionEnd()
+		e.mouse.anchor = e.selectionStart
+	default:
+		e.clearSelection()
+		e.buffer.MoveCursor(pos)
+		e.mouse.anchor = pos
+	}
+}
+
+// handleMouseDrag selects from where the drag started to the pointer, as
+// a block if Alt was held. Dragging above or below the text area scrolls.
+func (e *Editor) handleMouseDrag(x, y int) {
+	editRegion := e.layout.GetEditAreaRegion()
+	if y < editRegion.Y {
+		e.scrollBy(-1)
+	} else if y >= editRegion.Y+editRegion.Height {
+		e.scrollBy(1)
+	}
+
+	if e.mouse.block {
+		line, col := e.screenCell(x, y, e.currentViewport())
+		if line == e.mouse.blockLine && col == e.mouse.blockCol && e.block == nil {
+			return // Not moved yet: keep the cursor Alt+click toggled
+		}
+		e.selectBlock(blockSelection{anchorLine: e.mouse.blockLine, anchorCol: e.mouse.blockCol, line: line, col: col})
+		return
+	}
+
+	pos := e.bufferPosition(x, y, e.currentViewport())
+	e.buffer.MoveCursor(pos)
+	e.hasSelection = pos != e.mouse.anchor
+	e.selectionStart = e.mouse.anchor
+	e.selectionEnd = pos
+}
+
+// selectRange selects from start to end and puts the cursor at the end.
+func (e *Editor) selectRange(start, end buffer.Position) {
+	e.buffer.MoveCursor(end)
+	e.hasSelection = start != end
+	e.selectionStart = start
+	e.selectionEnd = end
+	e.mouse.anchor = start
+}
+
+// scrollBy scrolls the view by delta lines. The cursor stays where it is
+// unless it would leave the view; then it is kept at the nearest edge and
+// any selection is cleared.
+func (e *Editor) scrollBy(delta int) {
+	total := e.buffer.LineCount()
+	top := max(e.layout.LineAtRow(e.currentViewport(), delta), 0)
+	e.layout.ScrollTo(top)
+
+	// The viewport keeps the last line at the bottom, so it may not start
+	// at top
+	viewport := e.layout.CalculateViewport(top, total)
+	cursor := e.buffer.GetCursor()
+	if cursor.Line >= viewport.StartLine && cursor.Line <= viewport.EndLine {
+		return
+	}
+	cursor.Line = e.layout.VisibleLine(min(max(cursor.Line, viewport.StartLine), viewport.EndLine))
+	e.buffer.MoveCursor(cursor)
+	if !e.mouse.dragging {
+		e.clearSelection()
+	}
+}
+
+// currentViewport returns the part of the buffer on screen.
+func (e *Editor) currentViewport() layout.Viewport {
+	return e.layout.CalculateViewport(e.buffer.GetCursor().Line, e.buffer.LineCount())
+}
+
+// bufferPosition returns the buffer position drawn at screen x, y,
+// clamped to the text. Rows above or below the text area give the first
+// or last visible line.
+func (e *Editor) bufferPosition(x, y int, viewport layout.Viewport) buffer.Position {
+	line, col := e.screenCell(x, y, viewport)
+	text, _ := e.buffer.GetLine(line)
+	return buffer.Position{Line: line, Col: columnAt(text, col, e.renderer.TabSize())}
+}
+
+// screenCell returns the line and the screen column of the text drawn at
+// screen x, y. The column may lie past the end of the line. Rows above or
+// below the text area give the first or last visible line.
+func (e *Editor) screenCell(x, y int, viewport layout.Viewport) (line, col int) {
+	editRegion := e.layout.GetEditAreaRegion()
+	y = min(max(y, editRegion.Y), editRegion.Y+editRegion.Height-1)
+	line, col = e.layout.ScreenToBuffer(x, y, viewport)
+	return max(min(line, e.buffer.LineCount()-1), 0), max(col, 0)
+}
+
+// columnAt returns the byte offset in text of the character drawn at
+// screen column col, clamped to the text.
+func columnAt(text string, col, tabSize int) int {
+	// Text is drawn one byte per column with tabs expanded; land on the
+	// start of a character
+	col = min(max(layout.BufferColumn(text, max(col, 0), tabSize), 0), len(text))
+	for col > 0 && col < len(text) && !utf8.RuneStart(text[col]) {
+		col--
+	}
+	return col
+}
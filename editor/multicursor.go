@@ -0,0 +1,300 @@
+package editor
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/core/history"
+	"github.com/AndrewDonelson/ted/search"
+	"github.com/AndrewDonelson/ted/ui/layout"
+)
+
+// mainCursor returns the main cursor and its selection.
+func (e *Editor) mainCursor() buffer.Cursor {
+	pos := e.buffer.GetCursor()
+	if e.hasSelection {
+		return buffer.Cursor{Pos: pos, Anchor: e.selectionStart}
+	}
+	return buffer.Cursor{Pos: pos, Anchor: pos}
+}
+
+// setMainCursor moves the main cursor and its selection to c.
+func (e *Editor) setMainCursor(c buffer.Cursor) {
+	e.buffer.MoveCursor(c.Pos)
+	e.hasSelection = c.HasSelection()
+	e.selectionStart, e.selectionEnd = c.Anchor, c.Pos
+}
+
+// allCursors returns every cursor in document order and the index of the
+// main one.
+func (e *Editor) allCursors() ([]buffer.Cursor, int) {
+	main := e.mainCursor()
+	cursors := append(e.buffer.ExtraCursors(), main)
+	slices.SortStableFunc(cursors, func(a, b buffer.Cursor) int {
+		return buffer.ComparePositions(a.Pos, b.Pos)
+	})
+	return cursors, slices.Index(cursors, main)
+}
+
+// setAllCursors makes cursors[main] the main cursor and the others extra
+// cursors. Cursors that ended up in the same place become one.
+func (e *Editor) setAllCursors(cursors []buffer.Cursor, main int) {
+	e.setMainCursor(cursors[main])
+	var extra []buffer.Cursor
+	for i, c := range cursors {
+		same := func(other buffer.Cursor) bool { return other.Pos == c.Pos }
+		if i == main || same(cursors[main]) || slices.ContainsFunc(extra, same) {
+			continue
+		}
+		extra = append(extra, c)
+	}
+	e.buffer.SetExtraCursors(extra)
+}
+
+// atEachCursor runs f once for every cursor, in document order, with that
+// cursor made the main one. The buffer keeps the other cursors on their
+// text when f edits it.
+func (e *Editor) atEachCursor(f func()) {
+	if !e.buffer.HasExtraCursors() {
+		f()
+		return
+	}
+	cursors, main := e.allCursors()
+	for i := range cursors {
+		e.setMainCursor(cursors[i])
+		e.buffer.SetExtraCursors(slices.Delete(slices.Clone(cursors), i, i+1))
+		f()
+		cursors = slices.Insert(e.buffer.ExtraCursors(), i, e.mainCursor())
+	}
+	e.setAllCursors(cursors, main)
+}
+
+// replaceAtEachCursor edits the text at every cursor as one undoable
+// change. For the i-th cursor in document order, edit returns the range
+// to replace and the text to put there.
+func (e *Editor) replaceAtEachCursor(description string, edit func(i int, c buffer.Cursor) (start, end buffer.Position, text string)) {
+	op := &history.CompositeOperation{}
+	op.SetDescription(description)
+	i := 0
+	e.atEachCursor(func() {
+		start, end, text := edit(i, e.mainCursor())
+		i++
+		e.clearSelection()
+		if start == end && text == "" {
+			return
+		}
+		old, err := e.buffer.GetText(start, end)
+		if err != nil {
+			return
+		}
+		if _, err := e.buffer.Replace(start, end, text); err != nil {
+			return
+		}
+		op.Operations = append(op.Operations, &history.ReplaceOperation{StartPos: start, EndPos: end, Old: old, New: text})
+	})
+
+	if len(op.Operations) > 0 {
+		e.isDirty = true
+		e.history.Push(op)
+	}
+}
+
+// insertAtEachCursor types text at every cursor, replacing its selection.
+func (e *Editor) insertAtEachCursor(text string) {
+	e.replaceAtEachCursor("insert text", func(_ int, c buffer.Cursor) (buffer.Position, buffer.Position, string) {
+		start, end := c.Range()
+		return start, end, text
+	})
+}
+
+// pasteAtEachCursor pastes text at every cursor. Text with a line for
+// each cursor, as copying at several cursors gives, is shared out one
+// line per cursor.
+func (e *Editor) pasteAtEachCursor(text string) {
+	cursors, _ := e.allCursors()
+	lines := strings.Split(text, "\n")
+	if len(lines) != len(cursors) {
+		e.insertAtEachCursor(text)
+		return
+	}
+	e.replaceAtEachCursor("paste", func(i int, c buffer.Cursor) (buffer.Position, buffer.Position, string) {
+		start, end := c.Range()
+		return start, end, lines[i]
+	})
+}
+
+// backspaceAtEachCursor deletes the selection at every cursor, or the
+// character before it.
+func (e *Editor) backspaceAtEachCursor() {
+	e.replaceAtEachCursor("delete text", func(_ int, c buffer.Cursor) (buffer.Position, buffer.Position, string) {
+		if c.HasSelection() {
+			start, end := c.Range()
+			return start, end, ""
+		}
+		pos := c.Pos
+		if pos.Col > 0 {
+			line, _ := e.buffer.GetLine(pos.Line)
+			_, size := utf8.DecodeLastRuneInString(line[:pos.Col])
+			return buffer.Position{Line: pos.Line, Col: pos.Col - size}, pos, ""
+		}
+		if pos.Line > 0 {
+			prev, _ := e.buffer.GetLine(pos.Line - 1)
+			return buffer.Position{Line: pos.Line - 1, Col: len(prev)}, pos, ""
+		}
+		return pos, pos, ""
+	})
+}
+
+// deleteAtEachCursor deletes the selection at every cursor, or the
+// character at it.
+func (e *Editor) deleteAtEachCursor() {
+	e.replaceAtEachCursor("delete text", func(_ int, c buffer.Cursor) (buffer.Position, buffer.Position, string) {
+		if c.HasSelection() {
+			start, end := c.Range()
+			return start, end, ""
+		}
+		pos := c.Pos
+		line, _ := e.buffer.GetLine(pos.Line)
+		if pos.Col < len(line) {
+			_, size := utf8.DecodeRuneInString(line[pos.Col:])
+			return pos, buffer.Position{Line: pos.Line, Col: pos.Col + size}, ""
+		}
+		if pos.Line < e.buffer.LineCount()-1 {
+			return pos, buffer.Position{Line: pos.Line + 1, Col: 0}, ""
+		}
+		return pos, pos, ""
+	})
+}
+
+// textAtEachCursor returns the selected text of every cursor, or its line
+// if nothing is selected, one per line.
+func (e *Editor) textAtEachCursor() (string, error) {
+	cursors, _ := e.allCursors()
+	texts := make([]string, 0, len(cursors))
+	for _, c := range cursors {
+		var text string
+		var err error
+		if c.HasSelection() {
+			text, err = e.buffer.GetText(c.Range())
+		} else {
+			text, err = e.buffer.GetLine(c.Pos.Line)
+		}
+		if err != nil {
+			return "", err
+		}
+		texts = append(texts, text)
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// handleAddNextOccurrence selects the word at the cursor. With text
+// already selected it adds a cursor selecting the next occurrence of the
+// text instead, wrapping around at the end, which becomes the main cursor.
+func (e *Editor) handleAddNextOccurrence() {
+	main := e.mainCursor()
+	if !main.HasSelection() {
+		start, end := e.buffer.WordAt(main.Pos)
+		if start != end {
+			e.selectRange(start, end)
+		}
+		return
+	}
+
+	start, end := main.Range()
+	text, err := e.buffer.GetText(start, end)
+	if err != nil {
+		return
+	}
+	matches, err := search.FindMatches(context.Background(), e.buffer.GetAllLines(), text, search.Options{CaseSensitive: true})
+	if err != nil {
+		return
+	}
+
+	// Matches at or after the main cursor's selection come first
+	cursors, _ := e.allCursors()
+	after := slices.IndexFunc(matches, func(m search.Match) bool {
+		return buffer.ComparePositions(buffer.Position{Line: m.StartLine, Col: m.StartCol}, end) >= 0
+	})
+	if after > 0 {
+		matches = slices.Concat(matches[after:], matches[:after])
+	}
+	for _, m := range matches {
+		found := buffer.Cursor{
+			Pos:    buffer.Position{Line: m.EndLine, Col: m.EndCol},
+			Anchor: buffer.Position{Line: m.StartLine, Col: m.StartCol},
+		}
+		if slices.ContainsFunc(cursors, func(c buffer.Cursor) bool { return overlaps(c, found) }) {
+			continue
+		}
+		e.setMainCursor(found)
+		e.buffer.AddCursor(main)
+		return
+	}
+	e.statusMessage = "No more occurrences"
+}
+
+// overlaps reports whether the selections of two cursors share text.
+func overlaps(a, b buffer.Cursor) bool {
+	aStart, aEnd := a.Range()
+	bStart, bEnd := b.Range()
+	return buffer.ComparePositions(aStart, bEnd) < 0 && buffer.ComparePositions(bStart, aEnd) < 0
+}
+
+// handleAddCursor adds a cursor on the line above the first cursor, when
+// delta is -1, or below the last one, when it is 1, in the same screen
+// column as far as the line allows. The new cursor becomes the main one.
+func (e *Editor) handleAddCursor(delta int) {
+	cursors, _ := e.allCursors()
+	from := cursors[0].Pos
+	if delta > 0 {
+		from = cursors[len(cursors)-1].Pos
+	}
+	line := from.Line + delta
+	if line < 0 || line >= e.buffer.LineCount() {
+		return
+	}
+
+	tabSize := e.renderer.TabSize()
+	fromText, _ := e.buffer.GetLine(from.Line)
+	text, _ := e.buffer.GetLine(line)
+	col := columnAt(text, layout.DisplayColumn(fromText, from.Col, tabSize), tabSize)
+
+	main := e.mainCursor()
+	e.setMainCursor(buffer.Cursor{Pos: buffer.Position{Line: line, Col: col}, Anchor: buffer.Position{Line: line, Col: col}})
+	e.buffer.AddCursor(main)
+}
+
+// toggleCursorAt adds a cursor at pos, which becomes the main one, or
+// removes the cursor there if there is one besides it.
+func (e *Editor) toggleCursorAt(pos buffer.Position) {
+	extra := e.buffer.ExtraCursors()
+	if i := slices.IndexFunc(extra, func(c buffer.Cursor) bool { return c.Pos == pos }); i >= 0 {
+		e.buffer.SetExtraCursors(slices.Delete(extra, i, i+1))
+		return
+	}
+	if pos == e.buffer.GetCursor() {
+		if len(extra) > 0 {
+			// Hand the main cursor over to the last one added
+			e.setMainCursor(extra[len(extra)-1])
+			e.buffer.SetExtraCursors(extra[:len(extra)-1])
+		}
+		return
+	}
+
+	main := e.mainCursor()
+	e.setMainCursor(buffer.Cursor{Pos: pos, Anchor: pos})
+	e.buffer.AddCursor(main)
+}
+
+// extraCursorPositions returns where the cursors besides the main one are.
+func (e *Editor) extraCursorPositions() []buffer.Position {
+	extra := e.buffer.ExtraCursors()
+	positions := make([]buffer.Position, len(extra))
+	for i, c := range extra {
+		positions[i] = c.Pos
+	}
+	return positions
+}
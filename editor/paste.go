@@ -0,0 +1,92 @@
+package editor
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// handlePasteEvent collects a bracketed paste. The terminal sends the
+// pasted text as key events between a paste start and a paste end; they
+// are buffered rather than handled as keys, so pasted text cannot trigger
+// shortcuts, and inserted as a whole at the end. It reports whether the
+// event was consumed.
+func (e *Editor) handlePasteEvent(ev tcell.Event) (bool, error) {
+	switch ev := ev.(type) {
+	case *tcell.EventPaste:
+		if ev.Start() {
+			e.paste = &strings.Builder{}
+			return true, nil
+		}
+		if e.paste == nil {
+			return true, nil // End without a start
+		}
+		text := pastedLineBreaks(e.paste.String())
+		e.paste = nil
+		return true, e.insertPastedText(text)
+
+	case *tcell.EventKey:
+		if e.paste == nil {
+			return false, nil
+		}
+		switch ev.Key() {
+		case tcell.KeyRune:
+			e.paste.WriteRune(ev.Rune())
+		case tcell.KeyEnter:
+			// The terminal reports both CR and LF as Enter; kept as CR
+			// until the paste ends, as pastedLineBreaks explains
+			e.paste.WriteByte('\r')
+		case tcell.KeyTab:
+			e.paste.WriteByte('\t')
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// pastedLineBreaks turns the Enter keys of a paste, collected as CRs,
+// into newlines. The terminal reports CR and LF alike as Enter, so text
+// with CRLF line endings arrives with two Enters for every line break.
+// When every run of Enters in the paste is of even length, each pair is
+// taken as one line break. Text with LF line endings in which every line
+// break is doubled looks the same, and loses its blank lines.
+func pastedLineBreaks(text string) string {
+	crlf := strings.Contains(text, "\r")
+	for run := range strings.FieldsFuncSeq(text, func(r rune) bool { return r != '\r' }) {
+		if len(run)%2 != 0 {
+			crlf = false
+			break
+		}
+	}
+	if crlf {
+		text = strings.ReplaceAll(text, "\r\r", "\r")
+	}
+	return strings.ReplaceAll(text, "\r", "\n")
+}
+
+// insertPastedText inserts text from a bracketed paste. It goes into the
+// input of an open dialog, one line, or into the buffer as a single
+// undoable insert without auto-indent.
+func (e *Editor) insertPastedText(text string) error {
+	e.statusMessage = ""
+	e.completion.Hide()
+
+	if e.dialogManager.HasOpenDialog() {
+		line, _, _ := strings.Cut(text, "\n")
+		for _, r := range line {
+			e.dialogManager.HandleInput(tcell.KeyRune, tcell.ModNone, r)
+		}
+		return nil
+	}
+
+	if e.menuBar.IsOpen() {
+		e.menuBar.CloseMenu()
+	}
+	if e.readOnly {
+		e.showReadOnlyStatus()
+		return nil
+	}
+
+	return e.pasteText(text)
+}
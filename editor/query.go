@@ -0,0 +1,107 @@
+package editor
+
+import (
+	"fmt"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/search"
+	"github.com/AndrewDonelson/ted/ui/dialog"
+	"github.com/AndrewDonelson/ted/ui/renderer"
+)
+
+// queryReplace is the state of an interactive replace session.
+type queryReplace struct {
+	session *search.QueryReplace
+	dlg     *dialog.QueryReplaceDialog
+}
+
+// startQueryReplace asks about each match from the cursor onwards, wrapping
+// around to it. The whole session is undone as one operation.
+func (e *Editor) startQueryReplace() {
+	e.endIncrementalSearch(false)
+
+	replacer := e.searchManager.GetReplacer()
+	if err := replacer.ValidateReplacement(); err != nil {
+		e.searchStatus = fmt.Sprintf("Invalid replacement: %v", err)
+		return
+	}
+
+	session := replacer.StartQueryReplace(e.buffer, e.buffer.GetCursor())
+	if session.Done() {
+		e.searchStatus = "No matches"
+		return
+	}
+
+	q := &queryReplace{session: session}
+	q.dlg = dialog.NewQueryReplaceDialog(func(answer dialog.QueryAnswer) {
+		e.answerQueryReplace(q, answer)
+	})
+
+	width, height := e.screen.GetSize()
+	e.dialogManager.Push(q.dlg, width, height)
+	e.showQueryMatch(q)
+}
+
+// answerQueryReplace applies an answer and moves on to the next match,
+// finishing the session when there are none left.
+func (e *Editor) answerQueryReplace(q *queryReplace, answer dialog.QueryAnswer) {
+	var err error
+
+	switch answer {
+	case dialog.QueryYes:
+		err = q.session.Yes()
+	case dialog.QueryNo:
+		q.session.No()
+	case dialog.QueryAll:
+		err = q.session.All()
+	case dialog.QueryQuit:
+		q.session.Quit()
+	case dialog.QueryUndo:
+		var undone bool
+		undone, err = q.session.UndoLast()
+		if err == nil && !undone {
+			q.dlg.SetMessage("Nothing to undo")
+			return
+		}
+	}
+
+	if err != nil {
+		q.dlg.SetMessage(fmt.Sprintf("Replace failed: %v", err))
+		return
+	}
+
+	if q.session.Done() {
+		e.finishQueryReplace(q)
+		return
+	}
+	e.showQueryMatch(q)
+}
+
+// showQueryMatch moves the cursor to the current match and highlights it.
+func (e *Editor) showQueryMatch(q *queryReplace) {
+	match, ok := q.session.Current()
+	if !ok {
+		return
+	}
+
+	start := buffer.Position{Line: match.StartLine, Col: match.StartCol}
+	end := buffer.Position{Line: match.EndLine, Col: match.EndCol}
+	e.buffer.MoveCursor(start)
+	e.renderer.SetHighlights([]renderer.Highlight{{Start: start, End: end, Current: true}})
+
+	replaced, skipped := q.session.Counts()
+	q.dlg.SetMatch(match.Text, q.session.Preview())
+	q.dlg.SetMessage(fmt.Sprintf("%d replaced, %d skipped", replaced, skipped))
+}
+
+// finishQueryReplace records the session in the history and reports the
+// summary in the status bar.
+func (e *Editor) finishQueryReplace(q *queryReplace) {
+	if replaced, _ := q.session.Counts(); replaced > 0 {
+		e.isDirty = true
+	}
+	e.searchStatus = q.session.Finish(e.history)
+	e.syncSelectionToScope()
+	e.renderer.ClearHighlights()
+	q.dlg.Close()
+}
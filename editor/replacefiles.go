@@ -0,0 +1,166 @@
+package editor
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/AndrewDonelson/ted/core/file"
+	"github.com/AndrewDonelson/ted/search"
+	"github.com/AndrewDonelson/ted/ui/dialog"
+)
+
+// handleReplaceInFiles shows the replace in files panel. The search runs
+// as in find in files but each result is shown as a diff; the replacement
+// is written only when the user applies it.
+func (e *Editor) handleReplaceInFiles() error {
+	if e.forceReadOnly {
+		e.showReadOnlyStatus()
+		return nil
+	}
+
+	root, pattern, options := e.fileSearchDefaults()
+
+	var dlg *dialog.FindInFilesDialog
+	dlg = dialog.NewFindInFilesDialog(root, pattern, options,
+		func(root, pattern string, options search.Options) {
+			e.startFileSearch(dlg, root, pattern, options)
+		},
+		e.stopFileSearch,
+		e.openFileResult,
+		e.stopFileSearch,
+	)
+	dlg.EnableReplace(e.searchManager.GetReplacer().GetReplacement(),
+		func(edits []*search.FileEdit) {
+			e.applyFileReplace(dlg, edits)
+		},
+		func() {
+			dlg.SetMessage(e.undoFileReplace())
+		},
+		func(path string) {
+			dlg.SetMessage(e.undoFileReplaceFile(path))
+		},
+	)
+
+	width, height := e.screen.GetSize()
+	e.dialogManager.Push(dlg, width, height)
+	return nil
+}
+
+// applyFileReplace writes the included edits, keeping the original files in
+// a journal so the replace can be undone. The open file is skipped if it
+// has unsaved changes.
+func (e *Editor) applyFileReplace(dlg *dialog.FindInFilesDialog, edits []*search.FileEdit) {
+	skipped := ""
+	if e.buffer.IsModified() {
+		for _, edit := range edits {
+			if e.isOpenFile(edit.Path) && !edit.Excluded {
+				edit.Excluded = true
+				skipped = fmt.Sprintf(" (skipped %s: unsaved changes)", edit.RelPath)
+			}
+		}
+	}
+
+	journal, err := file.NewJournal()
+	if err != nil {
+		dlg.SetMessage(fmt.Sprintf("Replace failed: %v", err))
+		return
+	}
+
+	files, replacements, err := search.ApplyFileEdits(edits, journal)
+	if err != nil {
+		journal.Discard()
+		dlg.SetMessage(fmt.Sprintf("Replace failed: %v", err))
+		return
+	}
+
+	// Only the latest replace can be undone
+	e.discardReplaceJournal()
+	e.replaceJournal = journal
+	e.reloadReplacedFile(journal.Entries())
+
+	msg := fmt.Sprintf("Replaced %d in %d files%s - Alt+U undo, Alt+F undo file", replacements, files, skipped)
+	dlg.SetApplied(msg)
+	e.setStatus(fmt.Sprintf("Replaced %d in %d files", replacements, files))
+}
+
+// undoFileReplace rolls back every file changed by the last replace in
+// files and returns a message describing the outcome.
+func (e *Editor) undoFileReplace() string {
+	if e.replaceJournal == nil {
+		return "Nothing to undo"
+	}
+
+	entries := slices.Clone(e.replaceJournal.Entries())
+	err := e.replaceJournal.Rollback()
+	e.reloadReplacedFile(entries)
+	if err != nil {
+		return fmt.Sprintf("Undo failed: %v", err)
+	}
+
+	e.replaceJournal = nil
+	return fmt.Sprintf("Restored %d files", len(entries))
+}
+
+// undoFileReplaceFile rolls back one file changed by the last replace in
+// files and returns a message describing the outcome.
+func (e *Editor) undoFileReplaceFile(path string) string {
+	if e.replaceJournal == nil {
+		return "Nothing to undo"
+	}
+
+	entries := slices.Clone(e.replaceJournal.Entries())
+	if err := e.replaceJournal.Restore(path); err != nil {
+		return fmt.Sprintf("Undo failed: %v", err)
+	}
+	e.reloadReplacedFile(entries)
+
+	if len(e.replaceJournal.Entries()) == 0 {
+		e.discardReplaceJournal()
+	}
+	return fmt.Sprintf("Restored %s", path)
+}
+
+// discardReplaceJournal deletes the backups of the last replace in files,
+// keeping its changes.
+func (e *Editor) discardReplaceJournal() {
+	if e.replaceJournal != nil {
+		e.replaceJournal.Discard()
+		e.replaceJournal = nil
+	}
+}
+
+// reloadReplacedFile reloads the open file if it is one of entries and has
+// no unsaved changes, keeping the cursor where it was.
+func (e *Editor) reloadReplacedFile(entries []file.JournalEntry) {
+	if e.filePath == "" || e.buffer.IsModified() {
+		return
+	}
+
+	for _, entry := range entries {
+		if !e.isOpenFile(entry.Path) {
+			continue
+		}
+		cursor := e.buffer.GetCursor()
+		if err := e.OpenFile(e.filePath); err != nil {
+			e.setStatus(fmt.Sprintf("Reload failed: %v", err))
+			return
+		}
+		e.buffer.MoveCursor(cursor)
+		e.hasSelection = false
+		return
+	}
+}
+
+// isOpenFile reports whether path names the file being edited.
+func (e *Editor) isOpenFile(path string) bool {
+	if e.filePath == "" {
+		return false
+	}
+	current, err := filepath.Abs(e.filePath)
+	if err != nil {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	return err == nil && abs == current
+}
@@ -0,0 +1,140 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AndrewDonelson/ted/core/file"
+	"github.com/AndrewDonelson/ted/ui/dialog"
+)
+
+// SetReadOnly forces read-only mode (the --readonly flag).
+// Editing and saving stay disabled for every file until the editor exits.
+func (e *Editor) SetReadOnly(readOnly bool) {
+	e.forceReadOnly = readOnly
+	e.readOnly = readOnly || (e.filePath != "" && !file.IsWritable(e.filePath))
+}
+
+// IsReadOnly returns whether editing is currently disabled.
+func (e *Editor) IsReadOnly() bool {
+	return e.readOnly
+}
+
+// SetSaveHelper sets the command used to save files the user cannot write.
+// See file.WriteFileWithHelper for the {src} and {dst} placeholders.
+func (e *Editor) SetSaveHelper(helper []string) {
+	if len(helper) == 0 {
+		helper = file.DefaultSaveHelper
+	}
+	e.saveHelper = helper
+}
+
+// setStatus sets the transient message shown in the info bar.
+func (e *Editor) setStatus(msg string) {
+	e.statusMessage = msg
+}
+
+// showReadOnlyStatus explains why an edit was refused.
+func (e *Editor) showReadOnlyStatus() {
+	if e.forceReadOnly {
+		e.setStatus("Read-only mode (--readonly)")
+		return
+	}
+	e.setStatus("File is read-only (File > Toggle Read-only to edit)")
+}
+
+// handleToggleReadOnly unlocks or relocks editing of a file that was opened
+// read-only because it is not writable. Forced read-only cannot be unlocked.
+func (e *Editor) handleToggleReadOnly() {
+	if e.forceReadOnly {
+		e.showReadOnlyStatus()
+		return
+	}
+	e.readOnly = !e.readOnly
+	if e.readOnly {
+		e.setStatus("Editing locked")
+	} else {
+		e.setStatus("Editing unlocked")
+	}
+}
+
+// handleSave saves the current file, offering a privileged save when
+// the file cannot be written by the current user.
+func (e *Editor) handleSave() error {
+	if e.filePath == "" {
+		// No file path yet - ask for one
+		return e.handleSaveAs()
+	}
+
+	if e.forceReadOnly {
+		e.showReadOnlyStatus()
+		return nil
+	}
+
+	if !file.IsWritable(e.filePath) {
+		e.promptPrivilegedSave()
+		return nil
+	}
+
+	if err := e.SaveFile(); err != nil {
+		e.setStatus(fmt.Sprintf("Save failed: %v", err))
+		return nil
+	}
+
+	e.setStatus("Saved")
+	return nil
+}
+
+// promptPrivilegedSave asks whether to save through the save helper.
+func (e *Editor) promptPrivilegedSave() {
+	message := fmt.Sprintf("Cannot write '%s'.\nSave via %s?", e.getFileName(), e.saveHelper[0])
+
+	confirmDlg := dialog.NewConfirmDialog(
+		"Permission Denied",
+		message,
+		func() {
+			if err := e.SaveFileWithHelper(); err != nil {
+				e.setStatus(fmt.Sprintf("Save failed: %v", err))
+				return
+			}
+			e.setStatus(fmt.Sprintf("Saved via %s", e.saveHelper[0]))
+		},
+		func() {
+			e.setStatus("Save cancelled")
+		},
+	)
+
+	width, height := e.screen.GetSize()
+	e.dialogManager.Push(confirmDlg, width, height)
+}
+
+// SaveFileWithHelper saves the buffer through the save helper command
+// (by default "sudo cp"). The screen is suspended while the helper runs
+// so it can prompt for a password on the terminal.
+func (e *Editor) SaveFileWithHelper() error {
+	if e.filePath == "" {
+		return fmt.Errorf("no file path set")
+	}
+
+	opts := e.saveOptions()
+	lines := opts.PrepareLines(e.buffer.GetAllLines())
+
+	if err := e.screen.Suspend(); err != nil {
+		return fmt.Errorf("suspend screen: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "ted: saving %s with %q\n", e.filePath, e.saveHelper[0])
+	writeErr := file.WriteFileWithHelper(e.filePath, lines, opts, e.saveHelper)
+
+	if err := e.screen.Resume(); err != nil {
+		return fmt.Errorf("resume screen: %w", err)
+	}
+
+	if writeErr != nil {
+		return fmt.Errorf("write file: %w", writeErr)
+	}
+
+	e.applySavedLines(lines)
+	e.markSaved(lines)
+	return nil
+}
@@ -0,0 +1,314 @@
+package editor
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/core/clipboard"
+	"github.com/AndrewDonelson/ted/core/complete"
+	"github.com/AndrewDonelson/ted/core/history"
+	"github.com/AndrewDonelson/ted/core/snippet"
+	"github.com/AndrewDonelson/ted/ui/terminal"
+)
+
+// snippetSession is a snippet being filled in: Tab and Shift+Tab move
+// between its tab stops until the cursor reaches $0 or leaves it. Its
+// edits are undone together, as one.
+type snippetSession struct {
+	buffer   *buffer.Buffer // Buffer the snippet was expanded in
+	stops    []*snippetStop // In the order Tab visits them, $0 last
+	current  int            // Index of the tab stop being edited
+	whole    *buffer.TrackedRange
+	choosing bool // The completion popup offers the current tab stop's choices
+}
+
+// snippetStop is a tab stop of a snippet being filled in.
+type snippetStop struct {
+	fields  []*buffer.TrackedRange // Where it is edited, then its mirrors
+	choices []string
+	synced  string // Text last copied to the mirrors
+}
+
+// handleSnippetKey handles the keys a snippet uses. Tab after a snippet's
+// prefix expands it, and while one is being filled in Tab and Shift+Tab
+// move between its tab stops and Esc stops filling it in. Typing or
+// deleting over a placeholder that is still selected replaces it. It
+// reports whether the key was used up.
+func (e *Editor) handleSnippetKey(ke *terminal.KeyEvent) bool {
+	switch ke.Action {
+	case terminal.KeyActionIndent:
+		if e.expandSnippet() {
+			return true
+		}
+		if e.snippet == nil || e.completion.IsOpen() {
+			return false
+		}
+		e.moveToStop(e.snippet.current + 1)
+		return true
+	case terminal.KeyActionOutdent:
+		if e.snippet == nil || e.completion.IsOpen() {
+			return false
+		}
+		e.moveToStop(max(e.snippet.current-1, 0))
+		return true
+	case terminal.KeyActionEscape:
+		if e.snippet != nil && !e.completion.IsOpen() {
+			e.endSnippet()
+		}
+	case terminal.KeyActionCharacter:
+		if e.snippet != nil && ke.IsPrintable() {
+			e.clearPlaceholder()
+		}
+	case terminal.KeyActionBackspace, terminal.KeyActionDelete:
+		return e.snippet != nil && e.clearPlaceholder()
+	}
+	return false
+}
+
+// expandSnippet replaces the word before the cursor with the snippet it is
+// the prefix of, for the file's language, and selects the first tab stop.
+// It reports whether there was a snippet to expand.
+func (e *Editor) expandSnippet() bool {
+	if e.readOnly || e.hasSelection || e.buffer.HasExtraCursors() || e.activeBlock() != nil {
+		return false
+	}
+	cursor := e.buffer.GetCursor()
+	line, _ := e.buffer.GetLine(cursor.Line)
+	prefix := complete.Prefix(line, cursor.Col)
+	body, ok := e.config.LanguageSnippets(e.detectFileType())[prefix]
+	if prefix == "" || !ok {
+		return false
+	}
+	s, err := snippet.Parse(body)
+	if err != nil {
+		e.setStatus(err.Error()) // The configuration reported it too
+		return true
+	}
+
+	settings := e.editorSettings()
+	opts := snippet.Options{
+		Indent:   line[:len(line)-len(strings.TrimLeft(line, " \t"))],
+		Variable: e.snippetVariable(prefix),
+	}
+	if settings.UseSpaces {
+		opts.Tab = settings.indentUnit()
+	}
+	exp := s.Expand(opts)
+
+	e.endSnippet()
+	e.completion.Hide()
+	start := buffer.Position{Line: cursor.Line, Col: cursor.Col - len(prefix)}
+	e.history.BeginGroup("snippet")
+	end, err := e.buffer.Replace(start, cursor, exp.Text)
+	if err != nil {
+		e.history.EndGroup()
+		return true
+	}
+	e.isDirty = true
+	e.history.Push(&history.ReplaceOperation{StartPos: start, EndPos: cursor, Old: prefix, New: exp.Text})
+
+	session := &snippetSession{buffer: e.buffer, whole: e.buffer.Track(start, end)}
+	session.whole.Grow = true
+	for _, stop := range exp.Stops {
+		st := &snippetStop{choices: stop.Choices}
+		for _, field := range stop.Fields {
+			st.fields = append(st.fields, e.buffer.Track(
+				buffer.PositionAfter(start, exp.Text[:field.Start]),
+				buffer.PositionAfter(start, exp.Text[:field.End]),
+			))
+		}
+		st.synced = exp.Text[stop.Fields[0].Start:stop.Fields[0].End]
+		session.stops = append(session.stops, st)
+	}
+	e.snippet = session
+	e.moveToStop(0)
+	return true
+}
+
+// snippetVariable returns the values of the variables a snippet may use,
+// for one expanded in place of prefix at the cursor.
+func (e *Editor) snippetVariable(prefix string) func(name string) (string, bool) {
+	return func(name string) (string, bool) {
+		cursor := e.buffer.GetCursor()
+		base := ""
+		if e.filePath != "" {
+			base = filepath.Base(e.filePath)
+		}
+		switch name {
+		case "TM_FILENAME":
+			return base, true
+		case "TM_FILENAME_BASE":
+			return strings.TrimSuffix(base, filepath.Ext(base)), true
+		case "TM_FILEPATH":
+			return e.filePath, true
+		case "TM_DIRECTORY":
+			if e.filePath == "" {
+				return "", true
+			}
+			return filepath.Dir(e.filePath), true
+		case "TM_LINE_INDEX":
+			return strconv.Itoa(cursor.Line), true
+		case "TM_LINE_NUMBER":
+			return strconv.Itoa(cursor.Line + 1), true
+		case "TM_CURRENT_LINE":
+			line, _ := e.buffer.GetLine(cursor.Line)
+			return line[:cursor.Col-len(prefix)] + line[cursor.Col:], true
+		case "CLIPBOARD":
+			text, _ := clipboard.Read() // An unreadable clipboard is empty
+			return text, true
+		case "CURRENT_YEAR":
+			return time.Now().Format("2006"), true
+		case "CURRENT_MONTH":
+			return time.Now().Format("01"), true
+		case "CURRENT_DATE":
+			return time.Now().Format("02"), true
+		}
+		return "", false
+	}
+}
+
+// moveToStop moves to the snippet's tab stop i: its placeholder is
+// selected, or its choices offered. Reaching $0 ends the snippet with the
+// cursor there.
+func (e *Editor) moveToStop(i int) {
+	s := e.snippet
+	i = min(i, len(s.stops)-1)
+	s.stops[s.current].fields[0].Grow = false
+	s.current = i
+	stop := s.stops[i]
+	field := stop.fields[0]
+	if i == len(s.stops)-1 {
+		e.endSnippet()
+		e.clearSelection()
+		e.buffer.MoveCursor(field.Start)
+		return
+	}
+
+	// Text typed at either end of the field is part of it
+	field.Grow = true
+	e.selectRange(field.Start, field.End)
+	s.choosing = false
+	if len(stop.choices) > 0 {
+		items := make([]complete.Item, len(stop.choices))
+		for i, choice := range stop.choices {
+			items[i] = complete.Item{Text: choice}
+		}
+		e.completion.Show(items, field.Start)
+		s.choosing = true
+	}
+}
+
+// clearPlaceholder deletes the current tab stop's placeholder if it is
+// still selected, so what is typed replaces it. It reports whether it did.
+func (e *Editor) clearPlaceholder() bool {
+	field := e.snippet.stops[e.snippet.current].fields[0]
+	start, end := e.getSelectionRange()
+	if e.readOnly || !e.hasSelection || start != field.Start || end != field.End {
+		return false
+	}
+	text, err := e.buffer.GetText(start, end)
+	if err != nil {
+		return false
+	}
+	// Replaced rather than deleted, which would drop a line it empties
+	if _, err := e.buffer.Replace(start, end, ""); err != nil {
+		return false
+	}
+	e.isDirty = true
+	e.history.Push(&history.ReplaceOperation{StartPos: start, EndPos: end, Old: text, New: ""})
+	e.clearSelection()
+	return true
+}
+
+// chooseSnippetChoice replaces the current tab stop's text with one of its
+// choices.
+func (e *Editor) chooseSnippetChoice(choice string) {
+	field := e.snippet.stops[e.snippet.current].fields[0]
+	start, end := field.Start, field.End
+	old, err := e.buffer.GetText(start, end)
+	if err != nil {
+		return
+	}
+	if _, err := e.buffer.Replace(start, end, choice); err != nil {
+		return
+	}
+	e.isDirty = true
+	e.history.Push(&history.ReplaceOperation{StartPos: start, EndPos: end, Old: old, New: choice})
+	e.clearSelection()
+}
+
+// updateSnippet copies the text of each tab stop to its mirrors after an
+// edit, and ends the snippet once the cursor has left it.
+func (e *Editor) updateSnippet() {
+	s := e.snippet
+	if s == nil {
+		return
+	}
+	if s.buffer != e.buffer {
+		e.endSnippet()
+		return
+	}
+	e.syncMirrors()
+	cursor := e.buffer.GetCursor()
+	if buffer.ComparePositions(cursor, s.whole.Start) < 0 || buffer.ComparePositions(cursor, s.whole.End) > 0 {
+		e.endSnippet()
+	}
+}
+
+// syncMirrors copies the text of each tab stop that has changed to its
+// mirrors. The cursor and selection stay on the same text.
+func (e *Editor) syncMirrors() {
+	cursor := e.buffer.Track(e.buffer.GetCursor(), e.buffer.GetCursor())
+	cursor.Grow = true // Stays before text a mirror gains at the cursor
+	anchor := e.buffer.Track(e.selectionStart, e.selectionStart)
+	anchor.Grow = true
+	defer e.buffer.Untrack(cursor, anchor)
+
+	changed := false
+	for _, stop := range e.snippet.stops {
+		text, err := e.buffer.GetText(stop.fields[0].Start, stop.fields[0].End)
+		if err != nil || text == stop.synced {
+			continue
+		}
+		stop.synced = text
+		for _, mirror := range stop.fields[1:] {
+			start, end := mirror.Start, mirror.End
+			old, err := e.buffer.GetText(start, end)
+			if err != nil || old == text {
+				continue
+			}
+			mirror.Grow = true // Keeps the text even when it was empty
+			_, err = e.buffer.Replace(start, end, text)
+			mirror.Grow = false
+			if err != nil {
+				continue
+			}
+			e.history.Push(&history.ReplaceOperation{StartPos: start, EndPos: end, Old: old, New: text})
+			changed = true
+		}
+	}
+	if changed {
+		e.buffer.MoveCursor(cursor.Start)
+		e.selectionStart = anchor.Start
+		e.updateSelectionEnd()
+	}
+}
+
+// endSnippet stops filling in the snippet, if one is being filled in, and
+// records its edits as one.
+func (e *Editor) endSnippet() {
+	s := e.snippet
+	if s == nil {
+		return
+	}
+	e.snippet = nil
+	ranges := []*buffer.TrackedRange{s.whole}
+	for _, stop := range s.stops {
+		ranges = append(ranges, stop.fields...)
+	}
+	s.buffer.Untrack(ranges...)
+	e.history.EndGroup()
+}
@@ -0,0 +1,70 @@
+package editor
+
+import (
+	"github.com/AndrewDonelson/ted/core/state"
+)
+
+// SetStatePath sets the file used to remember state between sessions and
+// restores the search history and options, the recently used commands, the
+// saved macros and the bookmarks kept there. State is only persisted when a path is set.
+// An error reading the state is also shown in the status bar.
+func (e *Editor) SetStatePath(path string) error {
+	e.statePath = path
+
+	s, err := state.Load(path)
+	if err != nil {
+		e.statusMessage = err.Error()
+		return err
+	}
+
+	e.recentCommands = s.RecentCommands
+	e.namedMacros = macrosFromState(s.Macros)
+	e.savedBookmarks = s.Bookmarks
+	if e.filePath != "" {
+		e.restoreBookmarks(e.filePath)
+	}
+
+	finder := e.searchManager.GetFinder()
+	finder.SetHistory(s.Search.Patterns)
+	e.searchManager.GetReplacer().SetHistory(s.Search.Replacements)
+
+	options := finder.GetOptions()
+	options.CaseSensitive = s.Search.CaseSensitive
+	options.WholeWord = s.Search.WholeWord
+	options.UseRegex = s.Search.UseRegex
+	options.PreserveCase = s.Search.PreserveCase
+	options.Fuzzy = s.Search.Fuzzy
+	finder.SetOptions(options)
+	return nil
+}
+
+// saveState writes the search history and options, the recently used
+// commands, the saved macros and the bookmarks to the state file.
+func (e *Editor) saveState() error {
+	if e.statePath == "" {
+		return nil
+	}
+
+	s, err := state.Load(e.statePath)
+	if err != nil {
+		// Replace a damaged state file rather than failing every exit
+		s = &state.State{}
+	}
+
+	finder := e.searchManager.GetFinder()
+	options := finder.GetOptions()
+	s.Search = state.Search{
+		Patterns:      finder.GetHistory(),
+		Replacements:  e.searchManager.GetReplacer().GetHistory(),
+		CaseSensitive: options.CaseSensitive,
+		WholeWord:     options.WholeWord,
+		UseRegex:      options.UseRegex,
+		PreserveCase:  options.PreserveCase,
+		Fuzzy:         options.Fuzzy,
+	}
+	s.RecentCommands = e.recentCommands
+	s.Macros = macrosToState(e.namedMacros)
+	e.stashBookmarks()
+	s.Bookmarks = e.savedBookmarks
+	return s.Save(e.statePath)
+}
@@ -0,0 +1,25 @@
+package editor
+
+import (
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/search"
+	"github.com/AndrewDonelson/ted/ui/dialog"
+)
+
+// handleGoToSymbol shows a fuzzy picker of the functions, types and
+// headings in the buffer and moves the cursor to the chosen one.
+func (e *Editor) handleGoToSymbol() error {
+	symbols := search.ExtractSymbols(e.buffer.GetAllLines(), e.detectFileType())
+	if len(symbols) == 0 {
+		e.setStatus("No symbols found")
+		return nil
+	}
+
+	dlg := dialog.NewSymbolDialog(symbols, func(sym search.Symbol) {
+		e.jumpTo(buffer.Position{Line: sym.Line, Col: sym.Col})
+	}, nil)
+
+	width, height := e.screen.GetSize()
+	e.dialogManager.Push(dlg, width, height)
+	return nil
+}
@@ -5,17 +5,50 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 
+	"github.com/AndrewDonelson/ted/config"
+	"github.com/AndrewDonelson/ted/core/file"
+	"github.com/AndrewDonelson/ted/core/state"
 	"github.com/AndrewDonelson/ted/editor"
 )
 
 func main() {
 	// Parse command-line arguments
+	readOnly := flag.Bool("readonly", false, "open the file read-only (no editing or saving)")
+	saveHelper := flag.String("save-helper", "", "command used to save files you cannot write (default \"sudo cp {src} {dst}\")")
+	printKeys := flag.Bool("keys", false, "print the keyboard shortcuts and exit")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ted [options] [file]\n\nOptions:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
 	var filePath string
-	if len(os.Args) > 1 {
-		filePath = os.Args[1]
+	if flag.NArg() > 0 {
+		filePath = flag.Arg(0)
+	}
+
+	// Settings come from the user's config file and the project's
+	// .ted.toml; invalid entries are skipped and the rest still apply
+	configPath, err := config.UserPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if *printKeys {
+		cfg, err := config.Load(configPath, config.ProjectPath(filepath.Dir(filePath)))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		keymap, err := editor.NewKeymap(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		fmt.Print(keymap.CheatSheet())
+		return
 	}
 
 	// Create editor
@@ -25,6 +58,15 @@ func main() {
 		os.Exit(1)
 	}
 
+	ed.SetReadOnly(*readOnly)
+	ed.SetSaveHelper(file.ParseSaveHelper(*saveHelper))
+
+	// Search history is remembered between sessions; without it ted still
+	// works. Problems are shown in the status bar
+	if statePath, err := state.DefaultPath(); err == nil {
+		ed.SetStatePath(statePath)
+	}
+
 	// Set file path if provided (even if file doesn't exist yet - for new files)
 	if filePath != "" {
 		// Try to open file, but if it doesn't exist, set path anyway for new file
@@ -35,6 +77,10 @@ func main() {
 		}
 	}
 
+	// Load settings once the file is known, so its project's apply.
+	// Problems are shown in the status bar; the screen is already up
+	ed.SetConfigPath(configPath)
+
 	// Run editor
 	if err := ed.Run(); err != nil {
 		if err == editor.ErrQuit {
@@ -0,0 +1,171 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// caseShape is the letter case and word separator style of an identifier.
+type caseShape int
+
+const (
+	shapeUnknown    caseShape = iota // Mixed; replacement is used as typed
+	shapeLower                       // userid
+	shapeTitle                       // Userid
+	shapeUpper                       // USERID
+	shapeCamel                       // userId
+	shapePascal                      // UserId
+	shapeSnake                       // user_id
+	shapeUpperSnake                  // USER_ID
+	shapeKebab                       // user-id
+	shapeUpperKebab                  // USER-ID
+)
+
+// detectCase returns the case shape of s.
+func detectCase(s string) caseShape {
+	hasUpper, hasLower := false, false
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			hasUpper = true
+		} else if unicode.IsLower(r) {
+			hasLower = true
+		}
+	}
+	if !hasUpper && !hasLower {
+		return shapeUnknown
+	}
+
+	for _, sep := range []struct {
+		sep          string
+		lower, upper caseShape
+	}{
+		{"_", shapeSnake, shapeUpperSnake},
+		{"-", shapeKebab, shapeUpperKebab},
+	} {
+		if !strings.Contains(s, sep.sep) {
+			continue
+		}
+		switch {
+		case !hasUpper:
+			return sep.lower
+		case !hasLower:
+			return sep.upper
+		}
+		return shapeUnknown
+	}
+
+	switch {
+	case !hasUpper:
+		return shapeLower
+	case !hasLower:
+		return shapeUpper
+	}
+
+	first, size := utf8.DecodeRuneInString(s)
+	rest := s[size:]
+	switch {
+	case unicode.IsLower(first):
+		return shapeCamel
+	case strings.ToLower(rest) == rest:
+		return shapeTitle
+	}
+	return shapePascal
+}
+
+// applyCase rewrites replacement in the given case shape.
+// "accountId" becomes "ACCOUNT_ID" for shapeUpperSnake, for example.
+func applyCase(shape caseShape, replacement string) string {
+	switch shape {
+	case shapeLower:
+		return strings.ToLower(replacement)
+	case shapeUpper:
+		return strings.ToUpper(replacement)
+	case shapeTitle:
+		return upperFirst(replacement)
+	case shapeCamel, shapePascal:
+		words := splitWords(replacement)
+		for i, w := range words {
+			words[i] = upperFirst(strings.ToLower(w))
+		}
+		if shape == shapeCamel && len(words) > 0 {
+			words[0] = strings.ToLower(words[0])
+		}
+		return strings.Join(words, "")
+	case shapeSnake:
+		return strings.ToLower(strings.Join(splitWords(replacement), "_"))
+	case shapeUpperSnake:
+		return strings.ToUpper(strings.Join(splitWords(replacement), "_"))
+	case shapeKebab:
+		return strings.ToLower(strings.Join(splitWords(replacement), "-"))
+	case shapeUpperKebab:
+		return strings.ToUpper(strings.Join(splitWords(replacement), "-"))
+	}
+	return replacement
+}
+
+// preserveCase rewrites replacement in the case shape of match.
+func preserveCase(match, replacement string) string {
+	return applyCase(detectCase(match), replacement)
+}
+
+// splitWords splits an identifier into words at underscores, hyphens and
+// case changes: "userId", "user_id" and "USER-ID" all give "user"/"Id"
+// style pairs, and "HTTPServer" gives "HTTP", "Server".
+func splitWords(s string) []string {
+	var words []string
+	runes := []rune(s)
+	start := 0
+
+	flush := func(end int) {
+		if end > start {
+			words = append(words, string(runes[start:end]))
+		}
+	}
+
+	for i, r := range runes {
+		if r == '_' || r == '-' {
+			flush(i)
+			start = i + 1
+			continue
+		}
+		if i == start || !unicode.IsUpper(r) {
+			continue
+		}
+		prev := runes[i-1]
+		nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if !unicode.IsUpper(prev) || nextLower {
+			flush(i)
+			start = i
+		}
+	}
+	flush(len(runes))
+
+	return words
+}
+
+// upperFirst upper-cases the first letter of s.
+func upperFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// casingPattern returns a case-insensitive regexp source that matches
+// pattern in any case shape, so "userId" also finds "UserId", "USER_ID"
+// and "user-id".
+func casingPattern(pattern string) string {
+	words := splitWords(pattern)
+	if len(words) <= 1 {
+		return "(?i)" + regexp.QuoteMeta(pattern)
+	}
+
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = regexp.QuoteMeta(w)
+	}
+	return "(?i)" + strings.Join(quoted, "[_-]?")
+}
@@ -0,0 +1,107 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"userId", []string{"user", "Id"}},
+		{"UserId", []string{"User", "Id"}},
+		{"USER_ID", []string{"USER", "ID"}},
+		{"user-id", []string{"user", "id"}},
+		{"HTTPServer", []string{"HTTP", "Server"}},
+		{"userID", []string{"user", "ID"}},
+		{"user", []string{"user"}},
+		{"_private", []string{"private"}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		if got := splitWords(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitWords(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDetectCase(t *testing.T) {
+	tests := []struct {
+		in   string
+		want caseShape
+	}{
+		{"userid", shapeLower},
+		{"Userid", shapeTitle},
+		{"USERID", shapeUpper},
+		{"userId", shapeCamel},
+		{"UserId", shapePascal},
+		{"user_id", shapeSnake},
+		{"USER_ID", shapeUpperSnake},
+		{"user-id", shapeKebab},
+		{"USER-ID", shapeUpperKebab},
+		{"User_id", shapeUnknown},
+		{"123", shapeUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := detectCase(tt.in); got != tt.want {
+			t.Errorf("detectCase(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPreserveCase(t *testing.T) {
+	tests := []struct {
+		match string
+		want  string
+	}{
+		{"userid", "accountid"},
+		{"Userid", "AccountId"},
+		{"USERID", "ACCOUNTID"},
+		{"userId", "accountId"},
+		{"UserId", "AccountId"},
+		{"user_id", "account_id"},
+		{"USER_ID", "ACCOUNT_ID"},
+		{"user-id", "account-id"},
+		{"USER-ID", "ACCOUNT-ID"},
+		{"User_id", "accountId"},
+	}
+
+	for _, tt := range tests {
+		if got := preserveCase(tt.match, "accountId"); got != tt.want {
+			t.Errorf("preserveCase(%q, %q) = %q, want %q", tt.match, "accountId", got, tt.want)
+		}
+	}
+}
+
+func TestReplacer_ReplaceAll_PreserveCase(t *testing.T) {
+	finder := NewFinder()
+	finder.SetPattern("userId")
+	opts := finder.GetOptions()
+	opts.PreserveCase = true
+	finder.SetOptions(opts)
+
+	r := NewReplacer(finder)
+	r.SetReplacement("accountId")
+
+	buf := buffer.NewBuffer()
+	buf.SetLines([]string{"userId := u.UserId", "const USER_ID = 1", "<div data-user-id>", "useridentical"})
+
+	count, err := r.ReplaceAll(buf, nil)
+	if err != nil {
+		t.Fatalf("ReplaceAll() error = %v", err)
+	}
+	if count != 5 {
+		t.Errorf("ReplaceAll() count = %d, want 5", count)
+	}
+
+	want := []string{"accountId := u.AccountId", "const ACCOUNT_ID = 1", "<div data-account-id>", "accountidentical"}
+	if got := buf.GetAllLines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+}
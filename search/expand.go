@@ -0,0 +1,232 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// caseMode is the case conversion applied while expanding a replacement.
+type caseMode int
+
+const (
+	caseNone caseMode = iota
+	caseUpper
+	caseLower
+)
+
+// expander builds the expanded replacement text, applying the active
+// \U/\L mode and any pending one-shot \u/\l conversion.
+type expander struct {
+	sb      strings.Builder
+	mode    caseMode
+	oneShot caseMode
+}
+
+// write appends s with the current case conversions applied.
+func (x *expander) write(s string) {
+	if s == "" {
+		return
+	}
+
+	switch x.mode {
+	case caseUpper:
+		s = strings.ToUpper(s)
+	case caseLower:
+		s = strings.ToLower(s)
+	}
+
+	if x.oneShot != caseNone {
+		r, size := utf8.DecodeRuneInString(s)
+		if x.oneShot == caseUpper {
+			r = unicode.ToUpper(r)
+		} else {
+			r = unicode.ToLower(r)
+		}
+		x.sb.WriteRune(r)
+		s = s[size:]
+		x.oneShot = caseNone
+	}
+
+	x.sb.WriteString(s)
+}
+
+// expandTemplate expands a regex replacement template.
+//
+// groups holds the capture group text (groups[0] is the whole match) and
+// names the group names as returned by regexp.Regexp.SubexpNames.
+//
+// Supported syntax:
+//
+//	$0 $& ${0}   whole match
+//	$1 ${1}      numbered group
+//	${name}      named group
+//	$$ \$        literal dollar
+//	$` $'        kept as written
+//	\n \t \\     newline, tab, backslash
+//	\U \L        upper/lower case until \E
+//	\u \l        upper/lower case the next character
+//
+// References to groups that do not exist expand to nothing.
+// Unrecognized escapes are copied through unchanged.
+func expandTemplate(template string, groups []string, names []string) string {
+	var x expander
+
+	group := func(ref string) string {
+		if n, err := strconv.Atoi(ref); err == nil {
+			if n >= 0 && n < len(groups) {
+				return groups[n]
+			}
+			return ""
+		}
+		for i, name := range names {
+			if name != "" && name == ref && i < len(groups) {
+				return groups[i]
+			}
+		}
+		return ""
+	}
+
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+
+		if c == '$' && i+1 < len(template) {
+			next := template[i+1]
+			switch {
+			case next == '$':
+				x.write("$")
+				i++
+				continue
+			case next == '&':
+				x.write(group("0"))
+				i++
+				continue
+			case isDigit(next):
+				j := i + 1
+				for j < len(template) && isDigit(template[j]) {
+					j++
+				}
+				x.write(group(template[i+1 : j]))
+				i = j - 1
+				continue
+			case next == '{':
+				end := strings.IndexByte(template[i+2:], '}')
+				if end > 0 {
+					x.write(group(template[i+2 : i+2+end]))
+					i += 2 + end
+					continue
+				}
+			}
+		}
+
+		if c == '\\' && i+1 < len(template) {
+			next := template[i+1]
+			handled := true
+			switch next {
+			case 'n':
+				x.write("\n")
+			case 't':
+				x.write("\t")
+			case '\\':
+				x.write("\\")
+			case '$':
+				x.write("$")
+			case 'U':
+				x.mode = caseUpper
+			case 'L':
+				x.mode = caseLower
+			case 'E':
+				x.mode = caseNone
+			case 'u':
+				x.oneShot = caseUpper
+			case 'l':
+				x.oneShot = caseLower
+			default:
+				handled = false
+			}
+			if handled {
+				i++
+				continue
+			}
+		}
+
+		x.write(template[i : i+1])
+	}
+
+	return x.sb.String()
+}
+
+// validateTemplate checks that a replacement template is well-formed.
+// If re is non-nil, group references are also checked against it.
+func validateTemplate(template string, re *regexp.Regexp) error {
+	for i := 0; i < len(template); i++ {
+		switch template[i] {
+		case '$':
+			if i+1 >= len(template) {
+				return fmt.Errorf("incomplete escape at end of replacement")
+			}
+			next := template[i+1]
+			switch {
+			case next == '$' || next == '&' || next == '`' || next == '\'':
+				i++
+			case isDigit(next):
+				j := i + 1
+				for j < len(template) && isDigit(template[j]) {
+					j++
+				}
+				if err := checkGroupRef(template[i+1:j], re); err != nil {
+					return err
+				}
+				i = j - 1
+			case next == '{':
+				end := strings.IndexByte(template[i+2:], '}')
+				if end < 0 {
+					return fmt.Errorf("unclosed named group reference")
+				}
+				if end == 0 {
+					return fmt.Errorf("empty group reference")
+				}
+				if err := checkGroupRef(template[i+2:i+2+end], re); err != nil {
+					return err
+				}
+				i += 2 + end
+			default:
+				return fmt.Errorf("invalid escape sequence: $%c", next)
+			}
+		case '\\':
+			if i+1 >= len(template) {
+				return fmt.Errorf("incomplete escape at end of replacement")
+			}
+			i++
+		}
+	}
+
+	return nil
+}
+
+// checkGroupRef verifies that ref names a group defined by re.
+func checkGroupRef(ref string, re *regexp.Regexp) error {
+	if re == nil {
+		return nil
+	}
+
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n > re.NumSubexp() {
+			return fmt.Errorf("reference to undefined group $%d", n)
+		}
+		return nil
+	}
+
+	if re.SubexpIndex(ref) < 0 {
+		return fmt.Errorf("reference to undefined group ${%s}", ref)
+	}
+	return nil
+}
+
+// isDigit reports whether b is an ASCII digit.
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
@@ -0,0 +1,84 @@
+package search
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	groups := []string{"John Smith", "John", "Smith"}
+	names := []string{"", "first", "last"}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{name: "plain text", template: "name", want: "name"},
+		{name: "numbered groups", template: "$2, $1", want: "Smith, John"},
+		{name: "braced numbered group", template: "${1}x", want: "Johnx"},
+		{name: "named groups", template: "${last} ${first}", want: "Smith John"},
+		{name: "whole match dollar zero", template: "[$0]", want: "[John Smith]"},
+		{name: "whole match ampersand", template: "[$&]", want: "[John Smith]"},
+		{name: "escaped dollar", template: "$$1", want: "$1"},
+		{name: "before and after match kept", template: "$`$1$'", want: "$`John$'"},
+		{name: "backslash dollar", template: `\$1`, want: "$1"},
+		{name: "undefined group", template: "a$9b", want: "ab"},
+		{name: "undefined name", template: "a${nope}b", want: "ab"},
+		{name: "newline and tab", template: `$1\n\t$2`, want: "John\n\tSmith"},
+		{name: "escaped backslash", template: `$1\\$2`, want: `John\Smith`},
+		{name: "upper until end", template: `\U$1\E $2`, want: "JOHN Smith"},
+		{name: "upper to end of template", template: `\U$0`, want: "JOHN SMITH"},
+		{name: "lower", template: `\L$0`, want: "john smith"},
+		{name: "upper first", template: `\u${first}`, want: "John"},
+		{name: "lower first", template: `\l$1`, want: "john"},
+		{name: "upper first then lower", template: `\u\LjOHN`, want: "John"},
+		{name: "case applies to literal text", template: `\Uabc\E def`, want: "ABC def"},
+		{name: "unknown escape kept", template: `a\qb`, want: `a\qb`},
+		{name: "trailing dollar kept", template: "cost$", want: "cost$"},
+		{name: "trailing backslash kept", template: `a\`, want: `a\`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := expandTemplate(tt.template, groups, names)
+			if got != tt.want {
+				t.Errorf("expandTemplate(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	re := regexp.MustCompile(`(?P<first>\w+) (\w+)`)
+
+	tests := []struct {
+		name     string
+		template string
+		re       *regexp.Regexp
+		wantErr  bool
+	}{
+		{name: "plain text", template: "hello", re: re},
+		{name: "numbered group", template: "$2 $1", re: re},
+		{name: "named group", template: "${first}", re: re},
+		{name: "escapes", template: `$$ $& \n \U$1\E`, re: re},
+		{name: "before and after match", template: "$` $'", re: re},
+		{name: "undefined numbered group", template: "$3", re: re, wantErr: true},
+		{name: "undefined named group", template: "${last}", re: re, wantErr: true},
+		{name: "any group without regexp", template: "$9 ${x}", re: nil},
+		{name: "invalid escape", template: "$a", re: re, wantErr: true},
+		{name: "unclosed brace", template: "${first", re: re, wantErr: true},
+		{name: "empty brace", template: "${}", re: re, wantErr: true},
+		{name: "trailing dollar", template: "a$", re: re, wantErr: true},
+		{name: "trailing backslash", template: `a\`, re: re, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTemplate(tt.template, tt.re)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateTemplate(%q) error = %v, wantErr %v", tt.template, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/AndrewDonelson/ted/core/file"
+)
+
+// Hunk is a run of lines changed by one or more replacements in a file.
+type Hunk struct {
+	StartLine int      // First changed line, 0-based
+	OldLines  []string // Lines before the replacement
+	NewLines  []string // Lines after the replacement
+	Matches   int      // Number of replacements in the hunk
+	Excluded  bool     // Leave these lines unchanged when applying
+}
+
+// FileEdit is the planned replacement in one file, split into hunks that
+// can be excluded individually before it is applied.
+type FileEdit struct {
+	Path       string
+	RelPath    string
+	Lines      []string // Content when the edit was planned
+	LineEnding file.LineEnding
+	Hunks      []*Hunk
+	Excluded   bool // Leave the whole file unchanged
+}
+
+// PlanFileReplace reads result's file and plans replacing every match of
+// pattern with replacement, honoring opts as Replacer does (regex groups,
+// preserve case). Overlapping matches are replaced once.
+func PlanFileReplace(result FileResult, pattern, replacement string, opts Options) (*FileEdit, error) {
+	data, err := os.ReadFile(result.Path)
+	if err != nil {
+		return nil, fmt.Errorf("read file %q: %w", result.Path, err)
+	}
+	lines := file.SplitLines(data)
+
+	finder := NewFinder()
+	finder.SetOptions(opts)
+	finder.SetPattern(pattern)
+	replacer := NewReplacer(finder)
+	replacer.SetReplacement(replacement)
+	re := replacer.replacementRegex()
+
+	matches, err := FindMatches(context.Background(), lines, pattern, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	edit := &FileEdit{
+		Path:       result.Path,
+		RelPath:    result.RelPath,
+		Lines:      lines,
+		LineEnding: file.DetectLineEnding(data),
+	}
+
+	// Group matches that share lines into hunks
+	var group []Match
+	flush := func() {
+		if len(group) > 0 {
+			edit.Hunks = append(edit.Hunks, buildHunk(lines, group, replacer, re))
+			group = nil
+		}
+	}
+	for _, m := range nonOverlapping(matches) {
+		if len(group) > 0 && m.StartLine > group[len(group)-1].EndLine {
+			flush()
+		}
+		group = append(group, m)
+	}
+	flush()
+
+	return edit, nil
+}
+
+// buildHunk applies matches, which all fall within one run of lines, to
+// those lines. re is the replacer's compiled pattern, as replaceMatch
+// takes it.
+func buildHunk(lines []string, matches []Match, replacer *Replacer, re *regexp.Regexp) *Hunk {
+	start := matches[0].StartLine
+	end := matches[len(matches)-1].EndLine
+	old := lines[start : end+1]
+
+	// Offset of each line of the hunk within the joined text
+	offsets := make([]int, len(old))
+	for i := 1; i < len(old); i++ {
+		offsets[i] = offsets[i-1] + len(old[i-1]) + 1
+	}
+
+	text := strings.Join(old, "\n")
+	var sb strings.Builder
+	pos := 0
+	for _, m := range matches {
+		from := offsets[m.StartLine-start] + m.StartCol
+		to := offsets[m.EndLine-start] + m.EndCol
+		sb.WriteString(text[pos:from])
+		sb.WriteString(replacer.getReplacementText(m, re))
+		pos = to
+	}
+	sb.WriteString(text[pos:])
+
+	return &Hunk{
+		StartLine: start,
+		OldLines:  slices.Clone(old),
+		NewLines:  strings.Split(sb.String(), "\n"),
+		Matches:   len(matches),
+	}
+}
+
+// Apply returns the file's lines with the included hunks applied.
+func (e *FileEdit) Apply() []string {
+	var out []string
+	line := 0
+	for _, h := range e.Hunks {
+		out = append(out, e.Lines[line:h.StartLine]...)
+		if h.Excluded {
+			out = append(out, h.OldLines...)
+		} else {
+			out = append(out, h.NewLines...)
+		}
+		line = h.StartLine + len(h.OldLines)
+	}
+	return append(out, e.Lines[line:]...)
+}
+
+// Replacements returns the number of replacements that will be applied.
+func (e *FileEdit) Replacements() int {
+	if e.Excluded {
+		return 0
+	}
+	n := 0
+	for _, h := range e.Hunks {
+		if !h.Excluded {
+			n += h.Matches
+		}
+	}
+	return n
+}
+
+// ApplyFileEdits writes the included edits through journal, which records
+// each file's original content. A file that changed since its edit was
+// planned stops the replace. On any error the files already written are
+// rolled back.
+//
+// It returns the number of files changed and replacements made.
+func ApplyFileEdits(edits []*FileEdit, journal *file.Journal) (files, replacements int, err error) {
+	for _, edit := range edits {
+		n := edit.Replacements()
+		if n == 0 {
+			continue
+		}
+
+		if err := edit.checkUnchanged(); err != nil {
+			return 0, 0, rollbackAfter(journal, err)
+		}
+		if err := journal.WriteFile(edit.Path, edit.Apply(), edit.LineEnding); err != nil {
+			return 0, 0, rollbackAfter(journal, fmt.Errorf("write %q: %w", edit.RelPath, err))
+		}
+
+		files++
+		replacements += n
+	}
+
+	return files, replacements, nil
+}
+
+// checkUnchanged verifies the file still holds the content the edit was
+// planned against.
+func (e *FileEdit) checkUnchanged() error {
+	data, err := os.ReadFile(e.Path)
+	if err != nil {
+		return fmt.Errorf("read file %q: %w", e.Path, err)
+	}
+	if !slices.Equal(file.SplitLines(data), e.Lines) {
+		return fmt.Errorf("file %q changed since the preview", e.RelPath)
+	}
+	return nil
+}
+
+// rollbackAfter rolls back journal after err, reporting both errors if the
+// rollback fails too.
+func rollbackAfter(journal *file.Journal, err error) error {
+	if rbErr := journal.Rollback(); rbErr != nil {
+		return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+	}
+	return fmt.Errorf("%w (changes rolled back)", err)
+}
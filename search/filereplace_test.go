@@ -0,0 +1,153 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AndrewDonelson/ted/core/file"
+)
+
+func TestPlanFileReplace(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"a.go": "foo := 1\r\nbar(foo, foo)\r\n\r\nkeep\r\nfoo\r\n",
+	})
+	path := filepath.Join(dir, "a.go")
+
+	edit, err := PlanFileReplace(FileResult{Path: path, RelPath: "a.go"}, "foo", "baz", Options{})
+	if err != nil {
+		t.Fatalf("PlanFileReplace() error = %v", err)
+	}
+
+	if edit.LineEnding != file.LineEndingCRLF {
+		t.Errorf("LineEnding = %v, want CRLF", edit.LineEnding)
+	}
+	if len(edit.Hunks) != 3 {
+		t.Fatalf("hunks = %d, want 3", len(edit.Hunks))
+	}
+	if h := edit.Hunks[1]; h.StartLine != 1 || h.Matches != 2 || h.NewLines[0] != "bar(baz, baz)" {
+		t.Errorf("second hunk = %+v", h)
+	}
+	if edit.Replacements() != 4 {
+		t.Errorf("Replacements() = %d, want 4", edit.Replacements())
+	}
+
+	edit.Hunks[1].Excluded = true
+	want := []string{"baz := 1", "bar(foo, foo)", "", "keep", "baz", ""}
+	if got := edit.Apply(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply() = %q, want %q", got, want)
+	}
+	if edit.Replacements() != 2 {
+		t.Errorf("Replacements() with excluded hunk = %d, want 2", edit.Replacements())
+	}
+}
+
+func TestPlanFileReplace_MultiLineAndGroups(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"a.txt": "start\nname: ted\nend\n",
+	})
+
+	opts := Options{UseRegex: true}
+	edit, err := PlanFileReplace(FileResult{Path: filepath.Join(dir, "a.txt")}, `start\nname: (\w+)`, "$1 begins", opts)
+	if err != nil {
+		t.Fatalf("PlanFileReplace() error = %v", err)
+	}
+
+	if len(edit.Hunks) != 1 {
+		t.Fatalf("hunks = %d, want 1", len(edit.Hunks))
+	}
+	h := edit.Hunks[0]
+	if !reflect.DeepEqual(h.OldLines, []string{"start", "name: ted"}) || !reflect.DeepEqual(h.NewLines, []string{"ted begins"}) {
+		t.Errorf("hunk = %q -> %q", h.OldLines, h.NewLines)
+	}
+	if got := edit.Apply(); !reflect.DeepEqual(got, []string{"ted begins", "end", ""}) {
+		t.Errorf("Apply() = %q", got)
+	}
+}
+
+func TestApplyFileEdits(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"a.txt": "old a\n",
+		"b.txt": "old b\n",
+		"c.txt": "old c\n",
+	})
+
+	var edits []*FileEdit
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		edit, err := PlanFileReplace(FileResult{Path: filepath.Join(dir, name), RelPath: name}, "old", "new", Options{})
+		if err != nil {
+			t.Fatalf("PlanFileReplace(%s) error = %v", name, err)
+		}
+		edits = append(edits, edit)
+	}
+	edits[1].Excluded = true
+
+	journal, err := file.NewJournal()
+	if err != nil {
+		t.Fatalf("NewJournal() error = %v", err)
+	}
+	defer journal.Discard()
+
+	files, replacements, err := ApplyFileEdits(edits, journal)
+	if err != nil {
+		t.Fatalf("ApplyFileEdits() error = %v", err)
+	}
+	if files != 2 || replacements != 2 {
+		t.Errorf("ApplyFileEdits() = %d files, %d replacements, want 2, 2", files, replacements)
+	}
+
+	read := func(name string) string {
+		data, _ := os.ReadFile(filepath.Join(dir, name))
+		return string(data)
+	}
+	if read("a.txt") != "new a\n" || read("b.txt") != "old b\n" || read("c.txt") != "new c\n" {
+		t.Errorf("files after replace: %q %q %q", read("a.txt"), read("b.txt"), read("c.txt"))
+	}
+
+	if err := journal.Rollback(); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if read("a.txt") != "old a\n" || read("c.txt") != "old c\n" {
+		t.Errorf("files after rollback: %q %q", read("a.txt"), read("c.txt"))
+	}
+}
+
+func TestApplyFileEdits_ChangedFileRollsBack(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"a.txt": "old a\n",
+		"b.txt": "old b\n",
+	})
+
+	var edits []*FileEdit
+	for _, name := range []string{"a.txt", "b.txt"} {
+		edit, err := PlanFileReplace(FileResult{Path: filepath.Join(dir, name), RelPath: name}, "old", "new", Options{})
+		if err != nil {
+			t.Fatalf("PlanFileReplace(%s) error = %v", name, err)
+		}
+		edits = append(edits, edit)
+	}
+
+	// b.txt changes after the preview
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("edited\n"), 0644)
+
+	journal, err := file.NewJournal()
+	if err != nil {
+		t.Fatalf("NewJournal() error = %v", err)
+	}
+
+	_, _, err = ApplyFileEdits(edits, journal)
+	if err == nil || !strings.Contains(err.Error(), "changed since the preview") {
+		t.Fatalf("ApplyFileEdits() error = %v, want changed file error", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "a.txt"))
+	if string(data) != "old a\n" {
+		t.Errorf("a.txt = %q, want it rolled back", data)
+	}
+}
@@ -6,8 +6,11 @@
 package search
 
 import (
+	"context"
 	"regexp"
+	"slices"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/AndrewDonelson/ted/core/buffer"
 )
@@ -19,6 +22,13 @@ type Match struct {
 	EndLine   int    // End line number
 	EndCol    int    // End column
 	Text      string // The matched text
+
+	// Groups holds the text of each capture group for regex matches,
+	// with Groups[0] being the whole match. Nil for literal matches.
+	Groups []string
+
+	// Score rates fuzzy matches, higher being better. Zero otherwise.
+	Score int
 }
 
 // Options controls search behavior.
@@ -27,6 +37,9 @@ type Options struct {
 	WholeWord     bool // Match whole words only
 	UseRegex      bool // Treat pattern as regular expression
 	WrapAround    bool // Wrap to start when reaching end
+	InSelection   bool // Only match inside the scope set with SetScope
+	PreserveCase  bool // Match any case shape and keep it when replacing
+	Fuzzy         bool // Match the pattern's characters in order, with gaps, once per line
 }
 
 // DefaultOptions returns the default search options.
@@ -48,6 +61,11 @@ type Finder struct {
 	history      []string // Search history
 	historyIndex int      // Current position in history
 	maxHistory   int      // Maximum history entries
+
+	// Scope limits matches when Options.InSelection is set
+	scopeStart buffer.Position
+	scopeEnd   buffer.Position
+	hasScope   bool
 }
 
 // NewFinder creates a new search finder.
@@ -68,10 +86,8 @@ func (f *Finder) SetPattern(pattern string) {
 		return
 	}
 
-	// Only add to history if different from last search
-	if pattern != f.pattern && pattern != "" {
-		f.addToHistory(pattern)
-	}
+	// addToHistory skips the pattern if it is already the last entry
+	f.addToHistory(pattern)
 
 	f.pattern = pattern
 	f.matches = f.matches[:0]
@@ -98,18 +114,21 @@ func (f *Finder) GetOptions() Options {
 
 // addToHistory adds a pattern to the search history.
 func (f *Finder) addToHistory(pattern string) {
-	// Check if pattern is already at the end of history
-	if len(f.history) > 0 && f.history[len(f.history)-1] == pattern {
-		return
-	}
+	f.history = addHistory(f.history, pattern, f.maxHistory)
+	f.historyIndex = len(f.history) - 1
+}
 
-	// Remove oldest if at max capacity
-	if len(f.history) >= f.maxHistory {
-		f.history = append(f.history[:0], f.history[1:]...)
+// addHistory appends entry to history, oldest first, moving it to the end
+// if it is already present and dropping the oldest entries beyond max.
+func addHistory(history []string, entry string, max int) []string {
+	if i := slices.Index(history, entry); i >= 0 {
+		history = slices.Delete(history, i, i+1)
 	}
-
-	f.history = append(f.history, pattern)
-	f.historyIndex = len(f.history) - 1
+	history = append(history, entry)
+	if len(history) > max {
+		history = slices.Delete(history, 0, len(history)-max)
+	}
+	return history
 }
 
 // GetHistory returns the search history.
@@ -119,6 +138,28 @@ func (f *Finder) GetHistory() []string {
 	return result
 }
 
+// SetHistory replaces the search history, for example with one saved by an
+// earlier session. Only the newest entries that fit are kept.
+func (f *Finder) SetHistory(history []string) {
+	f.history = f.history[:0]
+	for _, pattern := range history {
+		if pattern != "" {
+			f.addToHistory(pattern)
+		}
+	}
+	f.historyIndex = len(f.history) - 1
+}
+
+// SetMaxHistory changes how many patterns the history keeps, dropping the
+// oldest ones if there are more. Zero disables the history.
+func (f *Finder) SetMaxHistory(max int) {
+	f.maxHistory = max
+	if excess := len(f.history) - max; excess > 0 {
+		f.history = slices.Delete(f.history, 0, excess)
+	}
+	f.historyIndex = len(f.history) - 1
+}
+
 // GetHistoryItem returns a specific history item by index.
 func (f *Finder) GetHistoryItem(index int) (string, bool) {
 	if index < 0 || index >= len(f.history) {
@@ -146,99 +187,225 @@ func (f *Finder) NextHistory() (string, bool) {
 }
 
 // FindAll finds all matches in the buffer.
+// The buffer is searched as one text with lines joined by "\n", so both
+// literal and regex patterns can match across line boundaries.
 func (f *Finder) FindAll(buf *buffer.Buffer) []Match {
 	if f.pattern == "" {
 		return nil
 	}
 
-	f.matches = f.matches[:0]
-	lines := buf.GetAllLines()
-
-	if f.options.UseRegex {
-		f.findAllRegex(lines)
-	} else {
-		f.findAllLiteral(lines)
-	}
+	// An invalid regex simply yields no matches
+	matches, _ := FindMatches(context.Background(), buf.GetAllLines(), f.pattern, f.options)
+	f.matches = f.appendInScope(f.matches[:0], matches)
 
 	return f.matches
 }
 
-// findAllLiteral finds all literal pattern matches.
-func (f *Finder) findAllLiteral(lines []string) {
-	pattern := f.pattern
-	if !f.options.CaseSensitive {
-		pattern = strings.ToLower(pattern)
+// SetMatches replaces the current matches with ones computed elsewhere,
+// typically by FindMatches running in the background for incremental search.
+// Unlike SetPattern, it does not record the pattern in the history.
+func (f *Finder) SetMatches(pattern string, matches []Match) {
+	f.pattern = pattern
+	f.matches = f.appendInScope(f.matches[:0], matches)
+	f.currentIndex = -1
+}
+
+// SetScope sets the range searched when Options.InSelection is enabled,
+// usually the selection when the search dialog was opened.
+func (f *Finder) SetScope(start, end buffer.Position) {
+	f.scopeStart = start
+	f.scopeEnd = end
+	f.hasScope = true
+	f.Clear()
+}
+
+// ClearScope removes the search scope, so InSelection has no effect.
+func (f *Finder) ClearScope() {
+	f.hasScope = false
+	f.Clear()
+}
+
+// GetScope returns the search scope and whether one is set.
+func (f *Finder) GetScope() (start, end buffer.Position, ok bool) {
+	return f.scopeStart, f.scopeEnd, f.hasScope
+}
+
+// IsScoped reports whether searches are currently limited to the scope.
+func (f *Finder) IsScoped() bool {
+	return f.hasScope && f.options.InSelection
+}
+
+// AdjustScope shifts the scope after the text between start and oldEnd
+// was replaced by text ending at newEnd, so it keeps covering the same text.
+func (f *Finder) AdjustScope(start, oldEnd, newEnd buffer.Position) {
+	if !f.hasScope {
+		return
+	}
+	f.scopeStart = buffer.AdjustForReplace(f.scopeStart, start, oldEnd, newEnd)
+	f.scopeEnd = buffer.AdjustForReplace(f.scopeEnd, start, oldEnd, newEnd)
+}
+
+// appendInScope appends the matches that lie entirely inside the scope
+// to dst, or all of them when the search is not scoped.
+func (f *Finder) appendInScope(dst, matches []Match) []Match {
+	if !f.IsScoped() {
+		return append(dst, matches...)
 	}
 
-	for lineNum, line := range lines {
-		searchLine := line
-		if !f.options.CaseSensitive {
-			searchLine = strings.ToLower(line)
+	for _, m := range matches {
+		startsInside := m.StartLine > f.scopeStart.Line ||
+			(m.StartLine == f.scopeStart.Line && m.StartCol >= f.scopeStart.Col)
+		endsInside := m.EndLine < f.scopeEnd.Line ||
+			(m.EndLine == f.scopeEnd.Line && m.EndCol <= f.scopeEnd.Col)
+		if startsInside && endsInside {
+			dst = append(dst, m)
 		}
+	}
+	return dst
+}
 
-		startCol := 0
-		for {
-			idx := strings.Index(searchLine[startCol:], pattern)
-			if idx == -1 {
-				break
-			}
+// FindMatches returns all matches of pattern in lines.
+// The lines are searched as one text joined by "\n", so matches may span lines.
+// It returns an error if a regex pattern does not compile, or ctx.Err() if
+// ctx is cancelled before the search completes. Since it does not touch a
+// Finder or Buffer, it is safe to run on a snapshot of the lines in another
+// goroutine.
+func FindMatches(ctx context.Context, lines []string, pattern string, opts Options) ([]Match, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if opts.Fuzzy {
+		return findAllFuzzy(ctx, lines, pattern, opts)
+	}
 
-			actualIdx := startCol + idx
+	text := newSearchText(lines)
 
-			// Check whole word constraint
-			if f.options.WholeWord && !f.isWholeWordMatch(line, actualIdx, len(pattern)) {
-				startCol = actualIdx + 1
-				continue
+	if opts.UseRegex {
+		re, err := compilePattern(pattern, opts)
+		if err != nil {
+			return nil, err
+		}
+		return findAllRegex(ctx, text, re)
+	}
+	return findAllLiteral(ctx, text, pattern, opts)
+}
+
+// cancelCheckInterval is how many matches are collected between checks
+// for cancellation.
+const cancelCheckInterval = 1024
+
+// findAllLiteral finds all literal pattern matches.
+// Matches may overlap, so "aa" is found twice in "aaa".
+func findAllLiteral(ctx context.Context, text *searchText, pattern string, opts Options) ([]Match, error) {
+	var index func(s string) (int, int)
+	if opts.CaseSensitive && !opts.PreserveCase {
+		index = func(s string) (int, int) {
+			idx := strings.Index(s, pattern)
+			return idx, idx + len(pattern)
+		}
+	} else {
+		// Case folding can change byte lengths, so match through the
+		// regexp engine rather than lower-casing the text.
+		source := "(?i)" + regexp.QuoteMeta(pattern)
+		if opts.PreserveCase {
+			source = casingPattern(pattern)
+		}
+		re, err := regexp.Compile(source)
+		if err != nil {
+			return nil, err
+		}
+		index = func(s string) (int, int) {
+			loc := re.FindStringIndex(s)
+			if loc == nil {
+				return -1, -1
 			}
+			return loc[0], loc[1]
+		}
+	}
 
-			match := Match{
-				StartLine: lineNum,
-				StartCol:  actualIdx,
-				EndLine:   lineNum,
-				EndCol:    actualIdx + len(pattern),
-				Text:      line[actualIdx : actualIdx+len(pattern)],
+	var matches []Match
+	from := 0
+	for n := 1; from <= len(text.text); n++ {
+		if n%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
 			}
-			f.matches = append(f.matches, match)
+		}
+
+		start, end := index(text.text[from:])
+		if start == -1 {
+			break
+		}
+		start += from
+		end += from
+
+		// Advance past the first rune so overlapping matches are found
+		_, size := utf8.DecodeRuneInString(text.text[start:])
+		from = start + max(size, 1)
 
-			startCol = actualIdx + 1
+		// Check whole word constraint
+		if opts.WholeWord && !isWholeWordMatch(text.text, start, end-start) {
+			continue
 		}
+
+		matches = append(matches, text.match(start, end, nil))
 	}
+
+	return matches, nil
 }
 
-// findAllRegex finds all regex pattern matches.
-func (f *Finder) findAllRegex(lines []string) {
-	var re *regexp.Regexp
-	var err error
+// compileRegex compiles the current pattern, honoring the case option.
+func (f *Finder) compileRegex() (*regexp.Regexp, error) {
+	return compilePattern(f.pattern, f.options)
+}
 
-	if f.options.CaseSensitive {
-		re, err = regexp.Compile(f.pattern)
-	} else {
-		re, err = regexp.Compile("(?i)" + f.pattern)
+// compilePattern compiles a regex pattern, honoring the case option.
+// Multi-line mode is always on so ^ and $ match at line boundaries, as
+// they did when lines were searched one at a time.
+func compilePattern(pattern string, opts Options) (*regexp.Regexp, error) {
+	if opts.CaseSensitive {
+		return regexp.Compile("(?m)" + pattern)
 	}
+	return regexp.Compile("(?mi)" + pattern)
+}
 
-	if err != nil {
-		// Invalid regex, no matches
-		return
+// findAllRegex finds all regex pattern matches.
+// The regexp engine cannot be interrupted, so cancellation is only
+// noticed while converting its results into matches.
+func findAllRegex(ctx context.Context, text *searchText, re *regexp.Regexp) ([]Match, error) {
+	locs := re.FindAllStringSubmatchIndex(text.text, -1)
+
+	matches := make([]Match, 0, len(locs))
+	for i, m := range locs {
+		if i%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		matches = append(matches, text.match(m[0], m[1], submatchGroups(text.text, m)))
 	}
 
-	for lineNum, line := range lines {
-		matches := re.FindAllStringIndex(line, -1)
-		for _, m := range matches {
-			// m[0] is start index, m[1] is end index
-			match := Match{
-				StartLine: lineNum,
-				StartCol:  m[0],
-				EndLine:   lineNum,
-				EndCol:    m[1],
-				Text:      line[m[0]:m[1]],
-			}
-			f.matches = append(f.matches, match)
+	return matches, nil
+}
+
+// submatchGroups extracts capture group text from a submatch index slice.
+// Groups that did not participate in the match are empty.
+func submatchGroups(s string, loc []int) []string {
+	groups := make([]string, len(loc)/2)
+	for i := range groups {
+		if loc[2*i] >= 0 {
+			groups[i] = s[loc[2*i]:loc[2*i+1]]
 		}
 	}
+	return groups
 }
 
 // isWholeWordMatch checks if a match is a whole word.
-func (f *Finder) isWholeWordMatch(line string, start, length int) bool {
+func isWholeWordMatch(line string, start, length int) bool {
 	// Check character before
 	if start > 0 && isWordChar(line[start-1]) {
 		return false
@@ -292,6 +459,45 @@ func (f *Finder) FindNext(buf *buffer.Buffer, fromPos buffer.Position) (Match, b
 	return Match{}, false
 }
 
+// FindFrom selects the first match at or after pos, wrapping to the first
+// match if WrapAround is enabled. Unlike FindNext, a match starting exactly
+// at pos is selected, so incremental search stays put while the pattern grows.
+// It uses the current matches and does not search the buffer.
+func (f *Finder) FindFrom(pos buffer.Position) (Match, bool) {
+	for i, match := range f.matches {
+		if match.StartLine > pos.Line ||
+			(match.StartLine == pos.Line && match.StartCol >= pos.Col) {
+			f.currentIndex = i
+			return match, true
+		}
+	}
+
+	if f.options.WrapAround && len(f.matches) > 0 {
+		f.currentIndex = 0
+		return f.matches[0], true
+	}
+
+	return Match{}, false
+}
+
+// FindBest selects the highest scoring match, for fuzzy searches. Among
+// equal scores the first is chosen. It uses the current matches and does
+// not search the buffer.
+func (f *Finder) FindBest() (Match, bool) {
+	best := -1
+	for i, match := range f.matches {
+		if best < 0 || match.Score > f.matches[best].Score {
+			best = i
+		}
+	}
+	if best < 0 {
+		return Match{}, false
+	}
+
+	f.currentIndex = best
+	return f.matches[best], true
+}
+
 // FindPrevious finds the previous match from the current position.
 // Returns the match and true if found, otherwise returns false.
 func (f *Finder) FindPrevious(buf *buffer.Buffer, fromPos buffer.Position) (Match, bool) {
@@ -344,6 +550,12 @@ func (f *Finder) SetCurrentMatch(index int) bool {
 	return true
 }
 
+// GetMatches returns the matches from the last search.
+// The returned slice is only valid until the next search.
+func (f *Finder) GetMatches() []Match {
+	return f.matches
+}
+
 // GetMatchCount returns the total number of matches.
 func (f *Finder) GetMatchCount() int {
 	return len(f.matches)
@@ -366,4 +578,5 @@ func (f *Finder) Reset() {
 	f.matches = f.matches[:0]
 	f.currentIndex = -1
 	f.options = DefaultOptions()
+	f.hasScope = false
 }
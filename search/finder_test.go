@@ -1,6 +1,9 @@
 package search
 
 import (
+	"context"
+	"fmt"
+	"slices"
 	"testing"
 
 	"github.com/AndrewDonelson/ted/core/buffer"
@@ -139,6 +142,56 @@ func TestFinder_History(t *testing.T) {
 	}
 }
 
+func TestFinder_HistoryMovesRepeats(t *testing.T) {
+	f := NewFinder()
+	f.SetPattern("first")
+	f.SetPattern("second")
+	f.SetPattern("first")
+
+	want := []string{"second", "first"}
+	if got := f.GetHistory(); !slices.Equal(got, want) {
+		t.Errorf("GetHistory() = %v, want %v", got, want)
+	}
+}
+
+func TestFinder_SetHistory(t *testing.T) {
+	f := NewFinder()
+
+	var saved []string
+	for i := 0; i < 25; i++ {
+		saved = append(saved, fmt.Sprintf("p%d", i))
+	}
+	f.SetHistory(saved)
+
+	history := f.GetHistory()
+	if len(history) != 20 || history[0] != "p5" || history[19] != "p24" {
+		t.Errorf("SetHistory() kept %v, want p5..p24", history)
+	}
+	if item, ok := f.PreviousHistory(); !ok || item != "p23" {
+		t.Errorf("PreviousHistory() = %q, %v, want %q, true", item, ok, "p23")
+	}
+}
+
+func TestFinder_SetMaxHistory(t *testing.T) {
+	f := NewFinder()
+	f.SetHistory([]string{"a", "b", "c", "d"})
+
+	f.SetMaxHistory(2)
+	if got := f.GetHistory(); !slices.Equal(got, []string{"c", "d"}) {
+		t.Errorf("SetMaxHistory(2) kept %v, want [c d]", got)
+	}
+	f.SetPattern("e")
+	if got := f.GetHistory(); !slices.Equal(got, []string{"d", "e"}) {
+		t.Errorf("GetHistory() = %v, want [d e]", got)
+	}
+
+	f.SetMaxHistory(0)
+	f.SetPattern("f")
+	if got := f.GetHistory(); len(got) != 0 {
+		t.Errorf("GetHistory() = %v, want none with history disabled", got)
+	}
+}
+
 func TestFinder_FindAll_Literal(t *testing.T) {
 	f := NewFinder()
 	f.SetPattern("hello")
@@ -600,3 +653,206 @@ func TestFinder_MatchAcrossLines(t *testing.T) {
 		}
 	}
 }
+
+func TestFinder_FindAll_MultiLine(t *testing.T) {
+	buf := buffer.NewBuffer()
+	buf.SetLines([]string{
+		"func a() {",
+		"}",
+		"",
+		"func b() {",
+		"}",
+	})
+
+	tests := []struct {
+		name    string
+		pattern string
+		regex   bool
+		want    []Match
+	}{
+		{
+			name:    "regex across blank line",
+			pattern: `}\n\nfunc`,
+			regex:   true,
+			want:    []Match{{StartLine: 1, StartCol: 0, EndLine: 3, EndCol: 4}},
+		},
+		{
+			name:    "dot-all regex",
+			pattern: `(?s)\{.*?\}`,
+			regex:   true,
+			want: []Match{
+				{StartLine: 0, StartCol: 9, EndLine: 1, EndCol: 1},
+				{StartLine: 3, StartCol: 9, EndLine: 4, EndCol: 1},
+			},
+		},
+		{
+			name:    "anchors match per line",
+			pattern: `^func`,
+			regex:   true,
+			want: []Match{
+				{StartLine: 0, StartCol: 0, EndLine: 0, EndCol: 4},
+				{StartLine: 3, StartCol: 0, EndLine: 3, EndCol: 4},
+			},
+		},
+		{
+			name:    "literal with newline",
+			pattern: "{\n}",
+			want: []Match{
+				{StartLine: 0, StartCol: 9, EndLine: 1, EndCol: 1},
+				{StartLine: 3, StartCol: 9, EndLine: 4, EndCol: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFinder()
+			opts := f.GetOptions()
+			opts.UseRegex = tt.regex
+			f.SetOptions(opts)
+			f.SetPattern(tt.pattern)
+
+			got := f.FindAll(buf)
+			if len(got) != len(tt.want) {
+				t.Fatalf("FindAll() returned %d matches, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				if g.StartLine != w.StartLine || g.StartCol != w.StartCol || g.EndLine != w.EndLine || g.EndCol != w.EndCol {
+					t.Errorf("match %d = (%d:%d)-(%d:%d), want (%d:%d)-(%d:%d)", i,
+						g.StartLine, g.StartCol, g.EndLine, g.EndCol,
+						w.StartLine, w.StartCol, w.EndLine, w.EndCol)
+				}
+			}
+		})
+	}
+}
+
+func TestFinder_FindAll_CaseInsensitiveUnicode(t *testing.T) {
+	buf := buffer.NewBuffer()
+	buf.SetLines([]string{"Straße STRASSE straße"})
+
+	f := NewFinder()
+	f.SetPattern("STRAßE")
+
+	got := f.FindAll(buf)
+	if len(got) != 2 {
+		t.Fatalf("FindAll() returned %d matches, want 2: %+v", len(got), got)
+	}
+	if got[1].StartCol != 16 || got[1].Text != "straße" {
+		t.Errorf("second match = %+v, want col 16 text %q", got[1], "straße")
+	}
+}
+
+func TestFindMatches_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := FindMatches(ctx, []string{"abc abc"}, "abc", DefaultOptions())
+	if err != context.Canceled {
+		t.Errorf("FindMatches() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestFindMatches_InvalidRegex(t *testing.T) {
+	opts := DefaultOptions()
+	opts.UseRegex = true
+
+	if _, err := FindMatches(context.Background(), []string{"abc"}, "a(", opts); err == nil {
+		t.Error("FindMatches() with invalid regex should return error")
+	}
+}
+
+func TestFinder_SetMatches(t *testing.T) {
+	f := NewFinder()
+	matches, _ := FindMatches(context.Background(), []string{"one two one"}, "one", DefaultOptions())
+
+	f.SetMatches("one", matches)
+
+	if f.GetPattern() != "one" {
+		t.Errorf("GetPattern() = %q, want %q", f.GetPattern(), "one")
+	}
+	if f.GetMatchCount() != 2 {
+		t.Errorf("GetMatchCount() = %d, want 2", f.GetMatchCount())
+	}
+	if len(f.GetHistory()) != 0 {
+		t.Errorf("SetMatches() added to history: %v", f.GetHistory())
+	}
+
+	// Accepting the search records it in the history
+	f.SetPattern("one")
+	if got := f.GetHistory(); len(got) != 1 || got[0] != "one" {
+		t.Errorf("history after SetPattern = %v, want [one]", got)
+	}
+}
+
+func TestFinder_FindFrom(t *testing.T) {
+	f := NewFinder()
+	matches, _ := FindMatches(context.Background(), []string{"ab ab", "ab"}, "ab", DefaultOptions())
+	f.SetMatches("ab", matches)
+
+	tests := []struct {
+		name     string
+		pos      buffer.Position
+		wrap     bool
+		wantLine int
+		wantCol  int
+		wantOK   bool
+	}{
+		{name: "match at position", pos: buffer.Position{Line: 0, Col: 3}, wrap: true, wantLine: 0, wantCol: 3, wantOK: true},
+		{name: "next match", pos: buffer.Position{Line: 0, Col: 4}, wrap: true, wantLine: 1, wantCol: 0, wantOK: true},
+		{name: "wraps", pos: buffer.Position{Line: 1, Col: 1}, wrap: true, wantLine: 0, wantCol: 0, wantOK: true},
+		{name: "no wrap", pos: buffer.Position{Line: 1, Col: 1}, wrap: false, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f.options.WrapAround = tt.wrap
+			m, ok := f.FindFrom(tt.pos)
+			if ok != tt.wantOK {
+				t.Fatalf("FindFrom() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && (m.StartLine != tt.wantLine || m.StartCol != tt.wantCol) {
+				t.Errorf("FindFrom() = %d:%d, want %d:%d", m.StartLine, m.StartCol, tt.wantLine, tt.wantCol)
+			}
+		})
+	}
+}
+
+func TestFinder_FindAll_InSelection(t *testing.T) {
+	buf := buffer.NewBuffer()
+	buf.SetLines([]string{
+		"x := 1",
+		"func f() {",
+		"	x := 2",
+		"	return x",
+		"}",
+		"y := x",
+	})
+
+	f := NewFinder()
+	f.SetPattern("x")
+	f.SetScope(buffer.Position{Line: 1, Col: 0}, buffer.Position{Line: 4, Col: 1})
+
+	// Scope is ignored until InSelection is enabled
+	if got := len(f.FindAll(buf)); got != 4 {
+		t.Errorf("FindAll() unscoped = %d matches, want 4", got)
+	}
+
+	opts := f.GetOptions()
+	opts.InSelection = true
+	f.SetOptions(opts)
+
+	matches := f.FindAll(buf)
+	if len(matches) != 2 {
+		t.Fatalf("FindAll() in selection = %d matches, want 2", len(matches))
+	}
+	if matches[0].StartLine != 2 || matches[1].StartLine != 3 {
+		t.Errorf("matches on lines %d and %d, want 2 and 3", matches[0].StartLine, matches[1].StartLine)
+	}
+
+	f.ClearScope()
+	if got := len(f.FindAll(buf)); got != 4 {
+		t.Errorf("FindAll() after ClearScope = %d matches, want 4", got)
+	}
+}
@@ -0,0 +1,205 @@
+package search
+
+import (
+	"context"
+	"slices"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Fuzzy scoring, modelled on fzf: every matched character scores, gaps
+// cost, and characters at word starts or continuing a run score extra.
+const (
+	fuzzyScoreMatch        = 16
+	fuzzyGapStart          = -3
+	fuzzyGapExtension      = -1
+	fuzzyBonusBoundary     = 8 // After a space, punctuation or the start of the text
+	fuzzyBonusCamel        = 7 // Lower to upper case or letter to digit
+	fuzzyBonusConsecutive  = 4 // Continuing a run of matched characters
+	fuzzyFirstCharMultiple = 2 // The first pattern character's bonus counts double
+)
+
+// FuzzyMatch reports whether the characters of pattern appear in text in
+// order, not necessarily adjacent, and scores the match: higher is better.
+// positions holds the byte offset in text of each matched character.
+//
+// Like fzf, it finds the first occurrence and then the shortest span
+// ending there, so the score describes the tightest match near the start.
+func FuzzyMatch(pattern, text string, caseSensitive bool) (score int, positions []int, ok bool) {
+	if pattern == "" {
+		return 0, nil, true
+	}
+
+	fold := func(r rune) rune {
+		if caseSensitive {
+			return r
+		}
+		return unicode.ToLower(r)
+	}
+	pat := []rune(pattern)
+	for i := range pat {
+		pat[i] = fold(pat[i])
+	}
+
+	// Forward: where does the first complete occurrence end?
+	end, p := -1, 0
+	for i, r := range text {
+		if fold(r) == pat[p] {
+			p++
+			if p == len(pat) {
+				_, size := utf8.DecodeRuneInString(text[i:])
+				end = i + size
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+
+	// Backward: the latest start that still matches, for the shortest span
+	start, p := end, len(pat)-1
+	for start > 0 && p >= 0 {
+		r, size := utf8.DecodeLastRuneInString(text[:start])
+		start -= size
+		if fold(r) == pat[p] {
+			p--
+		}
+	}
+
+	score, positions = fuzzyScore(text, start, end, pat, fold)
+	return score, positions, true
+}
+
+// fuzzyScore scores the greedy alignment of pat within text[start:end].
+func fuzzyScore(text string, start, end int, pat []rune, fold func(rune) rune) (int, []int) {
+	positions := make([]int, 0, len(pat))
+	score, consecutive, firstBonus := 0, 0, 0
+	inGap := false
+
+	prev := rune(' ')
+	if start > 0 {
+		prev, _ = utf8.DecodeLastRuneInString(text[:start])
+	}
+
+	p := 0
+	for i, r := range text[start:end] {
+		if p < len(pat) && fold(r) == pat[p] {
+			bonus := fuzzyBonus(prev, r)
+			if consecutive == 0 {
+				firstBonus = bonus
+			} else {
+				// A run keeps the bonus of the word start it began at
+				bonus = max(bonus, firstBonus, fuzzyBonusConsecutive)
+			}
+			if p == 0 {
+				bonus *= fuzzyFirstCharMultiple
+			}
+
+			score += fuzzyScoreMatch + bonus
+			positions = append(positions, start+i)
+			consecutive++
+			inGap = false
+			p++
+		} else {
+			if inGap {
+				score += fuzzyGapExtension
+			} else {
+				score += fuzzyGapStart
+			}
+			inGap = true
+			consecutive = 0
+			firstBonus = 0
+		}
+		prev = r
+	}
+
+	return score, positions
+}
+
+// fuzzyBonus returns the bonus for matching r after prev.
+func fuzzyBonus(prev, r rune) int {
+	switch {
+	case isFuzzyWordRune(r) && !isFuzzyWordRune(prev):
+		return fuzzyBonusBoundary
+	case unicode.IsLower(prev) && unicode.IsUpper(r),
+		!unicode.IsDigit(prev) && unicode.IsDigit(r):
+		return fuzzyBonusCamel
+	}
+	return 0
+}
+
+// isFuzzyWordRune reports whether r is part of a word for scoring.
+func isFuzzyWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// findAllFuzzy finds the best fuzzy match of pattern on each line. Matches
+// are in line order and carry their Score.
+func findAllFuzzy(ctx context.Context, lines []string, pattern string, opts Options) ([]Match, error) {
+	var matches []Match
+	for i, line := range lines {
+		if i%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		score, positions, ok := FuzzyMatch(pattern, line, opts.CaseSensitive)
+		if !ok {
+			continue
+		}
+		first := positions[0]
+		last := positions[len(positions)-1]
+		_, size := utf8.DecodeRuneInString(line[last:])
+		matches = append(matches, Match{
+			StartLine: i,
+			StartCol:  first,
+			EndLine:   i,
+			EndCol:    last + size,
+			Text:      line[first : last+size],
+			Score:     score,
+		})
+	}
+	return matches, nil
+}
+
+// RankMatches returns matches sorted best first by Score. Matches with
+// equal scores keep their order.
+func RankMatches(matches []Match) []Match {
+	ranked := slices.Clone(matches)
+	slices.SortStableFunc(ranked, func(a, b Match) int {
+		return b.Score - a.Score
+	})
+	return ranked
+}
+
+// FuzzyResult is one item accepted by FuzzyFilter.
+type FuzzyResult struct {
+	Index     int   // Index of the item in the filtered list
+	Score     int   // Match quality, higher is better
+	Positions []int // Byte offsets of the matched characters
+}
+
+// FuzzyFilter matches pattern against each item and returns the matching
+// ones best first. Ties go to the shorter item, then to the earlier one.
+// Matching is smart-case: case-sensitive only if pattern has upper case.
+func FuzzyFilter(pattern string, items []string) []FuzzyResult {
+	caseSensitive := slices.ContainsFunc([]rune(pattern), unicode.IsUpper)
+
+	var results []FuzzyResult
+	for i, item := range items {
+		score, positions, ok := FuzzyMatch(pattern, item, caseSensitive)
+		if ok {
+			results = append(results, FuzzyResult{Index: i, Score: score, Positions: positions})
+		}
+	}
+
+	slices.SortStableFunc(results, func(a, b FuzzyResult) int {
+		if a.Score != b.Score {
+			return b.Score - a.Score
+		}
+		return len(items[a.Index]) - len(items[b.Index])
+	})
+	return results
+}
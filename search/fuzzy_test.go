@@ -0,0 +1,129 @@
+package search
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		pattern       string
+		text          string
+		caseSensitive bool
+		wantOK        bool
+		wantPositions []int
+	}{
+		{"exact", "abc", "abc", false, true, []int{0, 1, 2}},
+		{"gaps", "fb", "foo_bar", false, true, []int{0, 4}},
+		{"shortest span", "ab", "a-a-ab", false, true, []int{4, 5}},
+		{"out of order", "ba", "ab", false, false, nil},
+		{"case folded", "FB", "fooBar", false, true, []int{0, 3}},
+		{"case sensitive", "FB", "fooBar", true, false, nil},
+		{"unicode", "éa", "café bar", false, true, []int{3, 7}},
+		{"empty pattern", "", "anything", false, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, positions, ok := FuzzyMatch(tt.pattern, tt.text, tt.caseSensitive)
+			if ok != tt.wantOK {
+				t.Fatalf("FuzzyMatch() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !reflect.DeepEqual(positions, tt.wantPositions) {
+				t.Errorf("FuzzyMatch() positions = %v, want %v", positions, tt.wantPositions)
+			}
+		})
+	}
+}
+
+func TestFuzzyMatch_Ranking(t *testing.T) {
+	// Each pair: the first text should score higher than the second
+	tests := []struct {
+		pattern       string
+		better, worse string
+	}{
+		{"fb", "foo_bar", "fxxxxb"},             // Word starts beat gaps
+		{"gtl", "goToLine", "gasthelm"},         // camelCase humps
+		{"abc", "abc", "a_b_c"},                 // Consecutive beats separated
+		{"run", "Run", "xrun"},                  // Start of text beats mid-word
+		{"buf", "buffer.go", "bigupfile"},       // Runs beat scattered letters
+		{"ed", "editor", "needed"},              // First letter at a boundary
+		{"fuzzy", "FuzzyMatch", "fuzz_y_thing"}, // Whole word beats broken run
+	}
+
+	for _, tt := range tests {
+		better, _, ok1 := FuzzyMatch(tt.pattern, tt.better, false)
+		worse, _, ok2 := FuzzyMatch(tt.pattern, tt.worse, false)
+		if !ok1 || !ok2 {
+			t.Errorf("%q: both %q and %q should match", tt.pattern, tt.better, tt.worse)
+			continue
+		}
+		if better <= worse {
+			t.Errorf("%q: score(%q) = %d, want more than score(%q) = %d", tt.pattern, tt.better, better, tt.worse, worse)
+		}
+	}
+}
+
+func TestFindMatches_Fuzzy(t *testing.T) {
+	lines := []string{
+		"func handleFind() {}",
+		"no match here",
+		"func hf() {}",
+	}
+
+	matches, err := FindMatches(context.Background(), lines, "hf", Options{Fuzzy: true})
+	if err != nil {
+		t.Fatalf("FindMatches() error = %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("FindMatches() found %d matches, want 2", len(matches))
+	}
+	if m := matches[0]; m.StartLine != 0 || m.StartCol != 5 || m.EndCol != 12 || m.Text != "handleF" {
+		t.Errorf("first match = %+v, want line 0 cols 5-12 %q", m, "handleF")
+	}
+
+	ranked := RankMatches(matches)
+	if ranked[0].StartLine != 2 {
+		t.Errorf("RankMatches()[0] on line %d, want the tighter match on line 2", ranked[0].StartLine)
+	}
+}
+
+func TestFinder_FindBest(t *testing.T) {
+	f := NewFinder()
+	f.SetMatches("x", []Match{{StartLine: 0, Score: 10}, {StartLine: 1, Score: 30}, {StartLine: 2, Score: 30}})
+
+	match, ok := f.FindBest()
+	if !ok || match.StartLine != 1 || f.GetCurrentMatchIndex() != 1 {
+		t.Errorf("FindBest() = line %d, %v (index %d), want line 1, true (index 1)", match.StartLine, ok, f.GetCurrentMatchIndex())
+	}
+
+	f.Clear()
+	if _, ok := f.FindBest(); ok {
+		t.Error("FindBest() with no matches should return false")
+	}
+}
+
+func TestFuzzyFilter(t *testing.T) {
+	items := []string{"Editor.handleFind", "Finder", "find", "unrelated"}
+
+	var got []string
+	for _, r := range FuzzyFilter("find", items) {
+		got = append(got, items[r.Index])
+	}
+	want := []string{"find", "Finder", "Editor.handleFind"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FuzzyFilter() = %v, want %v", got, want)
+	}
+
+	// Upper case in the pattern makes matching case-sensitive
+	got = nil
+	for _, r := range FuzzyFilter("Find", items) {
+		got = append(got, items[r.Index])
+	}
+	want = []string{"Finder", "Editor.handleFind"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FuzzyFilter() smart case = %v, want %v", got, want)
+	}
+}
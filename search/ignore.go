@@ -0,0 +1,159 @@
+package search
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreRule is one pattern from a .gitignore file.
+type ignoreRule struct {
+	re      *regexp.Regexp // Matches paths relative to base, with forward slashes
+	base    string         // Directory holding the .gitignore file
+	negate  bool           // Pattern started with "!"; re-includes matches
+	dirOnly bool           // Pattern ended with "/"; matches directories only
+}
+
+// ignoreRules is the set of .gitignore rules that apply in a directory,
+// from the outermost .gitignore to the innermost. Later rules win.
+type ignoreRules []ignoreRule
+
+// loadIgnoreFile returns rules extended with the patterns in dir/.gitignore.
+// A missing or unreadable file leaves the rules unchanged.
+func (rules ignoreRules) loadIgnoreFile(dir string) ignoreRules {
+	f, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return rules
+	}
+	defer f.Close()
+
+	var added []ignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreLine(scanner.Text(), dir); ok {
+			added = append(added, rule)
+		}
+	}
+	if len(added) == 0 {
+		return rules
+	}
+
+	// Copy so sibling directories do not share the appended rules
+	combined := make(ignoreRules, 0, len(rules)+len(added))
+	combined = append(combined, rules...)
+	return append(combined, added...)
+}
+
+// ignored reports whether path is excluded by the rules.
+func (rules ignoreRules) ignored(path string, isDir bool) bool {
+	ignored := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		rel, err := filepath.Rel(rule.base, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if rule.re.MatchString(filepath.ToSlash(rel)) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// parseIgnoreLine parses one line of a .gitignore file in dir.
+// It returns false for blank lines, comments and invalid patterns.
+//
+// Supported syntax: "#" comments, "!" negation, a trailing "/" for
+// directories, a leading or inner "/" to anchor the pattern to dir, and
+// the wildcards "*", "?", "[...]" and "**".
+func parseIgnoreLine(line, dir string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: dir}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:] // Escaped leading "#" or "!"
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	// A slash anywhere but the end anchors the pattern to dir;
+	// otherwise it matches a name at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	prefix := "^(?:.*/)?"
+	if anchored {
+		prefix = "^"
+	}
+
+	re, err := regexp.Compile(prefix + globToRegexp(line) + "$")
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.re = re
+	return rule, true
+}
+
+// globToRegexp converts a gitignore glob to regexp source.
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				switch {
+				case i+1 < len(glob) && glob[i+1] == '/':
+					// "**/" matches zero or more directories
+					sb.WriteString("(?:.*/)?")
+					i++
+				default:
+					sb.WriteString(".*")
+				}
+				continue
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	return sb.String()
+}
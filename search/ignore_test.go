@@ -0,0 +1,73 @@
+package search
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreRules_Ignored(t *testing.T) {
+	root := filepath.FromSlash("/project")
+
+	var rules ignoreRules
+	for _, line := range []string{
+		"# comment",
+		"",
+		"*.log",
+		"!keep.log",
+		"build/",
+		"/vendor",
+		"docs/*.tmp",
+		"**/cache/**",
+		"temp?.txt",
+		"[Tt]humbs.db",
+	} {
+		if rule, ok := parseIgnoreLine(line, root); ok {
+			rules = append(rules, rule)
+		}
+	}
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{path: "app.log", want: true},
+		{path: "sub/dir/app.log", want: true},
+		{path: "keep.log", want: false},
+		{path: "main.go", want: false},
+		{path: "build", isDir: true, want: true},
+		{path: "src/build", isDir: true, want: true},
+		{path: "build", isDir: false, want: false},
+		{path: "vendor", isDir: true, want: true},
+		{path: "src/vendor", isDir: true, want: false},
+		{path: "docs/notes.tmp", want: true},
+		{path: "docs/sub/notes.tmp", want: false},
+		{path: "a/cache/b/c.txt", want: true},
+		{path: "temp1.txt", want: true},
+		{path: "temp10.txt", want: false},
+		{path: "Thumbs.db", want: true},
+		{path: "thumbs.db", want: true},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(root, filepath.FromSlash(tt.path))
+		if got := rules.ignored(path, tt.isDir); got != tt.want {
+			t.Errorf("ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreRules_NestedBase(t *testing.T) {
+	rule, ok := parseIgnoreLine("/out", filepath.FromSlash("/project/sub"))
+	if !ok {
+		t.Fatal("parseIgnoreLine() rejected a valid pattern")
+	}
+	rules := ignoreRules{rule}
+
+	if !rules.ignored(filepath.FromSlash("/project/sub/out"), true) {
+		t.Error("anchored pattern should match relative to its .gitignore")
+	}
+	if rules.ignored(filepath.FromSlash("/project/out"), true) {
+		t.Error("pattern should not apply outside its .gitignore directory")
+	}
+}
@@ -0,0 +1,191 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/AndrewDonelson/ted/core/file"
+)
+
+// maxSearchFileSize is the largest file searched by SearchFiles.
+// Larger files are assumed to be data rather than source.
+const maxSearchFileSize = 8 << 20
+
+// binarySniffLen is how much of a file is checked for NUL bytes when
+// deciding whether it is binary, as git does.
+const binarySniffLen = 8000
+
+// FileResult holds the matches found in one file.
+type FileResult struct {
+	Path     string   // Path of the file, joined to the search root
+	RelPath  string   // Path relative to the search root, for display
+	Matches  []Match  // Matches in the order they appear
+	Previews []string // Line each match starts on, parallel to Matches
+}
+
+// SearchFiles searches every text file under root for pattern.
+//
+// The tree is walked in parallel, skipping the .git directory, anything
+// excluded by .gitignore files and binary files. fn is called once for
+// each file with matches, from a single goroutine, as results arrive.
+// Files are reported in no particular order.
+//
+// SearchFiles returns when the walk finishes or ctx is cancelled, in which
+// case it returns ctx's error.
+func SearchFiles(ctx context.Context, root, pattern string, opts Options, fn func(FileResult)) error {
+	if pattern == "" {
+		return nil
+	}
+	if opts.UseRegex {
+		if _, err := compilePattern(pattern, opts); err != nil {
+			return fmt.Errorf("compile pattern: %w", err)
+		}
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return fmt.Errorf("stat search root %q: %w", root, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("search root %q is not a directory", root)
+	}
+
+	paths := make(chan string, 64)
+	results := make(chan FileResult, 64)
+
+	var walkErr error
+	go func() {
+		defer close(paths)
+		walkErr = walkFiles(ctx, root, func(path string) {
+			select {
+			case paths <- path:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Keep draining paths after cancellation so the walker never blocks
+			for path := range paths {
+				if ctx.Err() != nil {
+					continue
+				}
+				result, ok := searchFile(ctx, root, path, pattern, opts)
+				if !ok {
+					continue
+				}
+				select {
+				case results <- result:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for result := range results {
+		if ctx.Err() == nil {
+			fn(result)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return walkErr
+}
+
+// walkFiles calls visit for each regular file under root that is not
+// ignored. Unreadable directories are skipped.
+func walkFiles(ctx context.Context, root string, visit func(path string)) error {
+	rules := map[string]ignoreRules{}
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			if path == root {
+				return err
+			}
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		parent := rules[filepath.Dir(path)]
+
+		if d.IsDir() {
+			if path != root && (d.Name() == ".git" || parent.ignored(path, true)) {
+				return filepath.SkipDir
+			}
+			rules[path] = parent.loadIgnoreFile(path)
+			return nil
+		}
+
+		if d.Type().IsRegular() && !parent.ignored(path, false) {
+			visit(path)
+		}
+		return nil
+	})
+}
+
+// searchFile searches one file. It returns false if the file has no
+// matches, is binary, too large or unreadable.
+func searchFile(ctx context.Context, root, path, pattern string, opts Options) (FileResult, bool) {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > maxSearchFileSize {
+		return FileResult{}, false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || isBinary(data) {
+		return FileResult{}, false
+	}
+
+	lines := file.SplitLines(data)
+	matches, err := FindMatches(ctx, lines, pattern, opts)
+	if err != nil || len(matches) == 0 {
+		return FileResult{}, false
+	}
+
+	result := FileResult{
+		Path:     path,
+		RelPath:  path,
+		Matches:  matches,
+		Previews: make([]string, len(matches)),
+	}
+	if rel, err := filepath.Rel(root, path); err == nil {
+		result.RelPath = rel
+	}
+	for i, m := range matches {
+		result.Previews[i] = lines[m.StartLine]
+	}
+
+	return result, true
+}
+
+// isBinary reports whether data looks like a binary file: one with a NUL
+// byte near the start.
+func isBinary(data []byte) bool {
+	sniff := data
+	if len(sniff) > binarySniffLen {
+		sniff = sniff[:binarySniffLen]
+	}
+	return bytes.IndexByte(sniff, 0) >= 0
+}
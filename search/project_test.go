@@ -0,0 +1,154 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// writeTree creates files under dir from a map of slash paths to contents.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSearchFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"main.go":            "package main\n\nfunc main() { todo() }\n",
+		"lib/util.go":        "// TODO: tidy\nfunc todo() {}\r\n",
+		"lib/.gitignore":     "generated.go\n",
+		"lib/generated.go":   "todo\n",
+		".gitignore":         "*.log\nbuild/\n",
+		"debug.log":          "todo\n",
+		"build/out.txt":      "todo\n",
+		".git/HEAD":          "todo\n",
+		"image.bin":          "todo\x00\x01",
+		"notes/readme.md":    "nothing here\n",
+		"notes/more/todo.md": "- todo: one\n- todo: two\n",
+	})
+
+	var results []FileResult
+	err := SearchFiles(context.Background(), dir, "todo", DefaultOptions(), func(r FileResult) {
+		results = append(results, r)
+	})
+	if err != nil {
+		t.Fatalf("SearchFiles() error = %v", err)
+	}
+
+	got := map[string]int{}
+	for _, r := range results {
+		got[filepath.ToSlash(r.RelPath)] = len(r.Matches)
+		if len(r.Previews) != len(r.Matches) {
+			t.Errorf("%s: %d previews for %d matches", r.RelPath, len(r.Previews), len(r.Matches))
+		}
+	}
+
+	want := map[string]int{
+		"main.go":            1,
+		"lib/util.go":        2,
+		"notes/more/todo.md": 2,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+
+	for _, r := range results {
+		if filepath.ToSlash(r.RelPath) != "lib/util.go" {
+			continue
+		}
+		if r.Previews[0] != "// TODO: tidy" || r.Previews[1] != "func todo() {}" {
+			t.Errorf("previews = %q", r.Previews)
+		}
+		if m := r.Matches[1]; m.StartLine != 1 || m.StartCol != 5 {
+			t.Errorf("second match at %d:%d, want 1:5", m.StartLine, m.StartCol)
+		}
+	}
+}
+
+func TestSearchFiles_Options(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"a.txt": "Item items item\n",
+	})
+
+	tests := []struct {
+		name string
+		opts Options
+		want []int
+	}{
+		{name: "default", opts: Options{}, want: []int{0, 5, 11}},
+		{name: "case sensitive", opts: Options{CaseSensitive: true}, want: []int{5, 11}},
+		{name: "whole word", opts: Options{WholeWord: true}, want: []int{0, 11}},
+		{name: "regex", opts: Options{UseRegex: true}, want: []int{0, 5, 11}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern := "item"
+			if tt.opts.UseRegex {
+				pattern = `i\w+m`
+			}
+
+			var cols []int
+			err := SearchFiles(context.Background(), dir, pattern, tt.opts, func(r FileResult) {
+				for _, m := range r.Matches {
+					cols = append(cols, m.StartCol)
+				}
+			})
+			if err != nil {
+				t.Fatalf("SearchFiles() error = %v", err)
+			}
+			sort.Ints(cols)
+			if !reflect.DeepEqual(cols, tt.want) {
+				t.Errorf("match columns = %v, want %v", cols, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchFiles_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := SearchFiles(context.Background(), dir, "(", Options{UseRegex: true}, func(FileResult) {}); err == nil {
+		t.Error("SearchFiles() with invalid regex should fail")
+	}
+	if err := SearchFiles(context.Background(), filepath.Join(dir, "missing"), "x", Options{}, func(FileResult) {}); err == nil {
+		t.Error("SearchFiles() with missing root should fail")
+	}
+}
+
+func TestSearchFiles_Cancel(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{}
+	for i := 0; i < 200; i++ {
+		files[filepath.Join("d", string(rune('a'+i%26)), "f"+string(rune('a'+i/26))+".txt")] = "match\n"
+	}
+	writeTree(t, dir, files)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := SearchFiles(ctx, dir, "match", Options{}, func(FileResult) {
+		calls++
+		cancel()
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("SearchFiles() error = %v, want context.Canceled", err)
+	}
+	if calls != 1 {
+		t.Errorf("callback called %d times after cancel, want 1", calls)
+	}
+}
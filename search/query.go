@@ -0,0 +1,322 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/core/history"
+)
+
+// QueryReplace is an interactive replace session that visits matches one
+// at a time, starting at a position and wrapping around to it, and lets
+// the caller replace or skip each one.
+//
+// All replacements made in the session are collected into a single
+// CompositeOperation, pushed to the history by Finish.
+//
+// The buffer is searched once, when the session starts; the matches are
+// then moved along with each replacement rather than searched for again.
+type QueryReplace struct {
+	replacer *Replacer
+	buf      *buffer.Buffer
+	op       *history.CompositeOperation
+	re       *regexp.Regexp // Compiled pattern for expanding the replacement
+
+	matches    []Match // Matches in the buffer, in order
+	index      int     // Index in matches of the current match
+	current    Match
+	hasCurrent bool
+	pos        buffer.Position // Next match must start at or after pos
+	stop       buffer.Position // Where the session started; reached again after wrapping
+	wrapped    bool
+	done       bool
+
+	replaced int
+	skipped  int
+	steps    []queryStep // Answers so far, for UndoLast
+}
+
+// queryStep records the session state before an answer so it can be undone.
+type queryStep struct {
+	op      *history.ReplaceOperation // Nil for a skipped match
+	match   Match
+	pos     buffer.Position
+	stop    buffer.Position
+	wrapped bool
+}
+
+// StartQueryReplace begins an interactive replace session at from.
+// Call Current to get the first match to ask about.
+func (r *Replacer) StartQueryReplace(buf *buffer.Buffer, from buffer.Position) *QueryReplace {
+	op := &history.CompositeOperation{}
+	op.SetDescription(fmt.Sprintf("query replace '%s' with '%s'", r.finder.GetPattern(), r.replacement))
+
+	q := &QueryReplace{
+		replacer: r,
+		buf:      buf,
+		op:       op,
+		re:       r.replacementRegex(),
+		matches:  slices.Clone(r.finder.FindAll(buf)),
+		pos:      from,
+		stop:     from,
+	}
+	q.advance()
+	return q
+}
+
+// Current returns the match being asked about.
+// It returns false once the session is done.
+func (q *QueryReplace) Current() (Match, bool) {
+	if q.done {
+		return Match{}, false
+	}
+	return q.current, q.hasCurrent
+}
+
+// Preview returns the text the current match would be replaced with.
+func (q *QueryReplace) Preview() string {
+	if !q.hasCurrent {
+		return ""
+	}
+	return q.replacer.getReplacementText(q.current, q.re)
+}
+
+// Done reports whether the session has no more matches to ask about.
+func (q *QueryReplace) Done() bool {
+	return q.done
+}
+
+// Yes replaces the current match and moves to the next one.
+func (q *QueryReplace) Yes() error {
+	if q.done || !q.hasCurrent {
+		return nil
+	}
+
+	step := q.saveStep()
+	op, err := q.replacer.replaceMatch(q.buf, q.current, q.re)
+	if err != nil {
+		return err
+	}
+	step.op = op
+	q.steps = append(q.steps, step)
+	q.op.Operations = append(q.op.Operations, op)
+	q.replaced++
+
+	newEnd := buffer.PositionAfter(op.StartPos, op.New)
+	q.stop = buffer.AdjustForReplace(q.stop, op.StartPos, op.EndPos, newEnd)
+	q.moveMatches(op.StartPos, op.EndPos, newEnd)
+	q.pos = newEnd
+	if op.StartPos == op.EndPos && op.New == "" {
+		// Empty match replaced by nothing; step over it
+		q.pos = q.positionAfter(newEnd)
+	}
+
+	q.advance()
+	return nil
+}
+
+// No skips the current match and moves to the next one.
+func (q *QueryReplace) No() {
+	if q.done || !q.hasCurrent {
+		return
+	}
+
+	q.steps = append(q.steps, q.saveStep())
+	q.skipped++
+
+	end := buffer.Position{Line: q.current.EndLine, Col: q.current.EndCol}
+	if end == (buffer.Position{Line: q.current.StartLine, Col: q.current.StartCol}) {
+		end = q.positionAfter(end)
+	}
+	q.pos = end
+
+	q.advance()
+}
+
+// All replaces the current match and every remaining one.
+func (q *QueryReplace) All() error {
+	for !q.done && q.hasCurrent {
+		if err := q.Yes(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Quit ends the session, leaving the remaining matches unchanged.
+func (q *QueryReplace) Quit() {
+	q.done = true
+	q.hasCurrent = false
+}
+
+// UndoLast reverts the most recent replacement and asks about that match
+// again. Matches skipped after it are asked about again too.
+// It returns false if nothing has been replaced.
+func (q *QueryReplace) UndoLast() (bool, error) {
+	last := -1
+	for i := len(q.steps) - 1; i >= 0; i-- {
+		if q.steps[i].op != nil {
+			last = i
+			break
+		}
+	}
+	if last < 0 {
+		return false, nil
+	}
+
+	step := q.steps[last]
+	if err := step.op.Undo(q.buf); err != nil {
+		return false, fmt.Errorf("undo replacement: %w", err)
+	}
+	newEnd := buffer.PositionAfter(step.op.StartPos, step.op.New)
+	q.replacer.finder.AdjustScope(step.op.StartPos, newEnd, step.op.EndPos)
+
+	// Undoing is rare, so the buffer is simply searched again
+	start := buffer.Position{Line: step.match.StartLine, Col: step.match.StartCol}
+	q.matches = slices.Clone(q.replacer.finder.FindAll(q.buf))
+	q.index, _ = slices.BinarySearchFunc(q.matches, start, compareMatchStart)
+
+	q.skipped -= len(q.steps) - last - 1
+	q.replaced--
+	q.steps = q.steps[:last]
+	q.op.Operations = q.op.Operations[:len(q.op.Operations)-1]
+
+	q.current = step.match
+	q.hasCurrent = true
+	q.pos = step.pos
+	q.stop = step.stop
+	q.wrapped = step.wrapped
+	q.done = false
+	return true, nil
+}
+
+// Finish ends the session and pushes its replacements to hist as a single
+// undoable operation. It returns a summary for the status bar.
+func (q *QueryReplace) Finish(hist *history.History) string {
+	q.Quit()
+
+	if hist != nil && len(q.op.Operations) > 0 {
+		hist.Push(q.op)
+	}
+	q.replacer.finder.Clear()
+
+	return q.Summary()
+}
+
+// Summary describes how many matches were replaced and skipped.
+func (q *QueryReplace) Summary() string {
+	return fmt.Sprintf("Replaced %d of %d matches", q.replaced, q.replaced+q.skipped)
+}
+
+// Counts returns the number of matches replaced and skipped so far.
+func (q *QueryReplace) Counts() (replaced, skipped int) {
+	return q.replaced, q.skipped
+}
+
+// saveStep captures the state needed to undo the next answer.
+func (q *QueryReplace) saveStep() queryStep {
+	return queryStep{
+		match:   q.current,
+		pos:     q.pos,
+		stop:    q.stop,
+		wrapped: q.wrapped,
+	}
+}
+
+// moveMatches keeps the matches on their text after the current match,
+// from start to oldEnd, was replaced by text ending at newEnd. Matches
+// overlapping the replaced text, as literal searches find, are dropped.
+func (q *QueryReplace) moveMatches(start, oldEnd, newEnd buffer.Position) {
+	// Matches before the current one that run into it
+	first := q.index
+	for first > 0 && positionBefore(start, matchEnd(q.matches[first-1])) {
+		first--
+	}
+	// Matches after it that start inside it
+	last := q.index + 1
+	for last < len(q.matches) && positionBefore(matchStart(q.matches[last]), oldEnd) {
+		last++
+	}
+	q.matches = slices.Delete(q.matches, q.index+1, last)
+	q.matches = slices.Delete(q.matches, first, q.index)
+	q.index = first
+	q.matches[q.index].EndLine, q.matches[q.index].EndCol = newEnd.Line, newEnd.Col
+
+	// Only matches on the replaced text's last line move unless the
+	// replacement changes the number of lines
+	lines := newEnd.Line - oldEnd.Line
+	for i := q.index + 1; i < len(q.matches); i++ {
+		m := &q.matches[i]
+		if lines == 0 && m.StartLine > oldEnd.Line {
+			break
+		}
+		s := buffer.AdjustForReplace(matchStart(*m), start, oldEnd, newEnd)
+		e := buffer.AdjustForReplace(matchEnd(*m), start, oldEnd, newEnd)
+		m.StartLine, m.StartCol = s.Line, s.Col
+		m.EndLine, m.EndCol = e.Line, e.Col
+	}
+}
+
+// advance finds the next match at or after pos, wrapping to the start of
+// the buffer once and stopping when it reaches where the session began.
+// Matches before index come before pos, so the search starts there.
+func (q *QueryReplace) advance() {
+	for {
+		for ; q.index < len(q.matches); q.index++ {
+			m := q.matches[q.index]
+			start := matchStart(m)
+			if positionBefore(start, q.pos) {
+				continue
+			}
+			if q.wrapped && !positionBefore(start, q.stop) {
+				break
+			}
+			q.current = m
+			q.hasCurrent = true
+			return
+		}
+
+		if q.wrapped {
+			break
+		}
+		q.wrapped = true
+		q.pos = buffer.Position{}
+		q.index = 0
+	}
+
+	q.done = true
+	q.hasCurrent = false
+}
+
+// positionAfter returns the position one character after pos, moving to
+// the next line at the end of a line.
+func (q *QueryReplace) positionAfter(pos buffer.Position) buffer.Position {
+	line, err := q.buf.GetLine(pos.Line)
+	if err != nil || pos.Col < len(line) {
+		return buffer.Position{Line: pos.Line, Col: pos.Col + 1}
+	}
+	return buffer.Position{Line: pos.Line + 1, Col: 0}
+}
+
+// matchStart returns where m starts.
+func matchStart(m Match) buffer.Position {
+	return buffer.Position{Line: m.StartLine, Col: m.StartCol}
+}
+
+// matchEnd returns where m ends.
+func matchEnd(m Match) buffer.Position {
+	return buffer.Position{Line: m.EndLine, Col: m.EndCol}
+}
+
+// compareMatchStart orders a match by its start against pos, for
+// slices.BinarySearchFunc.
+func compareMatchStart(m Match, pos buffer.Position) int {
+	return buffer.ComparePositions(matchStart(m), pos)
+}
+
+// positionBefore reports whether a comes before b.
+func positionBefore(a, b buffer.Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Col < b.Col)
+}
@@ -0,0 +1,215 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/core/history"
+)
+
+// newQueryTest returns a replacer for pattern → replacement over lines.
+func newQueryTest(lines []string, pattern, replacement string) (*Replacer, *buffer.Buffer) {
+	finder := NewFinder()
+	finder.SetPattern(pattern)
+	r := NewReplacer(finder)
+	r.SetReplacement(replacement)
+
+	buf := buffer.NewBuffer()
+	buf.SetLines(lines)
+	return r, buf
+}
+
+func TestQueryReplace_Answers(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers string // y, n, a, q, u
+		want    []string
+		summary string
+	}{
+		{name: "yes to all", answers: "yyy", want: []string{"b b", "b"}, summary: "Replaced 3 of 3 matches"},
+		{name: "skip middle", answers: "yny", want: []string{"b a", "b"}, summary: "Replaced 2 of 3 matches"},
+		{name: "all", answers: "na", want: []string{"a b", "b"}, summary: "Replaced 2 of 3 matches"},
+		{name: "quit", answers: "yq", want: []string{"b a", "a"}, summary: "Replaced 1 of 1 matches"},
+		{name: "undo last", answers: "yyu", want: []string{"b a", "a"}, summary: "Replaced 1 of 1 matches"},
+		{name: "undo after skip asks again", answers: "ynuyyy", want: []string{"b b", "b"}, summary: "Replaced 3 of 3 matches"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, buf := newQueryTest([]string{"a a", "a"}, "a", "b")
+			q := r.StartQueryReplace(buf, buffer.Position{})
+
+			for _, answer := range tt.answers {
+				var err error
+				switch answer {
+				case 'y':
+					err = q.Yes()
+				case 'n':
+					q.No()
+				case 'a':
+					err = q.All()
+				case 'q':
+					q.Quit()
+				case 'u':
+					_, err = q.UndoLast()
+				}
+				if err != nil {
+					t.Fatalf("answer %q: %v", answer, err)
+				}
+			}
+
+			if got := buf.GetAllLines(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lines = %q, want %q", got, tt.want)
+			}
+			if got := q.Summary(); got != tt.summary {
+				t.Errorf("Summary() = %q, want %q", got, tt.summary)
+			}
+		})
+	}
+}
+
+func TestQueryReplace_WrapsAroundToStart(t *testing.T) {
+	r, buf := newQueryTest([]string{"x1", "x2", "x3"}, "x", "y")
+	q := r.StartQueryReplace(buf, buffer.Position{Line: 1, Col: 1})
+
+	var visited []int
+	for !q.Done() {
+		m, _ := q.Current()
+		visited = append(visited, m.StartLine)
+		if err := q.Yes(); err != nil {
+			t.Fatalf("Yes() error = %v", err)
+		}
+	}
+
+	if want := []int{2, 0, 1}; !reflect.DeepEqual(visited, want) {
+		t.Errorf("visited lines = %v, want %v", visited, want)
+	}
+}
+
+func TestQueryReplace_ReplacementContainsPattern(t *testing.T) {
+	r, buf := newQueryTest([]string{"a a"}, "a", "aa")
+	q := r.StartQueryReplace(buf, buffer.Position{})
+
+	if err := q.All(); err != nil {
+		t.Fatalf("All() error = %v", err)
+	}
+
+	if got := buf.GetAllLines(); !reflect.DeepEqual(got, []string{"aa aa"}) {
+		t.Errorf("lines = %q, want %q", got, []string{"aa aa"})
+	}
+}
+
+func TestQueryReplace_MatchesFollowReplacements(t *testing.T) {
+	tests := []struct {
+		name        string
+		lines       []string
+		pattern     string
+		replacement string
+		from        buffer.Position
+		want        []string
+		summary     string
+	}{
+		{
+			name:        "longer replacement on the same line",
+			lines:       []string{"foo boo", "zoo"},
+			pattern:     "o",
+			replacement: "00",
+			want:        []string{"f0000 b0000", "z0000"},
+			summary:     "Replaced 6 of 6 matches",
+		},
+		{
+			name:        "replacement adds lines",
+			lines:       []string{"a a", "a"},
+			pattern:     "a",
+			replacement: "x\ny",
+			want:        []string{"x", "y x", "y", "x", "y"},
+			summary:     "Replaced 3 of 3 matches",
+		},
+		{
+			name:        "overlapping match after the replaced one",
+			lines:       []string{"aaaa"},
+			pattern:     "aa",
+			replacement: "b",
+			want:        []string{"bb"},
+			summary:     "Replaced 2 of 2 matches",
+		},
+		{
+			name:        "overlapping match reached after wrapping",
+			lines:       []string{"aaa"},
+			pattern:     "aa",
+			replacement: "b",
+			from:        buffer.Position{Line: 0, Col: 1},
+			want:        []string{"ab"},
+			summary:     "Replaced 1 of 1 matches",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, buf := newQueryTest(tt.lines, tt.pattern, tt.replacement)
+			q := r.StartQueryReplace(buf, tt.from)
+
+			if err := q.All(); err != nil {
+				t.Fatalf("All() error = %v", err)
+			}
+
+			if got := buf.GetAllLines(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lines = %q, want %q", got, tt.want)
+			}
+			if got := q.Summary(); got != tt.summary {
+				t.Errorf("Summary() = %q, want %q", got, tt.summary)
+			}
+		})
+	}
+}
+
+func TestQueryReplace_FinishIsOneUndo(t *testing.T) {
+	original := []string{"foo bar foo", "foo"}
+	r, buf := newQueryTest(original, "foo", "baz")
+	hist := history.NewHistory(100)
+
+	q := r.StartQueryReplace(buf, buffer.Position{})
+	if err := q.Yes(); err != nil {
+		t.Fatalf("Yes() error = %v", err)
+	}
+	q.No()
+	if err := q.Yes(); err != nil {
+		t.Fatalf("Yes() error = %v", err)
+	}
+
+	if got := q.Finish(hist); got != "Replaced 2 of 3 matches" {
+		t.Errorf("Finish() = %q, want %q", got, "Replaced 2 of 3 matches")
+	}
+	if !q.Done() {
+		t.Error("session should be done after Finish")
+	}
+
+	if err := hist.Undo(buf); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if got := buf.GetAllLines(); !reflect.DeepEqual(got, original) {
+		t.Errorf("after undo lines = %q, want %q", got, original)
+	}
+	if hist.CanUndo() {
+		t.Error("session should be a single history entry")
+	}
+}
+
+func TestQueryReplace_NoMatches(t *testing.T) {
+	r, buf := newQueryTest([]string{"abc"}, "xyz", "q")
+	hist := history.NewHistory(100)
+
+	q := r.StartQueryReplace(buf, buffer.Position{})
+	if !q.Done() {
+		t.Error("session with no matches should be done")
+	}
+	if undone, _ := q.UndoLast(); undone {
+		t.Error("UndoLast() = true with nothing replaced")
+	}
+
+	q.Finish(hist)
+	if hist.CanUndo() {
+		t.Error("empty session should not be pushed to history")
+	}
+}
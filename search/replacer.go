@@ -4,6 +4,7 @@ package search
 import (
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/AndrewDonelson/ted/core/buffer"
@@ -20,18 +21,25 @@ type ReplaceResult struct {
 type Replacer struct {
 	finder      *Finder
 	replacement string
+	history     []string // Replacement history, oldest first
+	maxHistory  int      // Maximum history entries
 }
 
 // NewReplacer creates a new replacer with the given finder.
 func NewReplacer(finder *Finder) *Replacer {
 	return &Replacer{
-		finder: finder,
+		finder:     finder,
+		maxHistory: 20,
 	}
 }
 
-// SetReplacement sets the replacement string.
+// SetReplacement sets the replacement string and records it in the
+// replacement history. Empty replacements are not recorded.
 func (r *Replacer) SetReplacement(replacement string) {
 	r.replacement = replacement
+	if replacement != "" {
+		r.history = addHistory(r.history, replacement, r.maxHistory)
+	}
 }
 
 // GetReplacement returns the current replacement string.
@@ -39,6 +47,31 @@ func (r *Replacer) GetReplacement() string {
 	return r.replacement
 }
 
+// GetHistory returns the replacement history, oldest first.
+func (r *Replacer) GetHistory() []string {
+	return slices.Clone(r.history)
+}
+
+// SetMaxHistory changes how many replacements the history keeps, dropping
+// the oldest ones if there are more. Zero disables the history.
+func (r *Replacer) SetMaxHistory(max int) {
+	r.maxHistory = max
+	if excess := len(r.history) - max; excess > 0 {
+		r.history = slices.Delete(r.history, 0, excess)
+	}
+}
+
+// SetHistory replaces the replacement history. Only the newest entries
+// that fit are kept.
+func (r *Replacer) SetHistory(history []string) {
+	r.history = nil
+	for _, replacement := range history {
+		if replacement != "" {
+			r.history = addHistory(r.history, replacement, r.maxHistory)
+		}
+	}
+}
+
 // ReplaceCurrent replaces the current match and advances to the next.
 // Returns true if a replacement was made.
 func (r *Replacer) ReplaceCurrent(buf *buffer.Buffer, hist *history.History) (bool, error) {
@@ -47,49 +80,16 @@ func (r *Replacer) ReplaceCurrent(buf *buffer.Buffer, hist *history.History) (bo
 		return false, nil
 	}
 
-	// Get the replacement text
-	replacement := r.getReplacementText(match)
+	op, err := r.replaceMatch(buf, match, r.replacementRegex())
+	if err != nil {
+		return false, err
+	}
 
 	// Record for undo
-	deletedText, _ := buf.GetText(
-		buffer.Position{Line: match.StartLine, Col: match.StartCol},
-		buffer.Position{Line: match.EndLine, Col: match.EndCol},
-	)
-
 	if hist != nil {
-		op := &history.DeleteOperation{
-			StartPos: buffer.Position{Line: match.StartLine, Col: match.StartCol},
-			EndPos:   buffer.Position{Line: match.EndLine, Col: match.EndCol},
-			Deleted:  deletedText,
-		}
 		hist.Push(op)
 	}
 
-	// Delete the match
-	if err := buf.Delete(
-		buffer.Position{Line: match.StartLine, Col: match.StartCol},
-		buffer.Position{Line: match.EndLine, Col: match.EndCol},
-	); err != nil {
-		return false, fmt.Errorf("delete match: %w", err)
-	}
-
-	// Insert replacement
-	if err := buf.Insert(
-		buffer.Position{Line: match.StartLine, Col: match.StartCol},
-		replacement,
-	); err != nil {
-		return false, fmt.Errorf("insert replacement: %w", err)
-	}
-
-	// Record insert for undo
-	if hist != nil {
-		insertOp := &history.InsertOperation{
-			Pos:  buffer.Position{Line: match.StartLine, Col: match.StartCol},
-			Text: replacement,
-		}
-		hist.Push(insertOp)
-	}
-
 	// Clear matches and refind - positions may have changed
 	r.finder.Clear()
 
@@ -104,7 +104,7 @@ func (r *Replacer) ReplaceAll(buf *buffer.Buffer, hist *history.History) (int, e
 	}
 
 	// Find all matches
-	matches := r.finder.FindAll(buf)
+	matches := nonOverlapping(r.finder.FindAll(buf))
 	if len(matches) == 0 {
 		return 0, nil
 	}
@@ -114,48 +114,14 @@ func (r *Replacer) ReplaceAll(buf *buffer.Buffer, hist *history.History) (int, e
 	compOp.SetDescription(fmt.Sprintf("replace all '%s' with '%s'", r.finder.GetPattern(), r.replacement))
 
 	// Replace from end to beginning to avoid position shifting
+	re := r.replacementRegex()
 	replaceCount := 0
 	for i := len(matches) - 1; i >= 0; i-- {
-		match := matches[i]
-		replacement := r.getReplacementText(match)
-
-		// Get deleted text for undo
-		deletedText, _ := buf.GetText(
-			buffer.Position{Line: match.StartLine, Col: match.StartCol},
-			buffer.Position{Line: match.EndLine, Col: match.EndCol},
-		)
-
-		// Record delete operation
-		op := &history.DeleteOperation{
-			StartPos: buffer.Position{Line: match.StartLine, Col: match.StartCol},
-			EndPos:   buffer.Position{Line: match.EndLine, Col: match.EndCol},
-			Deleted:  deletedText,
+		op, err := r.replaceMatch(buf, matches[i], re)
+		if err != nil {
+			return replaceCount, err
 		}
 		compOp.Operations = append(compOp.Operations, op)
-
-		// Delete the match
-		if err := buf.Delete(
-			buffer.Position{Line: match.StartLine, Col: match.StartCol},
-			buffer.Position{Line: match.EndLine, Col: match.EndCol},
-		); err != nil {
-			return replaceCount, fmt.Errorf("delete match: %w", err)
-		}
-
-		// Insert replacement
-		if err := buf.Insert(
-			buffer.Position{Line: match.StartLine, Col: match.StartCol},
-			replacement,
-		); err != nil {
-			return replaceCount, fmt.Errorf("insert replacement: %w", err)
-		}
-
-		// Record insert operation
-		insertOp := &history.InsertOperation{
-			Pos:  buffer.Position{Line: match.StartLine, Col: match.StartCol},
-			Text: replacement,
-		}
-		compOp.Operations = append([]history.Operation{insertOp}, compOp.Operations...)
-
 		replaceCount++
 	}
 
@@ -170,75 +136,133 @@ func (r *Replacer) ReplaceAll(buf *buffer.Buffer, hist *history.History) (int, e
 	return replaceCount, nil
 }
 
-// getReplacementText returns the actual replacement text for a match.
-// If using regex, this processes capture groups.
-func (r *Replacer) getReplacementText(match Match) string {
+// replaceMatch replaces a single match, which may span several lines, and
+// returns the operation that undoes it. re is the compiled pattern from
+// replacementRegex.
+func (r *Replacer) replaceMatch(buf *buffer.Buffer, match Match, re *regexp.Regexp) (*history.ReplaceOperation, error) {
+	start := buffer.Position{Line: match.StartLine, Col: match.StartCol}
+	end := buffer.Position{Line: match.EndLine, Col: match.EndCol}
+
+	old, err := buf.GetText(start, end)
+	if err != nil {
+		return nil, fmt.Errorf("get match text: %w", err)
+	}
+
+	replacement := r.getReplacementText(match, re)
+	newEnd, err := buf.Replace(start, end, replacement)
+	if err != nil {
+		return nil, fmt.Errorf("replace match: %w", err)
+	}
+
+	// Keep the search scope covering the same text
+	r.finder.AdjustScope(start, end, newEnd)
+
+	return &history.ReplaceOperation{
+		StartPos: start,
+		EndPos:   end,
+		Old:      old,
+		New:      replacement,
+	}, nil
+}
+
+// nonOverlapping drops matches that start inside the previous kept match,
+// as literal searches report overlapping matches.
+func nonOverlapping(matches []Match) []Match {
+	result := make([]Match, 0, len(matches))
+	for _, m := range matches {
+		if len(result) > 0 {
+			prev := result[len(result)-1]
+			if m.StartLine < prev.EndLine || (m.StartLine == prev.EndLine && m.StartCol < prev.EndCol) {
+				continue
+			}
+		}
+		result = append(result, m)
+	}
+	return result
+}
+
+// replacementRegex compiles the search pattern for expanding the
+// replacement, once per operation rather than once per match. It returns
+// nil for literal searches and for patterns that do not compile, whose
+// replacements are used as written.
+func (r *Replacer) replacementRegex() *regexp.Regexp {
 	if !r.finder.options.UseRegex {
-		return r.replacement
+		return nil
+	}
+	re, err := r.finder.compileRegex()
+	if err != nil {
+		return nil
+	}
+	return re
+}
+
+// getReplacementText returns the actual replacement text for a match.
+// If re, from replacementRegex, is not nil, this processes capture groups.
+func (r *Replacer) getReplacementText(match Match, re *regexp.Regexp) string {
+	text := r.replacement
+	if re != nil {
+		// Process regex replacement (handle $1, $2, etc.)
+		text = r.processRegexReplacement(match, re)
 	}
 
-	// Process regex replacement (handle $1, $2, etc.)
-	return r.processRegexReplacement(match)
+	if r.finder.options.PreserveCase {
+		text = preserveCase(match.Text, text)
+	}
+	return text
 }
 
-// processRegexReplacement processes regex capture group references.
-func (r *Replacer) processRegexReplacement(match Match) string {
-	result := r.replacement
+// processRegexReplacement expands capture group references and escapes
+// in the replacement template for a match of re. See expandTemplate for
+// the supported syntax.
+func (r *Replacer) processRegexReplacement(match Match, re *regexp.Regexp) string {
+	groups := match.Groups
+	if groups == nil {
+		// Match was not produced by a regex search; re-run the pattern
+		// over the matched text to recover its groups.
+		if loc := re.FindStringSubmatchIndex(match.Text); loc != nil {
+			groups = submatchGroups(match.Text, loc)
+		} else {
+			groups = []string{match.Text}
+		}
+	}
 
-	// Simple implementation: replace $1, $2, etc. with capture groups
-	// In a full implementation, you'd want to parse the regex and extract groups
-	// For now, we just return the replacement as-is
+	return expandTemplate(r.replacement, groups, re.SubexpNames())
+}
 
-	// TODO: Implement full capture group replacement
-	// This requires parsing the regex and extracting submatches
+// Preview returns the text that replacement would produce for the current
+// match, or for the first match if none is current. It returns false if
+// there is no match to preview.
+func (r *Replacer) Preview(replacement string) (string, bool) {
+	match, ok := r.finder.GetCurrentMatch()
+	if !ok {
+		if len(r.finder.matches) == 0 {
+			return "", false
+		}
+		match = r.finder.matches[0]
+	}
 
-	return result
+	saved := r.replacement
+	r.replacement = replacement
+	text := r.getReplacementText(match, r.replacementRegex())
+	r.replacement = saved
+
+	return text, true
 }
 
 // ValidateReplacement validates that the replacement string is valid.
-// For regex mode, this checks that capture group references are well-formed.
+// For regex mode, this checks that capture group references are well-formed
+// and, when the pattern compiles, that the referenced groups exist.
 func (r *Replacer) ValidateReplacement() error {
 	if !r.finder.options.UseRegex {
 		return nil
 	}
 
-	// Check for invalid capture group references
-	// $0, $1, $2, etc. are valid
-	// $$ escapes a literal $
-
-	for i := 0; i < len(r.replacement); i++ {
-		if r.replacement[i] == '$' {
-			if i+1 >= len(r.replacement) {
-				return fmt.Errorf("incomplete escape at end of replacement")
-			}
-			next := r.replacement[i+1]
-			if next == '$' || next == '&' || next == '`' || next == '\'' {
-				// Valid escape
-				i++
-				continue
-			}
-			if next >= '0' && next <= '9' {
-				// Capture group reference - valid
-				i++
-				continue
-			}
-			if next == '{' {
-				// Named group reference - simplified, just check it's closed
-				j := i + 2
-				for j < len(r.replacement) && r.replacement[j] != '}' {
-					j++
-				}
-				if j >= len(r.replacement) {
-					return fmt.Errorf("unclosed named group reference")
-				}
-				i = j
-				continue
-			}
-			return fmt.Errorf("invalid escape sequence: $%c", next)
-		}
+	var re *regexp.Regexp
+	if r.finder.GetPattern() != "" {
+		re, _ = r.finder.compileRegex()
 	}
 
-	return nil
+	return validateTemplate(r.replacement, re)
 }
 
 // CountMatches returns the number of matches in the buffer.
@@ -263,13 +287,7 @@ func (r *Replacer) IsPatternValid() bool {
 		return true
 	}
 
-	var err error
-	if r.finder.options.CaseSensitive {
-		_, err = regexp.Compile(pattern)
-	} else {
-		_, err = regexp.Compile("(?i)" + pattern)
-	}
-
+	_, err := r.finder.compileRegex()
 	return err == nil
 }
 
@@ -1,6 +1,8 @@
 package search
 
 import (
+	"reflect"
+	"slices"
 	"testing"
 
 	"github.com/AndrewDonelson/ted/core/buffer"
@@ -31,6 +33,31 @@ func TestReplacer_SetReplacement(t *testing.T) {
 	}
 }
 
+func TestReplacer_History(t *testing.T) {
+	r := NewReplacer(NewFinder())
+	r.SetHistory([]string{"a", "", "b"})
+	r.SetReplacement("c")
+	r.SetReplacement("")
+	r.SetReplacement("a")
+
+	want := []string{"b", "c", "a"}
+	if got := r.GetHistory(); !slices.Equal(got, want) {
+		t.Errorf("GetHistory() = %v, want %v", got, want)
+	}
+}
+
+func TestReplacer_SetMaxHistory(t *testing.T) {
+	r := NewReplacer(NewFinder())
+	r.SetHistory([]string{"a", "b", "c"})
+	r.SetMaxHistory(1)
+	r.SetReplacement("d")
+	r.SetReplacement("e")
+
+	if got, want := r.GetHistory(), []string{"e"}; !slices.Equal(got, want) {
+		t.Errorf("GetHistory() = %v, want %v", got, want)
+	}
+}
+
 func TestReplacer_CountMatches(t *testing.T) {
 	finder := NewFinder()
 	finder.SetPattern("test")
@@ -365,7 +392,7 @@ func TestReplacer_getReplacementText(t *testing.T) {
 		Text:      "test",
 	}
 
-	result := r.getReplacementText(match)
+	result := r.getReplacementText(match, r.replacementRegex())
 
 	if result != "new text" {
 		t.Errorf("getReplacementText = %q, want %q", result, "new text")
@@ -390,7 +417,7 @@ func TestReplacer_getReplacementText_Regex(t *testing.T) {
 		Text:      "hello",
 	}
 
-	result := r.getReplacementText(match)
+	result := r.getReplacementText(match, r.replacementRegex())
 
 	if result != "greeting" {
 		t.Errorf("getReplacementText = %q, want %q", result, "greeting")
@@ -472,3 +499,259 @@ func TestReplacer_ReplaceAll_CaseSensitive(t *testing.T) {
 		t.Errorf("line 2 = %q, want unchanged", lines[2])
 	}
 }
+
+func TestReplacer_ReplaceAll_RegexGroups(t *testing.T) {
+	finder := NewFinder()
+	finder.SetPattern(`(?P<key>\w+)=(\w+)`)
+	opts := finder.GetOptions()
+	opts.UseRegex = true
+	finder.SetOptions(opts)
+
+	r := NewReplacer(finder)
+	r.SetReplacement(`\U${key}\E: $2`)
+
+	buf := buffer.NewBuffer()
+	buf.SetLines([]string{"set name=ted", "mode=edit tab=4;"})
+
+	count, err := r.ReplaceAll(buf, history.NewHistory(100))
+	if err != nil {
+		t.Fatalf("ReplaceAll error: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("ReplaceAll count = %d, want 3", count)
+	}
+
+	lines := buf.GetAllLines()
+	want := []string{"set NAME: ted", "MODE: edit TAB: 4;"}
+	for i, line := range want {
+		if lines[i] != line {
+			t.Errorf("line %d = %q, want %q", i, lines[i], line)
+		}
+	}
+}
+
+func TestReplacer_ReplaceAll_RegexNewline(t *testing.T) {
+	finder := NewFinder()
+	finder.SetPattern(`, `)
+	opts := finder.GetOptions()
+	opts.UseRegex = true
+	finder.SetOptions(opts)
+
+	r := NewReplacer(finder)
+	r.SetReplacement(`,\n`)
+
+	buf := buffer.NewBuffer()
+	buf.SetLines([]string{"a, b, c"})
+
+	if _, err := r.ReplaceAll(buf, nil); err != nil {
+		t.Fatalf("ReplaceAll error: %v", err)
+	}
+
+	lines := buf.GetAllLines()
+	want := []string{"a,", "b,", "c"}
+	if len(lines) != len(want) {
+		t.Fatalf("line count = %d, want %d (%q)", len(lines), len(want), lines)
+	}
+	for i, line := range want {
+		if lines[i] != line {
+			t.Errorf("line %d = %q, want %q", i, lines[i], line)
+		}
+	}
+}
+
+func TestReplacer_getReplacementText_RegexWithoutGroups(t *testing.T) {
+	finder := NewFinder()
+	finder.SetPattern(`(\w+)@(\w+)`)
+	opts := finder.GetOptions()
+	opts.UseRegex = true
+	finder.SetOptions(opts)
+
+	r := NewReplacer(finder)
+	r.SetReplacement("$2 at $1")
+
+	// Groups are recovered from Text when the match does not carry them
+	match := Match{EndCol: 9, Text: "user@host"}
+
+	if got := r.getReplacementText(match, r.replacementRegex()); got != "host at user" {
+		t.Errorf("getReplacementText = %q, want %q", got, "host at user")
+	}
+}
+
+func TestReplacer_Preview(t *testing.T) {
+	finder := NewFinder()
+	finder.SetPattern(`(\d+)px`)
+	opts := finder.GetOptions()
+	opts.UseRegex = true
+	finder.SetOptions(opts)
+
+	r := NewReplacer(finder)
+
+	if _, ok := r.Preview("${1}em"); ok {
+		t.Error("Preview should return false before searching")
+	}
+
+	buf := buffer.NewBuffer()
+	buf.SetLines([]string{"width: 10px; height: 20px"})
+	finder.FindAll(buf)
+
+	got, ok := r.Preview("${1}em")
+	if !ok || got != "10em" {
+		t.Errorf("Preview() = %q, %v, want %q, true", got, ok, "10em")
+	}
+
+	finder.SetCurrentMatch(1)
+	got, ok = r.Preview("${1}em")
+	if !ok || got != "20em" {
+		t.Errorf("Preview() = %q, %v, want %q, true", got, ok, "20em")
+	}
+
+	if r.GetReplacement() != "" {
+		t.Errorf("Preview changed replacement to %q", r.GetReplacement())
+	}
+}
+
+func TestReplacer_ValidateReplacement_UndefinedGroup(t *testing.T) {
+	finder := NewFinder()
+	finder.SetPattern(`(a)(b)`)
+	opts := finder.GetOptions()
+	opts.UseRegex = true
+	finder.SetOptions(opts)
+
+	r := NewReplacer(finder)
+	r.SetReplacement("$3")
+
+	if err := r.ValidateReplacement(); err == nil {
+		t.Error("ValidateReplacement should error for undefined group")
+	}
+}
+
+func TestReplacer_ReplaceAll_MultiLineMatch(t *testing.T) {
+	finder := NewFinder()
+	finder.SetPattern(`\}\n\n+func`)
+	opts := finder.GetOptions()
+	opts.UseRegex = true
+	finder.SetOptions(opts)
+
+	r := NewReplacer(finder)
+	r.SetReplacement("}\n\nfunc")
+
+	original := []string{"func a() {", "}", "", "", "", "func b() {", "}"}
+	buf := buffer.NewBuffer()
+	buf.SetLines(original)
+
+	hist := history.NewHistory(100)
+	count, err := r.ReplaceAll(buf, hist)
+	if err != nil {
+		t.Fatalf("ReplaceAll error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("ReplaceAll count = %d, want 1", count)
+	}
+
+	want := []string{"func a() {", "}", "", "func b() {", "}"}
+	if got := buf.GetAllLines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+
+	if err := hist.Undo(buf); err != nil {
+		t.Fatalf("Undo error: %v", err)
+	}
+	if got := buf.GetAllLines(); !reflect.DeepEqual(got, original) {
+		t.Errorf("lines after undo = %q, want %q", got, original)
+	}
+}
+
+func TestReplacer_ReplaceAll_WholeLineMatch(t *testing.T) {
+	finder := NewFinder()
+	finder.SetPattern("remove me")
+
+	r := NewReplacer(finder)
+	r.SetReplacement("")
+
+	buf := buffer.NewBuffer()
+	buf.SetLines([]string{"keep", "remove me", "keep"})
+
+	if _, err := r.ReplaceAll(buf, nil); err != nil {
+		t.Fatalf("ReplaceAll error: %v", err)
+	}
+
+	// The emptied line stays in place
+	want := []string{"keep", "", "keep"}
+	if got := buf.GetAllLines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+}
+
+func TestReplacer_ReplaceAll_OverlappingLiteral(t *testing.T) {
+	finder := NewFinder()
+	finder.SetPattern("aa")
+
+	r := NewReplacer(finder)
+	r.SetReplacement("b")
+
+	buf := buffer.NewBuffer()
+	buf.SetLines([]string{"aaaaa"})
+
+	count, err := r.ReplaceAll(buf, nil)
+	if err != nil {
+		t.Fatalf("ReplaceAll error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("ReplaceAll count = %d, want 2", count)
+	}
+	if got := buf.GetAllLines()[0]; got != "bba" {
+		t.Errorf("line = %q, want %q", got, "bba")
+	}
+}
+
+func TestReplacer_ReplaceAll_InSelection(t *testing.T) {
+	finder := NewFinder()
+	finder.SetPattern("x")
+	opts := finder.GetOptions()
+	opts.CaseSensitive = true
+	opts.WholeWord = true
+	opts.InSelection = true
+	finder.SetOptions(opts)
+
+	buf := buffer.NewBuffer()
+	buf.SetLines([]string{"x = 0", "f(x, x) + x", "x = 1"})
+
+	// Select "f(x, x)" on the middle line
+	finder.SetScope(buffer.Position{Line: 1, Col: 0}, buffer.Position{Line: 1, Col: 7})
+
+	r := NewReplacer(finder)
+	r.SetReplacement("value")
+
+	if got := r.CountMatches(buf); got != 2 {
+		t.Errorf("CountMatches() = %d, want 2", got)
+	}
+
+	count, err := r.ReplaceAll(buf, nil)
+	if err != nil {
+		t.Fatalf("ReplaceAll error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("ReplaceAll count = %d, want 2", count)
+	}
+
+	want := []string{"x = 0", "f(value, value) + x", "x = 1"}
+	if got := buf.GetAllLines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+
+	// The scope grew with the replacements and still ends after ")"
+	start, end, ok := finder.GetScope()
+	if !ok || start != (buffer.Position{Line: 1, Col: 0}) || end != (buffer.Position{Line: 1, Col: 15}) {
+		t.Errorf("GetScope() = %v, %v, %v, want {1 0}, {1 15}, true", start, end, ok)
+	}
+
+	// A second pass finds nothing outside the shifted scope
+	r.SetReplacement("y")
+	finder.SetPattern("value")
+	if count, _ := r.ReplaceAll(buf, nil); count != 2 {
+		t.Errorf("second ReplaceAll count = %d, want 2", count)
+	}
+	if got := buf.GetAllLines()[1]; got != "f(y, y) + x" {
+		t.Errorf("line 1 = %q, want %q", got, "f(y, y) + x")
+	}
+}
@@ -0,0 +1,252 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SymbolKind classifies a symbol found by ExtractSymbols.
+type SymbolKind int
+
+const (
+	SymbolFunction SymbolKind = iota // Function, or arrow function bound to a name
+	SymbolMethod                     // Function belonging to a type or class
+	SymbolType                       // Type, interface or enum declaration
+	SymbolClass                      // Class declaration
+	SymbolHeading                    // Markdown heading
+)
+
+// String returns a short label for the kind.
+func (k SymbolKind) String() string {
+	switch k {
+	case SymbolFunction:
+		return "func"
+	case SymbolMethod:
+		return "method"
+	case SymbolType:
+		return "type"
+	case SymbolClass:
+		return "class"
+	case SymbolHeading:
+		return "heading"
+	}
+	return "symbol"
+}
+
+// Symbol is a named definition or heading in a buffer.
+type Symbol struct {
+	Name      string     // Name, qualified by its container ("Editor.Run")
+	Kind      SymbolKind // What the symbol is
+	Line      int        // Line of the definition, 0-based
+	Col       int        // Byte column of the name
+	Container string     // Enclosing type or class, if any
+	Level     int        // Heading level (1-6) for Markdown headings
+}
+
+// symbolRule extracts one kind of symbol from a line. The name is the
+// last capture group; an earlier group, if any, is the container.
+type symbolRule struct {
+	re   *regexp.Regexp
+	kind SymbolKind
+}
+
+var (
+	goSymbolRules = []symbolRule{
+		{regexp.MustCompile(`^func\s+\(\s*(?:\w+\s+)?\*?\s*(\w+)(?:\[[^\]]*\])?\s*\)\s*(\w+)`), SymbolMethod},
+		{regexp.MustCompile(`^func\s+(\w+)`), SymbolFunction},
+		{regexp.MustCompile(`^type\s+(\w+)`), SymbolType},
+	}
+	// Specs inside a grouped "type (" declaration, indented by one tab
+	goTypeSpecRule = symbolRule{regexp.MustCompile(`^\t(\w+)(?:\[[^\]]*\])?\s+\S`), SymbolType}
+
+	pythonSymbolRules = []symbolRule{
+		{regexp.MustCompile(`^\s*class\s+(\w+)`), SymbolClass},
+		{regexp.MustCompile(`^\s*(?:async\s+)?def\s+(\w+)`), SymbolFunction},
+	}
+
+	jsSymbolRules = []symbolRule{
+		{regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(\w+)`), SymbolClass},
+		{regexp.MustCompile(`^\s*(?:export\s+)?(?:declare\s+)?(?:interface|type|enum)\s+(\w+)`), SymbolType},
+		{regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*(\w+)`), SymbolFunction},
+		{regexp.MustCompile(`^\s*(?:export\s+)?(?:const|let|var)\s+(\w+)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function\b|(?:\([^)]*\)|\w+)\s*(?::[^=]+)?=>)`), SymbolFunction},
+		{regexp.MustCompile(`^\s+(?:(?:public|private|protected|static|async|override|readonly|get|set)\s+)*\*?(\w+)\s*(?:<[^>]*>)?\([^)]*\)\s*(?::[^{]+)?\{\s*$`), SymbolMethod},
+	}
+
+	markdownHeadingRule = regexp.MustCompile(`^(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+	markdownFenceRule   = regexp.MustCompile("^\\s*(```|~~~)")
+)
+
+// jsKeywords are words that look like method definitions in JS/TS
+// ("if (x) {") but are not.
+var jsKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true,
+	"function": true, "return": true, "with": true, "else": true,
+}
+
+// ExtractSymbols lists the functions, types and headings in lines, in the
+// order they appear. language is a file type as shown in the status bar:
+// "Go", "Python", "JavaScript", "TypeScript" or "Markdown". Other
+// languages have no symbols.
+//
+// Symbols are found with per-language line patterns rather than a parser,
+// so unusual formatting can hide a definition.
+func ExtractSymbols(lines []string, language string) []Symbol {
+	switch language {
+	case "Go":
+		return extractGoSymbols(lines)
+	case "Python":
+		return extractPythonSymbols(lines)
+	case "JavaScript", "TypeScript":
+		return extractJSSymbols(lines)
+	case "Markdown":
+		return extractMarkdownSymbols(lines)
+	}
+	return nil
+}
+
+// matchSymbol applies rules to line and returns the first symbol found.
+func matchSymbol(rules []symbolRule, line string, lineNum int) (Symbol, bool) {
+	for _, rule := range rules {
+		m := rule.re.FindStringSubmatchIndex(line)
+		if m == nil {
+			continue
+		}
+		groups := len(m)/2 - 1
+		nameStart, nameEnd := m[2*groups], m[2*groups+1]
+		sym := Symbol{
+			Name: line[nameStart:nameEnd],
+			Kind: rule.kind,
+			Line: lineNum,
+			Col:  nameStart,
+		}
+		if groups > 1 && m[2] >= 0 {
+			sym.Container = line[m[2]:m[3]]
+		}
+		return sym, true
+	}
+	return Symbol{}, false
+}
+
+// qualify prefixes a symbol's name with its container.
+func qualify(sym Symbol) Symbol {
+	if sym.Container != "" {
+		sym.Name = sym.Container + "." + sym.Name
+	}
+	return sym
+}
+
+// extractGoSymbols finds top-level functions, methods and types.
+func extractGoSymbols(lines []string) []Symbol {
+	var symbols []Symbol
+	inTypeGroup := false
+
+	for i, line := range lines {
+		if inTypeGroup {
+			if strings.HasPrefix(line, ")") {
+				inTypeGroup = false
+			} else if sym, ok := matchSymbol([]symbolRule{goTypeSpecRule}, line, i); ok {
+				symbols = append(symbols, sym)
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "type (") || strings.HasPrefix(line, "type(") {
+			inTypeGroup = true
+			continue
+		}
+		if sym, ok := matchSymbol(goSymbolRules, line, i); ok {
+			symbols = append(symbols, qualify(sym))
+		}
+	}
+	return symbols
+}
+
+// extractPythonSymbols finds classes and functions. Indentation gives the
+// enclosing definition: functions directly inside a class are its methods.
+func extractPythonSymbols(lines []string) []Symbol {
+	type scope struct {
+		indent  int
+		name    string // Qualified name
+		isClass bool
+	}
+	var symbols []Symbol
+	var scopes []scope // Enclosing definitions, innermost last
+
+	for i, line := range lines {
+		sym, ok := matchSymbol(pythonSymbolRules, line, i)
+		if !ok {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		for len(scopes) > 0 && scopes[len(scopes)-1].indent >= indent {
+			scopes = scopes[:len(scopes)-1]
+		}
+
+		if len(scopes) > 0 {
+			outer := scopes[len(scopes)-1]
+			sym.Container = outer.name
+			if outer.isClass && sym.Kind == SymbolFunction {
+				sym.Kind = SymbolMethod
+			}
+		}
+		sym = qualify(sym)
+		scopes = append(scopes, scope{indent: indent, name: sym.Name, isClass: sym.Kind == SymbolClass})
+		symbols = append(symbols, sym)
+	}
+	return symbols
+}
+
+// extractJSSymbols finds classes, interfaces, type aliases, enums,
+// functions, arrow functions bound to names and class methods.
+func extractJSSymbols(lines []string) []Symbol {
+	var symbols []Symbol
+	class := "" // Innermost class seen, for naming methods
+
+	for i, line := range lines {
+		sym, ok := matchSymbol(jsSymbolRules, line, i)
+		if !ok || jsKeywords[sym.Name] {
+			continue
+		}
+		switch sym.Kind {
+		case SymbolClass:
+			class = sym.Name
+		case SymbolMethod:
+			sym.Container = class
+		}
+		symbols = append(symbols, qualify(sym))
+	}
+	return symbols
+}
+
+// extractMarkdownSymbols finds ATX headings ("# Title") outside fenced
+// code blocks.
+func extractMarkdownSymbols(lines []string) []Symbol {
+	var symbols []Symbol
+	fence := "" // Opening fence of the code block being skipped
+
+	for i, line := range lines {
+		if m := markdownFenceRule.FindStringSubmatch(line); m != nil {
+			if fence == "" {
+				fence = m[1]
+			} else if fence == m[1] {
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+
+		m := markdownHeadingRule.FindStringSubmatchIndex(line)
+		if m == nil || m[4] == m[5] {
+			continue
+		}
+		symbols = append(symbols, Symbol{
+			Name:  line[m[4]:m[5]],
+			Kind:  SymbolHeading,
+			Line:  i,
+			Col:   m[4],
+			Level: m[3] - m[2],
+		})
+	}
+	return symbols
+}
@@ -0,0 +1,97 @@
+package search
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// symbolSummary formats symbols as "kind name@line" for comparison.
+func symbolSummary(symbols []Symbol) string {
+	var parts []string
+	for _, s := range symbols {
+		parts = append(parts, s.Kind.String()+" "+s.Name+"@"+strconv.Itoa(s.Line))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func TestExtractSymbols(t *testing.T) {
+	tests := []struct {
+		language string
+		source   string
+		want     string
+	}{
+		{
+			language: "Go",
+			source: "package main\n" +
+				"func main() {}\n" +
+				"func (e *Editor) Run() error {\n" +
+				"type Editor struct {\n" +
+				"type (\n" +
+				"\tMode int\n" +
+				"\tPair[T any] struct {\n" +
+				"\t\tField int\n" +
+				")\n",
+			want: "func main@1, method Editor.Run@2, type Editor@3, type Mode@5, type Pair@6",
+		},
+		{
+			language: "Python",
+			source: "import os\n" +
+				"class Finder:\n" +
+				"    def find(self):\n" +
+				"        def helper():\n" +
+				"async def main():\n" +
+				"    pass\n",
+			want: "class Finder@1, method Finder.find@2, func Finder.find.helper@3, func main@4",
+		},
+		{
+			language: "TypeScript",
+			source: "export class Editor {\n" +
+				"  private run(x: number): void {\n" +
+				"    if (x) {\n" +
+				"export interface Options {\n" +
+				"export const add = (a: number, b: number) => a + b;\n" +
+				"function* gen() {\n" +
+				"export default async function load() {\n",
+			want: "class Editor@0, method Editor.run@1, type Options@3, func add@4, func gen@5, func load@6",
+		},
+		{
+			language: "Markdown",
+			source: "# Title\n" +
+				"text\n" +
+				"```sh\n" +
+				"# not a heading\n" +
+				"```\n" +
+				"## Usage ##\n" +
+				"#NoSpace\n" +
+				"### C#\n",
+			want: "heading Title@0, heading Usage@5, heading C#@7",
+		},
+		{
+			language: "Plain Text",
+			source:   "func main() {}\n",
+			want:     "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			got := symbolSummary(ExtractSymbols(strings.Split(tt.source, "\n"), tt.language))
+			if got != tt.want {
+				t.Errorf("ExtractSymbols() = %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractSymbols_Position(t *testing.T) {
+	symbols := ExtractSymbols([]string{"## Install", "func (b *Buffer) Insert() {}"}, "Markdown")
+	if len(symbols) != 1 || symbols[0].Col != 3 || symbols[0].Level != 2 {
+		t.Errorf("heading = %+v, want Col 3, Level 2", symbols)
+	}
+
+	symbols = ExtractSymbols([]string{"func (b *Buffer) Insert() {}"}, "Go")
+	if len(symbols) != 1 || symbols[0].Col != 17 || symbols[0].Container != "Buffer" {
+		t.Errorf("method = %+v, want Col 17, Container Buffer", symbols)
+	}
+}
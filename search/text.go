@@ -0,0 +1,62 @@
+package search
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+)
+
+// searchText is the buffer content joined into a single string so that
+// patterns can match across line boundaries. It maps byte offsets in the
+// joined text back to buffer positions.
+type searchText struct {
+	text       string
+	lineStarts []int // Byte offset of the start of each line
+}
+
+// newSearchText joins lines with "\n" and records where each line starts.
+func newSearchText(lines []string) *searchText {
+	lineStarts := make([]int, len(lines))
+	offset := 0
+	for i, line := range lines {
+		lineStarts[i] = offset
+		offset += len(line) + 1
+	}
+
+	return &searchText{
+		text:       strings.Join(lines, "\n"),
+		lineStarts: lineStarts,
+	}
+}
+
+// position converts a byte offset in the joined text to a buffer position.
+func (t *searchText) position(offset int) buffer.Position {
+	if len(t.lineStarts) == 0 {
+		return buffer.Position{}
+	}
+
+	// Last line starting at or before offset
+	line := sort.Search(len(t.lineStarts), func(i int) bool {
+		return t.lineStarts[i] > offset
+	}) - 1
+	if line < 0 {
+		line = 0
+	}
+
+	return buffer.Position{Line: line, Col: offset - t.lineStarts[line]}
+}
+
+// match builds a Match for the byte range [start, end) of the joined text.
+func (t *searchText) match(start, end int, groups []string) Match {
+	startPos := t.position(start)
+	endPos := t.position(end)
+	return Match{
+		StartLine: startPos.Line,
+		StartCol:  startPos.Col,
+		EndLine:   endPos.Line,
+		EndCol:    endPos.Col,
+		Text:      t.text[start:end],
+		Groups:    groups,
+	}
+}
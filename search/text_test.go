@@ -0,0 +1,32 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+)
+
+func TestSearchText_position(t *testing.T) {
+	text := newSearchText([]string{"ab", "", "cde"})
+
+	if text.text != "ab\n\ncde" {
+		t.Fatalf("text = %q, want %q", text.text, "ab\n\ncde")
+	}
+
+	tests := []struct {
+		offset int
+		want   buffer.Position
+	}{
+		{offset: 0, want: buffer.Position{Line: 0, Col: 0}},
+		{offset: 2, want: buffer.Position{Line: 0, Col: 2}},
+		{offset: 3, want: buffer.Position{Line: 1, Col: 0}},
+		{offset: 4, want: buffer.Position{Line: 2, Col: 0}},
+		{offset: 7, want: buffer.Position{Line: 2, Col: 3}},
+	}
+
+	for _, tt := range tests {
+		if got := text.position(tt.offset); got != tt.want {
+			t.Errorf("position(%d) = %v, want %v", tt.offset, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Package dialog implements a list of choices to pick from.
+package dialog
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// ChoiceDialog lists a few fixed choices. Up and Down move the selection
+// and Enter picks it. Unlike the pickers, it has no filter: it suits short
+// lists such as the settings shown in the info bar.
+type ChoiceDialog struct {
+	BaseDialog
+	choices  []string
+	selected int // Selected choice
+	scroll   int // First visible choice
+	onSelect func(index int)
+	onCancel func()
+}
+
+// NewChoiceDialog creates a dialog listing choices with the one at
+// selected highlighted. onSelect is called with the index of the chosen
+// one.
+func NewChoiceDialog(title string, choices []string, selected int, onSelect func(index int), onCancel func()) *ChoiceDialog {
+	width := len(title) + 8
+	for _, choice := range choices {
+		width = max(width, len(choice)+6)
+	}
+	return &ChoiceDialog{
+		BaseDialog: BaseDialog{
+			title:  title,
+			width:  width,
+			height: len(choices) + 4,
+		},
+		choices:  choices,
+		selected: min(max(selected, 0), max(len(choices)-1, 0)),
+		onSelect: onSelect,
+		onCancel: onCancel,
+	}
+}
+
+// Show opens the dialog, shrunk to fit the screen if needed, with the
+// selection in view.
+func (d *ChoiceDialog) Show(screenWidth, screenHeight int) {
+	d.width = min(d.width, max(screenWidth-4, 20))
+	d.height = min(len(d.choices)+4, max(screenHeight-4, 5))
+	d.BaseDialog.Show(screenWidth, screenHeight)
+	d.moveSelection(0)
+}
+
+// HandleInput processes keyboard input for the dialog.
+func (d *ChoiceDialog) HandleInput(key tcell.Key, mod tcell.ModMask, ch rune) bool {
+	switch key {
+	case tcell.KeyEscape:
+		d.SetCancelled()
+		if d.onCancel != nil {
+			d.onCancel()
+		}
+		return true
+
+	case tcell.KeyEnter:
+		if len(d.choices) > 0 {
+			d.SetConfirmed()
+			if d.onSelect != nil {
+				d.onSelect(d.selected)
+			}
+		}
+		return true
+
+	case tcell.KeyUp:
+		d.moveSelection(-1)
+		return true
+
+	case tcell.KeyDown:
+		d.moveSelection(1)
+		return true
+
+	case tcell.KeyPgUp:
+		d.moveSelection(-d.listHeight())
+		return true
+
+	case tcell.KeyPgDn:
+		d.moveSelection(d.listHeight())
+		return true
+
+	case tcell.KeyHome:
+		d.moveSelection(-len(d.choices))
+		return true
+
+	case tcell.KeyEnd:
+		d.moveSelection(len(d.choices))
+		return true
+	}
+
+	return false
+}
+
+// HandleClick selects the clicked choice, or picks it if it was already
+// selected.
+func (d *ChoiceDialog) HandleClick(x, y int) bool {
+	top := d.y + 2 // First list row, as drawn by Render
+	if x <= d.x || x >= d.x+d.width-1 || y < top || y >= top+d.listHeight() {
+		return false
+	}
+	i := d.scroll + y - top
+	if i >= len(d.choices) {
+		return false
+	}
+	if i == d.selected {
+		return d.HandleInput(tcell.KeyEnter, tcell.ModNone, 0)
+	}
+	d.selected = i
+	return true
+}
+
+// moveSelection moves the selection by delta and scrolls it into view.
+func (d *ChoiceDialog) moveSelection(delta int) {
+	if len(d.choices) == 0 {
+		return
+	}
+
+	d.selected = min(max(d.selected+delta, 0), len(d.choices)-1)
+
+	height := d.listHeight()
+	if d.selected < d.scroll {
+		d.scroll = d.selected
+	} else if d.selected >= d.scroll+height {
+		d.scroll = d.selected - height + 1
+	}
+}
+
+// listHeight returns the number of choices that fit in the dialog.
+func (d *ChoiceDialog) listHeight() int {
+	// Border and a blank line above and below the list
+	return max(d.height-4, 1)
+}
+
+// Selected returns the index of the selected choice.
+func (d *ChoiceDialog) Selected() int {
+	return d.selected
+}
+
+// Render draws the dialog.
+func (d *ChoiceDialog) Render(screen Screen, style tcell.Style) {
+	if !d.isOpen {
+		return
+	}
+
+	d.Clear(screen, style)
+	d.DrawBorder(screen, style)
+
+	y := d.y + 2
+	height := d.listHeight()
+	for i := d.scroll; i < len(d.choices) && i < d.scroll+height; i++ {
+		rowStyle := style
+		if i == d.selected {
+			rowStyle = style.Reverse(true)
+		}
+		// Fill the row so the selection bar spans the list
+		d.DrawText(screen, d.x+2, y, strings.Repeat(" ", d.width-4), rowStyle)
+		d.DrawText(screen, d.x+3, y, d.choices[i], rowStyle)
+		y++
+	}
+}
+
+// GetResult returns the index of the selected choice.
+func (d *ChoiceDialog) GetResult() interface{} {
+	return d.selected
+}
@@ -0,0 +1,93 @@
+package dialog
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestChoiceDialog_Select(t *testing.T) {
+	chosen := -1
+	dlg := NewChoiceDialog("Indentation", []string{"Automatic", "Spaces: 2", "Spaces: 4", "Tabs"}, 2,
+		func(i int) { chosen = i }, nil)
+	dlg.Show(80, 24)
+
+	if got := dlg.Selected(); got != 2 {
+		t.Errorf("initial selection = %d, want 2", got)
+	}
+
+	tests := []struct {
+		key  tcell.Key
+		want int
+	}{
+		{tcell.KeyDown, 3},
+		{tcell.KeyDown, 3},
+		{tcell.KeyHome, 0},
+		{tcell.KeyUp, 0},
+		{tcell.KeyEnd, 3},
+		{tcell.KeyUp, 2},
+	}
+	for _, tt := range tests {
+		dlg.HandleInput(tt.key, 0, 0)
+		if got := dlg.Selected(); got != tt.want {
+			t.Errorf("after %v: selection = %d, want %d", tt.key, got, tt.want)
+		}
+	}
+
+	dlg.HandleInput(tcell.KeyEnter, 0, 0)
+	if chosen != 2 || dlg.IsOpen() {
+		t.Errorf("Enter: chose %d, open %v; want 2, closed", chosen, dlg.IsOpen())
+	}
+}
+
+func TestChoiceDialog_Cancel(t *testing.T) {
+	cancelled := false
+	dlg := NewChoiceDialog("Pick", []string{"a", "b"}, 0, func(int) { t.Error("nothing should be chosen") }, func() { cancelled = true })
+	dlg.Show(80, 24)
+
+	dlg.HandleInput(tcell.KeyEscape, 0, 0)
+	if !cancelled || dlg.IsOpen() {
+		t.Errorf("Esc: cancelled %v, open %v; want true, closed", cancelled, dlg.IsOpen())
+	}
+}
+
+func TestChoiceDialog_HandleClick(t *testing.T) {
+	chosen := -1
+	dlg := NewChoiceDialog("Pick", []string{"first", "second"}, 0, func(i int) { chosen = i }, nil)
+	dlg.Show(80, 24)
+	screen := newMockScreen()
+	dlg.Render(screen, tcell.StyleDefault)
+
+	x, y, ok := screen.find("second")
+	if !ok {
+		t.Fatal("choice not drawn")
+	}
+
+	// The first click selects, a click on the selection picks it
+	dlg.HandleClick(x, y)
+	if dlg.Selected() != 1 || !dlg.IsOpen() {
+		t.Errorf("after one click: selected %d, open %v; want 1, open", dlg.Selected(), dlg.IsOpen())
+	}
+	dlg.HandleClick(x, y)
+	if chosen != 1 || dlg.IsOpen() {
+		t.Errorf("after two clicks: chose %d, open %v; want 1, closed", chosen, dlg.IsOpen())
+	}
+}
+
+func TestChoiceDialog_Scroll(t *testing.T) {
+	choices := make([]string, 20)
+	for i := range choices {
+		choices[i] = string(rune('a' + i))
+	}
+	dlg := NewChoiceDialog("Pick", choices, 15, nil, nil)
+	dlg.Show(80, 12) // Room for 4 choices
+
+	if dlg.scroll > 15 || dlg.scroll+dlg.listHeight() <= 15 {
+		t.Errorf("selection 15 not in view: scroll %d, height %d", dlg.scroll, dlg.listHeight())
+	}
+	screen := newMockScreen()
+	dlg.Render(screen, tcell.StyleDefault)
+	if _, _, ok := screen.find("p"); !ok {
+		t.Error("selected choice not drawn")
+	}
+}
@@ -35,6 +35,13 @@ type Dialog interface {
 	IsConfirmed() bool
 }
 
+// Clickable is implemented by dialogs that respond to mouse clicks.
+type Clickable interface {
+	// HandleClick processes a click at screen coordinates and returns true
+	// if the dialog handled it
+	HandleClick(x, y int) bool
+}
+
 // Screen is the interface for screen operations used by dialogs.
 // This is compatible with terminal.Screen interface.
 type Screen interface {
@@ -54,6 +61,14 @@ type BaseDialog struct {
 	y          int
 	title      string
 	focusIndex int // Which element has focus (0=first button/input, 1=second, etc.)
+	buttons    []buttonRegion
+}
+
+// buttonRegion is where the last render drew a button, for mouse clicks.
+type buttonRegion struct {
+	x, y  int
+	width int
+	index int
 }
 
 // Show opens the dialog and calculates position.
@@ -169,6 +184,7 @@ func (d *BaseDialog) DrawButton(screen Screen, x, y, index int, label string, st
 
 	// Draw button with brackets
 	buttonText := fmt.Sprintf("[ %s ]", label)
+	d.buttons = append(d.buttons, buttonRegion{x: x, y: y, width: len(buttonText), index: index})
 	for i, ch := range buttonText {
 		if x+i < d.x+d.width-1 {
 			screen.SetContent(x+i, y, ch, []rune{}, buttonStyle)
@@ -185,8 +201,21 @@ func (d *BaseDialog) DrawText(screen Screen, x, y int, text string, style tcell.
 	}
 }
 
-// Clear clears the dialog area.
+// buttonAt returns the index of the button drawn at x, y by the last
+// render.
+func (d *BaseDialog) buttonAt(x, y int) (index int, ok bool) {
+	for _, b := range d.buttons {
+		if y == b.y && x >= b.x && x < b.x+b.width {
+			return b.index, true
+		}
+	}
+	return 0, false
+}
+
+// Clear clears the dialog area. Renders start with it, so it also forgets
+// the buttons drawn last time.
 func (d *BaseDialog) Clear(screen Screen, bgStyle tcell.Style) {
+	d.buttons = d.buttons[:0]
 	for y := 0; y < d.height; y++ {
 		for x := 0; x < d.width; x++ {
 			screen.SetContent(d.x+x, d.y+y, ' ', []rune{}, bgStyle)
@@ -315,6 +344,26 @@ func (d *InputDialog) HandleInput(key tcell.Key, mod tcell.ModMask, ch rune) boo
 	return false
 }
 
+// HandleClick focuses the input field or presses OK or Cancel.
+func (d *InputDialog) HandleClick(x, y int) bool {
+	if index, ok := d.buttonAt(x, y); ok {
+		if index == 1 {
+			return d.HandleInput(tcell.KeyEnter, tcell.ModNone, 0)
+		}
+		return d.HandleInput(tcell.KeyEscape, tcell.ModNone, 0)
+	}
+
+	// Input field, as drawn by Render
+	fieldWidth := d.width - 6
+	if y == d.y+3 && x >= d.x+2 && x < d.x+2+fieldWidth {
+		displayStart := max(d.cursorPos-fieldWidth, 0)
+		d.focusIndex = 0
+		d.cursorPos = min(displayStart+x-(d.x+2), len(d.input))
+		return true
+	}
+	return false
+}
+
 // Render draws the input dialog.
 func (d *InputDialog) Render(screen Screen, style tcell.Style) {
 	if !d.isOpen {
@@ -478,6 +527,19 @@ func (d *ConfirmDialog) HandleInput(key tcell.Key, mod tcell.ModMask, ch rune) b
 	return false
 }
 
+// HandleClick presses Yes or No.
+func (d *ConfirmDialog) HandleClick(x, y int) bool {
+	index, ok := d.buttonAt(x, y)
+	if !ok {
+		return false
+	}
+	if index == 0 {
+		d.focusIndex = 0
+		return d.HandleInput(tcell.KeyEnter, tcell.ModNone, 0)
+	}
+	return d.HandleInput(tcell.KeyEscape, tcell.ModNone, 0)
+}
+
 // Render draws the confirmation dialog.
 func (d *ConfirmDialog) Render(screen Screen, style tcell.Style) {
 	if !d.isOpen {
@@ -675,14 +737,43 @@ func (dm *DialogManager) HandleInput(key tcell.Key, mod tcell.ModMask, ch rune)
 	d := dm.Peek()
 	handled := d.HandleInput(key, mod, ch)
 
-	// If dialog closed, pop it
+	// If dialog closed, remove it. Its callbacks may have pushed another
+	// dialog, so it is not necessarily on top any more.
 	if !d.IsOpen() {
-		dm.Pop()
+		dm.remove(d)
 	}
 
 	return handled
 }
 
+// HandleClick routes a mouse click to the top dialog, if it takes clicks.
+func (dm *DialogManager) HandleClick(x, y int) bool {
+	d := dm.Peek()
+	c, ok := d.(Clickable)
+	if !ok {
+		return false
+	}
+	handled := c.HandleClick(x, y)
+
+	// As with keys, the clicked dialog may have closed and pushed another
+	if !d.IsOpen() {
+		dm.remove(d)
+	}
+
+	return handled
+}
+
+// remove removes d from the stack.
+func (dm *DialogManager) remove(d Dialog) {
+	for i := len(dm.dialogs) - 1; i >= 0; i-- {
+		if dm.dialogs[i] == d {
+			dm.dialogs = append(dm.dialogs[:i], dm.dialogs[i+1:]...)
+			d.Hide()
+			return
+		}
+	}
+}
+
 // Render renders all open dialogs (top one last = on top).
 func (dm *DialogManager) Render(screen Screen, style tcell.Style) {
 	for _, d := range dm.dialogs {
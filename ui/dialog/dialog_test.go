@@ -29,6 +29,25 @@ func (m *mockScreen) SetContent(x, y int, mainc rune, combc []rune, style tcell.
 	return nil
 }
 
+// find returns the screen position of text drawn on one row.
+func (m *mockScreen) find(text string) (x, y int, ok bool) {
+	for y, row := range m.contents {
+		for x := range row {
+			match := true
+			for i, ch := range text {
+				if row[x+i] != ch {
+					match = false
+					break
+				}
+			}
+			if match {
+				return x, y, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
 func TestNewInputDialog(t *testing.T) {
 	dlg := NewInputDialog("Test Title", "Enter value:", "default", nil, nil)
 
@@ -374,6 +393,96 @@ func TestDialogManager_MultipleDialogs(t *testing.T) {
 	}
 }
 
+func TestDialogManager_PushFromCallback(t *testing.T) {
+	dm := NewDialogManager()
+	next := NewConfirmDialog("Next", "Opened by the first dialog", nil, nil)
+
+	first := NewConfirmDialog("First", "Message", func() {
+		dm.Push(next, 80, 24)
+	}, nil)
+	dm.Push(first, 80, 24)
+
+	dm.HandleInput(tcell.KeyEnter, 0, 0)
+
+	if dm.Peek() != next {
+		t.Error("dialog pushed by a callback should stay open after its opener closes")
+	}
+	if !next.IsOpen() {
+		t.Error("pushed dialog should be open")
+	}
+}
+
+func TestInputDialog_HandleClick(t *testing.T) {
+	var result string
+	dlg := NewInputDialog("Test", "Name:", "hello", func(s string) { result = s }, nil)
+	dlg.Show(80, 24)
+	screen := newMockScreen()
+	dlg.Render(screen, tcell.StyleDefault)
+
+	// Clicking in the field places the cursor
+	x, y, ok := screen.find("hello")
+	if !ok {
+		t.Fatal("input text not drawn")
+	}
+	dlg.HandleClick(x+2, y)
+	if dlg.cursorPos != 2 {
+		t.Errorf("cursorPos = %d, want 2", dlg.cursorPos)
+	}
+
+	x, y, ok = screen.find("[ OK ]")
+	if !ok {
+		t.Fatal("OK button not drawn")
+	}
+	if !dlg.HandleClick(x+1, y) {
+		t.Error("click on OK should be handled")
+	}
+	if result != "hello" || !dlg.IsConfirmed() {
+		t.Errorf("click on OK: result %q, confirmed %v", result, dlg.IsConfirmed())
+	}
+}
+
+func TestDialogManager_HandleClick(t *testing.T) {
+	tests := []struct {
+		name          string
+		button        string
+		wantConfirmed bool
+		wantCancelled bool
+	}{
+		{name: "yes", button: "[ Yes ]", wantConfirmed: true},
+		{name: "no", button: "[ No ]", wantCancelled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dm := NewDialogManager()
+			confirmed, cancelled := false, false
+			dlg := NewConfirmDialog("Test", "Message", func() { confirmed = true }, func() { cancelled = true })
+			dm.Push(dlg, 80, 24)
+			screen := newMockScreen()
+			dm.Render(screen, tcell.StyleDefault)
+
+			// Clicks away from the buttons do nothing
+			if dm.HandleClick(0, 0) {
+				t.Error("click outside the buttons should not be handled")
+			}
+
+			x, y, ok := screen.find(tt.button)
+			if !ok {
+				t.Fatalf("button %q not drawn", tt.button)
+			}
+			if !dm.HandleClick(x, y) {
+				t.Errorf("click on %q should be handled", tt.button)
+			}
+			if confirmed != tt.wantConfirmed || cancelled != tt.wantCancelled {
+				t.Errorf("confirmed, cancelled = %v, %v, want %v, %v", confirmed, cancelled, tt.wantConfirmed, tt.wantCancelled)
+			}
+			if dm.HasOpenDialog() {
+				t.Error("dialog should close after a button click")
+			}
+		})
+	}
+}
+
 func TestBaseDialog_DrawBorder(t *testing.T) {
 	d := &BaseDialog{
 		title:  "Test",
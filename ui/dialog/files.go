@@ -0,0 +1,597 @@
+// Package dialog implements the find and replace in files results panel.
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AndrewDonelson/ted/search"
+	"github.com/gdamore/tcell/v2"
+)
+
+// rowKind is the kind of a line in the results list.
+type rowKind int
+
+const (
+	rowFile    rowKind = iota // File header
+	rowMatch                  // Match with its line preview
+	rowHunk                   // Replace mode: header of a changed run of lines
+	rowRemoved                // Replace mode: line before the replacement
+	rowAdded                  // Replace mode: line after the replacement
+)
+
+// resultRow is one line of the results list.
+type resultRow struct {
+	kind  rowKind
+	file  int // Index into results (find mode) or edits (replace mode)
+	index int // Match or hunk index within the file
+	line  int // Line within the hunk for removed and added rows
+}
+
+// FindInFilesDialog searches a directory tree and lists the matches
+// grouped by file. Results are added while the search runs; Enter on a
+// result opens it and Esc stops a running search.
+//
+// In replace mode it lists a diff preview for each file instead. Space
+// excludes the selected file or hunk, Alt+A applies the replacement and
+// Alt+U or Alt+F undo it for all files or the selected file.
+type FindInFilesDialog struct {
+	BaseDialog
+	patternInput string
+	replaceInput string
+	dirInput     string
+	options      search.Options
+	message      string
+	searching    bool
+
+	isReplaceMode bool
+	applied       bool // The previewed replacement has been written
+
+	results  []search.FileResult
+	edits    []*search.FileEdit
+	rows     []resultRow
+	matches  int
+	selected int // Selected row
+	scroll   int // First visible row
+
+	onSearch   func(root, pattern string, options search.Options)
+	onStop     func()
+	onOpen     func(result search.FileResult, match search.Match)
+	onCancel   func()
+	onApply    func(edits []*search.FileEdit)
+	onUndo     func()
+	onUndoFile func(path string)
+}
+
+// NewFindInFilesDialog creates a find in files panel searching root.
+// onSearch starts a search, onStop cancels the running one, and onOpen is
+// called with the chosen result when the user presses Enter on it.
+func NewFindInFilesDialog(root, pattern string, options search.Options,
+	onSearch func(root, pattern string, options search.Options),
+	onStop func(),
+	onOpen func(result search.FileResult, match search.Match),
+	onCancel func()) *FindInFilesDialog {
+	return &FindInFilesDialog{
+		BaseDialog: BaseDialog{
+			title:  "Find in Files",
+			width:  60,
+			height: 16,
+		},
+		patternInput: pattern,
+		dirInput:     root,
+		options:      options,
+		onSearch:     onSearch,
+		onStop:       onStop,
+		onOpen:       onOpen,
+		onCancel:     onCancel,
+	}
+}
+
+// EnableReplace switches the panel to replace mode. onApply writes the
+// included edits, onUndo rolls back the last replacement in all files and
+// onUndoFile in one file.
+func (d *FindInFilesDialog) EnableReplace(replacement string,
+	onApply func(edits []*search.FileEdit),
+	onUndo func(),
+	onUndoFile func(path string)) {
+	d.title = "Replace in Files"
+	d.isReplaceMode = true
+	d.replaceInput = replacement
+	d.onApply = onApply
+	d.onUndo = onUndo
+	d.onUndoFile = onUndoFile
+}
+
+// IsReplaceMode reports whether the panel previews a replacement.
+func (d *FindInFilesDialog) IsReplaceMode() bool {
+	return d.isReplaceMode
+}
+
+// Show opens the panel over most of the screen.
+func (d *FindInFilesDialog) Show(screenWidth, screenHeight int) {
+	d.width = max(screenWidth-4, 40)
+	d.height = max(screenHeight-4, 12)
+	d.BaseDialog.Show(screenWidth, screenHeight)
+}
+
+// fields returns the input fields in focus order.
+func (d *FindInFilesDialog) fields() []*string {
+	if d.isReplaceMode {
+		return []*string{&d.patternInput, &d.replaceInput, &d.dirInput}
+	}
+	return []*string{&d.patternInput, &d.dirInput}
+}
+
+// resultsFocus returns the focus index of the results list.
+func (d *FindInFilesDialog) resultsFocus() int {
+	return len(d.fields())
+}
+
+// HandleInput processes keyboard input.
+func (d *FindInFilesDialog) HandleInput(key tcell.Key, mod tcell.ModMask, ch rune) bool {
+	if key == tcell.KeyRune && mod&tcell.ModAlt != 0 {
+		return d.handleOptionKey(ch)
+	}
+
+	focusCount := d.resultsFocus() + 1
+	inResults := d.focusIndex == d.resultsFocus()
+
+	switch key {
+	case tcell.KeyEscape:
+		if d.searching {
+			// First Esc stops the walk; the next one closes the panel
+			if d.onStop != nil {
+				d.onStop()
+			}
+			return true
+		}
+		d.SetCancelled()
+		if d.onCancel != nil {
+			d.onCancel()
+		}
+		return true
+
+	case tcell.KeyTab:
+		d.focusIndex = (d.focusIndex + 1) % focusCount
+		return true
+
+	case tcell.KeyBacktab:
+		d.focusIndex = (d.focusIndex + focusCount - 1) % focusCount
+		return true
+
+	case tcell.KeyEnter:
+		if inResults {
+			d.openSelected()
+		} else {
+			d.startSearch()
+		}
+		return true
+
+	case tcell.KeyUp:
+		if inResults {
+			d.moveSelection(-1)
+		}
+		return true
+
+	case tcell.KeyDown:
+		if !inResults && len(d.rows) > 0 {
+			d.focusIndex = d.resultsFocus()
+			return true
+		}
+		d.moveSelection(1)
+		return true
+
+	case tcell.KeyPgUp:
+		d.moveSelection(-d.listHeight())
+		d.focusIndex = d.resultsFocus()
+		return true
+
+	case tcell.KeyPgDn:
+		d.moveSelection(d.listHeight())
+		d.focusIndex = d.resultsFocus()
+		return true
+
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if field := d.focusedField(); field != nil && len(*field) > 0 {
+			runes := []rune(*field)
+			*field = string(runes[:len(runes)-1])
+		}
+		return true
+
+	case tcell.KeyRune:
+		if field := d.focusedField(); field != nil {
+			*field += string(ch)
+		} else if ch == ' ' {
+			d.toggleSelected()
+		}
+		return true
+	}
+
+	return false
+}
+
+// handleOptionKey handles Alt+letter shortcuts: Alt+C case, Alt+W whole
+// word, Alt+R regex and, in replace mode, Alt+P preserve case, Alt+A apply,
+// Alt+U undo all and Alt+F undo the selected file.
+func (d *FindInFilesDialog) handleOptionKey(ch rune) bool {
+	switch ch {
+	case 'c', 'C':
+		d.options.CaseSensitive = !d.options.CaseSensitive
+	case 'w', 'W':
+		d.options.WholeWord = !d.options.WholeWord
+	case 'r', 'R':
+		d.options.UseRegex = !d.options.UseRegex
+	case 'p', 'P':
+		if !d.isReplaceMode {
+			return false
+		}
+		d.options.PreserveCase = !d.options.PreserveCase
+	case 'a', 'A':
+		if !d.isReplaceMode {
+			return false
+		}
+		d.apply()
+	case 'u', 'U':
+		if !d.isReplaceMode {
+			return false
+		}
+		if d.onUndo != nil {
+			d.onUndo()
+		}
+	case 'f', 'F':
+		if !d.isReplaceMode {
+			return false
+		}
+		if edit := d.selectedEdit(); edit != nil && d.onUndoFile != nil {
+			d.onUndoFile(edit.Path)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// focusedField returns the input field with focus, or nil.
+func (d *FindInFilesDialog) focusedField() *string {
+	fields := d.fields()
+	if d.focusIndex < len(fields) {
+		return fields[d.focusIndex]
+	}
+	return nil
+}
+
+// startSearch clears the results and asks for a new search.
+func (d *FindInFilesDialog) startSearch() {
+	if d.patternInput == "" {
+		d.message = "Enter a search pattern"
+		return
+	}
+
+	d.ClearResults()
+	d.searching = true
+	d.message = "Searching..."
+	if d.onSearch != nil {
+		d.onSearch(d.dirInput, d.patternInput, d.options)
+	}
+}
+
+// apply asks for the previewed replacement to be written.
+func (d *FindInFilesDialog) apply() {
+	switch {
+	case d.searching:
+		d.message = "Wait for the search to finish or press Esc to stop it"
+	case d.applied:
+		d.message = "Already replaced; search again to make another replacement"
+	case len(d.edits) == 0:
+		d.message = "Nothing to replace"
+	default:
+		if d.onApply != nil {
+			d.onApply(d.edits)
+		}
+	}
+}
+
+// toggleSelected excludes or includes the selected file or hunk.
+func (d *FindInFilesDialog) toggleSelected() {
+	if !d.isReplaceMode || d.applied || d.selected >= len(d.rows) {
+		return
+	}
+
+	row := d.rows[d.selected]
+	edit := d.edits[row.file]
+	if row.kind == rowFile {
+		edit.Excluded = !edit.Excluded
+		return
+	}
+	hunk := edit.Hunks[row.index]
+	hunk.Excluded = !hunk.Excluded
+}
+
+// openSelected opens the selected result. On a file header the file's
+// first match is opened.
+func (d *FindInFilesDialog) openSelected() {
+	result, match, ok := d.Selected()
+	if !ok {
+		return
+	}
+	d.SetConfirmed()
+	if d.onOpen != nil {
+		d.onOpen(result, match)
+	}
+}
+
+// moveSelection moves the selection by delta rows and scrolls it into view.
+func (d *FindInFilesDialog) moveSelection(delta int) {
+	if len(d.rows) == 0 {
+		return
+	}
+
+	d.selected = min(max(d.selected+delta, 0), len(d.rows)-1)
+
+	height := d.listHeight()
+	if d.selected < d.scroll {
+		d.scroll = d.selected
+	} else if d.selected >= d.scroll+height {
+		d.scroll = d.selected - height + 1
+	}
+}
+
+// listHeight returns the number of result rows that fit in the panel.
+func (d *FindInFilesDialog) listHeight() int {
+	// Border, input fields, options, status and a blank line
+	return max(d.height-len(d.fields())-5, 1)
+}
+
+// AddResult appends a file's matches to the results list.
+func (d *FindInFilesDialog) AddResult(result search.FileResult) {
+	index := len(d.results)
+	d.results = append(d.results, result)
+	d.matches += len(result.Matches)
+
+	d.rows = append(d.rows, resultRow{kind: rowFile, file: index})
+	for i := range result.Matches {
+		d.rows = append(d.rows, resultRow{kind: rowMatch, file: index, index: i})
+	}
+
+	d.message = fmt.Sprintf("Searching... %s", d.countText())
+}
+
+// AddEdit appends a file's planned replacement to the preview.
+func (d *FindInFilesDialog) AddEdit(edit *search.FileEdit) {
+	index := len(d.edits)
+	d.edits = append(d.edits, edit)
+	d.matches += edit.Replacements()
+
+	d.rows = append(d.rows, resultRow{kind: rowFile, file: index})
+	for h, hunk := range edit.Hunks {
+		d.rows = append(d.rows, resultRow{kind: rowHunk, file: index, index: h})
+		for i := range hunk.OldLines {
+			d.rows = append(d.rows, resultRow{kind: rowRemoved, file: index, index: h, line: i})
+		}
+		for i := range hunk.NewLines {
+			d.rows = append(d.rows, resultRow{kind: rowAdded, file: index, index: h, line: i})
+		}
+	}
+
+	d.message = fmt.Sprintf("Searching... %s", d.countText())
+}
+
+// ClearResults removes all results.
+func (d *FindInFilesDialog) ClearResults() {
+	d.results = nil
+	d.edits = nil
+	d.rows = nil
+	d.matches = 0
+	d.selected = 0
+	d.scroll = 0
+	d.applied = false
+}
+
+// FinishSearch marks the search as finished and shows msg, or the result
+// count if msg is empty.
+func (d *FindInFilesDialog) FinishSearch(msg string) {
+	d.searching = false
+	if msg == "" {
+		msg = d.countText()
+		if d.isReplaceMode && d.matches > 0 {
+			msg += " - Space exclude, Alt+A replace"
+		}
+	}
+	d.message = msg
+}
+
+// SetApplied marks the previewed replacement as written and shows msg.
+func (d *FindInFilesDialog) SetApplied(msg string) {
+	d.applied = true
+	d.message = msg
+}
+
+// SetMessage sets the status message.
+func (d *FindInFilesDialog) SetMessage(msg string) {
+	d.message = msg
+}
+
+// IsSearching reports whether a search is running.
+func (d *FindInFilesDialog) IsSearching() bool {
+	return d.searching
+}
+
+// Counts returns the number of files and matches found so far.
+func (d *FindInFilesDialog) Counts() (files, matches int) {
+	if d.isReplaceMode {
+		return len(d.edits), d.matches
+	}
+	return len(d.results), d.matches
+}
+
+// countText describes the number of matches found.
+func (d *FindInFilesDialog) countText() string {
+	files, matches := d.Counts()
+	if matches == 0 {
+		return "No matches"
+	}
+	return fmt.Sprintf("%d matches in %d files", matches, files)
+}
+
+// Selected returns the selected result and match. In replace mode the
+// match marks the start of the selected hunk.
+func (d *FindInFilesDialog) Selected() (search.FileResult, search.Match, bool) {
+	if d.selected < 0 || d.selected >= len(d.rows) {
+		return search.FileResult{}, search.Match{}, false
+	}
+
+	row := d.rows[d.selected]
+	if d.isReplaceMode {
+		edit := d.edits[row.file]
+		result := search.FileResult{Path: edit.Path, RelPath: edit.RelPath}
+		if len(edit.Hunks) == 0 {
+			return result, search.Match{}, true
+		}
+		line := edit.Hunks[row.index].StartLine
+		return result, search.Match{StartLine: line, EndLine: line}, true
+	}
+
+	result := d.results[row.file]
+	return result, result.Matches[row.index], true
+}
+
+// selectedEdit returns the file edit holding the selected row, or nil.
+func (d *FindInFilesDialog) selectedEdit() *search.FileEdit {
+	if !d.isReplaceMode || d.selected >= len(d.rows) {
+		return nil
+	}
+	return d.edits[d.rows[d.selected].file]
+}
+
+// GetPattern returns the search pattern.
+func (d *FindInFilesDialog) GetPattern() string {
+	return d.patternInput
+}
+
+// GetReplacement returns the replacement text.
+func (d *FindInFilesDialog) GetReplacement() string {
+	return d.replaceInput
+}
+
+// GetOptions returns the search options.
+func (d *FindInFilesDialog) GetOptions() search.Options {
+	return d.options
+}
+
+// Render draws the panel.
+func (d *FindInFilesDialog) Render(screen Screen, style tcell.Style) {
+	if !d.isOpen {
+		return
+	}
+
+	d.Clear(screen, style)
+	d.DrawBorder(screen, style)
+
+	labels := []string{"Find:", "In:"}
+	if d.isReplaceMode {
+		labels = []string{"Find:", "Replace:", "In:"}
+	}
+
+	y := d.y + 1
+	for i, field := range d.fields() {
+		fieldStyle := style
+		if d.focusIndex == i {
+			fieldStyle = style.Reverse(true)
+		}
+		d.DrawText(screen, d.x+2, y, labels[i], style)
+		d.DrawText(screen, d.x+12, y, *field+"█", fieldStyle)
+		y++
+	}
+
+	d.DrawText(screen, d.x+2, y, d.buildOptionsText(), style)
+	y++
+
+	if d.message != "" {
+		d.DrawText(screen, d.x+2, y, d.message, style.Foreground(tcell.ColorYellow))
+	}
+	y += 2
+
+	height := d.listHeight()
+	for i := d.scroll; i < len(d.rows) && i < d.scroll+height; i++ {
+		text, rowStyle := d.rowText(d.rows[i], style)
+		if i == d.selected {
+			if d.focusIndex == d.resultsFocus() {
+				rowStyle = rowStyle.Reverse(true)
+			} else {
+				rowStyle = rowStyle.Underline(true)
+			}
+		}
+		d.DrawText(screen, d.x+2, y, text, rowStyle)
+		y++
+	}
+}
+
+// rowText returns the text and style of a results row.
+func (d *FindInFilesDialog) rowText(row resultRow, style tcell.Style) (string, tcell.Style) {
+	clean := func(line string) string {
+		return strings.ReplaceAll(line, "\t", "    ")
+	}
+
+	if !d.isReplaceMode {
+		result := d.results[row.file]
+		if row.kind == rowFile {
+			return fmt.Sprintf("%s (%d)", result.RelPath, len(result.Matches)), style.Bold(true)
+		}
+		m := result.Matches[row.index]
+		preview := strings.TrimSpace(clean(result.Previews[row.index]))
+		return fmt.Sprintf("  %5d: %s", m.StartLine+1, preview), style
+	}
+
+	edit := d.edits[row.file]
+	if row.kind == rowFile {
+		return fmt.Sprintf("%s %s (%d)", checkbox(!edit.Excluded), edit.RelPath, edit.Replacements()), style.Bold(true)
+	}
+
+	hunk := edit.Hunks[row.index]
+	excluded := edit.Excluded || hunk.Excluded
+	if excluded {
+		style = style.Dim(true)
+	}
+
+	switch row.kind {
+	case rowHunk:
+		return fmt.Sprintf("  %s line %d", checkbox(!hunk.Excluded), hunk.StartLine+1), style
+	case rowRemoved:
+		if !excluded {
+			style = style.Foreground(tcell.ColorRed)
+		}
+		return "      - " + clean(hunk.OldLines[row.line]), style
+	default:
+		if !excluded {
+			style = style.Foreground(tcell.ColorGreen)
+		}
+		return "      + " + clean(hunk.NewLines[row.line]), style
+	}
+}
+
+// checkbox returns a check box showing on.
+func checkbox(on bool) string {
+	if on {
+		return "[✓]"
+	}
+	return "[ ]"
+}
+
+// buildOptionsText builds the options and key help line.
+func (d *FindInFilesDialog) buildOptionsText() string {
+	parts := []string{
+		checkbox(d.options.CaseSensitive) + " Case",
+		checkbox(d.options.WholeWord) + " Word",
+		checkbox(d.options.UseRegex) + " Regex",
+	}
+	if d.isReplaceMode {
+		parts = append(parts, checkbox(d.options.PreserveCase)+" Preserve case")
+	}
+	parts = append(parts, "   Enter search/open  Esc stop/close")
+	return strings.Join(parts, "  ")
+}
+
+// GetResult returns nil (results are opened through the callback).
+func (d *FindInFilesDialog) GetResult() interface{} {
+	return nil
+}
@@ -0,0 +1,149 @@
+package dialog
+
+import (
+	"testing"
+
+	"github.com/AndrewDonelson/ted/search"
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestFindInFilesDialog_SearchAndOpen(t *testing.T) {
+	var searched string
+	var opened search.Match
+	stopped := false
+
+	dlg := NewFindInFilesDialog("/src", "", search.DefaultOptions(),
+		func(root, pattern string, options search.Options) {
+			searched = root + ":" + pattern
+		},
+		func() { stopped = true },
+		func(result search.FileResult, match search.Match) { opened = match },
+		nil,
+	)
+	dlg.Show(80, 24)
+
+	for _, ch := range "todo" {
+		dlg.HandleInput(tcell.KeyRune, 0, ch)
+	}
+	dlg.HandleInput(tcell.KeyEnter, 0, 0)
+
+	if searched != "/src:todo" {
+		t.Errorf("onSearch called with %q, want %q", searched, "/src:todo")
+	}
+	if !dlg.IsSearching() {
+		t.Error("dialog should be searching")
+	}
+
+	dlg.AddResult(search.FileResult{
+		Path:     "/src/a.go",
+		RelPath:  "a.go",
+		Matches:  []search.Match{{StartLine: 0}, {StartLine: 4}},
+		Previews: []string{"// todo", "todo()"},
+	})
+	if files, matches := dlg.Counts(); files != 1 || matches != 2 {
+		t.Errorf("Counts() = %d, %d, want 1, 2", files, matches)
+	}
+
+	// Esc while searching stops the search but keeps the panel open
+	dlg.HandleInput(tcell.KeyEscape, 0, 0)
+	if !stopped || !dlg.IsOpen() {
+		t.Errorf("Esc while searching: stopped = %v, open = %v, want true, true", stopped, dlg.IsOpen())
+	}
+	dlg.FinishSearch("")
+
+	// Down to the results, past the file header, to the second match
+	for i := 0; i < 3; i++ {
+		dlg.HandleInput(tcell.KeyDown, 0, 0)
+	}
+	dlg.HandleInput(tcell.KeyEnter, 0, 0)
+
+	if opened.StartLine != 4 {
+		t.Errorf("opened match on line %d, want 4", opened.StartLine)
+	}
+	if dlg.IsOpen() {
+		t.Error("dialog should close after opening a result")
+	}
+}
+
+func TestFindInFilesDialog_EscapeCloses(t *testing.T) {
+	cancelled := false
+	dlg := NewFindInFilesDialog(".", "x", search.DefaultOptions(), nil, nil, nil, func() { cancelled = true })
+	dlg.Show(80, 24)
+
+	dlg.HandleInput(tcell.KeyEscape, 0, 0)
+
+	if dlg.IsOpen() || !cancelled {
+		t.Errorf("Esc when idle: open = %v, cancelled = %v, want false, true", dlg.IsOpen(), cancelled)
+	}
+}
+
+func TestFindInFilesDialog_ReplaceExclude(t *testing.T) {
+	var applied []*search.FileEdit
+	undone := ""
+
+	dlg := NewFindInFilesDialog("/src", "old", search.DefaultOptions(), nil, nil, nil, nil)
+	dlg.EnableReplace("new",
+		func(edits []*search.FileEdit) { applied = edits },
+		nil,
+		func(path string) { undone = path },
+	)
+	dlg.Show(80, 24)
+
+	dlg.AddEdit(&search.FileEdit{
+		Path:    "/src/a.go",
+		RelPath: "a.go",
+		Hunks: []*search.Hunk{
+			{StartLine: 0, OldLines: []string{"old"}, NewLines: []string{"new"}, Matches: 1},
+			{StartLine: 5, OldLines: []string{"old old"}, NewLines: []string{"new new"}, Matches: 2},
+		},
+	})
+	dlg.AddEdit(&search.FileEdit{
+		Path:    "/src/b.go",
+		RelPath: "b.go",
+		Hunks:   []*search.Hunk{{StartLine: 2, OldLines: []string{"old"}, NewLines: []string{"new"}, Matches: 1}},
+	})
+	dlg.FinishSearch("")
+
+	if files, matches := dlg.Counts(); files != 2 || matches != 4 {
+		t.Errorf("Counts() = %d, %d, want 2, 4", files, matches)
+	}
+
+	// Rows: a.go, hunk 1 (-, +), hunk 2 (-, +), b.go, ...
+	// Exclude the second hunk of a.go and all of b.go
+	dlg.HandleInput(tcell.KeyDown, 0, 0)
+	for i := 0; i < 4; i++ {
+		dlg.HandleInput(tcell.KeyDown, 0, 0)
+	}
+	dlg.HandleInput(tcell.KeyRune, 0, ' ')
+	for i := 0; i < 3; i++ {
+		dlg.HandleInput(tcell.KeyDown, 0, 0)
+	}
+	dlg.HandleInput(tcell.KeyRune, 0, ' ')
+
+	dlg.HandleInput(tcell.KeyRune, tcell.ModAlt, 'a')
+	if len(applied) != 2 {
+		t.Fatalf("onApply called with %d edits, want 2", len(applied))
+	}
+	if !applied[0].Hunks[1].Excluded || applied[0].Hunks[0].Excluded {
+		t.Error("only the second hunk of a.go should be excluded")
+	}
+	if !applied[1].Excluded {
+		t.Error("b.go should be excluded")
+	}
+	if got := applied[0].Replacements() + applied[1].Replacements(); got != 1 {
+		t.Errorf("included replacements = %d, want 1", got)
+	}
+
+	// After applying, Alt+A does nothing until the next search
+	applied = nil
+	dlg.SetApplied("done")
+	dlg.HandleInput(tcell.KeyRune, tcell.ModAlt, 'a')
+	if applied != nil {
+		t.Error("Alt+A after applying should not apply again")
+	}
+
+	dlg.HandleInput(tcell.KeyRune, tcell.ModAlt, 'f')
+	if undone != "/src/b.go" {
+		t.Errorf("onUndoFile called with %q, want %q", undone, "/src/b.go")
+	}
+}
@@ -0,0 +1,266 @@
+// Package dialog implements the command palette.
+package dialog
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/AndrewDonelson/ted/search"
+	"github.com/gdamore/tcell/v2"
+)
+
+// PaletteCommand is a command listed in the command palette.
+type PaletteCommand struct {
+	Name     string // Command name, as used in keybindings
+	Title    string // What the palette shows and matches against
+	Shortcut string // Keys bound to the command, if any
+}
+
+// CommandPalette lists commands with their key bindings and filters them
+// with fuzzy matching as the user types. Without input, recently used
+// commands come first. Enter runs the selected command.
+type CommandPalette struct {
+	BaseDialog
+	input    string
+	commands []PaletteCommand
+	titles   []string             // Text matched against the input, parallel to commands
+	recent   map[string]bool      // Names of recently used commands
+	results  []search.FuzzyResult // Commands shown, best first
+	selected int                  // Selected result
+	scroll   int                  // First visible result
+	onSelect func(cmd PaletteCommand)
+	onCancel func()
+}
+
+// NewCommandPalette creates a palette for commands. recent names the
+// recently used commands, most recent first. onSelect is called with the
+// chosen command.
+func NewCommandPalette(commands []PaletteCommand, recent []string, onSelect func(cmd PaletteCommand), onCancel func()) *CommandPalette {
+	d := &CommandPalette{
+		BaseDialog: BaseDialog{
+			title:  "Commands",
+			width:  60,
+			height: 16,
+		},
+		recent:   make(map[string]bool, len(recent)),
+		onSelect: onSelect,
+		onCancel: onCancel,
+	}
+
+	// Recent commands first, then the rest in their given order
+	for _, name := range recent {
+		i := slices.IndexFunc(commands, func(cmd PaletteCommand) bool { return cmd.Name == name })
+		if i >= 0 && !d.recent[name] {
+			d.recent[name] = true
+			d.commands = append(d.commands, commands[i])
+		}
+	}
+	for _, cmd := range commands {
+		if !d.recent[cmd.Name] {
+			d.commands = append(d.commands, cmd)
+		}
+	}
+
+	d.titles = make([]string, len(d.commands))
+	for i, cmd := range d.commands {
+		d.titles[i] = cmd.Title
+	}
+	d.filter()
+	return d
+}
+
+// Show opens the palette, sized to the screen.
+func (d *CommandPalette) Show(screenWidth, screenHeight int) {
+	d.width = min(max(screenWidth-4, 30), 70)
+	d.height = min(max(screenHeight-4, 8), 24)
+	d.BaseDialog.Show(screenWidth, screenHeight)
+}
+
+// filter updates the results for the current input. Without input every
+// command is shown, recent ones first.
+func (d *CommandPalette) filter() {
+	if d.input == "" {
+		d.results = make([]search.FuzzyResult, len(d.commands))
+		for i := range d.commands {
+			d.results[i] = search.FuzzyResult{Index: i}
+		}
+	} else {
+		d.results = search.FuzzyFilter(d.input, d.titles)
+	}
+	d.selected = 0
+	d.scroll = 0
+}
+
+// HandleInput processes keyboard input.
+func (d *CommandPalette) HandleInput(key tcell.Key, mod tcell.ModMask, ch rune) bool {
+	switch key {
+	case tcell.KeyEscape:
+		d.SetCancelled()
+		if d.onCancel != nil {
+			d.onCancel()
+		}
+		return true
+
+	case tcell.KeyEnter:
+		if cmd, ok := d.Selected(); ok {
+			d.SetConfirmed()
+			if d.onSelect != nil {
+				d.onSelect(cmd)
+			}
+		}
+		return true
+
+	case tcell.KeyUp:
+		d.moveSelection(-1)
+		return true
+
+	case tcell.KeyDown:
+		d.moveSelection(1)
+		return true
+
+	case tcell.KeyPgUp:
+		d.moveSelection(-d.listHeight())
+		return true
+
+	case tcell.KeyPgDn:
+		d.moveSelection(d.listHeight())
+		return true
+
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(d.input) > 0 {
+			runes := []rune(d.input)
+			d.input = string(runes[:len(runes)-1])
+			d.filter()
+		}
+		return true
+
+	case tcell.KeyRune:
+		if ch != 0 {
+			d.input += string(ch)
+			d.filter()
+		}
+		return true
+	}
+
+	return false
+}
+
+// HandleClick selects the clicked command, or runs it if it was already
+// selected.
+func (d *CommandPalette) HandleClick(x, y int) bool {
+	top := d.y + 4 // First list row, as drawn by Render
+	if x <= d.x || x >= d.x+d.width-1 || y < top || y >= top+d.listHeight() {
+		return false
+	}
+	i := d.scroll + y - top
+	if i >= len(d.results) {
+		return false
+	}
+	if i == d.selected {
+		return d.HandleInput(tcell.KeyEnter, tcell.ModNone, 0)
+	}
+	d.selected = i
+	return true
+}
+
+// moveSelection moves the selection by delta and scrolls it into view.
+func (d *CommandPalette) moveSelection(delta int) {
+	if len(d.results) == 0 {
+		return
+	}
+
+	d.selected = min(max(d.selected+delta, 0), len(d.results)-1)
+
+	height := d.listHeight()
+	if d.selected < d.scroll {
+		d.scroll = d.selected
+	} else if d.selected >= d.scroll+height {
+		d.scroll = d.selected - height + 1
+	}
+}
+
+// listHeight returns the number of commands that fit in the palette.
+func (d *CommandPalette) listHeight() int {
+	// Border, input, count and a blank line
+	return max(d.height-5, 1)
+}
+
+// Selected returns the selected command.
+func (d *CommandPalette) Selected() (PaletteCommand, bool) {
+	if d.selected >= len(d.results) {
+		return PaletteCommand{}, false
+	}
+	return d.commands[d.results[d.selected].Index], true
+}
+
+// SetInput sets the filter text.
+func (d *CommandPalette) SetInput(input string) {
+	d.input = input
+	d.filter()
+}
+
+// Render draws the palette.
+func (d *CommandPalette) Render(screen Screen, style tcell.Style) {
+	if !d.isOpen {
+		return
+	}
+
+	d.Clear(screen, style)
+	d.DrawBorder(screen, style)
+
+	y := d.y + 1
+	d.DrawText(screen, d.x+2, y, "> ", style)
+	d.DrawText(screen, d.x+4, y, d.input+"█", style.Reverse(true))
+	y++
+
+	count := fmt.Sprintf("%d of %d commands", len(d.results), len(d.commands))
+	d.DrawText(screen, d.x+2, y, count, style.Foreground(tcell.ColorYellow))
+	y += 2
+
+	height := d.listHeight()
+	for i := d.scroll; i < len(d.results) && i < d.scroll+height; i++ {
+		rowStyle := style
+		if i == d.selected {
+			rowStyle = style.Reverse(true)
+		}
+		d.renderRow(screen, y, d.results[i], rowStyle)
+		y++
+	}
+}
+
+// renderRow draws one command: its title with the matched characters
+// highlighted, a mark if it was used recently, and its key binding at the
+// right.
+func (d *CommandPalette) renderRow(screen Screen, y int, result search.FuzzyResult, style tcell.Style) {
+	cmd := d.commands[result.Index]
+
+	// Fill the row so the selection bar spans the list
+	d.DrawText(screen, d.x+2, y, strings.Repeat(" ", d.width-4), style)
+
+	matchStyle := style.Foreground(tcell.ColorYellow).Bold(true)
+	col := 0
+	for offset, ch := range cmd.Title {
+		chStyle := style
+		if slices.Contains(result.Positions, offset) {
+			chStyle = matchStyle
+		}
+		d.DrawText(screen, d.x+2+col, y, string(ch), chStyle)
+		col++
+	}
+	if d.recent[cmd.Name] {
+		d.DrawText(screen, d.x+3+col, y, "(recent)", style.Dim(true))
+	}
+
+	if cmd.Shortcut != "" {
+		d.DrawText(screen, d.x+d.width-2-len(cmd.Shortcut), y, cmd.Shortcut, style.Dim(true))
+	}
+}
+
+// GetResult returns the selected command, if any.
+func (d *CommandPalette) GetResult() interface{} {
+	if cmd, ok := d.Selected(); ok {
+		return cmd
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package dialog
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestCommandPalette_FilterAndSelect(t *testing.T) {
+	commands := []PaletteCommand{
+		{Name: "new", Title: "New file", Shortcut: "Ctrl+N"},
+		{Name: "save", Title: "Save file", Shortcut: "Ctrl+S"},
+		{Name: "save-as", Title: "Save file as", Shortcut: "Ctrl+Shift+S"},
+		{Name: "go-to-line", Title: "Go to line", Shortcut: "Ctrl+G"},
+	}
+
+	var chosen PaletteCommand
+	dlg := NewCommandPalette(commands, []string{"go-to-line", "missing", "save-as"}, func(cmd PaletteCommand) { chosen = cmd }, nil)
+	dlg.Show(80, 24)
+
+	// Without input recent commands come first, then the rest in order
+	want := []string{"go-to-line", "save-as", "new", "save"}
+	for i, result := range dlg.results {
+		if got := dlg.commands[result.Index].Name; got != want[i] {
+			t.Errorf("result %d = %q, want %q", i, got, want[i])
+		}
+	}
+
+	for _, ch := range "svf" {
+		dlg.HandleInput(tcell.KeyRune, 0, ch)
+	}
+	if len(dlg.results) != 2 {
+		t.Fatalf("%d results for %q, want 2", len(dlg.results), "svf")
+	}
+	if cmd, _ := dlg.Selected(); cmd.Name != "save" {
+		t.Errorf("best match = %q, want %q", cmd.Name, "save")
+	}
+
+	dlg.HandleInput(tcell.KeyDown, 0, 0)
+	dlg.HandleInput(tcell.KeyEnter, 0, 0)
+	if chosen.Name != "save-as" {
+		t.Errorf("chose %q, want %q", chosen.Name, "save-as")
+	}
+	if dlg.IsOpen() {
+		t.Error("palette should close after choosing a command")
+	}
+}
+
+func TestCommandPalette_Render(t *testing.T) {
+	commands := []PaletteCommand{
+		{Name: "quit", Title: "Quit", Shortcut: "Ctrl+Q"},
+		{Name: "about", Title: "About ted"},
+	}
+	dlg := NewCommandPalette(commands, []string{"about"}, nil, nil)
+	dlg.Show(80, 24)
+	screen := newMockScreen()
+	dlg.Render(screen, tcell.StyleDefault)
+
+	for _, text := range []string{"About ted (recent)", "Quit", "Ctrl+Q", "2 of 2 commands"} {
+		if _, _, ok := screen.find(text); !ok {
+			t.Errorf("palette should show %q", text)
+		}
+	}
+
+	// Clicking the selected row runs it
+	var chosen PaletteCommand
+	dlg.onSelect = func(cmd PaletteCommand) { chosen = cmd }
+	x, y, _ := screen.find("About")
+	dlg.HandleClick(x, y)
+	if chosen.Name != "about" {
+		t.Errorf("click chose %q, want %q", chosen.Name, "about")
+	}
+}
@@ -0,0 +1,139 @@
+// Package dialog implements the interactive query-replace prompt.
+package dialog
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// QueryAnswer is the user's answer to a query-replace prompt.
+type QueryAnswer int
+
+const (
+	// QueryYes replaces the current match and moves to the next.
+	QueryYes QueryAnswer = iota
+	// QueryNo skips the current match.
+	QueryNo
+	// QueryAll replaces the current match and all remaining ones.
+	QueryAll
+	// QueryQuit stops replacing.
+	QueryQuit
+	// QueryUndo reverts the last replacement.
+	QueryUndo
+)
+
+// QueryReplaceDialog asks whether to replace each match in turn.
+// It sits at the bottom of the screen so the highlighted match stays visible,
+// and answers are given with single keys.
+type QueryReplaceDialog struct {
+	BaseDialog
+	matchText   string
+	replacement string
+	message     string
+	onAnswer    func(QueryAnswer)
+}
+
+// NewQueryReplaceDialog creates a query-replace prompt.
+// onAnswer is called for each answer; the caller closes the dialog with
+// Close when the session ends.
+func NewQueryReplaceDialog(onAnswer func(QueryAnswer)) *QueryReplaceDialog {
+	return &QueryReplaceDialog{
+		BaseDialog: BaseDialog{
+			title:  "Replace?",
+			width:  60,
+			height: 7,
+		},
+		onAnswer: onAnswer,
+	}
+}
+
+// Show opens the dialog just above the info bar.
+func (d *QueryReplaceDialog) Show(screenWidth, screenHeight int) {
+	d.BaseDialog.Show(screenWidth, screenHeight)
+
+	d.y = screenHeight - d.height - 1
+	if d.y < 0 {
+		d.y = 0
+	}
+}
+
+// SetMatch sets the match being asked about and its replacement.
+func (d *QueryReplaceDialog) SetMatch(matchText, replacement string) {
+	d.matchText = matchText
+	d.replacement = replacement
+}
+
+// SetMessage sets the progress message shown in the dialog.
+func (d *QueryReplaceDialog) SetMessage(msg string) {
+	d.message = msg
+}
+
+// Close closes the dialog at the end of the session.
+func (d *QueryReplaceDialog) Close() {
+	d.SetConfirmed()
+}
+
+// HandleInput maps single keys to answers.
+func (d *QueryReplaceDialog) HandleInput(key tcell.Key, mod tcell.ModMask, ch rune) bool {
+	answer, ok := queryAnswerForKey(key, ch)
+	if !ok {
+		// Swallow other keys so they don't edit the buffer mid-session
+		return true
+	}
+
+	if d.onAnswer != nil {
+		d.onAnswer(answer)
+	}
+	if answer == QueryQuit && d.isOpen {
+		d.SetCancelled()
+	}
+	return true
+}
+
+// queryAnswerForKey returns the answer bound to a key.
+func queryAnswerForKey(key tcell.Key, ch rune) (QueryAnswer, bool) {
+	switch key {
+	case tcell.KeyEscape, tcell.KeyEnter:
+		return QueryQuit, true
+	case tcell.KeyRune:
+		switch ch {
+		case 'y', 'Y', ' ':
+			return QueryYes, true
+		case 'n', 'N':
+			return QueryNo, true
+		case 'a', 'A', '!':
+			return QueryAll, true
+		case 'q', 'Q':
+			return QueryQuit, true
+		case 'u', 'U':
+			return QueryUndo, true
+		}
+	}
+	return 0, false
+}
+
+// Render draws the prompt.
+func (d *QueryReplaceDialog) Render(screen Screen, style tcell.Style) {
+	if !d.isOpen {
+		return
+	}
+
+	d.Clear(screen, style)
+	d.DrawBorder(screen, style)
+
+	visible := strings.NewReplacer("\n", "↵", "\t", "→")
+	d.DrawText(screen, d.x+2, d.y+1, "Replace: "+visible.Replace(d.matchText), style)
+	d.DrawText(screen, d.x+2, d.y+2, "With:    "+visible.Replace(d.replacement), style.Foreground(tcell.ColorGreen))
+
+	if d.message != "" {
+		d.DrawText(screen, d.x+2, d.y+3, d.message, style.Foreground(tcell.ColorYellow))
+	}
+
+	d.DrawText(screen, d.x+2, d.y+5, "y Yes  n No  a All  u Undo last  q Quit", style.Bold(true))
+}
+
+// GetResult returns nil (answers are delivered through the callback).
+func (d *QueryReplaceDialog) GetResult() interface{} {
+	return nil
+}
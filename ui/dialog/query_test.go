@@ -0,0 +1,47 @@
+package dialog
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestQueryReplaceDialog_Keys(t *testing.T) {
+	var answers []QueryAnswer
+	dlg := NewQueryReplaceDialog(func(a QueryAnswer) {
+		answers = append(answers, a)
+	})
+	dlg.Show(80, 24)
+
+	for _, ch := range "ynaux" {
+		if !dlg.HandleInput(tcell.KeyRune, 0, ch) {
+			t.Errorf("HandleInput(%q) = false, want true", ch)
+		}
+	}
+
+	want := []QueryAnswer{QueryYes, QueryNo, QueryAll, QueryUndo}
+	if !reflect.DeepEqual(answers, want) {
+		t.Errorf("answers = %v, want %v", answers, want)
+	}
+	if !dlg.IsOpen() {
+		t.Error("dialog should stay open until the caller closes it")
+	}
+
+	dlg.HandleInput(tcell.KeyEscape, 0, 0)
+	if answers[len(answers)-1] != QueryQuit {
+		t.Errorf("Escape answer = %v, want QueryQuit", answers[len(answers)-1])
+	}
+	if dlg.IsOpen() {
+		t.Error("dialog should close on quit")
+	}
+}
+
+func TestQueryReplaceDialog_ShowAtBottom(t *testing.T) {
+	dlg := NewQueryReplaceDialog(nil)
+	dlg.Show(80, 24)
+
+	if got, want := dlg.y, 24-dlg.height-1; got != want {
+		t.Errorf("y = %d, want %d", got, want)
+	}
+}
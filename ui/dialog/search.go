@@ -3,7 +3,9 @@ package dialog
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/AndrewDonelson/ted/core/buffer"
 	"github.com/AndrewDonelson/ted/search"
@@ -23,17 +25,32 @@ type SearchDialog struct {
 	options       search.Options
 	onFind        func()
 	onFindNext    func()
+	onChange      func(pattern string, options search.Options)
+	onAccept      func()
 	onReplace     func()
 	onReplaceAll  func()
+	onReplaceEach func()
 	onCancel      func()
+
+	// Recall of earlier patterns and replacements, oldest first
+	patternHistory []string
+	replaceHistory []string
+	historyIndex   int    // Entry shown while recalling with Up/Down, or -1
+	historyDraft   string // Field text from before recalling started
+	dropdown       bool   // The recent entries list is open
+	dropdownIndex  int    // Selected entry in the list, newest first
 }
 
+// maxDropdownEntries is the number of recent entries the dropdown shows.
+const maxDropdownEntries = 8
+
 // NewSearchDialog creates a new search dialog.
 func NewSearchDialog(finder *search.Finder, replacer *search.Replacer, isReplace bool, onCancel func()) *SearchDialog {
-	width := 50
+	width := 64
 	height := 8
 	if isReplace {
-		height = 10
+		width = 72
+		height = 14
 	}
 
 	d := &SearchDialog{
@@ -47,6 +64,7 @@ func NewSearchDialog(finder *search.Finder, replacer *search.Replacer, isReplace
 		isReplaceMode: isReplace,
 		showOptions:   true,
 		onCancel:      onCancel,
+		historyIndex:  -1,
 	}
 
 	if isReplace {
@@ -57,9 +75,14 @@ func NewSearchDialog(finder *search.Finder, replacer *search.Replacer, isReplace
 	if finder != nil {
 		d.searchInput = finder.GetPattern()
 		d.options = finder.GetOptions()
+		d.patternHistory = finder.GetHistory()
 	}
 	if replacer != nil {
 		d.replaceInput = replacer.GetReplacement()
+		d.replaceHistory = replacer.GetHistory()
+	}
+	if isReplace {
+		d.options.Fuzzy = false // Fuzzy matching is for finding only
 	}
 
 	return d
@@ -67,6 +90,10 @@ func NewSearchDialog(finder *search.Finder, replacer *search.Replacer, isReplace
 
 // HandleInput processes keyboard input for the search dialog.
 func (d *SearchDialog) HandleInput(key tcell.Key, mod tcell.ModMask, ch rune) bool {
+	if d.dropdown && d.handleDropdownInput(key) {
+		return true
+	}
+
 	switch key {
 	case tcell.KeyEscape:
 		d.SetCancelled()
@@ -80,12 +107,26 @@ func (d *SearchDialog) HandleInput(key tcell.Key, mod tcell.ModMask, ch rune) bo
 		d.cycleFocus()
 		return true
 
+	case tcell.KeyUp:
+		d.recallHistory(true)
+		return true
+
+	case tcell.KeyDown:
+		if mod&tcell.ModAlt != 0 {
+			d.openDropdown()
+		} else {
+			d.recallHistory(false)
+		}
+		return true
+
 	case tcell.KeyBackspace, tcell.KeyBackspace2:
 		d.handleBackspace()
+		d.notifyEdit()
 		return true
 
 	case tcell.KeyDelete:
 		d.handleDelete()
+		d.notifyEdit()
 		return true
 
 	case tcell.KeyLeft:
@@ -116,8 +157,12 @@ func (d *SearchDialog) HandleInput(key tcell.Key, mod tcell.ModMask, ch rune) bo
 		return false
 
 	case tcell.KeyRune:
+		if mod&tcell.ModAlt != 0 {
+			return d.handleOptionKey(ch)
+		}
 		if ch != 0 {
 			d.handleCharacter(ch)
+			d.notifyEdit()
 			return true
 		}
 	}
@@ -127,16 +172,118 @@ func (d *SearchDialog) HandleInput(key tcell.Key, mod tcell.ModMask, ch rune) bo
 
 // cycleFocus moves focus to the next input element.
 func (d *SearchDialog) cycleFocus() {
-	maxFocus := 2 // Find Next, Replace, Replace All buttons
+	maxFocus := 2 // Search field, Find Next button
 	if d.isReplaceMode {
-		maxFocus = 4 // + search field, replace field
+		maxFocus = 5 // + Replace, Replace All, Confirm Each buttons
 	}
 
 	d.focusIndex = (d.focusIndex + 1) % (maxFocus + 1)
+	d.historyIndex = -1
+}
+
+// focusedField returns the text of the input field with focus and its
+// history, or nil if a button has focus.
+func (d *SearchDialog) focusedField() (*string, []string) {
+	switch {
+	case d.focusIndex == 0:
+		return &d.searchInput, d.patternHistory
+	case d.isReplaceMode && d.focusIndex == 1:
+		return &d.replaceInput, d.replaceHistory
+	}
+	return nil, nil
+}
+
+// recallHistory replaces the focused field with the previous (older) or
+// next (newer) history entry. Moving past the newest entry restores the
+// text typed before recalling started.
+func (d *SearchDialog) recallHistory(older bool) {
+	field, history := d.focusedField()
+	if field == nil || len(history) == 0 {
+		return
+	}
+
+	switch {
+	case d.historyIndex < 0:
+		if !older {
+			return
+		}
+		d.historyDraft = *field
+		d.historyIndex = len(history) - 1
+	case older:
+		if d.historyIndex == 0 {
+			return
+		}
+		d.historyIndex--
+	default:
+		d.historyIndex++
+	}
+
+	if d.historyIndex >= len(history) {
+		*field = d.historyDraft
+		d.historyIndex = -1
+	} else {
+		*field = history[d.historyIndex]
+	}
+	d.notifyEdit()
+}
+
+// dropdownEntries returns the recent entries of the focused field, newest
+// first.
+func (d *SearchDialog) dropdownEntries() []string {
+	_, history := d.focusedField()
+	entries := slices.Clone(history)
+	slices.Reverse(entries)
+	if len(entries) > maxDropdownEntries {
+		entries = entries[:maxDropdownEntries]
+	}
+	return entries
+}
+
+// openDropdown opens the list of recent entries for the focused field.
+func (d *SearchDialog) openDropdown() {
+	if len(d.dropdownEntries()) == 0 {
+		if field, _ := d.focusedField(); field != nil {
+			d.message = "No recent entries"
+		}
+		return
+	}
+	d.dropdown = true
+	d.dropdownIndex = 0
+}
+
+// handleDropdownInput handles keys while the recent entries list is open:
+// Up/Down select, Enter picks the entry and Esc closes the list. Other keys
+// close the list and are handled as usual.
+func (d *SearchDialog) handleDropdownInput(key tcell.Key) bool {
+	entries := d.dropdownEntries()
+
+	switch key {
+	case tcell.KeyUp:
+		d.dropdownIndex = max(d.dropdownIndex-1, 0)
+		return true
+	case tcell.KeyDown:
+		d.dropdownIndex = min(d.dropdownIndex+1, len(entries)-1)
+		return true
+	case tcell.KeyEnter:
+		if field, _ := d.focusedField(); field != nil && d.dropdownIndex < len(entries) {
+			*field = entries[d.dropdownIndex]
+			d.historyIndex = -1
+			d.notifyEdit()
+		}
+		d.dropdown = false
+		return true
+	case tcell.KeyEscape:
+		d.dropdown = false
+		return true
+	}
+
+	d.dropdown = false
+	return false
 }
 
 // handleBackspace handles backspace key.
 func (d *SearchDialog) handleBackspace() {
+	d.historyIndex = -1
 	if d.focusIndex == 0 {
 		if len(d.searchInput) > 0 {
 			d.searchInput = d.searchInput[:len(d.searchInput)-1]
@@ -188,6 +335,13 @@ func (d *SearchDialog) handleEnter() bool {
 		d.replacer.SetReplacement(d.replaceInput)
 	}
 
+	// In find mode, Enter in the search field accepts the incremental search
+	if !d.isReplaceMode && d.focusIndex == 0 && d.onAccept != nil {
+		d.SetConfirmed()
+		d.onAccept()
+		return true
+	}
+
 	switch d.focusIndex {
 	case 0, 1, 2: // Find Next button or search field
 		if d.onFindNext != nil {
@@ -204,6 +358,12 @@ func (d *SearchDialog) handleEnter() bool {
 			d.onReplaceAll()
 		}
 		return true
+	case 5: // Confirm Each button
+		if d.isReplaceMode && d.onReplaceEach != nil {
+			d.SetConfirmed()
+			d.onReplaceEach()
+		}
+		return true
 	}
 
 	return false
@@ -211,6 +371,7 @@ func (d *SearchDialog) handleEnter() bool {
 
 // handleCharacter processes a typed character.
 func (d *SearchDialog) handleCharacter(ch rune) {
+	d.historyIndex = -1
 	if d.focusIndex == 0 {
 		d.searchInput += string(ch)
 	} else if d.isReplaceMode && d.focusIndex == 1 {
@@ -218,6 +379,35 @@ func (d *SearchDialog) handleCharacter(ch rune) {
 	}
 }
 
+// handleOptionKey toggles the option bound to an Alt+letter shortcut:
+// Alt+C case, Alt+W whole word, Alt+R regex, Alt+S in selection, in
+// replace mode Alt+P preserve case and in find mode Alt+Z fuzzy.
+func (d *SearchDialog) handleOptionKey(ch rune) bool {
+	switch ch {
+	case 'p', 'P':
+		if !d.isReplaceMode {
+			return false
+		}
+		d.toggleOption("preserve")
+	case 'z', 'Z':
+		if d.isReplaceMode {
+			return false
+		}
+		d.toggleOption("fuzzy")
+	case 'c', 'C':
+		d.toggleOption("case")
+	case 'w', 'W':
+		d.toggleOption("word")
+	case 'r', 'R':
+		d.toggleOption("regex")
+	case 's', 'S':
+		d.toggleOption("selection")
+	default:
+		return false
+	}
+	return true
+}
+
 // toggleOption toggles a search option.
 func (d *SearchDialog) toggleOption(option string) {
 	switch option {
@@ -227,11 +417,62 @@ func (d *SearchDialog) toggleOption(option string) {
 		d.options.WholeWord = !d.options.WholeWord
 	case "regex":
 		d.options.UseRegex = !d.options.UseRegex
+		if d.options.UseRegex {
+			d.options.Fuzzy = false
+		}
+	case "fuzzy":
+		d.options.Fuzzy = !d.options.Fuzzy
+		if d.options.Fuzzy {
+			d.options.UseRegex = false
+		}
+	case "selection":
+		d.options.InSelection = !d.options.InSelection
+	case "preserve":
+		d.options.PreserveCase = !d.options.PreserveCase
 	}
 
 	if d.finder != nil {
 		d.finder.SetOptions(d.options)
 	}
+	d.notifyChange()
+}
+
+// notifyEdit reports an edit to the change callback if it was made in the
+// search field. Edits to the replace field do not change the search.
+func (d *SearchDialog) notifyEdit() {
+	if d.focusIndex == 0 {
+		d.notifyChange()
+	}
+}
+
+// notifyChange reports the current search input to the change callback.
+func (d *SearchDialog) notifyChange() {
+	if d.onChange != nil {
+		d.onChange(d.searchInput, d.options)
+	}
+}
+
+// HandleClick focuses the clicked field or presses the clicked button.
+func (d *SearchDialog) HandleClick(x, y int) bool {
+	d.dropdown = false
+
+	if index, ok := d.buttonAt(x, y); ok {
+		d.focusIndex = index
+		return d.handleEnter()
+	}
+
+	if x <= d.x || x >= d.x+d.width-1 {
+		return false
+	}
+	switch {
+	case y == d.y+3: // Search input
+		d.focusIndex = 0
+	case d.isReplaceMode && y == d.y+6: // Replace input
+		d.focusIndex = 1
+	default:
+		return false
+	}
+	return true
 }
 
 // Render draws the search dialog.
@@ -270,6 +511,12 @@ func (d *SearchDialog) Render(screen Screen, style tcell.Style) {
 			replaceStyle = style.Reverse(true)
 		}
 		d.DrawText(screen, d.x+2, currentY, d.replaceInput+"█", replaceStyle)
+		currentY++
+
+		if preview := d.buildPreviewText(); preview != "" {
+			previewStyle := style.Foreground(tcell.ColorGreen)
+			d.DrawText(screen, d.x+2, currentY, preview, previewStyle)
+		}
 		currentY += 2
 	}
 
@@ -291,37 +538,72 @@ func (d *SearchDialog) Render(screen Screen, style tcell.Style) {
 
 	// Draw buttons
 	buttonY := currentY
-	buttonSpacing := (d.width - 40) / 4 // Distribute buttons evenly
+	labels := []string{"Find Next"}
+	if d.isReplaceMode {
+		labels = append(labels, "Replace", "Replace All", "Confirm Each")
+	}
 
-	// Calculate button positions
-	btnX := d.x + buttonSpacing
+	btnX := d.x + 3
+	for i, label := range labels {
+		focus := i + 2 // Buttons follow the input fields
+		btnStyle := style
+		if d.focusIndex == focus {
+			btnStyle = style.Reverse(true).Bold(true)
+		}
+		d.DrawButton(screen, btnX, buttonY, focus, label, btnStyle, d.focusIndex == focus)
+		btnX += len(label) + 4 + 2 // "[ label ]" plus a gap
+	}
 
-	// Find Next button
-	findNextStyle := style
-	if d.focusIndex == 2 {
-		findNextStyle = style.Reverse(true).Bold(true)
+	if d.dropdown {
+		fieldY := d.y + 3 // Search input
+		if d.focusIndex == 1 {
+			fieldY = d.y + 6 // Replace input
+		}
+		d.renderDropdown(screen, fieldY+1, style)
 	}
-	d.DrawButton(screen, btnX, buttonY, 0, "Find Next", findNextStyle, d.focusIndex == 2)
+}
 
-	if d.isReplaceMode {
-		btnX += buttonSpacing + 10
+// renderDropdown draws the recent entries list below a field, starting at
+// row y. The list may extend below the dialog.
+func (d *SearchDialog) renderDropdown(screen Screen, y int, style tcell.Style) {
+	listStyle := style.Foreground(tcell.ColorBlack).Background(tcell.ColorSilver)
+	width := d.width - 4
 
-		// Replace button
-		replaceStyle := style
-		if d.focusIndex == 3 {
-			replaceStyle = style.Reverse(true).Bold(true)
+	for i, entry := range d.dropdownEntries() {
+		rowStyle := listStyle
+		if i == d.dropdownIndex {
+			rowStyle = listStyle.Reverse(true)
+		}
+		text := " " + strings.NewReplacer("\n", "↵", "\t", "→").Replace(entry)
+		if pad := width - utf8.RuneCountInString(text); pad > 0 {
+			text += strings.Repeat(" ", pad)
 		}
-		d.DrawButton(screen, btnX, buttonY, 0, "Replace", replaceStyle, d.focusIndex == 3)
+		d.DrawText(screen, d.x+2, y+i, text, rowStyle)
+	}
+}
 
-		btnX += buttonSpacing + 10
+// buildPreviewText builds the preview line showing what the replacement
+// expands to for the current match. It is empty unless regex or preserve
+// case mode is on, when there is no match yet, or when the search field has
+// been edited since the last search.
+func (d *SearchDialog) buildPreviewText() string {
+	if !d.isReplaceMode || d.replacer == nil || d.finder == nil {
+		return ""
+	}
+	if !d.options.UseRegex && !d.options.PreserveCase {
+		return ""
+	}
+	if d.searchInput != d.finder.GetPattern() {
+		return ""
+	}
 
-		// Replace All button
-		replaceAllStyle := style
-		if d.focusIndex == 4 {
-			replaceAllStyle = style.Reverse(true).Bold(true)
-		}
-		d.DrawButton(screen, btnX, buttonY, 0, "Replace All", replaceAllStyle, d.focusIndex == 4)
+	text, ok := d.replacer.Preview(d.replaceInput)
+	if !ok {
+		return ""
 	}
+
+	text = strings.NewReplacer("\n", "↵", "\t", "→").Replace(text)
+	return "Preview: " + text
 }
 
 // buildOptionsText builds the options display text.
@@ -346,6 +628,26 @@ func (d *SearchDialog) buildOptionsText() string {
 		parts = append(parts, "[ ] Regex")
 	}
 
+	if d.options.InSelection {
+		parts = append(parts, "[✓] In selection")
+	} else {
+		parts = append(parts, "[ ] In selection")
+	}
+
+	if d.isReplaceMode {
+		if d.options.PreserveCase {
+			parts = append(parts, "[✓] Preserve case")
+		} else {
+			parts = append(parts, "[ ] Preserve case")
+		}
+	} else {
+		if d.options.Fuzzy {
+			parts = append(parts, "[✓] Fuzzy")
+		} else {
+			parts = append(parts, "[ ] Fuzzy")
+		}
+	}
+
 	return strings.Join(parts, "  ")
 }
 
@@ -379,6 +681,24 @@ func (d *SearchDialog) SetOnFindNext(fn func()) {
 	d.onFindNext = fn
 }
 
+// SetOnChange sets the callback run whenever the search text or options
+// change, for incremental search.
+func (d *SearchDialog) SetOnChange(fn func(pattern string, options search.Options)) {
+	d.onChange = fn
+}
+
+// SetOnAccept sets the callback for accepting the search with Enter.
+// When set, Enter in the find field closes the dialog instead of finding
+// the next match.
+func (d *SearchDialog) SetOnAccept(fn func()) {
+	d.onAccept = fn
+}
+
+// GetOptions returns the search options selected in the dialog.
+func (d *SearchDialog) GetOptions() search.Options {
+	return d.options
+}
+
 // SetOnReplace sets the callback for Replace.
 func (d *SearchDialog) SetOnReplace(fn func()) {
 	d.onReplace = fn
@@ -389,6 +709,12 @@ func (d *SearchDialog) SetOnReplaceAll(fn func()) {
 	d.onReplaceAll = fn
 }
 
+// SetOnReplaceEach sets the callback for Confirm Each, which closes the
+// dialog and starts an interactive replace.
+func (d *SearchDialog) SetOnReplaceEach(fn func()) {
+	d.onReplaceEach = fn
+}
+
 // GetResult returns nil for search dialog (use callbacks).
 func (d *SearchDialog) GetResult() interface{} {
 	return nil
@@ -0,0 +1,328 @@
+package dialog
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/search"
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestSearchDialog_buildPreviewText(t *testing.T) {
+	finder := search.NewFinder()
+	replacer := search.NewReplacer(finder)
+
+	finder.SetPattern(`(\w+)\.go`)
+	opts := finder.GetOptions()
+	opts.UseRegex = true
+	finder.SetOptions(opts)
+
+	buf := buffer.NewBuffer()
+	buf.SetLines([]string{"main.go"})
+	finder.FindNext(buf, buffer.Position{Line: -1, Col: -1})
+
+	dlg := NewSearchDialog(finder, replacer, true, nil)
+	dlg.SetReplaceInput(`\u$1\n`)
+
+	if got, want := dlg.buildPreviewText(), "Preview: Main↵"; got != want {
+		t.Errorf("buildPreviewText() = %q, want %q", got, want)
+	}
+
+	// Preview is stale once the search field is edited
+	dlg.SetSearchInput("other")
+	if got := dlg.buildPreviewText(); got != "" {
+		t.Errorf("buildPreviewText() after editing search = %q, want empty", got)
+	}
+
+	// No preview in find mode
+	findDlg := NewSearchDialog(finder, replacer, false, nil)
+	if got := findDlg.buildPreviewText(); got != "" {
+		t.Errorf("buildPreviewText() in find mode = %q, want empty", got)
+	}
+}
+
+func TestSearchDialog_OnChange(t *testing.T) {
+	finder := search.NewFinder()
+	dlg := NewSearchDialog(finder, search.NewReplacer(finder), true, nil)
+
+	var patterns []string
+	dlg.SetOnChange(func(pattern string, options search.Options) {
+		patterns = append(patterns, pattern)
+	})
+
+	dlg.HandleInput(tcell.KeyRune, tcell.ModNone, 'a')
+	dlg.HandleInput(tcell.KeyRune, tcell.ModNone, 'b')
+	dlg.HandleInput(tcell.KeyBackspace2, tcell.ModNone, 0)
+
+	want := []string{"a", "ab", "a"}
+	if !reflect.DeepEqual(patterns, want) {
+		t.Errorf("onChange patterns = %v, want %v", patterns, want)
+	}
+
+	// Typing in the replace field does not change the search
+	dlg.HandleInput(tcell.KeyTab, tcell.ModNone, 0)
+	dlg.HandleInput(tcell.KeyRune, tcell.ModNone, 'x')
+	if len(patterns) != len(want) {
+		t.Errorf("onChange called for replace field edit: %v", patterns)
+	}
+}
+
+func TestSearchDialog_EnterAccepts(t *testing.T) {
+	finder := search.NewFinder()
+	accepted := false
+	findNext := false
+
+	dlg := NewFindDialog(finder, func() { findNext = true }, nil)
+	dlg.SetOnAccept(func() { accepted = true })
+	dlg.Show(80, 24)
+
+	dlg.HandleInput(tcell.KeyRune, tcell.ModNone, 'x')
+	dlg.HandleInput(tcell.KeyEnter, tcell.ModNone, 0)
+
+	if !accepted || findNext {
+		t.Errorf("Enter: accepted = %v, findNext = %v, want true, false", accepted, findNext)
+	}
+	if dlg.IsOpen() || !dlg.IsConfirmed() {
+		t.Error("Enter should close and confirm the dialog")
+	}
+	if finder.GetPattern() != "x" {
+		t.Errorf("finder pattern = %q, want %q", finder.GetPattern(), "x")
+	}
+}
+
+func TestSearchDialog_OptionKeys(t *testing.T) {
+	finder := search.NewFinder()
+	dlg := NewSearchDialog(finder, search.NewReplacer(finder), false, nil)
+
+	changes := 0
+	dlg.SetOnChange(func(pattern string, options search.Options) {
+		changes++
+	})
+
+	for _, ch := range "cwrs" {
+		if !dlg.HandleInput(tcell.KeyRune, tcell.ModAlt, ch) {
+			t.Errorf("Alt+%c not handled", ch)
+		}
+	}
+
+	opts := dlg.GetOptions()
+	if !opts.CaseSensitive || !opts.WholeWord || !opts.UseRegex || !opts.InSelection {
+		t.Errorf("options after toggles = %+v, want all enabled", opts)
+	}
+	if finder.GetOptions() != opts {
+		t.Errorf("finder options = %+v, want %+v", finder.GetOptions(), opts)
+	}
+	if changes != 4 {
+		t.Errorf("onChange called %d times, want 4", changes)
+	}
+	if dlg.GetSearchInput() != "" {
+		t.Errorf("Alt shortcuts typed into search field: %q", dlg.GetSearchInput())
+	}
+
+	want := "[✓] Case  [✓] Word  [✓] Regex  [✓] In selection  [ ] Fuzzy"
+	if got := dlg.buildOptionsText(); got != want {
+		t.Errorf("buildOptionsText() = %q, want %q", got, want)
+	}
+}
+
+func TestSearchDialog_ConfirmEach(t *testing.T) {
+	finder := search.NewFinder()
+	replacer := search.NewReplacer(finder)
+
+	called := false
+	dlg := NewSearchDialog(finder, replacer, true, nil)
+	dlg.SetOnReplaceEach(func() { called = true })
+	dlg.Show(80, 24)
+	dlg.SetSearchInput("foo")
+	dlg.SetReplaceInput("bar")
+
+	// Search field, replace field, Find Next, Replace, Replace All, Confirm Each
+	for i := 0; i < 5; i++ {
+		dlg.HandleInput(tcell.KeyTab, 0, 0)
+	}
+	dlg.HandleInput(tcell.KeyEnter, 0, 0)
+
+	if !called {
+		t.Error("Enter on Confirm Each should call the callback")
+	}
+	if dlg.IsOpen() {
+		t.Error("dialog should close when Confirm Each starts")
+	}
+	if replacer.GetReplacement() != "bar" || finder.GetPattern() != "foo" {
+		t.Errorf("replace values not applied: pattern %q, replacement %q", finder.GetPattern(), replacer.GetReplacement())
+	}
+}
+
+func TestSearchDialog_HandleClick(t *testing.T) {
+	finder := search.NewFinder()
+	replacer := search.NewReplacer(finder)
+
+	replacedAll := false
+	dlg := NewSearchDialog(finder, replacer, true, nil)
+	dlg.SetOnReplaceAll(func() { replacedAll = true })
+	dlg.Show(80, 24)
+	dlg.SetSearchInput("foo")
+	dlg.SetReplaceInput("bar")
+	screen := newMockScreen()
+	dlg.Render(screen, tcell.StyleDefault)
+
+	_, y, ok := screen.find("bar")
+	if !ok {
+		t.Fatal("replace field not drawn")
+	}
+	dlg.HandleClick(dlg.x+4, y)
+	if dlg.focusIndex != 1 {
+		t.Errorf("focusIndex = %d after clicking the replace field, want 1", dlg.focusIndex)
+	}
+
+	x, y, ok := screen.find("[ Replace All ]")
+	if !ok {
+		t.Fatal("Replace All button not drawn")
+	}
+	dlg.HandleClick(x+3, y)
+	if !replacedAll {
+		t.Error("click on Replace All should call the callback")
+	}
+	if replacer.GetReplacement() != "bar" {
+		t.Errorf("replacement = %q, want %q", replacer.GetReplacement(), "bar")
+	}
+}
+
+func TestSearchDialog_PreserveCase(t *testing.T) {
+	finder := search.NewFinder()
+	replacer := search.NewReplacer(finder)
+
+	// Preserve case is a replace-only option
+	findDlg := NewSearchDialog(finder, replacer, false, nil)
+	findDlg.HandleInput(tcell.KeyRune, tcell.ModAlt, 'p')
+	if findDlg.GetOptions().PreserveCase {
+		t.Error("Alt+P should not enable preserve case in find mode")
+	}
+
+	dlg := NewSearchDialog(finder, replacer, true, nil)
+	dlg.HandleInput(tcell.KeyRune, tcell.ModAlt, 'p')
+	if !finder.GetOptions().PreserveCase {
+		t.Fatal("Alt+P should enable preserve case")
+	}
+
+	finder.SetPattern("userId")
+	buf := buffer.NewBuffer()
+	buf.SetLines([]string{"USER_ID"})
+	finder.FindNext(buf, buffer.Position{Line: -1, Col: -1})
+
+	dlg.SetSearchInput("userId")
+	dlg.SetReplaceInput("accountId")
+	if got, want := dlg.buildPreviewText(), "Preview: ACCOUNT_ID"; got != want {
+		t.Errorf("buildPreviewText() = %q, want %q", got, want)
+	}
+}
+
+func TestSearchDialog_HistoryRecall(t *testing.T) {
+	finder := search.NewFinder()
+	replacer := search.NewReplacer(finder)
+	finder.SetHistory([]string{"alpha", "beta"})
+	replacer.SetHistory([]string{"one", "two"})
+
+	var changes []string
+	dlg := NewSearchDialog(finder, replacer, true, nil)
+	dlg.SetOnChange(func(pattern string, options search.Options) {
+		changes = append(changes, pattern)
+	})
+	dlg.SetSearchInput("dra")
+
+	steps := []struct {
+		key  tcell.Key
+		want string
+	}{
+		{tcell.KeyUp, "beta"},
+		{tcell.KeyUp, "alpha"},
+		{tcell.KeyUp, "alpha"}, // Oldest entry stays
+		{tcell.KeyDown, "beta"},
+		{tcell.KeyDown, "dra"}, // Past the newest restores the draft
+		{tcell.KeyDown, "dra"},
+	}
+	for i, step := range steps {
+		dlg.HandleInput(step.key, 0, 0)
+		if got := dlg.GetSearchInput(); got != step.want {
+			t.Errorf("step %d: search input = %q, want %q", i, got, step.want)
+		}
+	}
+	if want := []string{"beta", "alpha", "beta", "dra"}; !reflect.DeepEqual(changes, want) {
+		t.Errorf("onChange patterns = %v, want %v", changes, want)
+	}
+
+	// The replace field recalls replacements
+	dlg.HandleInput(tcell.KeyTab, 0, 0)
+	dlg.HandleInput(tcell.KeyUp, 0, 0)
+	if got := dlg.GetReplaceInput(); got != "two" {
+		t.Errorf("replace input = %q, want %q", got, "two")
+	}
+}
+
+func TestSearchDialog_HistoryDropdown(t *testing.T) {
+	finder := search.NewFinder()
+	finder.SetHistory([]string{"alpha", "beta", "gamma"})
+
+	dlg := NewSearchDialog(finder, search.NewReplacer(finder), false, nil)
+	dlg.Show(80, 24)
+
+	if got, want := dlg.dropdownEntries(), []string{"gamma", "beta", "alpha"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dropdownEntries() = %v, want %v", got, want)
+	}
+
+	// Alt+Down opens the list; Down, Down, Up selects "beta"
+	dlg.HandleInput(tcell.KeyDown, tcell.ModAlt, 0)
+	if !dlg.dropdown {
+		t.Fatal("Alt+Down should open the dropdown")
+	}
+	dlg.HandleInput(tcell.KeyDown, 0, 0)
+	dlg.HandleInput(tcell.KeyDown, 0, 0)
+	dlg.HandleInput(tcell.KeyUp, 0, 0)
+	dlg.HandleInput(tcell.KeyEnter, 0, 0)
+
+	if dlg.dropdown {
+		t.Error("Enter should close the dropdown")
+	}
+	if got := dlg.GetSearchInput(); got != "beta" {
+		t.Errorf("search input = %q, want %q", got, "beta")
+	}
+	if !dlg.IsOpen() {
+		t.Error("picking an entry should keep the dialog open")
+	}
+
+	// Esc closes only the list
+	dlg.HandleInput(tcell.KeyDown, tcell.ModAlt, 0)
+	dlg.HandleInput(tcell.KeyEscape, 0, 0)
+	if dlg.dropdown || !dlg.IsOpen() {
+		t.Errorf("Esc in dropdown: dropdown = %v, open = %v, want false, true", dlg.dropdown, dlg.IsOpen())
+	}
+}
+
+func TestSearchDialog_Fuzzy(t *testing.T) {
+	finder := search.NewFinder()
+	dlg := NewSearchDialog(finder, search.NewReplacer(finder), false, nil)
+
+	dlg.HandleInput(tcell.KeyRune, tcell.ModAlt, 'r')
+	dlg.HandleInput(tcell.KeyRune, tcell.ModAlt, 'z')
+	if opts := dlg.GetOptions(); !opts.Fuzzy || opts.UseRegex {
+		t.Errorf("after Alt+Z: Fuzzy = %v, UseRegex = %v, want true, false", opts.Fuzzy, opts.UseRegex)
+	}
+
+	dlg.HandleInput(tcell.KeyRune, tcell.ModAlt, 'r')
+	if opts := dlg.GetOptions(); opts.Fuzzy || !opts.UseRegex {
+		t.Errorf("after Alt+R: Fuzzy = %v, UseRegex = %v, want false, true", opts.Fuzzy, opts.UseRegex)
+	}
+
+	// Replace mode has no fuzzy option
+	opts := finder.GetOptions()
+	opts.Fuzzy = true
+	finder.SetOptions(opts)
+	replaceDlg := NewSearchDialog(finder, search.NewReplacer(finder), true, nil)
+	if replaceDlg.GetOptions().Fuzzy {
+		t.Error("replace dialog should turn fuzzy matching off")
+	}
+	if replaceDlg.HandleInput(tcell.KeyRune, tcell.ModAlt, 'z') {
+		t.Error("Alt+Z should not be handled in replace mode")
+	}
+}
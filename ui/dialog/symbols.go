@@ -0,0 +1,244 @@
+// Package dialog implements the go to symbol picker.
+package dialog
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/AndrewDonelson/ted/search"
+	"github.com/gdamore/tcell/v2"
+)
+
+// SymbolDialog lists the symbols of a buffer and filters them with fuzzy
+// matching as the user types, best matches first. Enter jumps to the
+// selected symbol.
+type SymbolDialog struct {
+	BaseDialog
+	input    string
+	symbols  []search.Symbol
+	names    []string             // Text matched against the input, parallel to symbols
+	results  []search.FuzzyResult // Symbols shown, best first
+	selected int                  // Selected result
+	scroll   int                  // First visible result
+	onSelect func(symbol search.Symbol)
+	onCancel func()
+}
+
+// NewSymbolDialog creates a picker for symbols. onSelect is called with
+// the chosen symbol.
+func NewSymbolDialog(symbols []search.Symbol, onSelect func(symbol search.Symbol), onCancel func()) *SymbolDialog {
+	d := &SymbolDialog{
+		BaseDialog: BaseDialog{
+			title:  "Go to Symbol",
+			width:  60,
+			height: 16,
+		},
+		symbols:  symbols,
+		names:    make([]string, len(symbols)),
+		onSelect: onSelect,
+		onCancel: onCancel,
+	}
+	for i, sym := range symbols {
+		d.names[i] = sym.Name
+	}
+	d.filter()
+	return d
+}
+
+// Show opens the picker, sized to the screen.
+func (d *SymbolDialog) Show(screenWidth, screenHeight int) {
+	d.width = min(max(screenWidth-4, 30), 80)
+	d.height = min(max(screenHeight-4, 8), 24)
+	d.BaseDialog.Show(screenWidth, screenHeight)
+}
+
+// filter updates the results for the current input. Without input every
+// symbol is shown in buffer order.
+func (d *SymbolDialog) filter() {
+	if d.input == "" {
+		d.results = make([]search.FuzzyResult, len(d.symbols))
+		for i := range d.symbols {
+			d.results[i] = search.FuzzyResult{Index: i}
+		}
+	} else {
+		d.results = search.FuzzyFilter(d.input, d.names)
+	}
+	d.selected = 0
+	d.scroll = 0
+}
+
+// HandleInput processes keyboard input.
+func (d *SymbolDialog) HandleInput(key tcell.Key, mod tcell.ModMask, ch rune) bool {
+	switch key {
+	case tcell.KeyEscape:
+		d.SetCancelled()
+		if d.onCancel != nil {
+			d.onCancel()
+		}
+		return true
+
+	case tcell.KeyEnter:
+		if sym, ok := d.Selected(); ok {
+			d.SetConfirmed()
+			if d.onSelect != nil {
+				d.onSelect(sym)
+			}
+		}
+		return true
+
+	case tcell.KeyUp:
+		d.moveSelection(-1)
+		return true
+
+	case tcell.KeyDown:
+		d.moveSelection(1)
+		return true
+
+	case tcell.KeyPgUp:
+		d.moveSelection(-d.listHeight())
+		return true
+
+	case tcell.KeyPgDn:
+		d.moveSelection(d.listHeight())
+		return true
+
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(d.input) > 0 {
+			runes := []rune(d.input)
+			d.input = string(runes[:len(runes)-1])
+			d.filter()
+		}
+		return true
+
+	case tcell.KeyRune:
+		if ch != 0 {
+			d.input += string(ch)
+			d.filter()
+		}
+		return true
+	}
+
+	return false
+}
+
+// HandleClick selects the clicked symbol, or jumps to it if it was
+// already selected.
+func (d *SymbolDialog) HandleClick(x, y int) bool {
+	top := d.y + 4 // First list row, as drawn by Render
+	if x <= d.x || x >= d.x+d.width-1 || y < top || y >= top+d.listHeight() {
+		return false
+	}
+	i := d.scroll + y - top
+	if i >= len(d.results) {
+		return false
+	}
+	if i == d.selected {
+		return d.HandleInput(tcell.KeyEnter, tcell.ModNone, 0)
+	}
+	d.selected = i
+	return true
+}
+
+// moveSelection moves the selection by delta and scrolls it into view.
+func (d *SymbolDialog) moveSelection(delta int) {
+	if len(d.results) == 0 {
+		return
+	}
+
+	d.selected = min(max(d.selected+delta, 0), len(d.results)-1)
+
+	height := d.listHeight()
+	if d.selected < d.scroll {
+		d.scroll = d.selected
+	} else if d.selected >= d.scroll+height {
+		d.scroll = d.selected - height + 1
+	}
+}
+
+// listHeight returns the number of symbols that fit in the picker.
+func (d *SymbolDialog) listHeight() int {
+	// Border, input, count and a blank line
+	return max(d.height-5, 1)
+}
+
+// Selected returns the selected symbol.
+func (d *SymbolDialog) Selected() (search.Symbol, bool) {
+	if d.selected >= len(d.results) {
+		return search.Symbol{}, false
+	}
+	return d.symbols[d.results[d.selected].Index], true
+}
+
+// SetInput sets the filter text.
+func (d *SymbolDialog) SetInput(input string) {
+	d.input = input
+	d.filter()
+}
+
+// Render draws the picker.
+func (d *SymbolDialog) Render(screen Screen, style tcell.Style) {
+	if !d.isOpen {
+		return
+	}
+
+	d.Clear(screen, style)
+	d.DrawBorder(screen, style)
+
+	y := d.y + 1
+	d.DrawText(screen, d.x+2, y, "Symbol: ", style)
+	d.DrawText(screen, d.x+10, y, d.input+"█", style.Reverse(true))
+	y++
+
+	count := fmt.Sprintf("%d of %d symbols", len(d.results), len(d.symbols))
+	d.DrawText(screen, d.x+2, y, count, style.Foreground(tcell.ColorYellow))
+	y += 2
+
+	height := d.listHeight()
+	for i := d.scroll; i < len(d.results) && i < d.scroll+height; i++ {
+		rowStyle := style
+		if i == d.selected {
+			rowStyle = style.Reverse(true)
+		}
+		d.renderRow(screen, y, d.results[i], rowStyle)
+		y++
+	}
+}
+
+// renderRow draws one symbol: its kind, its name with the matched
+// characters highlighted, and its line number at the right.
+func (d *SymbolDialog) renderRow(screen Screen, y int, result search.FuzzyResult, style tcell.Style) {
+	sym := d.symbols[result.Index]
+
+	// Fill the row so the selection bar spans the list
+	d.DrawText(screen, d.x+2, y, strings.Repeat(" ", d.width-4), style)
+
+	x := d.x + 2
+	d.DrawText(screen, x, y, fmt.Sprintf("%-7s", sym.Kind), style.Dim(true))
+	x += 8
+	if sym.Kind == search.SymbolHeading {
+		x += 2 * (sym.Level - 1) // Indent nested headings
+	}
+
+	matchStyle := style.Foreground(tcell.ColorYellow).Bold(true)
+	col := 0
+	for offset, ch := range sym.Name {
+		chStyle := style
+		if slices.Contains(result.Positions, offset) {
+			chStyle = matchStyle
+		}
+		d.DrawText(screen, x+col, y, string(ch), chStyle)
+		col++
+	}
+
+	line := fmt.Sprintf(":%d", sym.Line+1)
+	d.DrawText(screen, d.x+d.width-2-len(line), y, line, style.Dim(true))
+}
+
+// GetResult returns the selected symbol, if any.
+func (d *SymbolDialog) GetResult() interface{} {
+	if sym, ok := d.Selected(); ok {
+		return sym
+	}
+	return nil
+}
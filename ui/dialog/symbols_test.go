@@ -0,0 +1,91 @@
+package dialog
+
+import (
+	"testing"
+
+	"github.com/AndrewDonelson/ted/search"
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestSymbolDialog_FilterAndSelect(t *testing.T) {
+	symbols := []search.Symbol{
+		{Name: "main", Kind: search.SymbolFunction, Line: 3},
+		{Name: "Editor", Kind: search.SymbolType, Line: 10},
+		{Name: "Editor.handleFind", Kind: search.SymbolMethod, Line: 20},
+		{Name: "Finder", Kind: search.SymbolType, Line: 30},
+	}
+
+	var chosen search.Symbol
+	dlg := NewSymbolDialog(symbols, func(sym search.Symbol) { chosen = sym }, nil)
+	dlg.Show(80, 24)
+
+	// Without input every symbol is listed in buffer order
+	if sym, ok := dlg.Selected(); !ok || sym.Name != "main" || len(dlg.results) != 4 {
+		t.Errorf("initial selection = %q with %d results, want main with 4", sym.Name, len(dlg.results))
+	}
+
+	for _, ch := range "find" {
+		dlg.HandleInput(tcell.KeyRune, 0, ch)
+	}
+	if len(dlg.results) != 2 {
+		t.Fatalf("%d results for %q, want 2", len(dlg.results), "find")
+	}
+	if sym, _ := dlg.Selected(); sym.Name != "Finder" {
+		t.Errorf("best match = %q, want %q", sym.Name, "Finder")
+	}
+
+	dlg.HandleInput(tcell.KeyDown, 0, 0)
+	dlg.HandleInput(tcell.KeyEnter, 0, 0)
+	if chosen.Line != 20 {
+		t.Errorf("chose symbol on line %d, want 20", chosen.Line)
+	}
+	if dlg.IsOpen() {
+		t.Error("dialog should close after choosing a symbol")
+	}
+}
+
+func TestSymbolDialog_HandleClick(t *testing.T) {
+	symbols := []search.Symbol{
+		{Name: "main", Kind: search.SymbolFunction, Line: 3},
+		{Name: "run", Kind: search.SymbolFunction, Line: 8},
+	}
+
+	var chosen search.Symbol
+	dlg := NewSymbolDialog(symbols, func(sym search.Symbol) { chosen = sym }, nil)
+	dlg.Show(80, 24)
+	screen := newMockScreen()
+	dlg.Render(screen, tcell.StyleDefault)
+
+	x, y, ok := screen.find("run")
+	if !ok {
+		t.Fatal("symbol not drawn")
+	}
+
+	// The first click selects, a click on the selection jumps
+	dlg.HandleClick(x, y)
+	if sym, _ := dlg.Selected(); sym.Name != "run" || !dlg.IsOpen() {
+		t.Errorf("after one click: selected %q, open %v; want run, open", sym.Name, dlg.IsOpen())
+	}
+	dlg.HandleClick(x, y)
+	if chosen.Line != 8 || dlg.IsOpen() {
+		t.Errorf("after two clicks: chose line %d, open %v; want line 8, closed", chosen.Line, dlg.IsOpen())
+	}
+}
+
+func TestSymbolDialog_NoMatches(t *testing.T) {
+	called := false
+	dlg := NewSymbolDialog([]search.Symbol{{Name: "main"}}, func(search.Symbol) { called = true }, nil)
+	dlg.Show(80, 24)
+
+	dlg.SetInput("zzz")
+	dlg.HandleInput(tcell.KeyEnter, 0, 0)
+
+	if called || !dlg.IsOpen() {
+		t.Errorf("Enter with no matches: called = %v, open = %v, want false, true", called, dlg.IsOpen())
+	}
+
+	dlg.HandleInput(tcell.KeyBackspace, 0, 0)
+	if len(dlg.results) != 0 {
+		t.Errorf("%q should still match nothing", "zz")
+	}
+}
@@ -0,0 +1,105 @@
+// Package dialog implements a scrollable text viewer.
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// TextDialog shows read-only text, such as the keyboard shortcut cheat
+// sheet, scrolled with the arrow and page keys. Enter or Escape closes it.
+type TextDialog struct {
+	BaseDialog
+	lines  []string
+	scroll int // First visible line
+}
+
+// NewTextDialog creates a viewer for text.
+func NewTextDialog(title, text string) *TextDialog {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	width := len(title) + 8
+	for _, line := range lines {
+		width = max(width, len(line)+4)
+	}
+
+	return &TextDialog{
+		BaseDialog: BaseDialog{
+			title:  title,
+			width:  width,
+			height: len(lines) + 4, // Border, blank line and hint
+		},
+		lines: lines,
+	}
+}
+
+// Show opens the viewer, shrunk to fit the screen.
+func (d *TextDialog) Show(screenWidth, screenHeight int) {
+	d.width = min(d.width, screenWidth)
+	d.height = min(len(d.lines)+4, max(screenHeight-2, 5))
+	d.scroll = 0
+	d.BaseDialog.Show(screenWidth, screenHeight)
+}
+
+// HandleInput scrolls or closes the viewer.
+func (d *TextDialog) HandleInput(key tcell.Key, mod tcell.ModMask, ch rune) bool {
+	switch key {
+	case tcell.KeyEscape, tcell.KeyEnter:
+		d.SetConfirmed()
+	case tcell.KeyUp:
+		d.scrollBy(-1)
+	case tcell.KeyDown:
+		d.scrollBy(1)
+	case tcell.KeyPgUp:
+		d.scrollBy(-d.pageHeight())
+	case tcell.KeyPgDn:
+		d.scrollBy(d.pageHeight())
+	case tcell.KeyHome:
+		d.scroll = 0
+	case tcell.KeyEnd:
+		d.scrollBy(len(d.lines))
+	case tcell.KeyRune:
+		if ch == 'q' {
+			d.SetConfirmed()
+		}
+	}
+	return true // Modal: keys never reach the editor
+}
+
+// scrollBy scrolls by delta lines, keeping the last page full.
+func (d *TextDialog) scrollBy(delta int) {
+	d.scroll = min(max(d.scroll+delta, 0), max(len(d.lines)-d.pageHeight(), 0))
+}
+
+// pageHeight returns how many lines fit in the viewer.
+func (d *TextDialog) pageHeight() int {
+	return max(d.height-4, 1)
+}
+
+// Render draws the visible lines and a hint with the scroll position.
+func (d *TextDialog) Render(screen Screen, style tcell.Style) {
+	if !d.isOpen {
+		return
+	}
+
+	d.Clear(screen, style)
+	d.DrawBorder(screen, style)
+
+	height := d.pageHeight()
+	for i := 0; i < height && d.scroll+i < len(d.lines); i++ {
+		d.DrawText(screen, d.x+2, d.y+1+i, d.lines[d.scroll+i], style)
+	}
+
+	hint := "Esc to close"
+	if len(d.lines) > height {
+		hint = fmt.Sprintf("↑↓ PgUp PgDn to scroll (%d-%d of %d), Esc to close",
+			d.scroll+1, min(d.scroll+height, len(d.lines)), len(d.lines))
+	}
+	d.DrawText(screen, d.x+2, d.y+d.height-2, hint, style.Dim(true))
+}
+
+// GetResult returns nothing; the viewer only shows text.
+func (d *TextDialog) GetResult() interface{} {
+	return nil
+}
@@ -0,0 +1,47 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestTextDialog_Scroll(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 30; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	d := NewTextDialog("Keys", strings.Join(lines, "\n")+"\n")
+	d.Show(80, 14) // Room for 8 lines
+
+	tests := []struct {
+		key   tcell.Key
+		first string // First visible line after the key
+	}{
+		{tcell.KeyDown, "line 2"},
+		{tcell.KeyPgDn, "line 10"},
+		{tcell.KeyEnd, "line 23"},
+		{tcell.KeyDown, "line 23"},
+		{tcell.KeyPgUp, "line 15"},
+		{tcell.KeyHome, "line 1"},
+		{tcell.KeyUp, "line 1"},
+	}
+	for _, tt := range tests {
+		d.HandleInput(tt.key, tcell.ModNone, 0)
+		screen := newMockScreen()
+		d.Render(screen, tcell.StyleDefault)
+		if _, y, ok := screen.find(tt.first + " "); !ok || y != d.y+1 {
+			t.Errorf("after %v first line = row %d (found %v), want %q at row %d", tcell.KeyNames[tt.key], y, ok, tt.first, d.y+1)
+		}
+	}
+
+	if !d.HandleInput(tcell.KeyRune, tcell.ModNone, 'x') || !d.IsOpen() {
+		t.Error("HandleInput('x') should be consumed without closing the viewer")
+	}
+	d.HandleInput(tcell.KeyEscape, tcell.ModNone, 0)
+	if d.IsOpen() {
+		t.Error("Escape should close the viewer")
+	}
+}
@@ -0,0 +1,50 @@
+// Package layout implements conversion between buffer and screen columns.
+package layout
+
+// DefaultTabSize is the number of columns between tab stops when no other
+// size is configured.
+const DefaultTabSize = 4
+
+// DisplayColumn returns the screen column, relative to the start of the
+// line, at which byte offset col of line is drawn. Tabs advance to the next
+// multiple of tabSize; every other byte takes one column.
+func DisplayColumn(line string, col, tabSize int) int {
+	if tabSize < 1 {
+		tabSize = DefaultTabSize
+	}
+	display := 0
+	for i := 0; i < col; i++ {
+		if i < len(line) && line[i] == '\t' {
+			display += tabSize - display%tabSize
+		} else {
+			display++
+		}
+	}
+	return display
+}
+
+// BufferColumn returns the byte offset in line drawn at screen column
+// display, relative to the start of the line. A column inside a tab's
+// space maps to the tab, or to the byte after it when the column is in the
+// tab's right half. Columns past the end of the line continue one byte per
+// column.
+func BufferColumn(line string, display, tabSize int) int {
+	if tabSize < 1 {
+		tabSize = DefaultTabSize
+	}
+	x := 0
+	for i := 0; i < len(line); i++ {
+		width := 1
+		if line[i] == '\t' {
+			width = tabSize - x%tabSize
+		}
+		if display < x+width {
+			if display-x >= (width+1)/2 && width > 1 {
+				return i + 1
+			}
+			return i
+		}
+		x += width
+	}
+	return len(line) + display - x
+}
@@ -0,0 +1,50 @@
+package layout
+
+import "testing"
+
+func TestDisplayColumn(t *testing.T) {
+	tests := []struct {
+		line    string
+		col     int
+		tabSize int
+		want    int
+	}{
+		{"hello", 3, 4, 3},
+		{"\tx", 1, 4, 4},
+		{"\tx", 2, 4, 5},
+		{"ab\tx", 3, 4, 4},
+		{"ab\tx", 3, 8, 8},
+		{"abcd\tx", 5, 4, 8},
+		{"\t\t", 2, 2, 4},
+		{"ab", 4, 4, 4},  // Past the end
+		{"\tx", 1, 0, 4}, // Invalid size uses the default
+	}
+	for _, tt := range tests {
+		if got := DisplayColumn(tt.line, tt.col, tt.tabSize); got != tt.want {
+			t.Errorf("DisplayColumn(%q, %d, %d) = %d, want %d", tt.line, tt.col, tt.tabSize, got, tt.want)
+		}
+	}
+}
+
+func TestBufferColumn(t *testing.T) {
+	tests := []struct {
+		line    string
+		display int
+		tabSize int
+		want    int
+	}{
+		{"hello", 3, 4, 3},
+		{"\tx", 0, 4, 0},
+		{"\tx", 1, 4, 0},
+		{"\tx", 2, 4, 1}, // Right half of the tab
+		{"\tx", 4, 4, 1},
+		{"\tx", 5, 4, 2},
+		{"ab\tx", 3, 4, 3},
+		{"ab", 5, 4, 5}, // Past the end
+	}
+	for _, tt := range tests {
+		if got := BufferColumn(tt.line, tt.display, tt.tabSize); got != tt.want {
+			t.Errorf("BufferColumn(%q, %d, %d) = %d, want %d", tt.line, tt.display, tt.tabSize, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Package layout implements hiding folded lines from the viewport.
+package layout
+
+import (
+	"sort"
+)
+
+// LineRange is a run of buffer lines, from First to Last inclusive.
+type LineRange struct {
+	First int
+	Last  int
+}
+
+// SetHiddenLines hides runs of lines, such as folded code, from the
+// viewport. Ranges must be sorted and must not overlap. Nil shows every
+// line.
+func (l *Layout) SetHiddenLines(hidden []LineRange) {
+	l.hidden = hidden
+}
+
+// VisibleLine returns line if it is shown, otherwise the line above the
+// hidden run holding it.
+func (l *Layout) VisibleLine(line int) int {
+	if r, ok := l.hiddenRange(line); ok {
+		return max(r.First-1, 0)
+	}
+	return line
+}
+
+// LineAtRow returns the buffer line drawn at row of the viewport, counting
+// from 0. Rows past the end of the text give lines past its end.
+func (l *Layout) LineAtRow(viewport Viewport, row int) int {
+	return l.lineAtIndex(l.visibleIndex(viewport.StartLine) + row)
+}
+
+// RowOfLine returns the row of the viewport line is drawn at, or -1 if it
+// is not in view.
+func (l *Layout) RowOfLine(viewport Viewport, line int) int {
+	if line < viewport.StartLine || line > viewport.EndLine {
+		return -1
+	}
+	if _, ok := l.hiddenRange(line); ok {
+		return -1
+	}
+	return l.visibleIndex(line) - l.visibleIndex(viewport.StartLine)
+}
+
+// hiddenRange returns the hidden run holding line.
+func (l *Layout) hiddenRange(line int) (LineRange, bool) {
+	i := sort.Search(len(l.hidden), func(i int) bool { return l.hidden[i].Last >= line })
+	if i < len(l.hidden) && l.hidden[i].First <= line {
+		return l.hidden[i], true
+	}
+	return LineRange{}, false
+}
+
+// visibleIndex returns how many shown lines come before line.
+func (l *Layout) visibleIndex(line int) int {
+	index := line
+	for _, r := range l.hidden {
+		if r.First >= line {
+			break
+		}
+		index -= min(r.Last, line-1) - r.First + 1
+	}
+	return index
+}
+
+// lineAtIndex returns the shown line that has index shown lines before
+// it: the inverse of visibleIndex.
+func (l *Layout) lineAtIndex(index int) int {
+	line := index
+	for _, r := range l.hidden {
+		if r.First > line {
+			break
+		}
+		line += r.Last - r.First + 1
+	}
+	return line
+}
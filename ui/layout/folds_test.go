@@ -0,0 +1,58 @@
+package layout
+
+import (
+	"testing"
+)
+
+func TestLayout_HiddenLines(t *testing.T) {
+	l := NewLayout(80, 7) // 5 visible rows
+	l.SetHiddenLines([]LineRange{{First: 2, Last: 5}, {First: 8, Last: 8}})
+
+	// Shown lines: 0 1 6 7 9 10 11 ...
+	tests := []struct {
+		name       string
+		cursorLine int
+		wantStart  int
+		wantEnd    int
+	}{
+		{name: "top", cursorLine: 0, wantStart: 0, wantEnd: 9},
+		{name: "cursor on a hidden line", cursorLine: 4, wantStart: 0, wantEnd: 9},
+		{name: "centered", cursorLine: 10, wantStart: 7, wantEnd: 12},
+		{name: "end", cursorLine: 19, wantStart: 15, wantEnd: 19},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := l.CalculateViewport(tt.cursorLine, 20)
+			if got.StartLine != tt.wantStart || got.EndLine != tt.wantEnd {
+				t.Errorf("CalculateViewport(%d) = %d-%d, want %d-%d", tt.cursorLine, got.StartLine, got.EndLine, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+
+	viewport := l.CalculateViewport(0, 20)
+	for row, want := range []int{0, 1, 6, 7, 9} {
+		if got := l.LineAtRow(viewport, row); got != want {
+			t.Errorf("LineAtRow(%d) = %d, want %d", row, got, want)
+		}
+		if got := l.RowOfLine(viewport, want); got != row {
+			t.Errorf("RowOfLine(%d) = %d, want %d", want, got, row)
+		}
+		if line, _ := l.ScreenToBuffer(0, 1+row, viewport); line != want {
+			t.Errorf("ScreenToBuffer(0, %d) line = %d, want %d", 1+row, line, want)
+		}
+	}
+	if got := l.RowOfLine(viewport, 3); got != -1 {
+		t.Errorf("RowOfLine(3) = %d, want -1 for a hidden line", got)
+	}
+	if x, y := l.BufferToScreen(7, 2, viewport); x != 2 || y != 4 {
+		t.Errorf("BufferToScreen(7, 2) = (%d, %d), want (2, 4)", x, y)
+	}
+	if got := l.VisibleLine(5); got != 1 {
+		t.Errorf("VisibleLine(5) = %d, want 1", got)
+	}
+
+	l.SetHiddenLines(nil)
+	if got := l.CalculateViewport(0, 20); got.EndLine != 4 {
+		t.Errorf("EndLine = %d with no hidden lines, want 4", got.EndLine)
+	}
+}
@@ -25,8 +25,12 @@ type Viewport struct {
 type Layout struct {
 	width      int
 	height     int
-	menuHeight int // Height of menu bar (typically 1)
-	infoHeight int // Height of info bar (typically 1)
+	menuHeight int         // Height of menu bar (typically 1)
+	infoHeight int         // Height of info bar (typically 1)
+	gutter     int         // Columns left of the text for markers, or 0
+	hidden     []LineRange // Lines not shown, such as folded code
+	scrollTop  int         // First visible line while pinned
+	pinned     bool        // Whether scrollTop is kept instead of centering the cursor
 }
 
 // NewLayout creates a new layout with the given screen dimensions.
@@ -55,7 +59,26 @@ func (l *Layout) GetMenuBarRegion() Region {
 	}
 }
 
-// GetEditAreaRegion returns the region for the editable text area.
+// SetGutterWidth reserves columns left of the text for markers such as
+// bookmarks. Zero removes the gutter.
+func (l *Layout) SetGutterWidth(width int) {
+	l.gutter = max(width, 0)
+}
+
+// GetGutterRegion returns the region left of the text area for markers.
+// It is empty unless a gutter width is set.
+func (l *Layout) GetGutterRegion() Region {
+	editRegion := l.GetEditAreaRegion()
+	return Region{
+		X:      0,
+		Y:      editRegion.Y,
+		Width:  editRegion.X,
+		Height: editRegion.Height,
+	}
+}
+
+// GetEditAreaRegion returns the region for the editable text area, right
+// of the gutter if there is one.
 func (l *Layout) GetEditAreaRegion() Region {
 	editY := l.menuHeight
 	editHeight := l.height - l.menuHeight - l.infoHeight
@@ -65,10 +88,13 @@ func (l *Layout) GetEditAreaRegion() Region {
 		editHeight = 1
 	}
 
+	// The gutter never takes the whole width
+	gutter := min(l.gutter, max(l.width-1, 0))
+
 	return Region{
-		X:      0,
+		X:      gutter,
 		Y:      editY,
-		Width:  l.width,
+		Width:  l.width - gutter,
 		Height: editHeight,
 	}
 }
@@ -109,8 +135,18 @@ func (l *Layout) GetLineNumberWidth(totalLines int) int {
 	return digits + 2
 }
 
+// ScrollTo pins the viewport so that line is the first visible line.
+// The viewport stays put while the cursor moves within it, so the text
+// does not shift under the mouse.
+func (l *Layout) ScrollTo(line int) {
+	l.scrollTop = max(line, 0)
+	l.pinned = true
+}
+
 // CalculateViewport calculates the viewport based on cursor position and total lines.
-// It ensures the cursor is visible and centers it if possible.
+// It ensures the cursor is visible and centers it if possible. A viewport
+// pinned by ScrollTo is kept until the cursor leaves it. Hidden lines take
+// no rows, so the viewport may span more lines than it has rows.
 func (l *Layout) CalculateViewport(cursorLine, totalLines int) Viewport {
 	editRegion := l.GetEditAreaRegion()
 	viewportHeight := editRegion.Height
@@ -134,35 +170,53 @@ func (l *Layout) CalculateViewport(cursorLine, totalLines int) Viewport {
 		cursorLine = totalLines - 1
 	}
 
-	// Calculate start line to keep cursor visible
-	startLine := cursorLine - viewportHeight/2
-	if startLine < 0 {
-		startLine = 0
+	// Work in rows of shown lines, then map back to buffer lines
+	cursorRow := l.visibleIndex(l.VisibleLine(cursorLine))
+	totalRows := l.visibleIndex(totalLines)
+	viewport := func(startRow, endRow int) Viewport {
+		return Viewport{
+			StartLine: l.lineAtIndex(startRow),
+			EndLine:   l.lineAtIndex(endRow),
+			OffsetX:   0, // Horizontal scrolling not implemented in Phase 0
+			Width:     editRegion.Width,
+			Height:    viewportHeight,
+		}
 	}
 
-	// Calculate end line
-	endLine := startLine + viewportHeight - 1
-	if endLine >= totalLines {
-		endLine = totalLines - 1
-		// Adjust start line if we're at the end
-		startLine = endLine - viewportHeight + 1
-		if startLine < 0 {
-			startLine = 0
+	if l.pinned {
+		startRow := min(l.visibleIndex(l.VisibleLine(l.scrollTop)), max(totalRows-viewportHeight, 0))
+		if cursorRow >= startRow && cursorRow < startRow+viewportHeight {
+			return viewport(startRow, min(startRow+viewportHeight, totalRows)-1)
 		}
+		// The cursor moved out of view: follow it again
+		l.pinned = false
 	}
 
-	return Viewport{
-		StartLine: startLine,
-		EndLine:   endLine,
-		OffsetX:   0, // Horizontal scrolling not implemented in Phase 0
-		Width:     editRegion.Width,
-		Height:    viewportHeight,
+	// Calculate start row to keep cursor visible
+	startRow := cursorRow - viewportHeight/2
+	if startRow < 0 {
+		startRow = 0
 	}
+
+	// Calculate end row
+	endRow := startRow + viewportHeight - 1
+	if endRow >= totalRows {
+		endRow = totalRows - 1
+		// Adjust start row if we're at the end
+		startRow = endRow - viewportHeight + 1
+		if startRow < 0 {
+			startRow = 0
+		}
+	}
+
+	return viewport(startRow, endRow)
 }
 
 // ScreenToBuffer converts screen coordinates to buffer position.
-// Returns the line number and column in the buffer.
-func (l *Layout) ScreenToBuffer(screenX, screenY int) (line, col int) {
+// Returns the line drawn at screenY in the viewport, skipping hidden lines,
+// and the column right of the gutter, or -1, -1 outside the edit area.
+// The line may be past the end of the text.
+func (l *Layout) ScreenToBuffer(screenX, screenY int, viewport Viewport) (line, col int) {
 	editRegion := l.GetEditAreaRegion()
 
 	// Check if coordinates are in edit area
@@ -170,13 +224,10 @@ func (l *Layout) ScreenToBuffer(screenX, screenY int) (line, col int) {
 		return -1, -1
 	}
 
-	// Convert screen Y to buffer line (relative to viewport)
-	bufferLine := screenY - editRegion.Y
-
-	// Column is screen X (no horizontal scrolling in Phase 0)
-	col = screenX
+	// Column is screen X right of the gutter (no horizontal scrolling in Phase 0)
+	col = screenX - editRegion.X
 
-	return bufferLine, col
+	return l.LineAtRow(viewport, screenY-editRegion.Y), col
 }
 
 // BufferToScreen converts buffer position to screen coordinates.
@@ -185,20 +236,21 @@ func (l *Layout) BufferToScreen(bufferLine, bufferCol int, viewport Viewport) (s
 	editRegion := l.GetEditAreaRegion()
 
 	// Check if line is in viewport
-	if bufferLine < viewport.StartLine || bufferLine > viewport.EndLine {
+	row := l.RowOfLine(viewport, bufferLine)
+	if row < 0 {
 		return -1, -1
 	}
 
 	// Calculate screen coordinates
-	screenY = editRegion.Y + (bufferLine - viewport.StartLine)
-	screenX = bufferCol + viewport.OffsetX
+	screenY = editRegion.Y + row
+	col := bufferCol + viewport.OffsetX
 
 	// Check bounds
-	if screenX < 0 || screenX >= editRegion.Width {
+	if col < 0 || col >= editRegion.Width {
 		return -1, -1
 	}
 
-	return screenX, screenY
+	return editRegion.X + col, screenY
 }
 
 // GetWidth returns the current layout width.
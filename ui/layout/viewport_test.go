@@ -71,6 +71,33 @@ func TestLayout_GetEditAreaRegion(t *testing.T) {
 	}
 }
 
+func TestLayout_Gutter(t *testing.T) {
+	l := NewLayout(80, 24)
+	l.SetGutterWidth(2)
+
+	if got, want := l.GetGutterRegion(), (Region{X: 0, Y: 1, Width: 2, Height: 22}); got != want {
+		t.Errorf("GetGutterRegion() = %v, want %v", got, want)
+	}
+	if got, want := l.GetEditAreaRegion(), (Region{X: 2, Y: 1, Width: 78, Height: 22}); got != want {
+		t.Errorf("GetEditAreaRegion() = %v, want %v", got, want)
+	}
+	viewport := l.CalculateViewport(0, 10)
+	if line, col := l.ScreenToBuffer(12, 3, viewport); line != 2 || col != 10 {
+		t.Errorf("ScreenToBuffer(12, 3) = (%d, %d), want (2, 10)", line, col)
+	}
+	if x, y := l.BufferToScreen(2, 10, viewport); x != 12 || y != 3 {
+		t.Errorf("BufferToScreen(2, 10) = (%d, %d), want (12, 3)", x, y)
+	}
+	if x, y := l.BufferToScreen(2, 78, viewport); x != -1 || y != -1 {
+		t.Errorf("BufferToScreen(2, 78) = (%d, %d), want (-1, -1) past the right edge", x, y)
+	}
+
+	l.SetGutterWidth(0)
+	if got := l.GetGutterRegion().Width; got != 0 {
+		t.Errorf("GetGutterRegion().Width = %d after removing the gutter, want 0", got)
+	}
+}
+
 func TestLayout_GetInfoBarRegion(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -267,6 +294,48 @@ func TestLayout_CalculateViewport(t *testing.T) {
 	}
 }
 
+func TestLayout_ScrollTo(t *testing.T) {
+	tests := []struct {
+		name       string
+		scrollTop  int
+		cursorLine int
+		totalLines int
+		wantStart  int
+		wantEnd    int
+	}{
+		{name: "cursor in pinned view", scrollTop: 30, cursorLine: 35, totalLines: 100, wantStart: 30, wantEnd: 51},
+		{name: "cursor on first pinned line", scrollTop: 30, cursorLine: 30, totalLines: 100, wantStart: 30, wantEnd: 51},
+		{name: "pinned past the end", scrollTop: 95, cursorLine: 90, totalLines: 100, wantStart: 78, wantEnd: 99},
+		{name: "short buffer", scrollTop: 5, cursorLine: 3, totalLines: 10, wantStart: 0, wantEnd: 9},
+		{name: "cursor above pinned view", scrollTop: 30, cursorLine: 10, totalLines: 100, wantStart: 0, wantEnd: 21},
+		{name: "cursor below pinned view", scrollTop: 30, cursorLine: 60, totalLines: 100, wantStart: 49, wantEnd: 70},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLayout(80, 24) // 22 visible lines
+			l.ScrollTo(tt.scrollTop)
+
+			got := l.CalculateViewport(tt.cursorLine, tt.totalLines)
+			if got.StartLine != tt.wantStart || got.EndLine != tt.wantEnd {
+				t.Errorf("CalculateViewport() = %d-%d, want %d-%d", got.StartLine, got.EndLine, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestLayout_ScrollToUnpins(t *testing.T) {
+	l := NewLayout(80, 24)
+	l.ScrollTo(30)
+
+	// Leaving the pinned view centers the cursor again, even on return
+	l.CalculateViewport(60, 100)
+	got := l.CalculateViewport(35, 100)
+	if got.StartLine != 24 {
+		t.Errorf("CalculateViewport().StartLine = %d, want %d", got.StartLine, 24)
+	}
+}
+
 func TestLayout_AdjustForResize(t *testing.T) {
 	l := NewLayout(80, 24)
 
@@ -291,6 +360,7 @@ func TestLayout_AdjustForResize(t *testing.T) {
 
 func TestLayout_ScreenToBuffer(t *testing.T) {
 	l := NewLayout(80, 24)
+	viewport := l.CalculateViewport(0, 100)
 
 	tests := []struct {
 		name     string
@@ -331,7 +401,7 @@ func TestLayout_ScreenToBuffer(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			line, col := l.ScreenToBuffer(tt.screenX, tt.screenY)
+			line, col := l.ScreenToBuffer(tt.screenX, tt.screenY, viewport)
 
 			if line != tt.wantLine || col != tt.wantCol {
 				t.Errorf("ScreenToBuffer(%d, %d) = (%d, %d), want (%d, %d)",
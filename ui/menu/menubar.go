@@ -6,12 +6,13 @@ type MenuAction string
 
 const (
 	// File menu actions
-	ActionFileNew    MenuAction = "file.new"
-	ActionFileOpen   MenuAction = "file.open"
-	ActionFileSave   MenuAction = "file.save"
-	ActionFileSaveAs MenuAction = "file.saveas"
-	ActionFileClose  MenuAction = "file.close"
-	ActionFileQuit   MenuAction = "file.quit"
+	ActionFileNew            MenuAction = "file.new"
+	ActionFileOpen           MenuAction = "file.open"
+	ActionFileSave           MenuAction = "file.save"
+	ActionFileSaveAs         MenuAction = "file.saveas"
+	ActionFileToggleReadOnly MenuAction = "file.togglereadonly"
+	ActionFileClose          MenuAction = "file.close"
+	ActionFileQuit           MenuAction = "file.quit"
 
 	// Edit menu actions
 	ActionEditUndo          MenuAction = "edit.undo"
@@ -26,13 +27,18 @@ const (
 	ActionEditMoveLineDown  MenuAction = "edit.movelinedown"
 
 	// Search menu actions
-	ActionSearchFind     MenuAction = "search.find"
-	ActionSearchReplace  MenuAction = "search.replace"
-	ActionSearchGoToLine MenuAction = "search.gotoline"
+	ActionSearchFind           MenuAction = "search.find"
+	ActionSearchReplace        MenuAction = "search.replace"
+	ActionSearchFindInFiles    MenuAction = "search.findinfiles"
+	ActionSearchReplaceInFiles MenuAction = "search.replaceinfiles"
+	ActionSearchGoToLine       MenuAction = "search.gotoline"
+	ActionSearchGoToSymbol     MenuAction = "search.gotosymbol"
 
 	// View menu actions
 	ActionViewLineNumbers MenuAction = "view.linenumbers"
 	ActionViewWordWrap    MenuAction = "view.wordwrap"
+	ActionViewCommands    MenuAction = "view.commands"
+	ActionViewIndentation MenuAction = "view.indentation"
 
 	// Help menu actions
 	ActionHelpShortcuts MenuAction = "help.shortcuts"
@@ -79,6 +85,7 @@ func NewMenuBar() *MenuBar {
 					{IsSeparator: true},
 					{Label: "Save", Shortcut: "Ctrl+S", Action: ActionFileSave},
 					{Label: "Save As...", Shortcut: "Ctrl+Shift+S", Action: ActionFileSaveAs},
+					{Label: "Toggle Read-only", Action: ActionFileToggleReadOnly},
 					{IsSeparator: true},
 					{Label: "Close", Shortcut: "Ctrl+W", Action: ActionFileClose},
 					{Label: "Quit", Shortcut: "Ctrl+Q", Action: ActionFileQuit},
@@ -109,8 +116,11 @@ func NewMenuBar() *MenuBar {
 				Items: []MenuItem{
 					{Label: "Find...", Shortcut: "Ctrl+F", Action: ActionSearchFind},
 					{Label: "Replace...", Shortcut: "Ctrl+H", Action: ActionSearchReplace},
+					{Label: "Find in Files...", Shortcut: "Ctrl+Shift+F", Action: ActionSearchFindInFiles},
+					{Label: "Replace in Files...", Shortcut: "Ctrl+Shift+H", Action: ActionSearchReplaceInFiles},
 					{IsSeparator: true},
 					{Label: "Go to Line...", Shortcut: "Ctrl+G", Action: ActionSearchGoToLine},
+					{Label: "Go to Symbol...", Shortcut: "Ctrl+Shift+O", Action: ActionSearchGoToSymbol},
 				},
 			},
 			{
@@ -119,6 +129,9 @@ func NewMenuBar() *MenuBar {
 				Items: []MenuItem{
 					{Label: "Toggle Line Numbers", Shortcut: "Ctrl+L", Action: ActionViewLineNumbers},
 					{Label: "Toggle Word Wrap", Shortcut: "", Action: ActionViewWordWrap},
+					{Label: "Indentation...", Action: ActionViewIndentation},
+					{IsSeparator: true},
+					{Label: "Command Palette...", Shortcut: "Ctrl+Shift+P", Action: ActionViewCommands},
 				},
 			},
 			{
@@ -360,23 +373,18 @@ func (mb *MenuBar) HandleClick(x, y int) (handled bool, action MenuAction) {
 	return false, ActionNone
 }
 
-// getDropdownWidth calculates the width of the dropdown menu.
+// getDropdownWidth calculates the width of the dropdown menu: the longest
+// item with its shortcut and padding, at least 20 columns.
 func (mb *MenuBar) getDropdownWidth(menuIndex int) int {
 	if menuIndex < 0 || menuIndex >= len(mb.menus) {
 		return 0
 	}
 	menu := &mb.menus[menuIndex]
-	maxWidth := 0
+	width := max(len(menu.Label), 20)
 	for _, item := range menu.Items {
-		width := len(item.Label)
-		if item.Shortcut != "" {
-			width += 4 + len(item.Shortcut) // 4 spaces between label and shortcut
-		}
-		if width > maxWidth {
-			maxWidth = width
-		}
+		width = max(width, len(item.Label)+len(item.Shortcut)+4) // Padding and spacing
 	}
-	return maxWidth + 4 // Add padding
+	return width
 }
 
 // GetDropdownWidth returns the width of the dropdown for the active menu.
@@ -384,6 +392,18 @@ func (mb *MenuBar) GetDropdownWidth() int {
 	return mb.getDropdownWidth(mb.activeMenu)
 }
 
+// SetShortcut sets the shortcut shown beside the items with the given
+// action, so the labels follow the keymap. An empty shortcut shows none.
+func (mb *MenuBar) SetShortcut(action MenuAction, shortcut string) {
+	for i := range mb.menus {
+		for j := range mb.menus[i].Items {
+			if mb.menus[i].Items[j].Action == action {
+				mb.menus[i].Items[j].Shortcut = shortcut
+			}
+		}
+	}
+}
+
 // FindMenuByKey finds a menu by its Alt+Key shortcut and opens it.
 // Returns true if a menu was found and opened.
 func (mb *MenuBar) FindMenuByKey(key rune) bool {
@@ -215,7 +215,7 @@ func TestMenu_Items(t *testing.T) {
 	}
 
 	// Verify File menu has expected non-separator items
-	expectedItems := []string{"New", "Open...", "Save", "Save As...", "Close", "Quit"}
+	expectedItems := []string{"New", "Open...", "Save", "Save As...", "Toggle Read-only", "Close", "Quit"}
 	if nonSepItems != len(expectedItems) {
 		t.Errorf("File menu has %d non-separator items, want %d", nonSepItems, len(expectedItems))
 	}
@@ -459,6 +459,33 @@ func TestMenuItem_Separator(t *testing.T) {
 	}
 }
 
+func TestMenuBar_SetShortcut(t *testing.T) {
+	mb := NewMenuBar()
+	mb.SetShortcut(ActionFileSave, "Ctrl+K Ctrl+S")
+	mb.SetShortcut(ActionFileQuit, "")
+
+	tests := []struct {
+		label string
+		want  string
+	}{
+		{"Save", "Ctrl+K Ctrl+S"},
+		{"Quit", ""},
+		{"New", "Ctrl+N"},
+	}
+	for _, tt := range tests {
+		for _, item := range mb.GetMenu(0).Items {
+			if item.Label == tt.label && item.Shortcut != tt.want {
+				t.Errorf("%s shortcut = %q, want %q", tt.label, item.Shortcut, tt.want)
+			}
+		}
+	}
+
+	mb.OpenMenu(0)
+	if width := mb.GetDropdownWidth(); width < len("Save")+len("Ctrl+K Ctrl+S")+4 {
+		t.Errorf("GetDropdownWidth() = %d, too narrow for the new shortcut", width)
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) &&
 		(s[:len(substr)] == substr || s[len(s)-len(substr):] == substr ||
@@ -0,0 +1,92 @@
+// Package popup implements the completion popup.
+//
+// Unlike a dialog, the popup does not take the keyboard: the editor keeps
+// handling typing and only passes on the keys that pick a completion. The
+// popup is drawn over the text, next to the word being completed.
+package popup
+
+import (
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/core/complete"
+)
+
+// MaxRows is the most completions shown at once; the popup scrolls to
+// show the rest.
+const MaxRows = 8
+
+// Popup is a list of completions for the word starting at its anchor.
+type Popup struct {
+	items    []complete.Item
+	selected int
+	top      int // First item shown
+	anchor   buffer.Position
+	open     bool
+}
+
+// Show opens the popup with items for the word starting at anchor, with
+// the first item selected.
+func (p *Popup) Show(items []complete.Item, anchor buffer.Position) {
+	p.items = items
+	p.selected, p.top = 0, 0
+	p.anchor = anchor
+	p.open = len(items) > 0
+}
+
+// Hide closes the popup.
+func (p *Popup) Hide() {
+	p.open = false
+	p.items = nil
+}
+
+// IsOpen reports whether the popup is shown.
+func (p *Popup) IsOpen() bool {
+	return p.open
+}
+
+// Anchor returns the start of the word being completed.
+func (p *Popup) Anchor() buffer.Position {
+	return p.anchor
+}
+
+// Selected returns the selected completion.
+func (p *Popup) Selected() (complete.Item, bool) {
+	if !p.open {
+		return complete.Item{}, false
+	}
+	return p.items[p.selected], true
+}
+
+// Move selects the completion delta items down, or up if delta is
+// negative. A single step past either end wraps around; longer moves stop
+// at the end.
+func (p *Popup) Move(delta int) {
+	if !p.open {
+		return
+	}
+	n := len(p.items)
+	next := p.selected + delta
+	switch {
+	case next < 0 && p.selected == 0 && delta == -1:
+		next = n - 1
+	case next >= n && p.selected == n-1 && delta == 1:
+		next = 0
+	}
+	p.selected = min(max(next, 0), n-1)
+
+	// Scroll to keep the selection in view
+	if p.selected < p.top {
+		p.top = p.selected
+	} else if p.selected >= p.top+MaxRows {
+		p.top = p.selected - MaxRows + 1
+	}
+}
+
+// Visible returns the completions in view and the index among them of the
+// selected one.
+func (p *Popup) Visible() ([]complete.Item, int) {
+	if !p.open {
+		return nil, -1
+	}
+	end := min(p.top+MaxRows, len(p.items))
+	return p.items[p.top:end], p.selected - p.top
+}
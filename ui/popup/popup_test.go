@@ -0,0 +1,77 @@
+package popup
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/core/complete"
+)
+
+// items returns n completions named w0, w1, ...
+func items(n int) []complete.Item {
+	list := make([]complete.Item, n)
+	for i := range list {
+		list[i] = complete.Item{Text: fmt.Sprintf("w%d", i)}
+	}
+	return list
+}
+
+func TestPopup_ShowHide(t *testing.T) {
+	var p Popup
+	if _, ok := p.Selected(); ok || p.IsOpen() {
+		t.Fatal("a new popup should be closed")
+	}
+
+	anchor := buffer.Position{Line: 2, Col: 4}
+	p.Show(items(3), anchor)
+	if !p.IsOpen() || p.Anchor() != anchor {
+		t.Fatalf("after Show: open = %v, anchor = %v", p.IsOpen(), p.Anchor())
+	}
+	if item, ok := p.Selected(); !ok || item.Text != "w0" {
+		t.Errorf("Selected() = %v, %v, want w0", item, ok)
+	}
+
+	p.Hide()
+	if p.IsOpen() {
+		t.Error("popup still open after Hide")
+	}
+
+	p.Show(nil, anchor)
+	if p.IsOpen() {
+		t.Error("popup with no items should stay closed")
+	}
+}
+
+func TestPopup_Move(t *testing.T) {
+	tests := []struct {
+		name      string
+		moves     []int
+		want      string
+		wantIndex int // Index of the selection among the visible items
+	}{
+		{"down", []int{1, 1}, "w2", 2},
+		{"up wraps to the end", []int{-1}, "w11", MaxRows - 1},
+		{"down wraps to the start", []int{-1, 1}, "w0", 0},
+		{"page stops at the end", []int{MaxRows, MaxRows}, "w11", MaxRows - 1},
+		{"scrolls down", []int{9}, "w9", MaxRows - 1},
+		{"scrolls back up", []int{9, -9}, "w0", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Popup
+			p.Show(items(12), buffer.Position{})
+			for _, d := range tt.moves {
+				p.Move(d)
+			}
+			item, _ := p.Selected()
+			visible, index := p.Visible()
+			if item.Text != tt.want || index != tt.wantIndex || visible[index] != item {
+				t.Errorf("selected %q at visible row %d, want %q at %d", item.Text, index, tt.want, tt.wantIndex)
+			}
+			if len(visible) != MaxRows {
+				t.Errorf("%d items visible, want %d", len(visible), MaxRows)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Package renderer implements search match highlighting.
+package renderer
+
+import (
+	"sort"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/gdamore/tcell/v2"
+)
+
+// Highlight marks a range of buffer text (inclusive start, exclusive end)
+// to be drawn with a highlight style, such as a search match.
+type Highlight struct {
+	Start   buffer.Position
+	End     buffer.Position
+	Current bool // Drawn with the stronger current-match style
+}
+
+// colSpan is the part of a highlight that falls on a single line.
+type colSpan struct {
+	start   int
+	end     int
+	current bool
+}
+
+// SetHighlights sets the ranges highlighted in the text area.
+// Highlights must be sorted by start position.
+func (r *Renderer) SetHighlights(highlights []Highlight) {
+	r.highlights = highlights
+}
+
+// ClearHighlights removes all highlights from the text area.
+func (r *Renderer) ClearHighlights() {
+	r.highlights = nil
+}
+
+// lineSpans returns the highlighted column ranges on a line of the given length.
+// Highlights spanning a line break cover the rest of the line.
+func (r *Renderer) lineSpans(line, lineLen int) []colSpan {
+	if len(r.highlights) == 0 {
+		return nil
+	}
+
+	// Skip highlights that end before this line
+	first := sort.Search(len(r.highlights), func(i int) bool {
+		return r.highlights[i].End.Line >= line
+	})
+
+	var spans []colSpan
+	for _, h := range r.highlights[first:] {
+		if h.Start.Line > line {
+			break
+		}
+		if h.End.Line < line {
+			continue
+		}
+
+		span := colSpan{start: 0, end: lineLen + 1, current: h.Current}
+		if h.Start.Line == line {
+			span.start = h.Start.Col
+		}
+		if h.End.Line == line {
+			span.end = h.End.Col
+		}
+		if span.end > span.start {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// styleAt returns the style for column col given the line's highlight spans.
+// The current match wins over other matches covering the same column.
+func styleAt(spans []colSpan, col int, base tcell.Style) tcell.Style {
+	style := base
+	for _, span := range spans {
+		if col < span.start || col >= span.end {
+			continue
+		}
+		if span.current {
+			return GetCurrentMatchStyle()
+		}
+		style = GetSearchMatchStyle()
+	}
+	return style
+}
@@ -0,0 +1,109 @@
+package renderer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/ui/layout"
+)
+
+func TestRenderer_lineSpans(t *testing.T) {
+	r := NewRenderer(newMockScreen(80, 24), layout.NewLayout(80, 24))
+	r.SetHighlights([]Highlight{
+		{Start: buffer.Position{Line: 0, Col: 2}, End: buffer.Position{Line: 0, Col: 4}},
+		{Start: buffer.Position{Line: 0, Col: 6}, End: buffer.Position{Line: 2, Col: 3}, Current: true},
+		{Start: buffer.Position{Line: 4, Col: 0}, End: buffer.Position{Line: 4, Col: 1}},
+	})
+
+	tests := []struct {
+		name    string
+		line    int
+		lineLen int
+		want    []colSpan
+	}{
+		{
+			name:    "two highlights on first line",
+			line:    0,
+			lineLen: 8,
+			want:    []colSpan{{start: 2, end: 4}, {start: 6, end: 9, current: true}},
+		},
+		{
+			name:    "middle of multi-line highlight",
+			line:    1,
+			lineLen: 5,
+			want:    []colSpan{{start: 0, end: 6, current: true}},
+		},
+		{
+			name:    "end of multi-line highlight",
+			line:    2,
+			lineLen: 5,
+			want:    []colSpan{{start: 0, end: 3, current: true}},
+		},
+		{
+			name:    "no highlights",
+			line:    3,
+			lineLen: 5,
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := r.lineSpans(tt.line, tt.lineLen)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lineSpans(%d) = %+v, want %+v", tt.line, got, tt.want)
+			}
+		})
+	}
+
+	r.ClearHighlights()
+	if got := r.lineSpans(0, 8); got != nil {
+		t.Errorf("lineSpans() after ClearHighlights = %+v, want nil", got)
+	}
+}
+
+func TestRenderTextArea_Highlights(t *testing.T) {
+	mockScr := newMockScreen(80, 24)
+	lay := layout.NewLayout(80, 24)
+	r := NewRenderer(mockScr, lay)
+
+	buf := buffer.NewBuffer()
+	buf.SetLines([]string{"foo bar foo"})
+	r.SetHighlights([]Highlight{
+		{Start: buffer.Position{Line: 0, Col: 0}, End: buffer.Position{Line: 0, Col: 3}},
+		{Start: buffer.Position{Line: 0, Col: 8}, End: buffer.Position{Line: 0, Col: 11}, Current: true},
+	})
+
+	// Cursor on another line so the base style is the default style
+	if err := r.RenderTextArea(buf, buffer.Position{Line: 0, Col: 0}); err != nil {
+		t.Fatalf("RenderTextArea() error = %v", err)
+	}
+
+	region := lay.GetEditAreaRegion()
+	row := mockScr.styles[region.Y]
+
+	tests := []struct {
+		col  int
+		want string
+	}{
+		{col: 0, want: "match"},
+		{col: 2, want: "match"},
+		{col: 3, want: "none"},
+		{col: 8, want: "current"},
+		{col: 10, want: "current"},
+	}
+
+	for _, tt := range tests {
+		got := "none"
+		switch row[region.X+tt.col] {
+		case GetSearchMatchStyle():
+			got = "match"
+		case GetCurrentMatchStyle():
+			got = "current"
+		}
+		if got != tt.want {
+			t.Errorf("style at col %d = %s, want %s", tt.col, got, tt.want)
+		}
+	}
+}
@@ -11,15 +11,19 @@ import (
 
 // FileInfo contains information to display in the info bar.
 type FileInfo struct {
-	Name       string
-	Path       string
-	Size       int64
-	Type       string
-	Encoding   string
-	LineEnding string
-	TabSize    int
-	TotalLines int
-	IsModified bool
+	Name        string
+	Path        string
+	Size        int64
+	Type        string
+	Encoding    string
+	LineEnding  string
+	TabSize     int
+	UseSpaces   bool // Indentation uses spaces (shown as "Spaces: N" instead of "Tab: N")
+	TotalLines  int
+	IsModified  bool
+	IsReadOnly  bool   // Editing is disabled (shown with a lock indicator)
+	IsRecording bool   // A keyboard macro is being recorded
+	Message     string // Transient status message (e.g. "Saved", save errors)
 }
 
 // RenderInfoBar renders the info bar at the bottom of the screen.
@@ -54,6 +58,8 @@ func (r *Renderer) buildInfoBarContent(info *FileInfo, width int) string {
 	}
 
 	var parts []string
+	separator := " │ "
+	r.indentField = [2]int{}
 
 	// Filename
 	filename := info.Name
@@ -80,9 +86,30 @@ func (r *Renderer) buildInfoBarContent(info *FileInfo, width int) string {
 		parts = append(parts, "Saved")
 	}
 
-	// Tab size
+	// Read-only lock indicator
+	if info.IsReadOnly {
+		parts = append(parts, "🔒 Read-only")
+	}
+
+	// Macro recording indicator
+	if info.IsRecording {
+		parts = append(parts, "● Recording")
+	}
+
+	// Indentation, remembered so clicking it can change it
 	if info.TabSize > 0 {
-		parts = append(parts, fmt.Sprintf("Tab: %d", info.TabSize))
+		indent := fmt.Sprintf("Tab: %d", info.TabSize)
+		if info.UseSpaces {
+			indent = fmt.Sprintf("Spaces: %d", info.TabSize)
+		}
+		start := len(strings.Join(parts, separator)) + len(separator)
+		r.indentField = [2]int{start, start + len(indent)}
+		parts = append(parts, indent)
+	}
+
+	// Encoding, when it is not the usual UTF-8
+	if info.Encoding != "" && info.Encoding != "UTF-8" {
+		parts = append(parts, info.Encoding)
 	}
 
 	// Line ending
@@ -90,8 +117,12 @@ func (r *Renderer) buildInfoBarContent(info *FileInfo, width int) string {
 		parts = append(parts, info.LineEnding)
 	}
 
+	// Status message last so file details stay in place
+	if info.Message != "" {
+		parts = append(parts, info.Message)
+	}
+
 	// Join with separators
-	separator := " │ "
 	content := strings.Join(parts, separator)
 
 	// Truncate if too long
@@ -102,6 +133,17 @@ func (r *Renderer) buildInfoBarContent(info *FileInfo, width int) string {
 	return content
 }
 
+// IndentationFieldAt reports whether x, y is on the indentation setting
+// shown by the last info bar rendered.
+func (r *Renderer) IndentationFieldAt(x, y int) bool {
+	region := r.layout.GetInfoBarRegion()
+	if y != region.Y || r.indentField[1] == 0 {
+		return false
+	}
+	col := x - region.X
+	return col >= r.indentField[0] && col < r.indentField[1] && col < region.Width
+}
+
 // formatFileSize formats file size in human-readable format.
 func formatFileSize(size int64) string {
 	const (
@@ -188,6 +188,25 @@ func TestBuildInfoBarContent(t *testing.T) {
 			width:        80,
 			wantContains: []string{"test.txt", "1.0 KB", "Plain Text", "Saved", "Tab: 4", "LF"},
 		},
+		{
+			name: "indenting with spaces",
+			fileInfo: &FileInfo{
+				Name:      "main.py",
+				TabSize:   2,
+				UseSpaces: true,
+			},
+			width:        80,
+			wantContains: []string{"main.py", "Spaces: 2"},
+		},
+		{
+			name: "other encoding",
+			fileInfo: &FileInfo{
+				Name:     "legacy.txt",
+				Encoding: "Latin-1",
+			},
+			width:        80,
+			wantContains: []string{"legacy.txt", "Latin-1"},
+		},
 		{
 			name: "modified file",
 			fileInfo: &FileInfo{
@@ -197,6 +216,33 @@ func TestBuildInfoBarContent(t *testing.T) {
 			width:        80,
 			wantContains: []string{"test.txt", "Modified"},
 		},
+		{
+			name: "read-only file",
+			fileInfo: &FileInfo{
+				Name:       "hosts",
+				IsReadOnly: true,
+			},
+			width:        80,
+			wantContains: []string{"hosts", "🔒", "Read-only"},
+		},
+		{
+			name: "recording a macro",
+			fileInfo: &FileInfo{
+				Name:        "test.txt",
+				IsRecording: true,
+			},
+			width:        80,
+			wantContains: []string{"test.txt", "Recording"},
+		},
+		{
+			name: "status message",
+			fileInfo: &FileInfo{
+				Name:    "test.txt",
+				Message: "Save failed",
+			},
+			width:        80,
+			wantContains: []string{"test.txt", "Save failed"},
+		},
 		{
 			name:         "nil file info",
 			fileInfo:     nil,
@@ -373,3 +419,50 @@ func TestRenderInfoBar_FillEmptySpace(t *testing.T) {
 		}
 	}
 }
+
+func TestRenderInfoBar_IndentationField(t *testing.T) {
+	mockScr := newMockScreen(80, 24)
+	l := layout.NewLayout(80, 24)
+	renderer := NewRenderer(mockScr, l)
+	region := l.GetInfoBarRegion()
+
+	info := &FileInfo{Name: "main.py", Type: "Python", TabSize: 2, UseSpaces: true, LineEnding: "LF"}
+	if err := renderer.RenderInfoBar(info); err != nil {
+		t.Fatalf("RenderInfoBar() error = %v", err)
+	}
+
+	row := mockScr.contents[region.Y]
+	text := make([]rune, region.Width)
+	for x := range text {
+		text[x] = row[region.X+x]
+	}
+	start := strings.Index(string(text), "Spaces: 2")
+	if start < 0 {
+		t.Fatalf("info bar %q does not show the indentation", string(text))
+	}
+	start = len([]rune(string(text)[:start])) // Screen column
+
+	tests := []struct {
+		x, y int
+		want bool
+	}{
+		{region.X + start, region.Y, true},
+		{region.X + start + len("Spaces: 2") - 1, region.Y, true},
+		{region.X + start - 1, region.Y, false},
+		{region.X + start + len("Spaces: 2"), region.Y, false},
+		{region.X + start, region.Y - 1, false},
+	}
+	for _, tt := range tests {
+		if got := renderer.IndentationFieldAt(tt.x, tt.y); got != tt.want {
+			t.Errorf("IndentationFieldAt(%d, %d) = %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+
+	// Without an indentation setting there is nothing to click
+	if err := renderer.RenderInfoBar(&FileInfo{Name: "x"}); err != nil {
+		t.Fatalf("RenderInfoBar() error = %v", err)
+	}
+	if renderer.IndentationFieldAt(region.X+start, region.Y) {
+		t.Error("IndentationFieldAt() = true with no indentation shown")
+	}
+}
@@ -150,17 +150,7 @@ func (r *Renderer) renderDropdownMenu(menuBar *menu.MenuBar) error {
 	x := menuBar.GetMenuPosition(activeMenuIndex)
 	y := 1 // Below menu bar
 
-	// Calculate width based on longest item
-	width := len(activeMenu.Label)
-	for _, item := range activeMenu.Items {
-		itemWidth := len(item.Label) + len(item.Shortcut) + 4 // padding + spacing
-		if itemWidth > width {
-			width = itemWidth
-		}
-	}
-	if width < 20 {
-		width = 20
-	}
+	width := menuBar.GetDropdownWidth()
 
 	// Render each menu item
 	for i, item := range activeMenu.Items {
@@ -0,0 +1,77 @@
+// Package renderer implements completion popup rendering.
+package renderer
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/ui/layout"
+	"github.com/AndrewDonelson/ted/ui/popup"
+	"github.com/gdamore/tcell/v2"
+)
+
+// SetPopup sets the completion popup drawn over the text while it is open.
+// Nil draws none.
+func (r *Renderer) SetPopup(p *popup.Popup) {
+	r.popup = p
+}
+
+// renderPopup draws the completion popup below the start of the word it
+// completes, or above it if there is no room below.
+func (r *Renderer) renderPopup(buf *buffer.Buffer, cursorPos buffer.Position) {
+	if r.popup == nil || !r.popup.IsOpen() {
+		return
+	}
+	items, selected := r.popup.Visible()
+	anchor := r.popup.Anchor()
+	viewport := r.layout.CalculateViewport(cursorPos.Line, buf.LineCount())
+	col := anchor.Col
+	if line, err := buf.GetLine(anchor.Line); err == nil {
+		col = layout.DisplayColumn(line, col, r.tabSize)
+	}
+	x, y := r.layout.BufferToScreen(anchor.Line, col, viewport)
+	if x < 0 || y < 0 {
+		return
+	}
+
+	// Wide enough for every completion and its detail, in columns
+	textWidth, detailWidth := 0, 0
+	for _, item := range items {
+		textWidth = max(textWidth, utf8.RuneCountInString(item.Text))
+		detailWidth = max(detailWidth, utf8.RuneCountInString(item.Detail))
+	}
+	screenWidth, _ := r.screen.GetSize()
+	editRegion := r.layout.GetEditAreaRegion()
+	x = max(min(x-1, screenWidth-(textWidth+detailWidth+3)), 0) // Text lines up with the word
+
+	top := y + 1
+	if top+len(items) > editRegion.Y+editRegion.Height && y-len(items) >= editRegion.Y {
+		top = y - len(items)
+	}
+
+	for i, item := range items {
+		style, detailStyle := GetPopupStyle(), GetPopupDetailStyle()
+		if i == selected {
+			style, detailStyle = GetPopupSelectedStyle(), GetPopupSelectedStyle()
+		}
+		cx := x
+		put := func(s string, style tcell.Style) {
+			for _, ch := range s {
+				if cx < screenWidth {
+					r.screen.SetContent(cx, top+i, ch, nil, style)
+				}
+				cx++
+			}
+		}
+		put(" "+padRight(item.Text, textWidth)+" ", style)
+		if detailWidth > 0 {
+			put(padRight(item.Detail, detailWidth)+" ", detailStyle)
+		}
+	}
+}
+
+// padRight pads s with spaces to width columns.
+func padRight(s string, width int) string {
+	return s + strings.Repeat(" ", max(width-utf8.RuneCountInString(s), 0))
+}
@@ -0,0 +1,80 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/AndrewDonelson/ted/core/complete"
+	"github.com/AndrewDonelson/ted/ui/layout"
+	"github.com/AndrewDonelson/ted/ui/popup"
+)
+
+// rowText returns the text of screen row y from column x, n columns long.
+func rowText(m *mockScreen, x, y, n int) string {
+	var text []rune
+	for i := range n {
+		text = append(text, m.contents[y][x+i])
+	}
+	return string(text)
+}
+
+func TestRenderPopup(t *testing.T) {
+	mockScr := newMockScreen(40, 12)
+	l := layout.NewLayout(40, 12)
+	r := NewRenderer(mockScr, l)
+
+	buf := buffer.NewBuffer()
+	lines := make([]string, 20)
+	for i := range lines {
+		lines[i] = "\tfoo.co"
+	}
+	buf.SetLines(lines)
+
+	var p popup.Popup
+	r.SetPopup(&p)
+	items := []complete.Item{{Text: "count"}, {Text: "cost", Detail: "open file"}}
+	editRegion := l.GetEditAreaRegion()
+
+	tests := []struct {
+		name  string
+		line  int
+		wantY func(cursorY int) int // Row of the first completion
+	}{
+		{"below the word", 0, func(y int) int { return y + 1 }},
+		{"above the word at the bottom", 19, func(y int) int { return y - 2 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockScr.Clear()
+			cursor := buffer.Position{Line: tt.line, Col: 7}
+			p.Show(items, buffer.Position{Line: tt.line, Col: 5})
+			p.Move(1)
+			r.RenderTextArea(buf, cursor)
+			r.renderPopup(buf, cursor)
+
+			viewport := l.CalculateViewport(cursor.Line, buf.LineCount())
+			wordX, cursorY := l.BufferToScreen(tt.line, 8, viewport) // After the tab
+			if cursorY < editRegion.Y {
+				t.Fatalf("line %d not in view", tt.line)
+			}
+			y := tt.wantY(cursorY)
+			if got := rowText(mockScr, wordX, y, 5); got != "count" {
+				t.Errorf("first row = %q, want %q under the word", got, "count")
+			}
+			if got := rowText(mockScr, wordX, y+1, 16); got != "cost  open file " {
+				t.Errorf("second row = %q", got)
+			}
+			if got := mockScr.styles[y+1][wordX]; got != GetPopupSelectedStyle() {
+				t.Error("selected completion not drawn in the selected style")
+			}
+		})
+	}
+
+	// A closed popup draws nothing
+	mockScr.Clear()
+	p.Hide()
+	r.renderPopup(buf, buffer.Position{})
+	if len(mockScr.contents) != 0 {
+		t.Error("closed popup was drawn")
+	}
+}
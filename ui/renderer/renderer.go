@@ -8,24 +8,66 @@ import (
 	"github.com/AndrewDonelson/ted/core/buffer"
 	"github.com/AndrewDonelson/ted/ui/layout"
 	"github.com/AndrewDonelson/ted/ui/menu"
+	"github.com/AndrewDonelson/ted/ui/popup"
 	"github.com/AndrewDonelson/ted/ui/terminal"
 	"github.com/gdamore/tcell/v2"
 )
 
 // Renderer handles all rendering operations for the editor.
 type Renderer struct {
-	screen terminal.Screen
-	layout *layout.Layout
+	screen      terminal.Screen
+	layout      *layout.Layout
+	highlights  []Highlight       // Ranges drawn with a highlight style (e.g., search matches)
+	tabSize     int               // Columns between tab stops
+	guide       int               // Column marked as the preferred line length, or 0
+	indentField [2]int            // Columns of the info bar's indentation setting, end exclusive
+	cursors     []buffer.Position // Cursors besides the terminal's, drawn as highlighted cells
+	marks       map[int]string    // Gutter markers of each line that has some, such as a bookmark
+	popup       *popup.Popup      // Completion popup drawn over the text, if any
 }
 
 // NewRenderer creates a new renderer with the given screen and layout.
-func NewRenderer(screen terminal.Screen, layout *layout.Layout) *Renderer {
+func NewRenderer(screen terminal.Screen, l *layout.Layout) *Renderer {
 	return &Renderer{
-		screen: screen,
-		layout: layout,
+		screen:  screen,
+		layout:  l,
+		tabSize: layout.DefaultTabSize,
 	}
 }
 
+// SetTabSize sets the number of columns between tab stops. Values below 1
+// restore the default.
+func (r *Renderer) SetTabSize(size int) {
+	if size < 1 {
+		size = layout.DefaultTabSize
+	}
+	r.tabSize = size
+}
+
+// SetGuide marks column as the preferred maximum line length: blank cells
+// in that column are shaded. Zero removes the guide.
+func (r *Renderer) SetGuide(column int) {
+	r.guide = max(column, 0)
+}
+
+// SetExtraCursors sets the cursors drawn besides the terminal's own, for
+// editing at several places at once.
+func (r *Renderer) SetExtraCursors(cursors []buffer.Position) {
+	r.cursors = cursors
+}
+
+// SetGutterMarks sets the markers drawn in the gutter beside each line that
+// has some, keyed by line, one per column from the gutter's left edge. The
+// layout's gutter must be wide enough to show them.
+func (r *Renderer) SetGutterMarks(marks map[int]string) {
+	r.marks = marks
+}
+
+// TabSize returns the number of columns between tab stops.
+func (r *Renderer) TabSize() int {
+	return r.tabSize
+}
+
 // Clear clears the entire screen.
 func (r *Renderer) Clear() {
 	r.screen.Clear()
@@ -60,12 +102,11 @@ func (r *Renderer) RenderAll(buf *buffer.Buffer, cursorPos buffer.Position, file
 		return err
 	}
 
+	// Render the completion popup over the text
+	r.renderPopup(buf, cursorPos)
+
 	// Show cursor
-	viewport := r.layout.CalculateViewport(cursorPos.Line, buf.LineCount())
-	screenX, screenY := r.layout.BufferToScreen(cursorPos.Line, cursorPos.Col, viewport)
-	if screenX >= 0 && screenY >= 0 {
-		r.screen.ShowCursor(screenX, screenY)
-	}
+	r.showCursor(buf, cursorPos)
 
 	return r.Refresh()
 }
@@ -94,6 +135,9 @@ func (r *Renderer) RenderAllWithMenu(buf *buffer.Buffer, cursorPos buffer.Positi
 		return err
 	}
 
+	// Render the completion popup over the text
+	r.renderPopup(buf, cursorPos)
+
 	// Render dropdown menu AFTER everything else so it's on top
 	if err := r.RenderDropdownMenu(menuBar); err != nil {
 		return err
@@ -101,11 +145,7 @@ func (r *Renderer) RenderAllWithMenu(buf *buffer.Buffer, cursorPos buffer.Positi
 
 	// Show cursor only if menu is not open
 	if !menuBar.IsOpen() {
-		viewport := r.layout.CalculateViewport(cursorPos.Line, buf.LineCount())
-		screenX, screenY := r.layout.BufferToScreen(cursorPos.Line, cursorPos.Col, viewport)
-		if screenX >= 0 && screenY >= 0 {
-			r.screen.ShowCursor(screenX, screenY)
-		}
+		r.showCursor(buf, cursorPos)
 	} else {
 		r.screen.HideCursor()
 	}
@@ -113,6 +153,20 @@ func (r *Renderer) RenderAllWithMenu(buf *buffer.Buffer, cursorPos buffer.Positi
 	return r.Refresh()
 }
 
+// showCursor places the terminal cursor at cursorPos, allowing for tabs
+// before it on its line.
+func (r *Renderer) showCursor(buf *buffer.Buffer, cursorPos buffer.Position) {
+	viewport := r.layout.CalculateViewport(cursorPos.Line, buf.LineCount())
+	col := cursorPos.Col
+	if line, err := buf.GetLine(cursorPos.Line); err == nil {
+		col = layout.DisplayColumn(line, col, r.tabSize)
+	}
+	screenX, screenY := r.layout.BufferToScreen(cursorPos.Line, col, viewport)
+	if screenX >= 0 && screenY >= 0 {
+		r.screen.ShowCursor(screenX, screenY)
+	}
+}
+
 // fillScreen fills the entire screen with the default background color.
 func (r *Renderer) fillScreen() error {
 	screenWidth, screenHeight := r.screen.GetSize()
@@ -169,6 +223,11 @@ func GetInfoBarStyle() tcell.Style {
 		Foreground(tcell.Color235)  // Dark gray text (#1e1e1e)
 }
 
+// GetGuideStyle returns the style for the line length guide.
+func GetGuideStyle() tcell.Style {
+	return GetDefaultStyle().Background(tcell.Color237) // Slightly lighter than the text area
+}
+
 // GetLineNumberStyle returns the style for line numbers.
 func GetLineNumberStyle() tcell.Style {
 	return tcell.StyleDefault.
@@ -189,3 +248,36 @@ func GetCursorStyle() tcell.Style {
 		Foreground(tcell.Color235). // Dark background
 		Background(tcell.Color255)  // White cursor (#ffffff)
 }
+
+// GetSearchMatchStyle returns the style for search matches.
+func GetSearchMatchStyle() tcell.Style {
+	return tcell.StyleDefault.
+		Foreground(tcell.Color252).
+		Background(tcell.Color58) // Muted olive (#5f5f00)
+}
+
+// GetCurrentMatchStyle returns the style for the current search match.
+func GetCurrentMatchStyle() tcell.Style {
+	return tcell.StyleDefault.
+		Foreground(tcell.Color235). // Dark text
+		Background(tcell.Color214)  // Orange (#ffaf00)
+}
+
+// GetPopupStyle returns the style for completions in the popup.
+func GetPopupStyle() tcell.Style {
+	return tcell.StyleDefault.
+		Foreground(tcell.Color252).
+		Background(tcell.Color238) // Lifted above the text area
+}
+
+// GetPopupDetailStyle returns the style for the notes beside completions.
+func GetPopupDetailStyle() tcell.Style {
+	return GetPopupStyle().Foreground(tcell.Color245) // Muted gray
+}
+
+// GetPopupSelectedStyle returns the style for the selected completion.
+func GetPopupSelectedStyle() tcell.Style {
+	return tcell.StyleDefault.
+		Foreground(tcell.Color255).
+		Background(tcell.Color25) // Blue (#005faf)
+}
@@ -69,10 +69,22 @@ func (m *mockScreen) PollEvent() tcell.Event {
 	return nil // Not used in tests
 }
 
+func (m *mockScreen) PostEvent(ev tcell.Event) error {
+	return nil // Not used in tests
+}
+
 func (m *mockScreen) Fini() {
 	// No-op for mock
 }
 
+func (m *mockScreen) Suspend() error {
+	return nil
+}
+
+func (m *mockScreen) Resume() error {
+	return nil
+}
+
 func (m *mockScreen) GetRawScreen() tcell.Screen {
 	// Return nil for mock - dialogs won't be tested here
 	return nil
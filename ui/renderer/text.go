@@ -2,13 +2,17 @@
 package renderer
 
 import (
+	"slices"
 	"strconv"
+	"unicode/utf8"
 
 	"github.com/AndrewDonelson/ted/core/buffer"
+	"github.com/gdamore/tcell/v2"
 )
 
 // RenderTextArea renders the buffer text in the edit area.
-// It handles scrolling based on the viewport and highlights the current line.
+// It handles scrolling based on the viewport, skipping hidden lines, and
+// highlights the current line.
 func (r *Renderer) RenderTextArea(buf *buffer.Buffer, cursorPos buffer.Position) error {
 	editRegion := r.layout.GetEditAreaRegion()
 	viewport := r.layout.CalculateViewport(cursorPos.Line, buf.LineCount())
@@ -18,7 +22,8 @@ func (r *Renderer) RenderTextArea(buf *buffer.Buffer, cursorPos buffer.Position)
 
 	// Render visible lines
 	for viewLine := 0; viewLine < viewport.Height; viewLine++ {
-		bufferLine := viewport.StartLine + viewLine
+		bufferLine := r.layout.LineAtRow(viewport, viewLine)
+		r.renderGutter(editRegion.Y+viewLine, bufferLine)
 
 		// Check if we've exceeded the buffer
 		if bufferLine >= buf.LineCount() {
@@ -43,18 +48,7 @@ func (r *Renderer) RenderTextArea(buf *buffer.Buffer, cursorPos buffer.Position)
 		}
 
 		// Render line content
-		x := editRegion.X
-		for i, char := range lineText {
-			if i >= editRegion.Width {
-				break // Line too long, truncate
-			}
-			r.screen.SetContent(x+i, editRegion.Y+viewLine, char, nil, lineStyle)
-		}
-
-		// Fill remaining space in line with background
-		for x := len(lineText); x < editRegion.Width; x++ {
-			r.screen.SetContent(editRegion.X+x, editRegion.Y+viewLine, ' ', nil, lineStyle)
-		}
+		r.renderLine(editRegion.X, editRegion.Y+viewLine, editRegion.Width, bufferLine, lineText, lineStyle)
 	}
 
 	return nil
@@ -80,7 +74,7 @@ func (r *Renderer) RenderTextAreaWithLineNumbers(buf *buffer.Buffer, cursorPos b
 
 	// Render visible lines
 	for viewLine := 0; viewLine < viewport.Height; viewLine++ {
-		bufferLine := viewport.StartLine + viewLine
+		bufferLine := r.layout.LineAtRow(viewport, viewLine)
 
 		// Render line numbers if enabled
 		if showLineNumbers {
@@ -119,21 +113,81 @@ func (r *Renderer) RenderTextAreaWithLineNumbers(buf *buffer.Buffer, cursorPos b
 		}
 
 		// Render line content
-		x := editRegion.X
-		for i, char := range lineText {
-			if i >= editRegion.Width {
-				break
-			}
-			r.screen.SetContent(x+i, editRegion.Y+viewLine, char, nil, lineStyle)
+		r.renderLine(editRegion.X, editRegion.Y+viewLine, editRegion.Width, bufferLine, lineText, lineStyle)
+	}
+
+	return nil
+}
+
+// renderGutter draws the gutter beside a buffer line at row y: the line's
+// markers, if any, then blank cells.
+func (r *Renderer) renderGutter(y, bufferLine int) {
+	gutter := r.layout.GetGutterRegion()
+	style := GetLineNumberStyle()
+	marks := []rune(r.marks[bufferLine])
+	for x := 0; x < gutter.Width; x++ {
+		char := ' '
+		if x < len(marks) {
+			char = marks[x]
+		}
+		r.screen.SetContent(gutter.X+x, y, char, nil, style)
+	}
+}
+
+// renderLine draws one buffer line at (x, y), truncated to width columns.
+// Tabs are drawn as spaces up to the next tab stop.
+func (r *Renderer) renderLine(x, y, width, bufferLine int, lineText string, lineStyle tcell.Style) {
+	spans := r.lineSpans(bufferLine, len(lineText))
+	var cursorCols []int
+	for _, c := range r.cursors {
+		if c.Line == bufferLine {
+			cursorCols = append(cursorCols, c.Col)
+		}
+	}
+	cellStyle := func(i int) tcell.Style {
+		if slices.Contains(cursorCols, i) {
+			return GetCursorStyle()
 		}
+		return styleAt(spans, i, lineStyle)
+	}
 
-		// Fill remaining space in line
-		for x := len(lineText); x < editRegion.Width; x++ {
-			r.screen.SetContent(editRegion.X+x, editRegion.Y+viewLine, ' ', nil, lineStyle)
+	col := 0
+	for i := 0; i < len(lineText); {
+		if col >= width {
+			return // Line too long, truncate
+		}
+		char, size := utf8.DecodeRuneInString(lineText[i:])
+		style := cellStyle(i)
+		i += size
+		if char == '\t' {
+			next := col + r.tabSize - col%r.tabSize
+			for ; col < next && col < width; col++ {
+				r.screen.SetContent(x+col, y, ' ', nil, r.guideStyle(col, style, lineStyle))
+			}
+			continue
 		}
+		if char == ' ' {
+			style = r.guideStyle(col, style, lineStyle)
+		}
+		r.screen.SetContent(x+col, y, char, nil, style)
+		col += size // One column per byte, as the cursor is placed
 	}
 
-	return nil
+	// Fill remaining space in line with background
+	// (a match spanning the line break highlights the first cell, as does
+	// a cursor at the end of the line)
+	for i := len(lineText); col < width; i, col = i+1, col+1 {
+		r.screen.SetContent(x+col, y, ' ', nil, r.guideStyle(col, cellStyle(i), lineStyle))
+	}
+}
+
+// guideStyle returns the style for a blank cell at col: the guide's if the
+// guide is at col and the cell has the line's own style, otherwise style.
+func (r *Renderer) guideStyle(col int, style, lineStyle tcell.Style) tcell.Style {
+	if r.guide > 0 && col == r.guide && style == lineStyle {
+		return GetGuideStyle()
+	}
+	return style
 }
 
 // formatLineNumber formats a line number with right alignment.
@@ -277,3 +277,161 @@ func TestRenderTextArea_FillEmptySpace(t *testing.T) {
 		}
 	}
 }
+
+func TestRenderTextArea_Tabs(t *testing.T) {
+	mockScr := newMockScreen(80, 24)
+	l := layout.NewLayout(80, 24)
+	renderer := NewRenderer(mockScr, l)
+
+	buf := buffer.NewBuffer()
+	buf.SetLines([]string{"\tx", "ab\ty"})
+
+	tests := []struct {
+		tabSize int
+		want    [2]string
+	}{
+		{4, [2]string{"    x", "ab  y"}},
+		{8, [2]string{"        x", "ab      y"}},
+	}
+	for _, tt := range tests {
+		renderer.SetTabSize(tt.tabSize)
+		if err := renderer.RenderAll(buf, buffer.Position{Line: 1, Col: 3}, &FileInfo{}); err != nil {
+			t.Fatalf("RenderAll() error = %v", err)
+		}
+
+		y := l.GetEditAreaRegion().Y
+		for line, want := range tt.want {
+			row := mockScr.contents[y+line]
+			got := make([]rune, len(want))
+			for x := range got {
+				got[x] = row[x]
+			}
+			if string(got) != want {
+				t.Errorf("tab size %d: line %d = %q, want %q", tt.tabSize, line, string(got), want)
+			}
+		}
+
+		// The cursor on "y" is placed after the expanded tab
+		if wantX := len(tt.want[1]) - 1; mockScr.cursorX != wantX || mockScr.cursorY != y+1 {
+			t.Errorf("tab size %d: cursor at (%d, %d), want (%d, %d)", tt.tabSize, mockScr.cursorX, mockScr.cursorY, wantX, y+1)
+		}
+	}
+}
+
+func TestRenderTextArea_Guide(t *testing.T) {
+	mockScr := newMockScreen(80, 24)
+	l := layout.NewLayout(80, 24)
+	renderer := NewRenderer(mockScr, l)
+	renderer.SetGuide(4)
+
+	buf := buffer.NewBuffer()
+	buf.SetLines([]string{"ab", "abcdef", "a\t\tb", "other"})
+	if err := renderer.RenderAll(buf, buffer.Position{Line: 3, Col: 0}, &FileInfo{}); err != nil {
+		t.Fatalf("RenderAll() error = %v", err)
+	}
+
+	region := l.GetEditAreaRegion()
+	tests := []struct {
+		name string
+		line int
+		want bool
+	}{
+		{"blank after short line", 0, true},
+		{"text over the guide", 1, false},
+		{"expanded tab", 2, true},
+		{"current line", 3, false},
+	}
+	for _, tt := range tests {
+		style := mockScr.styles[region.Y+tt.line][region.X+4]
+		if got := style == GetGuideStyle(); got != tt.want {
+			t.Errorf("%s: guide drawn = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	renderer.SetGuide(0)
+	if err := renderer.RenderAll(buf, buffer.Position{Line: 3, Col: 0}, &FileInfo{}); err != nil {
+		t.Fatalf("RenderAll() error = %v", err)
+	}
+	if mockScr.styles[region.Y][region.X+4] == GetGuideStyle() {
+		t.Error("SetGuide(0) still draws the guide")
+	}
+}
+
+func TestRenderTextArea_ExtraCursors(t *testing.T) {
+	mockScr := newMockScreen(80, 24)
+	l := layout.NewLayout(80, 24)
+	renderer := NewRenderer(mockScr, l)
+	renderer.SetExtraCursors([]buffer.Position{{Line: 1, Col: 2}, {Line: 2, Col: 3}})
+
+	buf := buffer.NewBuffer()
+	buf.SetLines([]string{"abc", "abc", "abc"})
+	if err := renderer.RenderTextArea(buf, buffer.Position{Line: 0, Col: 0}); err != nil {
+		t.Fatalf("RenderTextArea() error = %v", err)
+	}
+
+	region := l.GetEditAreaRegion()
+	tests := []struct {
+		name      string
+		line, col int
+		want      bool
+	}{
+		{"on a character", 1, 2, true},
+		{"at the end of the line", 2, 3, true},
+		{"main cursor", 0, 0, false},
+		{"elsewhere", 1, 1, false},
+	}
+	for _, tt := range tests {
+		style := mockScr.styles[region.Y+tt.line][region.X+tt.col]
+		if got := style == GetCursorStyle(); got != tt.want {
+			t.Errorf("%s: cursor drawn = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRenderTextArea_GutterMarks(t *testing.T) {
+	mockScr := newMockScreen(80, 24)
+	l := layout.NewLayout(80, 24)
+	l.SetGutterWidth(2)
+	renderer := NewRenderer(mockScr, l)
+	renderer.SetGutterMarks(map[int]string{1: "3"})
+
+	buf := buffer.NewBuffer()
+	buf.SetLines([]string{"abc", "def"})
+	if err := renderer.RenderTextArea(buf, buffer.Position{Line: 0, Col: 0}); err != nil {
+		t.Fatalf("RenderTextArea() error = %v", err)
+	}
+
+	region := l.GetEditAreaRegion()
+	if got := mockScr.contents[region.Y+1][0]; got != '3' {
+		t.Errorf("gutter of line 1 = %q, want %q", got, '3')
+	}
+	if got := mockScr.contents[region.Y][0]; got != ' ' {
+		t.Errorf("gutter of line 0 = %q, want blank", got)
+	}
+	if got := mockScr.contents[region.Y+1][region.X]; got != 'd' {
+		t.Errorf("text of line 1 starts with %q, want %q right of the gutter", got, 'd')
+	}
+}
+
+func TestRenderTextArea_HiddenLines(t *testing.T) {
+	mockScr := newMockScreen(80, 24)
+	l := layout.NewLayout(80, 24)
+	l.SetGutterWidth(2)
+	l.SetHiddenLines([]layout.LineRange{{First: 1, Last: 2}})
+	renderer := NewRenderer(mockScr, l)
+	renderer.SetGutterMarks(map[int]string{0: " ▸"})
+
+	buf := buffer.NewBuffer()
+	buf.SetLines([]string{"a {", "b", "c", "}"})
+	if err := renderer.RenderTextArea(buf, buffer.Position{Line: 0, Col: 0}); err != nil {
+		t.Fatalf("RenderTextArea() error = %v", err)
+	}
+
+	region := l.GetEditAreaRegion()
+	if got := mockScr.contents[region.Y][1]; got != '▸' {
+		t.Errorf("second gutter column of line 0 = %q, want %q", got, '▸')
+	}
+	if got := mockScr.contents[region.Y+1][region.X]; got != '}' {
+		t.Errorf("second row starts with %q, want %q from the line after the hidden ones", got, '}')
+	}
+}
@@ -65,8 +65,14 @@ const (
 	KeyActionFind
 	// KeyActionReplace represents Ctrl+H (replace).
 	KeyActionReplace
+	// KeyActionFindInFiles represents Ctrl+Shift+F (find in files).
+	KeyActionFindInFiles
+	// KeyActionReplaceInFiles represents Ctrl+Shift+H (replace in files).
+	KeyActionReplaceInFiles
 	// KeyActionGoToLine represents Ctrl+G (go to line).
 	KeyActionGoToLine
+	// KeyActionGoToSymbol represents Ctrl+Shift+O (go to symbol).
+	KeyActionGoToSymbol
 	// KeyActionToggleLineNumbers represents Ctrl+L (toggle line numbers).
 	KeyActionToggleLineNumbers
 	// KeyActionHelp represents F1 (help).
@@ -100,6 +106,92 @@ const (
 	KeyActionPageUp
 	// KeyActionPageDown represents Page Down key.
 	KeyActionPageDown
+	// KeyActionSaveAs represents Ctrl+Shift+S (save as).
+	KeyActionSaveAs
+	// KeyActionClose represents Ctrl+W (close file).
+	KeyActionClose
+	// KeyActionToggleReadOnly toggles read-only mode (no default key).
+	KeyActionToggleReadOnly
+	// KeyActionToggleWordWrap toggles word wrap (no default key).
+	KeyActionToggleWordWrap
+	// KeyActionAbout shows information about ted (no default key).
+	KeyActionAbout
+	// KeyActionCommandPalette represents Ctrl+Shift+P (command palette).
+	KeyActionCommandPalette
+	// KeyActionIndent represents Tab (indent).
+	KeyActionIndent
+	// KeyActionOutdent represents Shift+Tab (outdent).
+	KeyActionOutdent
+	// KeyActionIndentation changes how the file is indented (no default key).
+	KeyActionIndentation
+	// KeyActionAddNextOccurrence represents Alt+D (select the word, then
+	// add a cursor at its next occurrence).
+	KeyActionAddNextOccurrence
+	// KeyActionAddCursorAbove represents Ctrl+Alt+Up (add a cursor above).
+	KeyActionAddCursorAbove
+	// KeyActionAddCursorBelow represents Ctrl+Alt+Down (add a cursor below).
+	KeyActionAddCursorBelow
+	// KeyActionBlockSelectLeft represents Alt+Shift+Left (extend block selection left).
+	KeyActionBlockSelectLeft
+	// KeyActionBlockSelectRight represents Alt+Shift+Right (extend block selection right).
+	KeyActionBlockSelectRight
+	// KeyActionBlockSelectUp represents Alt+Shift+Up (extend block selection up).
+	KeyActionBlockSelectUp
+	// KeyActionBlockSelectDown represents Alt+Shift+Down (extend block selection down).
+	KeyActionBlockSelectDown
+	// KeyActionRecordMacro represents Ctrl+Shift+R (start or stop recording a macro).
+	KeyActionRecordMacro
+	// KeyActionPlayMacro represents Ctrl+R (play the last macro).
+	KeyActionPlayMacro
+	// KeyActionPlayMacroTimes plays the last macro a number of times (no default key).
+	KeyActionPlayMacroTimes
+	// KeyActionPlayMacroOnLines plays the last macro on each selected line (no default key).
+	KeyActionPlayMacroOnLines
+	// KeyActionSaveMacro saves the last macro by name (no default key).
+	KeyActionSaveMacro
+	// KeyActionPlayNamedMacro plays a macro saved by name (no default key).
+	KeyActionPlayNamedMacro
+	// KeyActionDocumentStart represents Ctrl+Home (go to the start of the document).
+	KeyActionDocumentStart
+	// KeyActionDocumentEnd represents Ctrl+End (go to the end of the document).
+	KeyActionDocumentEnd
+	// KeyActionJumpBack represents Alt+Left (go back to where the cursor was before a jump).
+	KeyActionJumpBack
+	// KeyActionJumpForward represents Alt+Right (go forward again after going back).
+	KeyActionJumpForward
+	// KeyActionToggleBookmark represents Ctrl+F2 (toggle a numbered bookmark on the line).
+	KeyActionToggleBookmark
+	// KeyActionSetBookmark sets a named bookmark at the cursor (no default key).
+	KeyActionSetBookmark
+	// KeyActionNextBookmark represents F2 (go to the next bookmark).
+	KeyActionNextBookmark
+	// KeyActionPreviousBookmark represents Shift+F2 (go to the previous bookmark).
+	KeyActionPreviousBookmark
+	// KeyActionGoToBookmark lists the bookmarks to go to one (no default key).
+	KeyActionGoToBookmark
+	// KeyActionGoToBookmark1 to KeyActionGoToBookmark9 represent Alt+1 to
+	// Alt+9 (go to a numbered bookmark). They are consecutive.
+	KeyActionGoToBookmark1
+	KeyActionGoToBookmark2
+	KeyActionGoToBookmark3
+	KeyActionGoToBookmark4
+	KeyActionGoToBookmark5
+	KeyActionGoToBookmark6
+	KeyActionGoToBookmark7
+	KeyActionGoToBookmark8
+	KeyActionGoToBookmark9
+	// KeyActionToggleFold folds or unfolds the block at the cursor (no default key).
+	KeyActionToggleFold
+	// KeyActionFold represents Alt+- (fold the block at the cursor).
+	KeyActionFold
+	// KeyActionUnfold represents Alt+= (unfold the fold at the cursor).
+	KeyActionUnfold
+	// KeyActionFoldAll represents Alt+_ (fold every block).
+	KeyActionFoldAll
+	// KeyActionUnfoldAll represents Alt++ (unfold every block).
+	KeyActionUnfoldAll
+	// KeyActionComplete represents Ctrl+Space (show completions for the word at the cursor).
+	KeyActionComplete
 )
 
 // KeyEvent represents a processed keyboard event.
@@ -108,124 +200,24 @@ type KeyEvent struct {
 	Character rune
 	Key       tcell.Key
 	Modifiers tcell.ModMask
+	Pending   bool   // The key started a chord; more keys are expected
+	Chord     string // Keys of an unfinished or unbound chord, e.g. "Ctrl+K"
 }
 
-// ProcessEvent processes a tcell event and converts it to a KeyEvent.
-// Returns nil if the event is not a keyboard event or is not handled.
+// ProcessEvent processes a tcell event and converts it to a KeyEvent using
+// the default keymap. Returns nil if the event is not a keyboard event.
 func ProcessEvent(ev tcell.Event) *KeyEvent {
-	switch ev := ev.(type) {
-	case *tcell.EventKey:
-		return processKeyEvent(ev)
-	default:
-		return nil
-	}
+	return defaultKeymap.Process(ContextEditor, ev)
 }
 
-// processKeyEvent processes a tcell key event.
-func processKeyEvent(ev *tcell.EventKey) *KeyEvent {
+// unboundKeyEvent handles a key with no binding: characters are typed and
+// Alt+letter opens menus.
+func unboundKeyEvent(ev *tcell.EventKey) *KeyEvent {
 	key := ev.Key()
 	modifiers := ev.Modifiers()
 	r := ev.Rune()
 
-	// Handle special keys first
-	switch key {
-	case tcell.KeyEscape:
-		return &KeyEvent{Action: KeyActionEscape, Key: key, Modifiers: modifiers}
-	case tcell.KeyF1:
-		return &KeyEvent{Action: KeyActionHelp, Key: key, Modifiers: modifiers}
-	case tcell.KeyF10:
-		return &KeyEvent{Action: KeyActionMenuToggle, Key: key, Modifiers: modifiers}
-	case tcell.KeyCtrlS:
-		return &KeyEvent{Action: KeyActionSave, Key: key, Modifiers: modifiers}
-	case tcell.KeyCtrlQ:
-		return &KeyEvent{Action: KeyActionQuit, Key: key, Modifiers: modifiers}
-	case tcell.KeyCtrlZ:
-		return &KeyEvent{Action: KeyActionUndo, Key: key, Modifiers: modifiers}
-	case tcell.KeyCtrlY:
-		return &KeyEvent{Action: KeyActionRedo, Key: key, Modifiers: modifiers}
-	case tcell.KeyCtrlX:
-		return &KeyEvent{Action: KeyActionCut, Key: key, Modifiers: modifiers}
-	case tcell.KeyCtrlC:
-		return &KeyEvent{Action: KeyActionCopy, Key: key, Modifiers: modifiers}
-	case tcell.KeyCtrlV:
-		return &KeyEvent{Action: KeyActionPaste, Key: key, Modifiers: modifiers}
-	case tcell.KeyCtrlA:
-		return &KeyEvent{Action: KeyActionSelectAll, Key: key, Modifiers: modifiers}
-	case tcell.KeyCtrlN:
-		return &KeyEvent{Action: KeyActionNew, Key: key, Modifiers: modifiers}
-	case tcell.KeyCtrlO:
-		return &KeyEvent{Action: KeyActionOpen, Key: key, Modifiers: modifiers}
-	case tcell.KeyCtrlF:
-		return &KeyEvent{Action: KeyActionFind, Key: key, Modifiers: modifiers}
-	case tcell.KeyCtrlH:
-		return &KeyEvent{Action: KeyActionReplace, Key: key, Modifiers: modifiers}
-	case tcell.KeyCtrlG:
-		return &KeyEvent{Action: KeyActionGoToLine, Key: key, Modifiers: modifiers}
-	case tcell.KeyCtrlL:
-		return &KeyEvent{Action: KeyActionToggleLineNumbers, Key: key, Modifiers: modifiers}
-	case tcell.KeyLeft:
-		if modifiers&tcell.ModCtrl != 0 {
-			return &KeyEvent{Action: KeyActionWordLeft, Key: key, Modifiers: modifiers}
-		}
-		if modifiers&tcell.ModShift != 0 {
-			return &KeyEvent{Action: KeyActionSelectLeft, Key: key, Modifiers: modifiers}
-		}
-		return &KeyEvent{Action: KeyActionMoveLeft, Key: key, Modifiers: modifiers}
-	case tcell.KeyRight:
-		if modifiers&tcell.ModCtrl != 0 {
-			return &KeyEvent{Action: KeyActionWordRight, Key: key, Modifiers: modifiers}
-		}
-		if modifiers&tcell.ModShift != 0 {
-			return &KeyEvent{Action: KeyActionSelectRight, Key: key, Modifiers: modifiers}
-		}
-		return &KeyEvent{Action: KeyActionMoveRight, Key: key, Modifiers: modifiers}
-	case tcell.KeyUp:
-		if modifiers&tcell.ModAlt != 0 {
-			return &KeyEvent{Action: KeyActionMoveLineUp, Key: key, Modifiers: modifiers}
-		}
-		if modifiers&tcell.ModShift != 0 {
-			return &KeyEvent{Action: KeyActionSelectUp, Key: key, Modifiers: modifiers}
-		}
-		return &KeyEvent{Action: KeyActionMoveUp, Key: key, Modifiers: modifiers}
-	case tcell.KeyDown:
-		if modifiers&tcell.ModAlt != 0 {
-			return &KeyEvent{Action: KeyActionMoveLineDown, Key: key, Modifiers: modifiers}
-		}
-		if modifiers&tcell.ModShift != 0 {
-			return &KeyEvent{Action: KeyActionSelectDown, Key: key, Modifiers: modifiers}
-		}
-		return &KeyEvent{Action: KeyActionMoveDown, Key: key, Modifiers: modifiers}
-	case tcell.KeyBackspace, tcell.KeyBackspace2:
-		return &KeyEvent{Action: KeyActionBackspace, Key: key, Modifiers: modifiers}
-	case tcell.KeyDelete:
-		return &KeyEvent{Action: KeyActionDelete, Key: key, Modifiers: modifiers}
-	case tcell.KeyEnter:
-		return &KeyEvent{Action: KeyActionEnter, Key: key, Modifiers: modifiers, Character: '\n'}
-	case tcell.KeyHome:
-		return &KeyEvent{Action: KeyActionHome, Key: key, Modifiers: modifiers}
-	case tcell.KeyEnd:
-		return &KeyEvent{Action: KeyActionEnd, Key: key, Modifiers: modifiers}
-	case tcell.KeyPgUp:
-		return &KeyEvent{Action: KeyActionPageUp, Key: key, Modifiers: modifiers}
-	case tcell.KeyPgDn:
-		return &KeyEvent{Action: KeyActionPageDown, Key: key, Modifiers: modifiers}
-	case tcell.KeyCtrlK:
-		// Ctrl+Shift+K for delete line
-		if modifiers&tcell.ModShift != 0 {
-			return &KeyEvent{Action: KeyActionDeleteLine, Key: key, Modifiers: modifiers}
-		}
-		return &KeyEvent{Action: KeyActionNone, Key: key, Modifiers: modifiers}
-	case tcell.KeyCtrlD:
-		return &KeyEvent{Action: KeyActionDuplicateLine, Key: key, Modifiers: modifiers}
-	case tcell.KeyCtrlJ:
-		// Ctrl+J for insert line below, Ctrl+Shift+J for insert line above
-		// Note: We'll also handle Ctrl+Enter in the main loop since tcell
-		// may treat it differently
-		if modifiers&tcell.ModShift != 0 {
-			return &KeyEvent{Action: KeyActionInsertLineAbove, Key: key, Modifiers: modifiers}
-		}
-		return &KeyEvent{Action: KeyActionInsertLineBelow, Key: key, Modifiers: modifiers}
-	case tcell.KeyRune:
+	if key == tcell.KeyRune {
 		// Check for Alt+key combinations (for menu shortcuts)
 		if modifiers&tcell.ModAlt != 0 && r != 0 {
 			upperR := unicode.ToUpper(r)
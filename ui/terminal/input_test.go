@@ -35,6 +35,34 @@ func TestProcessEvent(t *testing.T) {
 			wantChar:   0,
 			wantNil:    false,
 		},
+		{
+			name:       "Ctrl+F",
+			ev:         tcell.NewEventKey(tcell.KeyCtrlF, 0, tcell.ModCtrl),
+			wantAction: KeyActionFind,
+			wantChar:   0,
+			wantNil:    false,
+		},
+		{
+			name:       "Ctrl+Shift+F",
+			ev:         tcell.NewEventKey(tcell.KeyCtrlF, 0, tcell.ModCtrl|tcell.ModShift),
+			wantAction: KeyActionFindInFiles,
+			wantChar:   0,
+			wantNil:    false,
+		},
+		{
+			name:       "Ctrl+Shift+O",
+			ev:         tcell.NewEventKey(tcell.KeyCtrlO, 0, tcell.ModCtrl|tcell.ModShift),
+			wantAction: KeyActionGoToSymbol,
+			wantChar:   0,
+			wantNil:    false,
+		},
+		{
+			name:       "Ctrl+Shift+H",
+			ev:         tcell.NewEventKey(tcell.KeyCtrlH, 0, tcell.ModCtrl|tcell.ModShift),
+			wantAction: KeyActionReplaceInFiles,
+			wantChar:   0,
+			wantNil:    false,
+		},
 		{
 			name:       "arrow left",
 			ev:         tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModNone),
@@ -0,0 +1,544 @@
+// Package terminal implements configurable keybindings.
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Context is where a key is pressed. Each context has its own bindings.
+type Context string
+
+const (
+	// ContextEditor is the text being edited.
+	ContextEditor Context = "editor"
+	// ContextMenu is an open menu.
+	ContextMenu Context = "menu"
+	// ContextDialog is an open dialog.
+	ContextDialog Context = "dialog"
+)
+
+// contexts lists the contexts in cheat sheet order.
+var contexts = []Context{ContextEditor, ContextMenu, ContextDialog}
+
+// contextActions limits what the menu and dialog contexts can bind; the
+// editor context can bind every action.
+var contextActions = map[Context][]KeyAction{
+	ContextMenu: {
+		KeyActionMoveLeft, KeyActionMoveRight, KeyActionMoveUp, KeyActionMoveDown,
+		KeyActionEnter, KeyActionEscape,
+	},
+	ContextDialog: {KeyActionEnter, KeyActionEscape},
+}
+
+// actionInfo names an action in keybinding files and describes it in the
+// cheat sheet.
+type actionInfo struct {
+	action      KeyAction
+	name        string
+	description string
+}
+
+// actionTable lists the actions that can be bound, in cheat sheet order.
+var actionTable = []actionInfo{
+	{KeyActionNew, "new", "New file"},
+	{KeyActionOpen, "open", "Open file"},
+	{KeyActionSave, "save", "Save file"},
+	{KeyActionSaveAs, "save-as", "Save file as"},
+	{KeyActionClose, "close", "Close file"},
+	{KeyActionToggleReadOnly, "toggle-read-only", "Toggle read-only"},
+	{KeyActionQuit, "quit", "Quit"},
+	{KeyActionUndo, "undo", "Undo"},
+	{KeyActionRedo, "redo", "Redo"},
+	{KeyActionCut, "cut", "Cut"},
+	{KeyActionCopy, "copy", "Copy"},
+	{KeyActionPaste, "paste", "Paste"},
+	{KeyActionSelectAll, "select-all", "Select all"},
+	{KeyActionFind, "find", "Find"},
+	{KeyActionReplace, "replace", "Replace"},
+	{KeyActionFindInFiles, "find-in-files", "Find in files"},
+	{KeyActionReplaceInFiles, "replace-in-files", "Replace in files"},
+	{KeyActionGoToLine, "go-to-line", "Go to line"},
+	{KeyActionGoToSymbol, "go-to-symbol", "Go to symbol"},
+	{KeyActionDeleteLine, "delete-line", "Delete line"},
+	{KeyActionDuplicateLine, "duplicate-line", "Duplicate line"},
+	{KeyActionMoveLineUp, "move-line-up", "Move line up"},
+	{KeyActionMoveLineDown, "move-line-down", "Move line down"},
+	{KeyActionInsertLineAbove, "insert-line-above", "Insert line above"},
+	{KeyActionInsertLineBelow, "insert-line-below", "Insert line below"},
+	{KeyActionIndent, "indent", "Indent / insert tab"},
+	{KeyActionOutdent, "outdent", "Outdent"},
+	{KeyActionComplete, "complete", "Complete word"},
+	{KeyActionAddNextOccurrence, "add-next-occurrence", "Add cursor at next occurrence"},
+	{KeyActionAddCursorAbove, "add-cursor-above", "Add cursor on line above"},
+	{KeyActionAddCursorBelow, "add-cursor-below", "Add cursor on line below"},
+	{KeyActionMoveLeft, "move-left", "Move left"},
+	{KeyActionMoveRight, "move-right", "Move right"},
+	{KeyActionMoveUp, "move-up", "Move up"},
+	{KeyActionMoveDown, "move-down", "Move down"},
+	{KeyActionWordLeft, "word-left", "Previous word"},
+	{KeyActionWordRight, "word-right", "Next word"},
+	{KeyActionHome, "home", "Start of line"},
+	{KeyActionEnd, "end", "End of line"},
+	{KeyActionPageUp, "page-up", "Page up"},
+	{KeyActionPageDown, "page-down", "Page down"},
+	{KeyActionDocumentStart, "document-start", "Start of document"},
+	{KeyActionDocumentEnd, "document-end", "End of document"},
+	{KeyActionJumpBack, "jump-back", "Go back"},
+	{KeyActionJumpForward, "jump-forward", "Go forward"},
+	{KeyActionSelectLeft, "select-left", "Extend selection left"},
+	{KeyActionSelectRight, "select-right", "Extend selection right"},
+	{KeyActionSelectUp, "select-up", "Extend selection up"},
+	{KeyActionSelectDown, "select-down", "Extend selection down"},
+	{KeyActionBlockSelectLeft, "block-select-left", "Extend block selection left"},
+	{KeyActionBlockSelectRight, "block-select-right", "Extend block selection right"},
+	{KeyActionBlockSelectUp, "block-select-up", "Extend block selection up"},
+	{KeyActionBlockSelectDown, "block-select-down", "Extend block selection down"},
+	{KeyActionToggleBookmark, "toggle-bookmark", "Toggle bookmark"},
+	{KeyActionSetBookmark, "set-bookmark", "Set a named bookmark"},
+	{KeyActionNextBookmark, "next-bookmark", "Next bookmark"},
+	{KeyActionPreviousBookmark, "previous-bookmark", "Previous bookmark"},
+	{KeyActionGoToBookmark, "go-to-bookmark", "Go to bookmark"},
+	{KeyActionGoToBookmark1, "go-to-bookmark-1", "Go to bookmark 1"},
+	{KeyActionGoToBookmark2, "go-to-bookmark-2", "Go to bookmark 2"},
+	{KeyActionGoToBookmark3, "go-to-bookmark-3", "Go to bookmark 3"},
+	{KeyActionGoToBookmark4, "go-to-bookmark-4", "Go to bookmark 4"},
+	{KeyActionGoToBookmark5, "go-to-bookmark-5", "Go to bookmark 5"},
+	{KeyActionGoToBookmark6, "go-to-bookmark-6", "Go to bookmark 6"},
+	{KeyActionGoToBookmark7, "go-to-bookmark-7", "Go to bookmark 7"},
+	{KeyActionGoToBookmark8, "go-to-bookmark-8", "Go to bookmark 8"},
+	{KeyActionGoToBookmark9, "go-to-bookmark-9", "Go to bookmark 9"},
+	{KeyActionToggleFold, "toggle-fold", "Fold or unfold block"},
+	{KeyActionFold, "fold", "Fold block"},
+	{KeyActionUnfold, "unfold", "Unfold block"},
+	{KeyActionFoldAll, "fold-all", "Fold all blocks"},
+	{KeyActionUnfoldAll, "unfold-all", "Unfold all blocks"},
+	{KeyActionRecordMacro, "record-macro", "Start or stop recording a macro"},
+	{KeyActionPlayMacro, "play-macro", "Play the last macro"},
+	{KeyActionPlayMacroTimes, "play-macro-times", "Play the last macro several times"},
+	{KeyActionPlayMacroOnLines, "play-macro-on-lines", "Play the last macro on each selected line"},
+	{KeyActionSaveMacro, "save-macro", "Save the last macro by name"},
+	{KeyActionPlayNamedMacro, "play-named-macro", "Play a saved macro"},
+	{KeyActionBackspace, "backspace", "Delete character before cursor"},
+	{KeyActionDelete, "delete", "Delete character at cursor"},
+	{KeyActionEnter, "enter", "New line / confirm"},
+	{KeyActionEscape, "escape", "Cancel / close"},
+	{KeyActionToggleLineNumbers, "toggle-line-numbers", "Toggle line numbers"},
+	{KeyActionToggleWordWrap, "toggle-word-wrap", "Toggle word wrap"},
+	{KeyActionIndentation, "indentation", "Change indentation"},
+	{KeyActionCommandPalette, "command-palette", "Show all commands"},
+	{KeyActionMenuToggle, "menu", "Open or close the menu bar"},
+	{KeyActionHelp, "help", "Show keyboard shortcuts"},
+	{KeyActionAbout, "about", "About ted"},
+}
+
+// String returns the action's name as used in keybinding files.
+func (a KeyAction) String() string {
+	for _, info := range actionTable {
+		if info.action == a {
+			return info.name
+		}
+	}
+	switch a {
+	case KeyActionNone:
+		return "none"
+	case KeyActionCharacter:
+		return "character"
+	case KeyActionMenuAlt:
+		return "menu-alt"
+	}
+	return fmt.Sprintf("KeyAction(%d)", int(a))
+}
+
+// Description returns a short description of the action for the cheat
+// sheet and command lists.
+func (a KeyAction) Description() string {
+	for _, info := range actionTable {
+		if info.action == a {
+			return info.description
+		}
+	}
+	return a.String()
+}
+
+// ParseKeyAction returns the action with the given name, such as "save".
+func ParseKeyAction(name string) (KeyAction, bool) {
+	for _, info := range actionTable {
+		if info.name == name {
+			return info.action, true
+		}
+	}
+	return KeyActionNone, false
+}
+
+// binding ties a chord to an action.
+type binding struct {
+	chord  Chord
+	action KeyAction
+}
+
+// Keymap maps keys to actions, separately for each context. Bindings may
+// be chords of several keys, such as Ctrl+K Ctrl+C; Process remembers the
+// keys of an unfinished chord between calls.
+type Keymap struct {
+	bindings   map[Context]map[string]binding // Keyed by Chord.String()
+	pending    Chord                          // Keys of an unfinished chord
+	pendingCtx Context                        // Context the pending keys were pressed in
+}
+
+// defaultKeymap is used by ProcessEvent.
+var defaultKeymap = DefaultKeymap()
+
+// NewKeymap creates a keymap with no bindings.
+func NewKeymap() *Keymap {
+	km := &Keymap{bindings: make(map[Context]map[string]binding)}
+	for _, ctx := range contexts {
+		km.bindings[ctx] = make(map[string]binding)
+	}
+	return km
+}
+
+// DefaultKeymap creates a keymap with ted's standard bindings.
+func DefaultKeymap() *Keymap {
+	km := NewKeymap()
+	defaults := []struct {
+		ctx    Context
+		keys   string
+		action KeyAction
+	}{
+		{ContextEditor, "Ctrl+N", KeyActionNew},
+		{ContextEditor, "Ctrl+O", KeyActionOpen},
+		{ContextEditor, "Ctrl+S", KeyActionSave},
+		{ContextEditor, "Ctrl+Shift+S", KeyActionSaveAs},
+		{ContextEditor, "Ctrl+W", KeyActionClose},
+		{ContextEditor, "Ctrl+Q", KeyActionQuit},
+		{ContextEditor, "Ctrl+Z", KeyActionUndo},
+		{ContextEditor, "Ctrl+Y", KeyActionRedo},
+		{ContextEditor, "Ctrl+X", KeyActionCut},
+		{ContextEditor, "Ctrl+C", KeyActionCopy},
+		{ContextEditor, "Ctrl+V", KeyActionPaste},
+		{ContextEditor, "Ctrl+A", KeyActionSelectAll},
+		{ContextEditor, "Ctrl+F", KeyActionFind},
+		{ContextEditor, "Ctrl+H", KeyActionReplace},
+		{ContextEditor, "Ctrl+Shift+F", KeyActionFindInFiles},
+		{ContextEditor, "Ctrl+Shift+H", KeyActionReplaceInFiles},
+		{ContextEditor, "Ctrl+G", KeyActionGoToLine},
+		{ContextEditor, "Ctrl+Shift+O", KeyActionGoToSymbol},
+		{ContextEditor, "Ctrl+Shift+K", KeyActionDeleteLine},
+		{ContextEditor, "Ctrl+D", KeyActionDuplicateLine},
+		{ContextEditor, "Alt+Up", KeyActionMoveLineUp},
+		{ContextEditor, "Alt+Down", KeyActionMoveLineDown},
+		{ContextEditor, "Ctrl+Shift+J", KeyActionInsertLineAbove},
+		{ContextEditor, "Ctrl+J", KeyActionInsertLineBelow},
+		{ContextEditor, "Tab", KeyActionIndent},
+		{ContextEditor, "Shift+Tab", KeyActionOutdent},
+		{ContextEditor, "Ctrl+Space", KeyActionComplete},
+		{ContextEditor, "Alt+D", KeyActionAddNextOccurrence},
+		{ContextEditor, "Ctrl+Alt+Up", KeyActionAddCursorAbove},
+		{ContextEditor, "Ctrl+Alt+Down", KeyActionAddCursorBelow},
+		{ContextEditor, "Left", KeyActionMoveLeft},
+		{ContextEditor, "Right", KeyActionMoveRight},
+		{ContextEditor, "Up", KeyActionMoveUp},
+		{ContextEditor, "Down", KeyActionMoveDown},
+		{ContextEditor, "Ctrl+Left", KeyActionWordLeft},
+		{ContextEditor, "Ctrl+Right", KeyActionWordRight},
+		{ContextEditor, "Home", KeyActionHome},
+		{ContextEditor, "End", KeyActionEnd},
+		{ContextEditor, "PageUp", KeyActionPageUp},
+		{ContextEditor, "PageDown", KeyActionPageDown},
+		{ContextEditor, "Ctrl+Home", KeyActionDocumentStart},
+		{ContextEditor, "Ctrl+End", KeyActionDocumentEnd},
+		{ContextEditor, "Alt+Left", KeyActionJumpBack},
+		{ContextEditor, "Alt+Right", KeyActionJumpForward},
+		{ContextEditor, "Shift+Left", KeyActionSelectLeft},
+		{ContextEditor, "Shift+Right", KeyActionSelectRight},
+		{ContextEditor, "Shift+Up", KeyActionSelectUp},
+		{ContextEditor, "Shift+Down", KeyActionSelectDown},
+		{ContextEditor, "Alt+Shift+Left", KeyActionBlockSelectLeft},
+		{ContextEditor, "Alt+Shift+Right", KeyActionBlockSelectRight},
+		{ContextEditor, "Alt+Shift+Up", KeyActionBlockSelectUp},
+		{ContextEditor, "Alt+Shift+Down", KeyActionBlockSelectDown},
+		{ContextEditor, "Ctrl+F2", KeyActionToggleBookmark},
+		{ContextEditor, "F2", KeyActionNextBookmark},
+		{ContextEditor, "Shift+F2", KeyActionPreviousBookmark},
+		{ContextEditor, "Alt+1", KeyActionGoToBookmark1},
+		{ContextEditor, "Alt+2", KeyActionGoToBookmark2},
+		{ContextEditor, "Alt+3", KeyActionGoToBookmark3},
+		{ContextEditor, "Alt+4", KeyActionGoToBookmark4},
+		{ContextEditor, "Alt+5", KeyActionGoToBookmark5},
+		{ContextEditor, "Alt+6", KeyActionGoToBookmark6},
+		{ContextEditor, "Alt+7", KeyActionGoToBookmark7},
+		{ContextEditor, "Alt+8", KeyActionGoToBookmark8},
+		{ContextEditor, "Alt+9", KeyActionGoToBookmark9},
+		{ContextEditor, "Alt+-", KeyActionFold},
+		{ContextEditor, "Alt+=", KeyActionUnfold},
+		{ContextEditor, "Alt+_", KeyActionFoldAll},
+		{ContextEditor, "Alt++", KeyActionUnfoldAll},
+		{ContextEditor, "Ctrl+Shift+R", KeyActionRecordMacro},
+		{ContextEditor, "Ctrl+R", KeyActionPlayMacro},
+		{ContextEditor, "Backspace", KeyActionBackspace},
+		{ContextEditor, "Delete", KeyActionDelete},
+		{ContextEditor, "Enter", KeyActionEnter},
+		{ContextEditor, "Esc", KeyActionEscape},
+		{ContextEditor, "Ctrl+L", KeyActionToggleLineNumbers},
+		{ContextEditor, "Ctrl+Shift+P", KeyActionCommandPalette},
+		{ContextEditor, "F10", KeyActionMenuToggle},
+		{ContextEditor, "F1", KeyActionHelp},
+		{ContextMenu, "Left", KeyActionMoveLeft},
+		{ContextMenu, "Right", KeyActionMoveRight},
+		{ContextMenu, "Up", KeyActionMoveUp},
+		{ContextMenu, "Down", KeyActionMoveDown},
+		{ContextMenu, "Enter", KeyActionEnter},
+		{ContextMenu, "Esc", KeyActionEscape},
+	}
+	for _, d := range defaults {
+		if err := km.Bind(d.ctx, d.keys, d.action); err != nil {
+			panic(err)
+		}
+	}
+	return km
+}
+
+// Bind binds keys, such as "Ctrl+S" or "Ctrl+K Ctrl+C", to an action in a
+// context, replacing any action the same keys had. It fails if the keys
+// cannot be parsed, the action cannot be bound in the context, or the
+// keys start another binding or another binding starts them: one of the
+// two could never be pressed.
+func (km *Keymap) Bind(ctx Context, keys string, action KeyAction) error {
+	bindings, ok := km.bindings[ctx]
+	if !ok {
+		return fmt.Errorf("unknown context %q", ctx)
+	}
+	if _, ok := ParseKeyAction(action.String()); !ok {
+		return fmt.Errorf("action %s cannot be bound", action)
+	}
+	if allowed, ok := contextActions[ctx]; ok && !slices.Contains(allowed, action) {
+		return fmt.Errorf("action %s cannot be bound in the %s context", action, ctx)
+	}
+	chord, err := ParseChord(keys)
+	if err != nil {
+		return err
+	}
+
+	text := chord.String()
+	for other, b := range bindings {
+		if strings.HasPrefix(other, text+" ") || strings.HasPrefix(text, other+" ") {
+			return fmt.Errorf("%s conflicts with %s (%s)", text, other, b.action)
+		}
+	}
+	bindings[text] = binding{chord: chord, action: action}
+	return nil
+}
+
+// Unbind removes the binding for keys in a context, if there is one.
+func (km *Keymap) Unbind(ctx Context, keys string) error {
+	bindings, ok := km.bindings[ctx]
+	if !ok {
+		return fmt.Errorf("unknown context %q", ctx)
+	}
+	chord, err := ParseChord(keys)
+	if err != nil {
+		return err
+	}
+	delete(bindings, chord.String())
+	return nil
+}
+
+// Pending returns the keys of an unfinished chord, or "" if there is none.
+func (km *Keymap) Pending() string {
+	return km.pending.String()
+}
+
+// Lookup returns the action bound to a chord in a context. A key with
+// Shift that has no binding of its own falls back to the same key
+// without Shift, so Ctrl+Shift+Z still undoes.
+func (km *Keymap) Lookup(ctx Context, chord Chord) (KeyAction, bool) {
+	bindings := km.bindings[ctx]
+	if b, ok := bindings[chord.String()]; ok {
+		return b.action, true
+	}
+
+	last := chord[len(chord)-1]
+	if last.Mod&tcell.ModShift == 0 || (last.Code == tcell.KeyRune && last.Mod&(tcell.ModCtrl|tcell.ModAlt) == 0) {
+		return KeyActionNone, false // Capital letters are typed, not bound
+	}
+	unshifted := slices.Clone(chord)
+	unshifted[len(unshifted)-1].Mod &^= tcell.ModShift
+	b, ok := bindings[unshifted.String()]
+	return b.action, ok
+}
+
+// Shortcut returns the keys bound to an action in a context, such as
+// "Ctrl+S", or "" if it has none. With several bindings the shortest is
+// returned.
+func (km *Keymap) Shortcut(ctx Context, action KeyAction) string {
+	best := ""
+	for text, b := range km.bindings[ctx] {
+		if b.action != action {
+			continue
+		}
+		if best == "" || len(text) < len(best) || (len(text) == len(best) && text < best) {
+			best = text
+		}
+	}
+	return best
+}
+
+// Process converts a tcell event to a KeyEvent using the bindings of a
+// context. Returns nil if the event is not a keyboard event.
+//
+// A key that starts a chord returns a KeyEvent with Pending set and the
+// keys so far in Chord; the next call completes the chord. A chord that
+// turns out to be unbound returns KeyActionNone with the keys in Chord.
+// Esc cancels a chord quietly. Keys that are not bound are typed if they
+// are characters, and Alt+letter gives KeyActionMenuAlt.
+func (km *Keymap) Process(ctx Context, ev tcell.Event) *KeyEvent {
+	keyEv, ok := ev.(*tcell.EventKey)
+	if !ok {
+		return nil
+	}
+	if ctx != km.pendingCtx {
+		km.pending = nil
+	}
+
+	key := KeyFromEvent(keyEv)
+	chord := append(slices.Clone(km.pending), key)
+	km.pending = nil
+	event := &KeyEvent{Key: keyEv.Key(), Modifiers: keyEv.Modifiers()}
+
+	if action, ok := km.Lookup(ctx, chord); ok {
+		event.Action = action
+		if action == KeyActionEnter {
+			event.Character = '\n'
+		}
+		return event
+	}
+
+	prefix := chord.String() + " "
+	for text := range km.bindings[ctx] {
+		if strings.HasPrefix(text, prefix) {
+			km.pending, km.pendingCtx = chord, ctx
+			event.Pending = true
+			event.Chord = chord.String()
+			return event
+		}
+	}
+
+	if len(chord) > 1 {
+		if key != (Key{Code: tcell.KeyEscape}) {
+			event.Chord = chord.String()
+		}
+		return event
+	}
+	return unboundKeyEvent(keyEv)
+}
+
+// CheatSheet lists the bindings of every context as aligned text, one
+// binding per line, grouped by context.
+func (km *Keymap) CheatSheet() string {
+	order := make(map[KeyAction]int, len(actionTable))
+	for i, info := range actionTable {
+		order[info.action] = i
+	}
+
+	var b strings.Builder
+	for _, ctx := range contexts {
+		bindings := make([]binding, 0, len(km.bindings[ctx]))
+		width := 0
+		for _, bind := range km.bindings[ctx] {
+			bindings = append(bindings, bind)
+			width = max(width, len(bind.chord.String()))
+		}
+		if len(bindings) == 0 {
+			continue
+		}
+		sort.Slice(bindings, func(i, j int) bool {
+			if bindings[i].action != bindings[j].action {
+				return order[bindings[i].action] < order[bindings[j].action]
+			}
+			ki, kj := bindings[i].chord.String(), bindings[j].chord.String()
+			return len(ki) < len(kj) || (len(ki) == len(kj) && ki < kj)
+		})
+
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s%s\n", strings.ToUpper(string(ctx[:1])), ctx[1:])
+		for _, bind := range bindings {
+			fmt.Fprintf(&b, "  %-*s  %s\n", width, bind.chord, bind.action.Description())
+		}
+	}
+	return b.String()
+}
+
+// Apply changes the keymap by the [keybindings.editor],
+// [keybindings.menu] and [keybindings.dialog] tables of a configuration
+// file. Each entry maps keys to an action name; an empty action removes the
+// binding:
+//
+//	[keybindings.editor]
+//	"Ctrl+K Ctrl+C" = "copy"
+//	"Ctrl+Shift+S" = ""
+//
+// Entries that are invalid or conflict are skipped and reported together in
+// the error, so the keymap stays usable.
+func (km *Keymap) Apply(tables map[string]map[string]string) error {
+	var problems []error
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		ctx := Context(name)
+		if _, ok := km.bindings[ctx]; !ok {
+			problems = append(problems, fmt.Errorf("keybindings.%s: unknown context (want editor, menu or dialog)", name))
+			continue
+		}
+		entries := tables[name]
+		keys := make([]string, 0, len(entries))
+		for k := range entries {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		// Remove bindings first, so new ones can take over their keys
+		seen := make(map[string]string)
+		var binds []string
+		for _, k := range keys {
+			chord, err := ParseChord(k)
+			if err != nil {
+				problems = append(problems, fmt.Errorf("keybindings.%s: %w", name, err))
+				continue
+			}
+			if first, ok := seen[chord.String()]; ok {
+				problems = append(problems, fmt.Errorf("keybindings.%s: %q and %q are the same keys", name, first, k))
+				continue
+			}
+			seen[chord.String()] = k
+			delete(km.bindings[ctx], chord.String())
+			if entries[k] != "" {
+				binds = append(binds, k)
+			}
+		}
+
+		for _, k := range binds {
+			action, ok := ParseKeyAction(entries[k])
+			if !ok {
+				problems = append(problems, fmt.Errorf("keybindings.%s: unknown action %q for %s", name, entries[k], k))
+				continue
+			}
+			if err := km.Bind(ctx, k, action); err != nil {
+				problems = append(problems, fmt.Errorf("keybindings.%s: %w", name, err))
+			}
+		}
+	}
+
+	return errors.Join(problems...)
+}
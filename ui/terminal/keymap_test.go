@@ -0,0 +1,241 @@
+package terminal
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestKeymap_ProcessChord(t *testing.T) {
+	km := DefaultKeymap()
+	if err := km.Bind(ContextEditor, "Ctrl+K Ctrl+C", KeyActionCopy); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	ctrlK := tcell.NewEventKey(tcell.KeyCtrlK, 0, tcell.ModCtrl)
+	ctrlC := tcell.NewEventKey(tcell.KeyCtrlC, 0, tcell.ModCtrl)
+	ctrlX := tcell.NewEventKey(tcell.KeyCtrlX, 0, tcell.ModCtrl)
+	esc := tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone)
+
+	tests := []struct {
+		name        string
+		ctx         Context
+		ev          *tcell.EventKey
+		wantAction  KeyAction
+		wantPending bool
+		wantChord   string
+	}{
+		{"chord starts", ContextEditor, ctrlK, KeyActionNone, true, "Ctrl+K"},
+		{"chord completes", ContextEditor, ctrlC, KeyActionCopy, false, ""},
+		{"single key still works", ContextEditor, ctrlC, KeyActionCopy, false, ""},
+		{"chord starts again", ContextEditor, ctrlK, KeyActionNone, true, "Ctrl+K"},
+		{"unbound chord", ContextEditor, ctrlX, KeyActionNone, false, "Ctrl+K Ctrl+X"},
+		{"key after unbound chord", ContextEditor, ctrlX, KeyActionCut, false, ""},
+		{"chord cancelled by Esc", ContextEditor, ctrlK, KeyActionNone, true, "Ctrl+K"},
+		{"Esc", ContextEditor, esc, KeyActionNone, false, ""},
+		{"chord in another context", ContextEditor, ctrlK, KeyActionNone, true, "Ctrl+K"},
+		{"context change drops chord", ContextMenu, esc, KeyActionEscape, false, ""},
+	}
+
+	for _, tt := range tests {
+		got := km.Process(tt.ctx, tt.ev)
+		if got.Action != tt.wantAction || got.Pending != tt.wantPending || got.Chord != tt.wantChord {
+			t.Errorf("%s: Process() = {%v pending=%v chord=%q}, want {%v pending=%v chord=%q}",
+				tt.name, got.Action, got.Pending, got.Chord, tt.wantAction, tt.wantPending, tt.wantChord)
+		}
+	}
+	if km.Pending() != "" {
+		t.Errorf("Pending() = %q, want none", km.Pending())
+	}
+}
+
+func TestKeymap_Lookup(t *testing.T) {
+	km := DefaultKeymap()
+	tests := []struct {
+		keys   string
+		want   KeyAction
+		wantOK bool
+	}{
+		{"Ctrl+Z", KeyActionUndo, true},
+		{"Ctrl+Shift+Z", KeyActionUndo, true}, // Falls back to Ctrl+Z
+		{"Ctrl+Shift+F", KeyActionFindInFiles, true},
+		{"Shift+Home", KeyActionHome, true},
+		{"Shift+A", KeyActionNone, false}, // Typed, never bound through A
+		{"Ctrl+K", KeyActionNone, false},
+	}
+
+	for _, tt := range tests {
+		chord, err := ParseChord(tt.keys)
+		if err != nil {
+			t.Fatalf("ParseChord(%q) error = %v", tt.keys, err)
+		}
+		got, ok := km.Lookup(ContextEditor, chord)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Lookup(%q) = %v, %v, want %v, %v", tt.keys, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestKeymap_BindConflicts(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     Context
+		keys    string
+		action  KeyAction
+		wantErr bool
+	}{
+		{"rebinding replaces", ContextEditor, "Ctrl+S", KeyActionSaveAs, false},
+		{"chord under a bound key", ContextEditor, "Ctrl+S Ctrl+S", KeyActionSave, true},
+		{"key starting a bound chord", ContextEditor, "Ctrl+K", KeyActionCut, true},
+		{"menu cannot save", ContextMenu, "Ctrl+S", KeyActionSave, true},
+		{"dialog enter", ContextDialog, "Ctrl+M", KeyActionEnter, false},
+		{"typing cannot be bound", ContextEditor, "Ctrl+T", KeyActionCharacter, true},
+		{"unknown context", Context("terminal"), "Ctrl+T", KeyActionSave, true},
+		{"bad key", ContextEditor, "Ctrl+Nope", KeyActionSave, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km := DefaultKeymap()
+			if err := km.Bind(ContextEditor, "Ctrl+K Ctrl+C", KeyActionCopy); err != nil {
+				t.Fatalf("Bind() error = %v", err)
+			}
+			err := km.Bind(tt.ctx, tt.keys, tt.action)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Bind(%s, %q, %v) error = %v, wantErr %v", tt.ctx, tt.keys, tt.action, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeymap_Shortcut(t *testing.T) {
+	km := DefaultKeymap()
+	km.Bind(ContextEditor, "Ctrl+K Ctrl+S", KeyActionSave)
+	km.Unbind(ContextEditor, "Ctrl+W")
+
+	tests := []struct {
+		action KeyAction
+		want   string
+	}{
+		{KeyActionSave, "Ctrl+S"}, // Shortest binding wins
+		{KeyActionSaveAs, "Ctrl+Shift+S"},
+		{KeyActionClose, ""},
+		{KeyActionMoveLineUp, "Alt+Up"},
+	}
+	for _, tt := range tests {
+		if got := km.Shortcut(ContextEditor, tt.action); got != tt.want {
+			t.Errorf("Shortcut(%v) = %q, want %q", tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestKeyAction_Names(t *testing.T) {
+	for _, info := range actionTable {
+		got, ok := ParseKeyAction(info.action.String())
+		if !ok || got != info.action {
+			t.Errorf("ParseKeyAction(%q) = %v, %v, want %v", info.action.String(), got, ok, info.action)
+		}
+	}
+	if _, ok := ParseKeyAction("character"); ok {
+		t.Error("ParseKeyAction(\"character\") should fail: typing cannot be bound")
+	}
+}
+
+func TestKeymap_Apply(t *testing.T) {
+	km := DefaultKeymap()
+	err := km.Apply(map[string]map[string]string{
+		"editor": {
+			"Ctrl+K Ctrl+C": "copy",
+			"Ctrl+Shift+K":  "",
+			"Alt+D":         "delete-line",
+			"Ctrl+S":        "save-as",
+		},
+		"dialog": {
+			"Ctrl+G": "escape",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	tests := []struct {
+		ctx    Context
+		action KeyAction
+		want   string
+	}{
+		{ContextEditor, KeyActionCopy, "Ctrl+C"},
+		{ContextEditor, KeyActionDeleteLine, "Alt+D"},
+		{ContextEditor, KeyActionSave, ""},
+		{ContextEditor, KeyActionSaveAs, "Ctrl+S"},
+		{ContextEditor, KeyActionQuit, "Ctrl+Q"},
+		{ContextDialog, KeyActionEscape, "Ctrl+G"},
+	}
+	for _, tt := range tests {
+		if got := km.Shortcut(tt.ctx, tt.action); got != tt.want {
+			t.Errorf("Shortcut(%s, %v) = %q, want %q", tt.ctx, tt.action, got, tt.want)
+		}
+	}
+
+	ev := km.Process(ContextEditor, tcell.NewEventKey(tcell.KeyCtrlK, 0, tcell.ModCtrl))
+	if !ev.Pending {
+		t.Error("Ctrl+K should start a chord")
+	}
+}
+
+func TestKeymap_ApplyProblems(t *testing.T) {
+	km := DefaultKeymap()
+	err := km.Apply(map[string]map[string]string{
+		"editor": {
+			"Ctrl+K":        "cut",
+			"Ctrl+K Ctrl+C": "copy",
+			"Ctrl+Nope":     "save",
+			"Ctrl+E":        "explode",
+			"Alt+Q":         "quit",
+			"alt+q":         "quit",
+		},
+		"menu": {
+			"Ctrl+S": "save",
+		},
+		"sidebar": {
+			"Ctrl+B": "open",
+		},
+	})
+	if err == nil {
+		t.Fatal("Apply() should report the invalid entries")
+	}
+	for _, want := range []string{"conflicts", "Nope", "explode", "same keys", "menu context", "sidebar"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Apply() error should mention %q, got:\n%v", want, err)
+		}
+	}
+
+	// Valid entries still apply
+	if got := km.Shortcut(ContextEditor, KeyActionCut); got != "Ctrl+K" {
+		t.Errorf("Shortcut(cut) = %q, want %q", got, "Ctrl+K")
+	}
+	if got := km.Shortcut(ContextEditor, KeyActionSave); got != "Ctrl+S" {
+		t.Errorf("Shortcut(save) = %q, want %q", got, "Ctrl+S")
+	}
+}
+
+func TestKeymap_CheatSheet(t *testing.T) {
+	km := DefaultKeymap()
+	km.Bind(ContextEditor, "Ctrl+K Ctrl+C", KeyActionCopy)
+	sheet := km.CheatSheet()
+
+	for _, want := range []string{"Editor\n", "\nMenu\n", "Ctrl+S", "Save file"} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("CheatSheet() should contain %q, got:\n%s", want, sheet)
+		}
+	}
+	if !regexp.MustCompile(`\n  Ctrl\+K Ctrl\+C +Copy\n`).MatchString(sheet) {
+		t.Errorf("CheatSheet() should list the chord with its description, got:\n%s", sheet)
+	}
+	if strings.Contains(sheet, "Dialog") {
+		t.Error("CheatSheet() should leave out contexts without bindings")
+	}
+	if strings.Index(sheet, "Ctrl+C ") > strings.Index(sheet, "Ctrl+K Ctrl+C") {
+		t.Error("CheatSheet() should list bindings of one action shortest first")
+	}
+}
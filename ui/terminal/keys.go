@@ -0,0 +1,194 @@
+// Package terminal implements key names for keybindings.
+package terminal
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Key is a single key press as used in keybindings. Keys are normalized so
+// that the same press always compares equal: Ctrl+letter is the letter
+// rune with ModCtrl, letters are lower case with ModShift for capitals, and
+// Shift+Tab is Tab with ModShift.
+type Key struct {
+	Code tcell.Key     // tcell.KeyRune for character keys
+	Rune rune          // The character, for tcell.KeyRune
+	Mod  tcell.ModMask // Ctrl, Alt and Shift only
+}
+
+// keyNames are the names of the non-character keys, as written in
+// keybindings.
+var keyNames = map[tcell.Key]string{
+	tcell.KeyEnter:     "Enter",
+	tcell.KeyTab:       "Tab",
+	tcell.KeyBackspace: "Backspace",
+	tcell.KeyDelete:    "Delete",
+	tcell.KeyInsert:    "Insert",
+	tcell.KeyEscape:    "Esc",
+	tcell.KeyUp:        "Up",
+	tcell.KeyDown:      "Down",
+	tcell.KeyLeft:      "Left",
+	tcell.KeyRight:     "Right",
+	tcell.KeyHome:      "Home",
+	tcell.KeyEnd:       "End",
+	tcell.KeyPgUp:      "PageUp",
+	tcell.KeyPgDn:      "PageDown",
+	tcell.KeyF1:        "F1",
+	tcell.KeyF2:        "F2",
+	tcell.KeyF3:        "F3",
+	tcell.KeyF4:        "F4",
+	tcell.KeyF5:        "F5",
+	tcell.KeyF6:        "F6",
+	tcell.KeyF7:        "F7",
+	tcell.KeyF8:        "F8",
+	tcell.KeyF9:        "F9",
+	tcell.KeyF10:       "F10",
+	tcell.KeyF11:       "F11",
+	tcell.KeyF12:       "F12",
+}
+
+// keyAliases are other accepted spellings of key names, lower case.
+var keyAliases = map[string]tcell.Key{
+	"return":   tcell.KeyEnter,
+	"escape":   tcell.KeyEscape,
+	"del":      tcell.KeyDelete,
+	"ins":      tcell.KeyInsert,
+	"pgup":     tcell.KeyPgUp,
+	"pgdn":     tcell.KeyPgDn,
+	"pagedown": tcell.KeyPgDn,
+	"pageup":   tcell.KeyPgUp,
+}
+
+// KeyFromEvent returns the normalized key of a key event.
+func KeyFromEvent(ev *tcell.EventKey) Key {
+	code := ev.Key()
+	mod := ev.Modifiers() & (tcell.ModCtrl | tcell.ModAlt | tcell.ModShift)
+
+	switch {
+	case code >= tcell.KeyCtrlA && code <= tcell.KeyCtrlZ:
+		return Key{Code: tcell.KeyRune, Rune: 'a' + rune(code-tcell.KeyCtrlA), Mod: mod | tcell.ModCtrl}
+	case code == tcell.KeyBacktab:
+		return Key{Code: tcell.KeyTab, Mod: mod | tcell.ModShift}
+	case code == tcell.KeyCtrlSpace:
+		return Key{Code: tcell.KeyRune, Rune: ' ', Mod: mod | tcell.ModCtrl}
+	case code == tcell.KeyRune:
+		r := ev.Rune()
+		if unicode.IsUpper(r) {
+			r = unicode.ToLower(r)
+			mod |= tcell.ModShift
+		}
+		return Key{Code: tcell.KeyRune, Rune: r, Mod: mod}
+	}
+	return Key{Code: code, Mod: mod}
+}
+
+// ParseKey parses a key such as "Ctrl+S", "Alt+Shift+Up" or "F3".
+// Modifiers and key names are case-insensitive.
+func ParseKey(s string) (Key, error) {
+	parts := strings.Split(s, "+")
+	if strings.HasSuffix(s, "++") || s == "+" {
+		// The key itself is "+"
+		parts = append(parts[:len(parts)-2], "+")
+	}
+
+	var key Key
+	for _, mod := range parts[:len(parts)-1] {
+		switch strings.ToLower(strings.TrimSpace(mod)) {
+		case "ctrl":
+			key.Mod |= tcell.ModCtrl
+		case "alt":
+			key.Mod |= tcell.ModAlt
+		case "shift":
+			key.Mod |= tcell.ModShift
+		default:
+			return Key{}, fmt.Errorf("unknown modifier %q in %q", mod, s)
+		}
+	}
+
+	name := strings.TrimSpace(parts[len(parts)-1])
+	lower := strings.ToLower(name)
+	if lower == "space" {
+		key.Code, key.Rune = tcell.KeyRune, ' '
+		return key, nil
+	}
+	if utf8.RuneCountInString(name) == 1 {
+		r, _ := utf8.DecodeRuneInString(name)
+		key.Code, key.Rune = tcell.KeyRune, unicode.ToLower(r)
+		return key, nil
+	}
+	if code, ok := keyAliases[lower]; ok {
+		key.Code = code
+		return key, nil
+	}
+	for code, keyName := range keyNames {
+		if strings.ToLower(keyName) == lower {
+			key.Code = code
+			return key, nil
+		}
+	}
+	if name == "" {
+		return Key{}, fmt.Errorf("missing key in %q", s)
+	}
+	return Key{}, fmt.Errorf("unknown key %q in %q", name, s)
+}
+
+// String returns the key as written in keybindings, such as "Ctrl+Shift+K".
+func (k Key) String() string {
+	var b strings.Builder
+	if k.Mod&tcell.ModCtrl != 0 {
+		b.WriteString("Ctrl+")
+	}
+	if k.Mod&tcell.ModAlt != 0 {
+		b.WriteString("Alt+")
+	}
+	if k.Mod&tcell.ModShift != 0 {
+		b.WriteString("Shift+")
+	}
+
+	switch {
+	case k.Code == tcell.KeyRune && k.Rune == ' ':
+		b.WriteString("Space")
+	case k.Code == tcell.KeyRune:
+		b.WriteRune(unicode.ToUpper(k.Rune))
+	case keyNames[k.Code] != "":
+		b.WriteString(keyNames[k.Code])
+	default:
+		b.WriteString(tcell.KeyNames[k.Code])
+	}
+	return b.String()
+}
+
+// Chord is a sequence of keys pressed one after another, such as
+// Ctrl+K Ctrl+C. Most bindings are a single key.
+type Chord []Key
+
+// ParseChord parses keys separated by spaces, such as "Ctrl+K Ctrl+C".
+func ParseChord(s string) (Chord, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty key binding")
+	}
+
+	chord := make(Chord, len(fields))
+	for i, field := range fields {
+		key, err := ParseKey(field)
+		if err != nil {
+			return nil, err
+		}
+		chord[i] = key
+	}
+	return chord, nil
+}
+
+// String returns the chord as written in keybindings.
+func (c Chord) String() string {
+	keys := make([]string, len(c))
+	for i, key := range c {
+		keys[i] = key.String()
+	}
+	return strings.Join(keys, " ")
+}
@@ -0,0 +1,88 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Key
+		wantStr string
+		wantErr bool
+	}{
+		{"Ctrl+S", Key{tcell.KeyRune, 's', tcell.ModCtrl}, "Ctrl+S", false},
+		{"ctrl+shift+k", Key{tcell.KeyRune, 'k', tcell.ModCtrl | tcell.ModShift}, "Ctrl+Shift+K", false},
+		{"Shift+Alt+Up", Key{tcell.KeyUp, 0, tcell.ModAlt | tcell.ModShift}, "Alt+Shift+Up", false},
+		{"F10", Key{tcell.KeyF10, 0, 0}, "F10", false},
+		{"Escape", Key{tcell.KeyEscape, 0, 0}, "Esc", false},
+		{"PgDn", Key{tcell.KeyPgDn, 0, 0}, "PageDown", false},
+		{"Ctrl+Space", Key{tcell.KeyRune, ' ', tcell.ModCtrl}, "Ctrl+Space", false},
+		{"Ctrl++", Key{tcell.KeyRune, '+', tcell.ModCtrl}, "Ctrl++", false},
+		{"Ctrl+/", Key{tcell.KeyRune, '/', tcell.ModCtrl}, "Ctrl+/", false},
+		{"Hyper+S", Key{}, "", true},
+		{"Ctrl+Foo", Key{}, "", true},
+		{"Ctrl+", Key{}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseKey(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKey(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseKey(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+			if got.String() != tt.wantStr {
+				t.Errorf("ParseKey(%q).String() = %q, want %q", tt.input, got.String(), tt.wantStr)
+			}
+		})
+	}
+}
+
+func TestKeyFromEvent(t *testing.T) {
+	tests := []struct {
+		name string
+		ev   *tcell.EventKey
+		want string
+	}{
+		{"Ctrl letter without ModCtrl", tcell.NewEventKey(tcell.KeyCtrlS, 0, tcell.ModNone), "Ctrl+S"},
+		{"Ctrl+Shift letter", tcell.NewEventKey(tcell.KeyCtrlK, 'K', tcell.ModCtrl|tcell.ModShift), "Ctrl+Shift+K"},
+		{"capital letter", tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModNone), "Shift+A"},
+		{"Alt letter", tcell.NewEventKey(tcell.KeyRune, 'f', tcell.ModAlt), "Alt+F"},
+		{"Backtab", tcell.NewEventKey(tcell.KeyBacktab, 0, tcell.ModNone), "Shift+Tab"},
+		{"Ctrl+Space", tcell.NewEventKey(tcell.KeyCtrlSpace, 0, tcell.ModCtrl), "Ctrl+Space"},
+		{"arrow", tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModCtrl), "Ctrl+Left"},
+		{"Backspace", tcell.NewEventKey(tcell.KeyBackspace2, 0, tcell.ModNone), "Backspace"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KeyFromEvent(tt.ev).String(); got != tt.want {
+				t.Errorf("KeyFromEvent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseChord(t *testing.T) {
+	chord, err := ParseChord("  ctrl+k   Ctrl+C ")
+	if err != nil {
+		t.Fatalf("ParseChord() error = %v", err)
+	}
+	if got, want := chord.String(), "Ctrl+K Ctrl+C"; got != want {
+		t.Errorf("ParseChord().String() = %q, want %q", got, want)
+	}
+
+	for _, input := range []string{"", "Ctrl+K Nope"} {
+		if _, err := ParseChord(input); err == nil {
+			t.Errorf("ParseChord(%q) should fail", input)
+		}
+	}
+}
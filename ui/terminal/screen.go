@@ -33,9 +33,20 @@ type Screen interface {
 	// PollEvent waits for and returns the next event.
 	PollEvent() tcell.Event
 
+	// PostEvent queues an event to be returned by PollEvent.
+	// It is safe to call from other goroutines.
+	PostEvent(ev tcell.Event) error
+
 	// Fini finalizes the screen and restores the terminal state.
 	Fini()
 
+	// Suspend temporarily restores the terminal so an external program
+	// (such as a sudo password prompt) can use it.
+	Suspend() error
+
+	// Resume takes the terminal back after Suspend.
+	Resume() error
+
 	// GetRawScreen returns the underlying tcell.Screen for advanced operations.
 	// This should be used sparingly and only when necessary.
 	GetRawScreen() tcell.Screen
@@ -61,6 +72,12 @@ func NewScreen() (*TCellScreen, error) {
 	// Set default style
 	s.SetStyle(tcell.StyleDefault)
 
+	// Report clicks, drags and the wheel
+	s.EnableMouse(tcell.MouseButtonEvents | tcell.MouseDragEvents)
+
+	// Mark pasted text so it is not handled as typed keys
+	s.EnablePaste()
+
 	// Clear the screen
 	s.Clear()
 
@@ -104,11 +121,26 @@ func (s *TCellScreen) PollEvent() tcell.Event {
 	return s.screen.PollEvent()
 }
 
+// PostEvent queues an event to be returned by PollEvent.
+func (s *TCellScreen) PostEvent(ev tcell.Event) error {
+	return s.screen.PostEvent(ev)
+}
+
 // Fini finalizes the screen and restores the terminal state.
 func (s *TCellScreen) Fini() {
 	s.screen.Fini()
 }
 
+// Suspend temporarily restores the terminal so an external program can use it.
+func (s *TCellScreen) Suspend() error {
+	return s.screen.Suspend()
+}
+
+// Resume takes the terminal back after Suspend.
+func (s *TCellScreen) Resume() error {
+	return s.screen.Resume()
+}
+
 // GetRawScreen returns the underlying tcell.Screen for advanced operations.
 // This should be used sparingly and only when necessary.
 func (s *TCellScreen) GetRawScreen() tcell.Screen {
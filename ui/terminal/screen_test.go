@@ -174,6 +174,22 @@ func TestTCellScreen_Fini(t *testing.T) {
 	screen.Fini()
 }
 
+func TestTCellScreen_SuspendResume(t *testing.T) {
+	screen, err := NewScreen()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer screen.Fini()
+
+	if err := screen.Suspend(); err != nil {
+		t.Errorf("Suspend() error = %v", err)
+	}
+	if err := screen.Resume(); err != nil {
+		t.Errorf("Resume() error = %v", err)
+	}
+}
+
 func TestTCellScreen_GetRawScreen(t *testing.T) {
 	screen, err := NewScreen()
 	if err != nil {
@@ -187,3 +203,28 @@ func TestTCellScreen_GetRawScreen(t *testing.T) {
 		t.Error("GetRawScreen() returned nil")
 	}
 }
+
+func TestTCellScreen_PostEvent(t *testing.T) {
+	screen, err := NewScreen()
+	if err != nil {
+		t.Skipf("Skipping test - terminal not available: %v", err)
+		return
+	}
+	defer screen.Fini()
+
+	posted := tcell.NewEventInterrupt("search done")
+	if err := screen.PostEvent(posted); err != nil {
+		t.Fatalf("PostEvent() error = %v", err)
+	}
+
+	// Skip any events the terminal queued on startup
+	for {
+		ev := screen.PollEvent()
+		if ev == nil {
+			t.Fatal("PollEvent() returned nil before posted event")
+		}
+		if ev == posted {
+			break
+		}
+	}
+}